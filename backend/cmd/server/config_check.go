@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"ai-git-workbench/internal/infrastructure/config"
+)
+
+// runConfigCheck implements `server config check`: it loads the configuration
+// from all sources, prints it with secrets redacted and reports validation errors
+func runConfigCheck(args []string, stdout, stderr io.Writer) int {
+	cfg, err := config.Load(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "config error: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprint(stdout, cfg.String())
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(stderr, "\nconfig is invalid:\n%v\n", err)
+		return 1
+	}
+
+	fmt.Fprintln(stderr, "\nconfig is valid")
+	return 0
+}
+
+// isConfigCheck reports whether the arguments select the `config check` command
+func isConfigCheck(args []string) bool {
+	return len(args) >= 2 && args[0] == "config" && args[1] == "check"
+}
@@ -1,49 +1,335 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
+	echomw "github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/delivery/http/handlers"
+	"ai-git-workbench/internal/delivery/http/middleware"
 	"ai-git-workbench/internal/delivery/http/routes"
+	"ai-git-workbench/internal/delivery/http/validation"
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/infrastructure/ai"
 	"ai-git-workbench/internal/infrastructure/config"
+	"ai-git-workbench/internal/infrastructure/database"
+	"ai-git-workbench/internal/infrastructure/github"
+	"ai-git-workbench/internal/infrastructure/logger"
+	"ai-git-workbench/internal/infrastructure/notify"
+	"ai-git-workbench/internal/infrastructure/tracing"
+	"ai-git-workbench/internal/infrastructure/webpush"
+	"ai-git-workbench/internal/usecase/activity"
+	"ai-git-workbench/internal/usecase/assistant"
+	"ai-git-workbench/internal/usecase/auth"
+	"ai-git-workbench/internal/usecase/execution"
+	"ai-git-workbench/internal/usecase/idempotency"
+	"ai-git-workbench/internal/usecase/issuesync"
+	"ai-git-workbench/internal/usecase/notification"
+	"ai-git-workbench/internal/usecase/realtime"
+	"ai-git-workbench/internal/usecase/search"
+	"ai-git-workbench/internal/usecase/workflow"
+	"ai-git-workbench/internal/usecase/workspace"
 )
 
 func main() {
+	// Log in JSON until the configured logger is available
+	bootLogger, _ := logger.New(config.LogConfig{})
+	slog.SetDefault(bootLogger)
+
+	args := os.Args[1:]
+	if isConfigCheck(args) {
+		os.Exit(runConfigCheck(args[2:], os.Stdout, os.Stderr))
+	}
+	if isTokenCreate(args) {
+		os.Exit(runTokenCreate(args[2:], os.Stdout, os.Stderr))
+	}
+
+	if err := run(args); err != nil {
+		slog.Error("server stopped with error", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the server and blocks until it has shut down
+func run(args []string) error {
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "errors", strings.Split(err.Error(), "\n"))
+		return errors.New("invalid configuration, run `server config check` for details")
+	}
+
+	log, err := logger.New(cfg.Log)
+	if err != nil {
+		return fmt.Errorf("invalid logging configuration: %w", err)
+	}
+	slog.SetDefault(log)
+
+	// Stop on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Tracing
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Error("failed to flush traces", "error", err)
+		}
+	}()
+
+	// Database
+	db, err := database.Open(ctx, &cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Error("failed to close database", "error", err)
+			return
+		}
+		log.Info("database connection pool closed")
+	}()
+
+	taskStore := database.NewTaskStore(db)
+	repositoryStore := database.NewRepositoryStore(db)
+
+	// Changes made through the API are streamed to WebSocket clients of
+	// every instance through the shared change event log
+	hub := realtime.NewHub(database.NewChangeEventStore(db), cfg.Realtime.PollInterval, cfg.Realtime.Retention)
+	go hub.Run(ctx)
+
+	authService := auth.NewService(database.NewUserStore(db), database.NewTokenStore(db))
+
+	idempotencyService := idempotency.NewService(database.NewIdempotencyStore(db), cfg.Server.IdempotencyTTL)
+	go idempotencyService.Run(ctx)
+
+	runner := execution.NewRunner(taskStore)
+
+	// Notifications; email and push are only offered when configured
+	notifyClient := notify.NewClient(cfg.Notify.Timeout)
+	channels := map[string]notification.Channel{
+		entities.ChannelSlack:   notify.NewSlackChannel(notifyClient),
+		entities.ChannelWebhook: notify.NewWebhookChannel(notifyClient),
+	}
+	if cfg.Notify.SMTP.Host != "" {
+		channels[entities.ChannelEmail] = notify.NewEmailChannel(cfg.Notify.SMTP)
+	}
+	var push *notification.PushChannel
+	if cfg.Notify.Push.Enabled {
+		pushStore := database.NewPushStore(db)
+		keys, err := notification.LoadVAPIDKeys(ctx, pushStore, cfg.Notify.Push.VAPIDPrivateKey)
+		if err != nil {
+			return fmt.Errorf("failed to load VAPID keys: %w", err)
+		}
+		push = notification.NewPushChannel(webpush.NewClient(notifyClient, keys, cfg.Notify.Push.Subject),
+			pushStore, cfg.Notify.Push.TTL)
+		channels[entities.ChannelPush] = push
+	}
+	notifications := notification.NewService(database.NewNotificationStore(db), channels, cfg.Notify.Timeout)
+
+	// Workflows
+	githubClient := github.NewClient(cfg.GitHub)
+	aiProvider, err := ai.NewProvider(cfg.AI)
+	if err != nil {
+		return err
+	}
+	workflowStore := database.NewWorkflowStore(db)
+	engine := workflow.NewEngine(workflowStore, database.NewWorkflowRunStore(db),
+		workflow.NewExecutors(workflow.ExecutorDeps{
+			AI:          aiProvider,
+			GitHub:      githubClient,
+			GitHubToken: cfg.GitHub.Token,
+			AllowShell:  cfg.Workflow.AllowShell,
+		}),
+		notifications,
+		cfg.Workflow.WorkDir,
+	)
+	if err := engine.Recover(ctx); err != nil {
+		return fmt.Errorf("failed to resume workflow runs: %w", err)
+	}
+	go engine.Watch(ctx)
+	triggers := workflow.NewDispatcher(workflowStore, engine)
+	go triggers.Run(ctx)
+
+	// Tasks of repositories that opted in are mirrored to GitHub issues
+	tasks := realtime.PublishTasks(taskStore, hub)
+	repos := realtime.PublishRepositories(repositoryStore, hub)
+	activityService := activity.NewService(database.NewActivityStore(db))
+	issueSync := issuesync.NewService(tasks, repos, database.NewIssueSyncStore(db), githubClient,
+		activityService, triggers, notifications)
+	tasks = issueSync.Mirror(tasks)
+	go issueSync.Run(ctx)
+
+	// Tasks are carried out by the AI provider, spending the caller's tokens
+	assistantService := assistant.NewService(aiProvider, database.NewAIUsageStore(db), cfg.AI.DailyTokenLimit)
+	executor := execution.NewExecutor(tasks, runner, assistantService, triggers, notifications)
+	workspaceService, err := workspace.NewService(repos, cfg.Workspace.Dir, cfg.GitHub.Token, cfg.Workspace.CloneTimeout)
+	if err != nil {
+		return err
+	}
+
+	// Signing in with GitHub needs an OAuth app
+	var oauth *github.OAuth
+	signupRole := ""
+	if cfg.Auth.GitHubClientID != "" {
+		oauth = github.NewOAuth(cfg.Auth.GitHubURL, cfg.Auth.GitHubClientID, cfg.Auth.GitHubClientSecret)
+		if cfg.Auth.AllowSignup {
+			signupRole = cfg.Auth.SignupRole
+		}
+	}
+
+	webhookSecret := ""
+	if cfg.GitHub.WebhooksEnabled {
+		webhookSecret = cfg.GitHub.WebhookSecret
+	}
 
 	// Create Echo instance
 	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.HTTPErrorHandler = apierror.Handler
+	e.Validator = validation.New()
+	e.IPExtractor = echo.ExtractIPDirect()
+	if cfg.Server.TrustProxy {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	}
 
 	// Middleware
-	e.Use(middleware.Logger())
-	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
+	e.Use(otelecho.Middleware(cfg.Tracing.ServiceName))
+	e.Use(middleware.RequestID())
+	e.Use(middleware.ClientInfo())
+	e.Use(middleware.RequestLogger(log))
+	e.Use(middleware.Recover(log))
+	e.Use(echomw.CORSWithConfig(echomw.CORSConfig{
+		ExposeHeaders: []string{echo.HeaderXRequestID, handlers.HeaderETag, middleware.HeaderIdempotentReplayed},
+	}))
 
 	// Routes
-	routes.SetupRoutes(e)
+	routes.SetupRoutes(e, routes.Dependencies{
+		GitHub:        githubClient,
+		Tasks:         tasks,
+		Repositories:  repos,
+		Auth:          authService,
+		Assistant:     assistantService,
+		Executor:      executor,
+		Workspace:     workspaceService,
+		Search:        search.NewService(database.NewSearchStore(db)),
+		Workflows:     workflow.NewService(workflowStore),
+		Engine:        engine,
+		Triggers:      triggers,
+		Notifications: notifications,
+		Push:          push,
+		Activity:      activityService,
+		IssueSync:     issueSync,
+		Idempotency:   idempotencyService,
+
+		IdempotencyMaxBody: int64(cfg.Server.IdempotencyMaxBody),
+		TokenMaxTTL:        cfg.Auth.TokenMaxTTL,
+
+		OAuth: oauth,
+		SignIn: handlers.SignInOptions{
+			CallbackURL:      cfg.Auth.CallbackURL,
+			LoginRedirectURL: cfg.Auth.LoginRedirectURL,
+			SignupRole:       signupRole,
+			SessionTTL:       cfg.Auth.SessionTTL,
+		},
+
+		Realtime:             hub,
+		RealtimePingInterval: cfg.Realtime.PingInterval,
+		RealtimeSendBuffer:   cfg.Realtime.SendBuffer,
+
+		WebhookSecret: webhookSecret,
+	})
 
 	// Health check endpoint
 	e.GET("/health", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]interface{}{
-			"status": "OK",
+			"status":  "OK",
 			"message": "Workflow Backend Server is running",
 			"version": "1.0.0",
 		})
 	})
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = cfg.Server.Port
+	port := cfg.Server.Port
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Info("server starting", "port", port)
+		if err := e.Start(":" + port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	return shutdown(log, cfg.Server, e, runner, engine)
+}
+
+// drainer is a background executor that can be drained on shutdown
+type drainer interface {
+	Running() []string
+	Shutdown(ctx context.Context) error
+}
+
+// shutdown stops accepting connections, then drains in-flight requests,
+// running tasks and workflow runs until the configured deadline
+func shutdown(log *slog.Logger, cfg config.ServerConfig, e *echo.Echo, runner, engine drainer) error {
+	log.Info("shutting down",
+		"timeout", cfg.ShutdownTimeout.String(),
+		"running_tasks", len(runner.Running()),
+		"running_workflows", len(engine.Running()),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	httpErr := make(chan error, 1)
+	go func() {
+		httpErr <- e.Shutdown(ctx)
+	}()
+
+	workflowErr := make(chan error, 1)
+	go func() {
+		workflowErr <- engine.Shutdown(ctx)
+	}()
+
+	var errs []error
+	if err := runner.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to checkpoint running tasks: %w", err))
+	}
+	if err := <-workflowErr; err != nil {
+		errs = append(errs, fmt.Errorf("failed to checkpoint workflow runs: %w", err))
+	}
+	if err := <-httpErr; err != nil {
+		errs = append(errs, fmt.Errorf("failed to drain http requests: %w", err))
 	}
 
-	log.Printf("🚀 Server starting on port %s", port)
-	if err := e.Start(":" + port); err != nil && err != http.ErrServerClosed {
-		log.Fatal("Failed to start server:", err)
+	if len(errs) == 0 {
+		log.Info("shutdown complete")
 	}
-}
\ No newline at end of file
+	return errors.Join(errs...)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/infrastructure/config"
+	"ai-git-workbench/internal/infrastructure/database"
+	"ai-git-workbench/internal/infrastructure/logger"
+	"ai-git-workbench/internal/usecase/auth"
+)
+
+// runTokenCreate implements `server token create`: it issues an API token for
+// a user, creating the user first if needed, and prints the secret once
+func runTokenCreate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("token create", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFile := fs.String("config", "", "path to a YAML or TOML config file")
+	login := fs.String("user", "", "login of the token owner (required)")
+	role := fs.String("role", entities.RoleMember, "role given to the user if it does not exist yet (admin, member, viewer)")
+	name := fs.String("name", "cli", "name describing the token")
+	expires := fs.Duration("expires", 0, "token lifetime such as 720h; 0 never expires")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *login == "" {
+		fmt.Fprintln(stderr, "token create: -user is required")
+		return 2
+	}
+
+	// Keep stdout for the token so it can be captured by scripts
+	if log, err := logger.NewWithWriter(config.LogConfig{Level: "warn"}, stderr); err == nil {
+		slog.SetDefault(log)
+	}
+
+	var configArgs []string
+	if *configFile != "" {
+		configArgs = []string{"-config", *configFile}
+	}
+	cfg, err := config.Load(configArgs)
+	if err != nil {
+		fmt.Fprintf(stderr, "config error: %v\n", err)
+		return 1
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(stderr, "config is invalid:\n%v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	db, err := database.Open(ctx, &cfg.Database)
+	if err != nil {
+		fmt.Fprintf(stderr, "database error: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	svc := auth.NewService(database.NewUserStore(db), database.NewTokenStore(db))
+	user, err := svc.EnsureUser(ctx, *login, *role)
+	if err != nil {
+		fmt.Fprintf(stderr, "token create: %v\n", err)
+		return 1
+	}
+	raw, token, err := svc.IssueToken(ctx, user, *name, *expires)
+	if err != nil {
+		fmt.Fprintf(stderr, "token create: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stderr, "created token %s for %s (%s)\n", token.Prefix, user.Login, user.Role)
+	fmt.Fprintln(stdout, raw)
+	return 0
+}
+
+// isTokenCreate reports whether the arguments select the `token create` command
+func isTokenCreate(args []string) bool {
+	return len(args) >= 2 && args[0] == "token" && args[1] == "create"
+}
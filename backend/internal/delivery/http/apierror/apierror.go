@@ -0,0 +1,142 @@
+// Package apierror defines the body of every error response of the HTTP API
+// and the Echo error handler writing it
+package apierror
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Codes identify the kind of an error independently of its message
+const (
+	CodeBadRequest           = "bad_request"
+	CodeValidation           = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeConflict             = "conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeUnprocessable        = "unprocessable_entity"
+	CodeRateLimited          = "rate_limited"
+	CodeInternal             = "internal_error"
+	CodeBadGateway           = "bad_gateway"
+	CodeUnavailable          = "unavailable"
+	CodeTimeout              = "timeout"
+)
+
+// Codes of the details of a validation failure
+const (
+	DetailRequired    = "required"
+	DetailInvalid     = "invalid"
+	DetailOutOfRange  = "out_of_range"
+	DetailInvalidType = "invalid_type"
+	DetailMalformed   = "malformed"
+)
+
+// Codes lists every error code, for documentation
+var Codes = []string{
+	CodeBadRequest, CodeValidation, CodeUnauthorized, CodeForbidden, CodeNotFound, CodeMethodNotAllowed,
+	CodeConflict, CodePreconditionFailed, CodePayloadTooLarge, CodeUnsupportedMediaType, CodeUnprocessable,
+	CodeRateLimited, CodeInternal, CodeBadGateway, CodeUnavailable, CodeTimeout,
+}
+
+// statusCodes maps HTTP statuses to the code of errors that do not set one
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusConflict:              CodeConflict,
+	http.StatusPreconditionFailed:    CodePreconditionFailed,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusUnsupportedMediaType:  CodeUnsupportedMediaType,
+	http.StatusUnprocessableEntity:   CodeUnprocessable,
+	http.StatusTooManyRequests:       CodeRateLimited,
+	http.StatusInternalServerError:   CodeInternal,
+	http.StatusBadGateway:            CodeBadGateway,
+	http.StatusServiceUnavailable:    CodeUnavailable,
+	http.StatusGatewayTimeout:        CodeTimeout,
+}
+
+// Error is an error response. Handlers return it, or an *echo.HTTPError, and
+// Handler writes it with the request ID filled in.
+type Error struct {
+	HTTPStatus int      `json:"-"`
+	Status     string   `json:"status"` // always "error", next to "success" of other responses
+	Code       string   `json:"code"`
+	Message    string   `json:"message"`
+	Details    []Detail `json:"details,omitempty"`
+	RequestID  string   `json:"request_id,omitempty"`
+
+	// Internal is the underlying error; it is logged but never sent
+	Internal error `json:"-"`
+}
+
+// Detail describes a problem with one field of the request
+type Detail struct {
+	Field   string `json:"field,omitempty"` // JSON name of the body field or name of the parameter
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// New creates an error response. An empty code is derived from the status.
+func New(status int, code, message string) *Error {
+	if code == "" {
+		code = CodeFor(status)
+	}
+	return &Error{HTTPStatus: status, Status: "error", Code: code, Message: message}
+}
+
+// Invalid creates a 400 reporting invalid fields. The message is the one of
+// the only detail, or a summary when there are several.
+func Invalid(details ...Detail) *Error {
+	message := "Request validation failed"
+	if len(details) == 1 {
+		message = details[0].Message
+	}
+	err := New(http.StatusBadRequest, CodeValidation, message)
+	err.Details = details
+	return err
+}
+
+// Required reports a missing field
+func Required(field string) *Error {
+	return Invalid(Detail{Field: field, Code: DetailRequired, Message: field + " is required"})
+}
+
+// InvalidField reports a field whose value is not accepted; message reads
+// after the field name, such as "must be an integer"
+func InvalidField(field, message string) *Error {
+	return Invalid(Detail{Field: field, Code: DetailInvalid, Message: field + " " + message})
+}
+
+// CodeFor returns the code of errors with the given HTTP status
+func CodeFor(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// Error returns the message
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the internal error
+func (e *Error) Unwrap() error {
+	return e.Internal
+}
+
+// WithInternal sets the underlying error
+func (e *Error) WithInternal(err error) *Error {
+	e.Internal = err
+	return e
+}
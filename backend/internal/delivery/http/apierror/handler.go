@@ -0,0 +1,121 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler is the Echo HTTPErrorHandler. It writes every error returned by a
+// handler or middleware, and every route Echo fails to match, as an Error.
+func Handler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	apiErr := From(err)
+	apiErr.RequestID = c.Response().Header().Get(echo.HeaderXRequestID)
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(apiErr.HTTPStatus)
+	} else {
+		err = c.JSON(apiErr.HTTPStatus, apiErr)
+	}
+	if err != nil {
+		slog.WarnContext(c.Request().Context(), "failed to write error response", "error", err)
+	}
+}
+
+// From converts an error returned by a handler to an Error. Errors other than
+// *Error and *echo.HTTPError are unexpected and reported as a 500 without
+// their message.
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		var message string
+		switch m := httpErr.Message.(type) {
+		case string:
+			message = m
+		case error:
+			message = m.Error()
+		default:
+			message = fmt.Sprint(m)
+		}
+		return New(httpErr.Code, "", message).WithInternal(httpErr.Internal)
+	}
+
+	return New(http.StatusInternalServerError, CodeInternal, "Internal server error").WithInternal(err)
+}
+
+// FromBind converts an error of echo.Context.Bind to an Error, reporting the
+// offending field when the body has a value of the wrong type
+func FromBind(err error) *Error {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Code != http.StatusBadRequest {
+		// Such as an unsupported Content-Type
+		return From(httpErr)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		return Invalid(Detail{
+			Field:   typeErr.Field,
+			Code:    DetailInvalidType,
+			Message: field + " must be " + jsonType(typeErr.Type),
+		}).WithInternal(err)
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return Invalid(Detail{
+			Code:    DetailMalformed,
+			Message: fmt.Sprintf("Malformed JSON at offset %d", syntaxErr.Offset),
+		}).WithInternal(err)
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return Invalid(Detail{Code: DetailMalformed, Message: "Malformed JSON: unexpected end of body"}).WithInternal(err)
+	}
+
+	return New(http.StatusBadRequest, CodeBadRequest, "Invalid request body").WithInternal(err)
+}
+
+// jsonType names the JSON type a Go type is decoded from
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a non-negative integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return "a " + t.String()
+	}
+}
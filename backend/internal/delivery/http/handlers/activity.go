@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/usecase/activity"
+)
+
+// ActivityRecorder appends an entry to the audit log after a mutation.
+// before is nil for created resources and after is nil for deleted ones.
+type ActivityRecorder interface {
+	Record(ctx context.Context, action, resourceType, resourceID string, before, after interface{})
+}
+
+// activityCSVHeader is the first row of a CSV export
+var activityCSVHeader = []string{
+	"id", "created_at", "action", "resource_type", "resource_id", "actor_id", "actor",
+	"ip", "request_id", "user_agent", "changes",
+}
+
+// ActivityHandler handles audit log endpoints
+type ActivityHandler struct {
+	activity *activity.Service
+}
+
+// NewActivityHandler creates a new ActivityHandler
+func NewActivityHandler(activity *activity.Service) *ActivityHandler {
+	return &ActivityHandler{activity: activity}
+}
+
+// GetActivity returns a page of audit log entries matching the query filters,
+// newest first
+func (h *ActivityHandler) GetActivity(c echo.Context) error {
+	filter, err := parseActivityFilter(c)
+	if err != nil {
+		return err
+	}
+	opts, err := parseListOptions(c)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.activity.List(c.Request().Context(), filter, opts)
+	if err != nil {
+		return storeError(c, err, "Activity not found")
+	}
+
+	return c.JSON(http.StatusOK, newListResponse(page, opts))
+}
+
+// ExportActivity streams every audit log entry matching the query filters as
+// CSV or JSON Lines, selected with ?format=csv|jsonl
+func (h *ActivityHandler) ExportActivity(c echo.Context) error {
+	filter, err := parseActivityFilter(c)
+	if err != nil {
+		return err
+	}
+	opts, err := parseListOptions(c)
+	if err != nil {
+		return err
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "jsonl"
+	}
+	var contentType string
+	switch format {
+	case "csv":
+		contentType = "text/csv; charset=utf-8"
+	case "jsonl":
+		contentType = "application/x-ndjson"
+	default:
+		return apierror.InvalidField("format", "must be csv or jsonl")
+	}
+
+	// Validate the filter and sort before the response is committed
+	ctx := c.Request().Context()
+	if _, err := h.activity.List(ctx, filter, repositories.ListOptions{Limit: 1, Sort: opts.Sort}); err != nil {
+		return storeError(c, err, "Activity not found")
+	}
+
+	resp := c.Response()
+	resp.Header().Set(echo.HeaderContentType, contentType)
+	resp.Header().Set(echo.HeaderContentDisposition,
+		`attachment; filename="activity-`+time.Now().UTC().Format("20060102T150405Z")+"."+format+`"`)
+	resp.WriteHeader(http.StatusOK)
+
+	var write func(*entities.ActivityLog) error
+	if format == "csv" {
+		w := csv.NewWriter(resp)
+		if err := w.Write(activityCSVHeader); err != nil {
+			return nil
+		}
+		write = func(entry *entities.ActivityLog) error {
+			if err := w.Write(activityCSVRow(entry)); err != nil {
+				return err
+			}
+			w.Flush()
+			return w.Error()
+		}
+	} else {
+		enc := json.NewEncoder(resp)
+		write = func(entry *entities.ActivityLog) error {
+			return enc.Encode(entry)
+		}
+	}
+
+	count := 0
+	err = h.activity.Export(ctx, filter, opts.Sort, func(entry *entities.ActivityLog) error {
+		count++
+		return write(entry)
+	})
+	if err != nil {
+		// The status line is already sent; the truncated body signals the failure
+		slog.ErrorContext(ctx, "activity export failed", "format", format, "exported", count, "error", err)
+		return nil
+	}
+	slog.InfoContext(ctx, "activity exported", "format", format, "exported", count)
+	return nil
+}
+
+// activityCSVRow renders an entry as a CSV export row
+func activityCSVRow(entry *entities.ActivityLog) []string {
+	actorID, changes := "", ""
+	if entry.ActorID != nil {
+		actorID = strconv.FormatInt(*entry.ActorID, 10)
+	}
+	if len(entry.Changes) > 0 {
+		if b, err := json.Marshal(entry.Changes); err == nil {
+			changes = string(b)
+		}
+	}
+	return []string{
+		strconv.FormatInt(entry.ID, 10), entry.CreatedAt.UTC().Format(time.RFC3339), entry.Action,
+		entry.ResourceType, entry.ResourceID, actorID, entry.Actor, entry.IP, entry.RequestID,
+		entry.UserAgent, changes,
+	}
+}
+
+// parseActivityFilter reads the audit log filters from the query string
+func parseActivityFilter(c echo.Context) (repositories.ActivityFilter, error) {
+	filter := repositories.ActivityFilter{
+		Actor:        c.QueryParam("actor"),
+		Action:       splitList(c.QueryParam("action")),
+		ResourceType: c.QueryParam("resource_type"),
+		ResourceID:   c.QueryParam("resource_id"),
+		RequestID:    c.QueryParam("request_id"),
+	}
+
+	for _, bound := range []struct {
+		param  string
+		target **time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	} {
+		raw := c.QueryParam(bound.param)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, apierror.InvalidField(bound.param, "must be an RFC 3339 timestamp")
+		}
+		*bound.target = &t
+	}
+	return filter, nil
+}
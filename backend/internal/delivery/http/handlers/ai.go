@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/infrastructure/ai"
+	"ai-git-workbench/internal/usecase/assistant"
+	"ai-git-workbench/internal/usecase/auth"
+)
+
+// AIHandler handles AI assistant endpoints
+type AIHandler struct {
+	assistant *assistant.Service
+}
+
+// NewAIHandler creates a new AIHandler
+func NewAIHandler(assistant *assistant.Service) *AIHandler {
+	return &AIHandler{assistant: assistant}
+}
+
+// processRequest is the body of POST /ai/process
+type processRequest struct {
+	Prompt    string `json:"prompt" validate:"required"`
+	System    string `json:"system"`
+	MaxTokens int    `json:"max_tokens" validate:"gte=0,lte=8192"` // 0 uses the provider default
+}
+
+// Process sends a prompt to the AI provider and returns its completion
+func (h *AIHandler) Process(c echo.Context) error {
+	var req processRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	user := auth.UserFromContext(c.Request().Context())
+	resp, err := h.assistant.Complete(c.Request().Context(), user, entities.AIUsageProcess, "", ai.CompletionRequest{
+		System:    req.System,
+		Messages:  []ai.Message{{Role: "user", Content: req.Prompt}},
+		MaxTokens: req.MaxTokens,
+	})
+	if errors.Is(err, assistant.ErrTokenLimit) {
+		return echo.NewHTTPError(http.StatusTooManyRequests, "Daily AI token limit reached")
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "ai completion failed", "user_id", user.ID, "error", err)
+		return echo.NewHTTPError(http.StatusBadGateway, "AI provider request failed")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":   resp,
+		"status": "success",
+	})
+}
+
+// GetTokenStatus returns the AI token usage of the caller
+func (h *AIHandler) GetTokenStatus(c echo.Context) error {
+	status, err := h.assistant.Status(c.Request().Context(), auth.UserFromContext(c.Request().Context()))
+	if err != nil {
+		return storeError(c, err, "Usage not found")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":   status,
+		"status": "success",
+	})
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/infrastructure/github"
+	"ai-git-workbench/internal/usecase/auth"
+)
+
+const (
+	// stateCookie carries the OAuth state between the redirect and the callback
+	stateCookie = "oauth_state"
+	// stateTTL bounds how long a user may take to authorize the app
+	stateTTL = 10 * time.Minute
+	// signInTokenName names the tokens issued at sign-in
+	signInTokenName = "github-login"
+)
+
+// SignInOptions configures signing in with GitHub
+type SignInOptions struct {
+	CallbackURL      string // registered with the OAuth app
+	LoginRedirectURL string // receives the token as #token=; empty responds with JSON
+	SignupRole       string // role of users created at first sign-in; empty rejects unknown users
+	SessionTTL       time.Duration
+}
+
+// AuthHandler handles sign-in and session endpoints
+type AuthHandler struct {
+	auth     *auth.Service
+	oauth    *github.OAuth
+	github   *github.Client
+	opts     SignInOptions
+	activity ActivityRecorder
+}
+
+// NewAuthHandler creates a new AuthHandler. Signing in with GitHub is
+// disabled when oauth is nil.
+func NewAuthHandler(auth *auth.Service, oauth *github.OAuth, client *github.Client, opts SignInOptions,
+	activity ActivityRecorder) *AuthHandler {
+	return &AuthHandler{auth: auth, oauth: oauth, github: client, opts: opts, activity: activity}
+}
+
+// GitHubLogin redirects the user to GitHub to authorize the app
+func (h *AuthHandler) GitHubLogin(c echo.Context) error {
+	if h.oauth == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "GitHub sign-in is not configured")
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to generate oauth state", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+	state := hex.EncodeToString(b)
+
+	c.SetCookie(h.newStateCookie(state, int(stateTTL/time.Second)))
+	return c.Redirect(http.StatusFound, h.oauth.AuthorizeURL(state, h.opts.CallbackURL))
+}
+
+// GitHubCallback completes a GitHub sign-in and issues an API token for the user
+func (h *AuthHandler) GitHubCallback(c echo.Context) error {
+	if h.oauth == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "GitHub sign-in is not configured")
+	}
+	ctx := c.Request().Context()
+
+	cookie, err := c.Cookie(stateCookie)
+	state := c.QueryParam("state")
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid or expired sign-in state")
+	}
+	c.SetCookie(h.newStateCookie("", -1))
+
+	if reason := c.QueryParam("error"); reason != "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "GitHub sign-in was denied: "+reason)
+	}
+	code := c.QueryParam("code")
+	if code == "" {
+		return apierror.Required("code")
+	}
+
+	accessToken, err := h.oauth.Exchange(ctx, code, h.opts.CallbackURL)
+	if err != nil {
+		slog.WarnContext(ctx, "github oauth exchange failed", "error", err)
+		return echo.NewHTTPError(http.StatusUnauthorized, "GitHub sign-in failed")
+	}
+	profile, err := h.github.WithToken(accessToken).AuthenticatedUser(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to fetch github user", "error", err)
+		return echo.NewHTTPError(http.StatusBadGateway, "Failed to fetch GitHub user")
+	}
+
+	user, err := h.auth.SignIn(ctx, entities.User{Login: profile.Login, Name: profile.Name, Email: profile.Email},
+		h.opts.SignupRole)
+	if errors.Is(err, auth.ErrUnknownUser) {
+		slog.WarnContext(ctx, "sign-in by unregistered user", "login", profile.Login)
+		return echo.NewHTTPError(http.StatusForbidden, "User is not registered")
+	}
+	if err != nil {
+		return storeError(c, err, "User not found")
+	}
+
+	raw, token, err := h.auth.IssueToken(ctx, user, signInTokenName, h.opts.SessionTTL)
+	if err != nil {
+		return storeError(c, err, "User not found")
+	}
+
+	slog.InfoContext(ctx, "user signed in", "user_id", user.ID, "login", user.Login, "token_id", token.ID)
+	// The token is recorded against the user who just signed in
+	h.activity.Record(auth.WithUser(ctx, user), entities.ActivityTokenCreated, entities.ResourceToken,
+		strconv.FormatInt(token.ID, 10), nil, token)
+
+	if h.opts.LoginRedirectURL != "" {
+		// A fragment is not sent to servers or kept in their logs
+		return c.Redirect(http.StatusFound, h.opts.LoginRedirectURL+"#token="+url.QueryEscape(raw))
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Signed in successfully",
+		"token":   raw,
+		"user":    user,
+		"details": token,
+		"status":  "success",
+	})
+}
+
+// Logout revokes the token the request was made with
+func (h *AuthHandler) Logout(c echo.Context) error {
+	raw := strings.TrimSpace(strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer "))
+	if err := h.auth.Revoke(c.Request().Context(), raw); err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired token")
+		}
+		return storeError(c, err, "Token not found")
+	}
+
+	slog.InfoContext(c.Request().Context(), "user signed out", "user_id", auth.UserFromContext(c.Request().Context()).ID)
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Signed out successfully",
+		"status":  "success",
+	})
+}
+
+// Me returns the calling user
+func (h *AuthHandler) Me(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":   auth.UserFromContext(c.Request().Context()),
+		"status": "success",
+	})
+}
+
+// newStateCookie builds the OAuth state cookie; a negative maxAge deletes it
+func (h *AuthHandler) newStateCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     stateCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.opts.CallbackURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
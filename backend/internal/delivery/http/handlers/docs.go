@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/openapi"
+)
+
+// DocsHandler serves the OpenAPI document and the API reference rendering it
+type DocsHandler struct {
+	spec []byte
+}
+
+// NewDocsHandler creates a new DocsHandler. The document is built once; it
+// only changes with the code.
+func NewDocsHandler() *DocsHandler {
+	spec, err := json.Marshal(OpenAPI())
+	if err != nil {
+		// The document only holds plain values, so this is a programming error
+		panic("openapi: " + err.Error())
+	}
+	return &DocsHandler{spec: spec}
+}
+
+// Spec returns the OpenAPI document
+func (h *DocsHandler) Spec(c echo.Context) error {
+	return c.JSONBlob(http.StatusOK, h.spec)
+}
+
+// UI returns the API reference page, which loads the document from Spec
+func (h *DocsHandler) UI(c echo.Context) error {
+	return c.HTMLBlob(http.StatusOK, openapi.UI)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// storeError maps a persistence error to an HTTP error, logging unexpected failures
+func storeError(c echo.Context, err error, notFound string) error {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, notFound)
+	case errors.Is(err, repositories.ErrInvalidQuery):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, repositories.ErrConflict):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, repositories.ErrStale):
+		return echo.NewHTTPError(http.StatusPreconditionFailed, "The record was modified by another request; fetch it again")
+	default:
+		slog.ErrorContext(c.Request().Context(), "database operation failed", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+}
+
+// bindError reports a request body that could not be decoded, naming the
+// offending field when there is one
+func bindError(c echo.Context, err error) error {
+	slog.WarnContext(c.Request().Context(), "invalid request body", "error", err)
+	return apierror.FromBind(err)
+}
+
+// bind decodes the request body into req and validates it against its
+// `validate` struct tags
+func bind(c echo.Context, req interface{}) error {
+	if err := c.Bind(req); err != nil {
+		return bindError(c, err)
+	}
+	return c.Validate(req)
+}
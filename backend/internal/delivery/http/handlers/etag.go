@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+)
+
+// Conditional request headers (RFC 9110 section 13)
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// etag returns the entity tag of a version of a record
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// setETag sends the entity tag of the version of the record in the response
+func setETag(c echo.Context, version int64) {
+	c.Response().Header().Set(HeaderETag, etag(version))
+}
+
+// checkIfMatch rejects a request with 412 when its If-Match header names
+// none of the current version. Requests without the header are not checked.
+func checkIfMatch(c echo.Context, version int64) error {
+	header := c.Request().Header.Get(HeaderIfMatch)
+	if header == "" || matchETag(header, etag(version), false) {
+		return nil
+	}
+	return apierror.New(http.StatusPreconditionFailed, "",
+		"The record was modified since it was read (current version "+strconv.FormatInt(version, 10)+")")
+}
+
+// notModified reports whether the If-None-Match header of a GET names the
+// current version, in which case the client copy is still valid
+func notModified(c echo.Context, version int64) bool {
+	header := c.Request().Header.Get(HeaderIfNoneMatch)
+	return header != "" && matchETag(header, etag(version), true)
+}
+
+// matchETag reports whether a list of entity tags, or "*", contains tag. If-Match
+// compares strongly, so weak tags never match it; If-None-Match compares weakly.
+func matchETag(header, tag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/infrastructure/github"
+	"ai-git-workbench/internal/usecase/workflow"
+)
+
+// maxWebhookPayload bounds the size of a webhook delivery (GitHub caps them at 25MB)
+const maxWebhookPayload = 25 << 20
+
+// WebhookListener is notified of every verified webhook event, flattened by
+// github.ParseWebhookEvent
+type WebhookListener interface {
+	GitHubEvent(ctx context.Context, event map[string]string)
+}
+
+// GitHubHandler handles GitHub integration endpoints
+type GitHubHandler struct {
+	client        *github.Client
+	triggers      *workflow.Dispatcher
+	listeners     []WebhookListener
+	webhookSecret string
+}
+
+// NewGitHubHandler creates a new GitHubHandler. Webhooks are rejected unless
+// webhookSecret is set.
+func NewGitHubHandler(client *github.Client, triggers *workflow.Dispatcher, webhookSecret string,
+	listeners ...WebhookListener) *GitHubHandler {
+	return &GitHubHandler{client: client, triggers: triggers, listeners: listeners, webhookSecret: webhookSecret}
+}
+
+// Webhook verifies a webhook delivery and starts the workflow runs it triggers
+func (h *GitHubHandler) Webhook(c echo.Context) error {
+	if h.webhookSecret == "" {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "GitHub webhooks are not enabled")
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(c.Request().Body, maxWebhookPayload))
+	if err != nil {
+		slog.WarnContext(c.Request().Context(), "invalid request body", "error", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if !github.VerifySignature(h.webhookSecret, payload, c.Request().Header.Get(github.HeaderSignature)) {
+		slog.WarnContext(c.Request().Context(), "github webhook signature mismatch")
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid webhook signature")
+	}
+
+	name := c.Request().Header.Get(github.HeaderEvent)
+	if name == "ping" {
+		return c.JSON(http.StatusOK, map[string]string{
+			"message": "pong",
+			"status":  "OK",
+		})
+	}
+
+	event, err := github.ParseWebhookEvent(name, c.Request().Header.Get(github.HeaderDelivery), payload)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid webhook payload")
+	}
+
+	for _, listener := range h.listeners {
+		listener.GitHubEvent(c.Request().Context(), event)
+	}
+	runs := h.triggers.GitHubEvent(c.Request().Context(), event)
+	runIDs := make([]string, len(runs))
+	for i, run := range runs {
+		runIDs[i] = run.ID
+	}
+
+	slog.InfoContext(c.Request().Context(), "github webhook received",
+		"event", name, "action", event["action"], "repository", event["repository"], "runs", len(runs))
+
+	return c.JSON(http.StatusAccepted, map[string]interface{}{
+		"message": "GitHub webhook received",
+		"runs":    runIDs,
+		"status":  "OK",
+	})
+}
+
+// GetRepos returns the repositories visible to the configured GitHub token
+func (h *GitHubHandler) GetRepos(c echo.Context) error {
+	if h.client == nil || !h.client.Configured() {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "GitHub integration is not configured")
+	}
+
+	repos, err := h.client.ListRepositories(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to list github repositories", "error", err)
+		return echo.NewHTTPError(http.StatusBadGateway, "Failed to fetch GitHub repositories")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "GitHub repositories",
+		"repos":   repos,
+	})
+}
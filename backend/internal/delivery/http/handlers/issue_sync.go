@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/usecase/issuesync"
+)
+
+// IssueSyncHandler handles GitHub issue sync endpoints
+type IssueSyncHandler struct {
+	sync *issuesync.Service
+}
+
+// NewIssueSyncHandler creates a new IssueSyncHandler
+func NewIssueSyncHandler(sync *issuesync.Service) *IssueSyncHandler {
+	return &IssueSyncHandler{sync: sync}
+}
+
+// GetConflicts returns a page of the conflicts between tasks and their
+// issues, newest first, optionally of one repository or task
+func (h *IssueSyncHandler) GetConflicts(c echo.Context) error {
+	opts, err := parseListOptions(c)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.sync.Conflicts(c.Request().Context(), repositories.SyncConflictFilter{
+		Repository: c.QueryParam("repository"),
+		TaskID:     c.QueryParam("task_id"),
+	}, opts)
+	if err != nil {
+		return storeError(c, err, "Conflict not found")
+	}
+
+	return c.JSON(http.StatusOK, newListResponse(page, opts))
+}
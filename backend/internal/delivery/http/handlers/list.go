@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// listResponse is the envelope shared by every list endpoint. NextCursor is
+// null on the last page; pass it back as ?cursor= to fetch the next one.
+type listResponse[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+	HasMore    bool    `json:"has_more"`
+	Limit      int     `json:"limit"`
+	Status     string  `json:"status"`
+}
+
+// newListResponse wraps a page in the list envelope
+func newListResponse[T any](page repositories.Page[T], opts repositories.ListOptions) listResponse[T] {
+	resp := listResponse[T]{
+		Items:  page.Items,
+		Limit:  opts.PageSize(),
+		Status: "success",
+	}
+	if page.NextCursor != "" {
+		resp.NextCursor = &page.NextCursor
+		resp.HasMore = true
+	}
+	return resp
+}
+
+// parseListOptions reads ?limit=, ?cursor= and ?sort=field,-field
+func parseListOptions(c echo.Context) (repositories.ListOptions, error) {
+	opts := repositories.ListOptions{Cursor: c.QueryParam("cursor")}
+
+	if raw := c.QueryParam("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return opts, apierror.InvalidField("limit", "must be a positive integer")
+		}
+		if limit > repositories.MaxPageSize {
+			return opts, apierror.InvalidField("limit",
+				"must not exceed "+strconv.Itoa(repositories.MaxPageSize))
+		}
+		opts.Limit = limit
+	}
+
+	for _, field := range splitList(c.QueryParam("sort")) {
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(strings.TrimPrefix(field, "-"), "+")
+		opts.Sort = append(opts.Sort, repositories.SortField{Field: field, Desc: desc})
+	}
+	return opts, nil
+}
+
+// parseTaskFilter reads the task list filters from the query string
+func parseTaskFilter(c echo.Context) (repositories.TaskFilter, error) {
+	filter := repositories.TaskFilter{
+		Status:     splitList(c.QueryParam("status")),
+		Repository: c.QueryParam("repository"),
+		Epic:       c.QueryParam("epic"),
+		Branch:     c.QueryParam("branch"),
+		Assignee:   c.QueryParam("assignee"),
+	}
+	for _, status := range filter.Status {
+		if !entities.ValidTaskStatus(status) {
+			return filter, echo.NewHTTPError(http.StatusBadRequest, "unknown status "+strconv.Quote(status))
+		}
+	}
+
+	for _, bound := range []struct {
+		param  string
+		target **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+		{"updated_after", &filter.UpdatedAfter},
+		{"updated_before", &filter.UpdatedBefore},
+	} {
+		raw := c.QueryParam(bound.param)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, apierror.InvalidField(bound.param, "must be an RFC 3339 timestamp")
+		}
+		*bound.target = &t
+	}
+	return filter, nil
+}
+
+// splitList splits a comma separated query parameter, dropping empty items
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
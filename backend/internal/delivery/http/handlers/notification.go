@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/usecase/auth"
+	"ai-git-workbench/internal/usecase/notification"
+)
+
+// NotificationHandler handles notification subscription and delivery endpoints
+type NotificationHandler struct {
+	notifications *notification.Service
+}
+
+// NewNotificationHandler creates a new NotificationHandler
+func NewNotificationHandler(notifications *notification.Service) *NotificationHandler {
+	return &NotificationHandler{notifications: notifications}
+}
+
+// subscribeRequest is the body of POST /notifications/subscribe
+type subscribeRequest struct {
+	EventTypes []string `json:"event_types"`
+	Repository string   `json:"repository"`
+	Channel    string   `json:"channel"`
+	Target     string   `json:"target"`
+	Secret     string   `json:"secret"`
+}
+
+// sendRequest is the body of POST /notifications/send
+type sendRequest struct {
+	Type       string            `json:"type"`
+	Repository string            `json:"repository"`
+	Title      string            `json:"title"`
+	Message    string            `json:"message"`
+	URL        string            `json:"url"`
+	Data       map[string]string `json:"data"`
+	Recipients []string          `json:"recipients"`
+}
+
+// GetSubscriptions returns the caller's subscriptions and the available channels
+func (h *NotificationHandler) GetSubscriptions(c echo.Context) error {
+	subs, err := h.notifications.Subscriptions(c.Request().Context(), auth.UserFromContext(c.Request().Context()))
+	if err != nil {
+		return storeError(c, err, "Subscription not found")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"subscriptions": subs,
+		"channels":      h.notifications.Channels(),
+		"status":        "success",
+	})
+}
+
+// Subscribe subscribes the caller to event types on a channel
+func (h *NotificationHandler) Subscribe(c echo.Context) error {
+	var req subscribeRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	user := auth.UserFromContext(c.Request().Context())
+	sub := &entities.NotificationSubscription{
+		EventTypes: req.EventTypes,
+		Repository: req.Repository,
+		Channel:    req.Channel,
+		Target:     req.Target,
+		Secret:     req.Secret,
+	}
+	if err := h.notifications.Subscribe(c.Request().Context(), user, sub); err != nil {
+		return notificationError(c, err)
+	}
+
+	slog.InfoContext(c.Request().Context(), "notification subscription created",
+		"subscription_id", sub.ID, "user_id", user.ID, "channel", sub.Channel)
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message":      "Subscribed successfully",
+		"subscription": sub,
+		"status":       "success",
+	})
+}
+
+// Unsubscribe deletes a subscription of the caller
+func (h *NotificationHandler) Unsubscribe(c echo.Context) error {
+	id, err := subscriptionID(c)
+	if err != nil {
+		return err
+	}
+	if err := h.notifications.Unsubscribe(c.Request().Context(), auth.UserFromContext(c.Request().Context()), id); err != nil {
+		return notificationError(c, err)
+	}
+
+	slog.InfoContext(c.Request().Context(), "notification subscription deleted", "subscription_id", id)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Unsubscribed successfully",
+		"status":  "success",
+	})
+}
+
+// TestSubscription sends a test notification through a subscription of the
+// caller. A failed delivery is reported with 502; the channel error is only
+// logged.
+func (h *NotificationHandler) TestSubscription(c echo.Context) error {
+	id, err := subscriptionID(c)
+	if err != nil {
+		return err
+	}
+	delivery, err := h.notifications.Test(c.Request().Context(), auth.UserFromContext(c.Request().Context()), id)
+	if err != nil {
+		return notificationError(c, err)
+	}
+	// The cause is not returned so the endpoint cannot be used to probe what
+	// the target answers
+	if delivery.Error != "" {
+		return apierror.New(http.StatusBadGateway, "", "Test notification failed")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":  "Test notification sent",
+		"delivery": delivery,
+		"status":   "success",
+	})
+}
+
+// Send delivers an event to its subscribers and reports each delivery. It is
+// meant for events raised outside this server, such as budget alerts.
+func (h *NotificationHandler) Send(c echo.Context) error {
+	var req sendRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	if !entities.ValidEventType(req.Type) {
+		return apierror.InvalidField("type", "is not a known event type")
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		return apierror.Required("title")
+	}
+
+	deliveries, err := h.notifications.Deliver(c.Request().Context(), &entities.Notification{
+		Type:       req.Type,
+		Repository: req.Repository,
+		Title:      req.Title,
+		Message:    req.Message,
+		URL:        req.URL,
+		Data:       req.Data,
+		Recipients: req.Recipients,
+	})
+	if err != nil {
+		return storeError(c, err, "Subscription not found")
+	}
+
+	failed := 0
+	for _, d := range deliveries {
+		if d.Error != "" {
+			failed++
+		}
+	}
+	slog.InfoContext(c.Request().Context(), "notification sent",
+		"type", req.Type, "repository", req.Repository, "deliveries", len(deliveries), "failed", failed)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":    "Notification sent",
+		"deliveries": deliveries,
+		"failed":     failed,
+		"status":     "success",
+	})
+}
+
+// notificationError maps an invalid subscription to 400 and other errors as
+// store errors
+func notificationError(c echo.Context, err error) error {
+	if errors.Is(err, notification.ErrInvalidSubscription) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return storeError(c, err, "Subscription not found")
+}
+
+// subscriptionID parses the :id path parameter
+func subscriptionID(c echo.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid subscription ID")
+	}
+	return id, nil
+}
@@ -0,0 +1,733 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ai-git-workbench/internal/delivery/http/middleware"
+	"ai-git-workbench/internal/delivery/http/openapi"
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/infrastructure/ai"
+	"ai-git-workbench/internal/infrastructure/github"
+	"ai-git-workbench/internal/usecase/assistant"
+	"ai-git-workbench/internal/usecase/notification"
+	"ai-git-workbench/internal/usecase/search"
+	"ai-git-workbench/internal/usecase/workspace"
+)
+
+// APIVersion is the version of the HTTP API reported in the OpenAPI document
+const APIVersion = "1.0.0"
+
+// OpenAPI describes every /api/v1 route. Request and response schemas are
+// derived from the types the handlers bind and return, so they follow the
+// code; adding a route without describing it here fails the routes tests.
+func OpenAPI() *openapi.Document {
+	d := openapi.New(openapi.Info{
+		Title:   "AI Git Workbench API",
+		Version: APIVersion,
+		Description: "Tasks, repositories and workflows of the AI Git Workbench. Send an API token as " +
+			"`Authorization: Bearer <token>`; requests without one are anonymous.",
+	})
+	d.Servers = []openapi.Server{{URL: "/api/v1"}}
+	d.Tags = []openapi.Tag{
+		{Name: "Health"}, {Name: "Auth"}, {Name: "Tasks"}, {Name: "Repositories"}, {Name: "AI"},
+		{Name: "Search"}, {Name: "Tokens"}, {Name: "Activity"}, {Name: "GitHub"}, {Name: "Workflows"},
+		{Name: "Notifications"}, {Name: "Push"}, {Name: "Realtime"}, {Name: "Docs"},
+	}
+
+	describeHealth(d)
+	describeAuth(d)
+	describeTasks(d)
+	describeRepositories(d)
+	describeAI(d)
+	describeSearch(d)
+	describeActivity(d)
+	describeGitHub(d)
+	describeWorkflows(d)
+	describeNotifications(d)
+	describePush(d)
+
+	d.Add(http.MethodGet, "/ws", "streamChanges", "Stream task and repository changes", "Realtime").
+		Describe("Upgrades to a WebSocket. Clients send {\"action\": \"auth\"|\"subscribe\"|\"unsubscribe\"|\"ping\"} "+
+			"messages and receive change events for the topics they subscribed to.").
+		Returns(http.StatusSwitchingProtocols, "Switched to the WebSocket protocol", nil).
+		Errors(http.StatusBadRequest)
+	d.Add(http.MethodGet, "/openapi.json", "getOpenAPI", "This OpenAPI document", "Docs").
+		Returns(http.StatusOK, "The OpenAPI document", openapi.Any())
+	d.Add(http.MethodGet, "/docs", "getDocs", "API reference", "Docs").
+		ReturnsAs(http.StatusOK, "An HTML page rendering this document", "text/html", openapi.String())
+
+	describeIdempotency(d)
+	return d
+}
+
+// describeIdempotency documents the Idempotency-Key header every change accepts
+func describeIdempotency(d *openapi.Document) {
+	for _, item := range d.Paths {
+		for method, op := range item {
+			if method != "post" && method != "put" && method != "patch" && method != "delete" {
+				continue
+			}
+			op.Header(middleware.HeaderIdempotencyKey, openapi.String(),
+				"Retries with the same key and body get the first response again, marked "+
+					middleware.HeaderIdempotentReplayed+": true; another body with the key fails with 422. "+
+					"Bodies sent with a key are limited in size (413).").
+				Errors(http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity)
+		}
+	}
+}
+
+func describeHealth(d *openapi.Document) {
+	d.Add(http.MethodGet, "/health", "getHealth", "Health with runtime statistics", "Health").
+		Returns(http.StatusOK, "The server is healthy", openapi.Object(map[string]*openapi.Schema{
+			"status":    openapi.String(),
+			"timestamp": openapi.DateTime(),
+			"service":   openapi.String(),
+			"version":   openapi.String(),
+			"system":    openapi.MapOf(openapi.Any()),
+			"checks":    openapi.MapOf(openapi.Any()),
+		}, "status", "timestamp"))
+	d.Add(http.MethodGet, "/ping", "ping", "Liveness check", "Health").
+		Returns(http.StatusOK, "Pong", openapi.Object(map[string]*openapi.Schema{
+			"message": openapi.String(),
+			"status":  openapi.String(),
+		}, "message", "status"))
+}
+
+func describeAuth(d *openapi.Document) {
+	user := d.SchemaOf(entities.User{})
+	token := d.SchemaOf(entities.APIToken{})
+
+	d.Add(http.MethodGet, "/auth/github", "signInWithGitHub", "Start signing in with GitHub", "Auth").
+		Returns(http.StatusFound, "Redirect to GitHub to authorize the app", nil).
+		Errors(http.StatusServiceUnavailable)
+	d.Add(http.MethodGet, "/auth/github/callback", "completeGitHubSignIn", "Complete signing in with GitHub", "Auth").
+		Describe("Issues an API token. Redirects to the configured login page with #token= when one is set.").
+		RequiredQuery("state", openapi.String(), "The state sent to GitHub").
+		Query("code", openapi.String(), "The authorization code").
+		Query("error", openapi.String(), "Set by GitHub when the user denied access").
+		Returns(http.StatusOK, "Signed in", envelope(map[string]*openapi.Schema{
+			"message": openapi.String(),
+			"token":   openapi.String().Describe("The API token; only returned here"),
+			"user":    user,
+			"details": token,
+		})).
+		Returns(http.StatusFound, "Redirect to the login page with the token", nil).
+		Errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusBadGateway,
+			http.StatusServiceUnavailable)
+	d.Add(http.MethodPost, "/auth/logout", "signOut", "Revoke the token of the request", "Auth").
+		RequireUser().
+		Returns(http.StatusOK, "Signed out", message())
+	d.Add(http.MethodGet, "/auth/me", "getCurrentUser", "The calling user", "Auth").
+		RequireUser().
+		Returns(http.StatusOK, "The user", envelope(map[string]*openapi.Schema{"data": user}))
+
+	d.Add(http.MethodPost, "/tokens", "createToken", "Issue an API token for the caller", "Tokens").
+		Describe("The token expires after expires_in, a duration such as 720h. It defaults to the longest "+
+			"lifetime the server allows, and longer ones fail with 400.").
+		RequireUser().
+		Body(d.RequestSchemaOf(createTokenRequest{})).
+		Returns(http.StatusCreated, "The token; the secret is only returned here", envelope(map[string]*openapi.Schema{
+			"message": openapi.String(),
+			"token":   openapi.String(),
+			"details": token,
+		})).
+		Errors(http.StatusBadRequest)
+}
+
+func describeTasks(d *openapi.Document) {
+	task := d.SchemaOf(entities.Task{})
+	body := d.RequestSchemaOf(taskRequest{})
+	status := openapi.Enum(entities.TaskStatusPending, entities.TaskStatusQueued, entities.TaskStatusInProgress,
+		entities.TaskStatusCompleted, entities.TaskStatusFailed)
+	d.Property("Task", "status", status)
+	statusChange := func() *openapi.Schema {
+		return openapi.Enum(entities.TaskStatusPending, entities.TaskStatusQueued, entities.TaskStatusInProgress,
+			entities.TaskStatusCompleted, entities.TaskStatusFailed).Describe(taskStatusChanges)
+	}
+	d.Property("TaskRequest", "status", statusChange())
+
+	filter := func(op *openapi.Operation) *openapi.Operation {
+		op.Query("status", status, "Comma separated statuses").
+			Query("repository", openapi.String(), "Repository full name").
+			Query("epic", openapi.String(), "").
+			Query("branch", openapi.String(), "").
+			Query("assignee", openapi.String(), "")
+		for _, param := range []string{"created_after", "created_before", "updated_after", "updated_before"} {
+			op.Query(param, openapi.DateTime(), "RFC 3339 timestamp")
+		}
+		return op
+	}
+	sortable := "id, title, status, repository, epic, branch, assignee, tokens_used, created_at, updated_at"
+	listed(d, filter(d.Add(http.MethodGet, "/tasks", "listTasks", "List tasks", "Tasks")),
+		"TaskList", listResponse[entities.Task]{}, sortable)
+
+	filter(d.Add(http.MethodGet, "/tasks/export", "exportTasks", "Export tasks", "Tasks")).
+		Describe("Exports the tasks the caller can see. CSV columns are "+strings.Join(taskCSVHeader, ", ")+", with metadata as a JSON object. "+
+			"The Markdown checklist has an item per task, checked when completed, its description indented "+
+			"below and its other fields as a nested \"- field: value\" list.").
+		Query("format", openapi.Enum(formatJSONL, formatCSV, formatMarkdown), "Default jsonl").
+		Query("sort", openapi.String(), sortable+", - for descending").
+		ReturnsAs(http.StatusOK, "Every matching task", "application/x-ndjson", task).
+		ReturnsAs(http.StatusOK, "Every matching task", "text/csv", openapi.String()).
+		ReturnsAs(http.StatusOK, "Every matching task", "text/markdown", openapi.String()).
+		RequireUser().
+		Errors(http.StatusBadRequest)
+	importErr := d.NamedSchemaOf("TaskImportError", importError{})
+	d.Add(http.MethodPost, "/tasks/import", "importTasks", "Import tasks", "Tasks").
+		Describe("Creates a task per CSV row, JSON line or Markdown checklist item, in the formats of the "+
+			"export; ids and timestamps are ignored. Every row is validated and needs the same permission as a "+
+			"batch create. Rows move from pending to their status like a status change, so finished ones get "+
+			"completed_at, and cannot be in_progress. "+
+			"The tasks are created in one transaction when every row is valid, or else none is "+
+			"and the details name each problem as rows[i].field. At most "+strconv.Itoa(MaxImportRows)+" tasks.").
+		RequireUser().
+		Query("format", openapi.Enum(formatJSONL, formatCSV, formatMarkdown), "Default from the Content-Type").
+		Query("dry_run", openapi.Boolean(), "Only report the problems of every row").
+		BodyAs("text/csv", openapi.String()).
+		BodyAs("application/x-ndjson", openapi.String()).
+		BodyAs("text/markdown", openapi.String()).
+		Returns(http.StatusOK, "The report of a dry run", envelope(map[string]*openapi.Schema{
+			"dry_run": openapi.Boolean(),
+			"format":  openapi.String(),
+			"total":   openapi.Integer(),
+			"valid":   openapi.Integer(),
+			"invalid": openapi.Integer(),
+			"errors":  openapi.ArrayOf(importErr),
+		})).
+		Returns(http.StatusCreated, "The created tasks", envelope(map[string]*openapi.Schema{
+			"message":  openapi.String(),
+			"imported": openapi.Integer(),
+			"tasks":    openapi.ArrayOf(task),
+		})).
+		Errors(http.StatusBadRequest, http.StatusForbidden, http.StatusRequestEntityTooLarge,
+			http.StatusUnsupportedMediaType)
+
+	conditionalGet(d.Add(http.MethodGet, "/tasks/{id}", "getTask", "Get a task", "Tasks").
+		Returns(http.StatusOK, "The task", envelope(map[string]*openapi.Schema{"task": task})).
+		Errors(http.StatusNotFound))
+	d.Add(http.MethodPost, "/tasks", "createTask", "Create a task", "Tasks").
+		Describe(taskWriteAccess).
+		RequireUser().
+		Body(body).
+		Returns(http.StatusCreated, "The created task", taskChange(task)).
+		ReturnsHeader(http.StatusCreated, HeaderETag, openapi.String(), "Version of the task").
+		Errors(http.StatusBadRequest, http.StatusForbidden)
+	conditionalWrite(d.Add(http.MethodPut, "/tasks/{id}", "updateTask", "Replace the fields of a task", "Tasks").
+		Describe(taskWriteAccess).
+		RequireUser().
+		Body(body).
+		Returns(http.StatusOK, "The updated task", taskChange(task)).
+		ReturnsHeader(http.StatusOK, HeaderETag, openapi.String(), "Version of the updated task").
+		Errors(http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict))
+	conditionalWrite(patchBody(d.Add(http.MethodPatch, "/tasks/{id}", "patchTask", "Change some fields of a task", "Tasks"),
+		d.PatchSchemaOf("TaskPatch", taskRequest{})).
+		RequireUser().
+		Returns(http.StatusOK, "The updated task", taskChange(task)).
+		ReturnsHeader(http.StatusOK, HeaderETag, openapi.String(), "Version of the updated task").
+		Errors(http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict,
+			http.StatusUnsupportedMediaType))
+	conditionalWrite(d.Add(http.MethodDelete, "/tasks/{id}", "deleteTask", "Delete a task", "Tasks").
+		Describe(taskWriteAccess).
+		RequireUser().
+		Returns(http.StatusOK, "The task was deleted", envelope(map[string]*openapi.Schema{
+			"message": openapi.String(),
+			"task_id": openapi.String(),
+		})).
+		Errors(http.StatusForbidden, http.StatusNotFound))
+	d.NamedSchemaOf("TaskBatchOperation", batchOperation{})
+	d.Property("TaskBatchOperation", "status", statusChange())
+	result := d.NamedSchemaOf("TaskBatchResult", batchResult{})
+	d.NamedSchemaOf("TaskBatchRequest", batchRequest{})
+	d.Add(http.MethodPost, "/tasks:batch", "batchTasks", "Create, update, delete and transition tasks at once", "Tasks").
+		Describe("Up to "+strconv.Itoa(MaxBatchSize)+" operations: create takes task, update a merge patch in "+
+			"patch, transition a status, and every operation but create the task id. Status changes follow the "+
+			"rules of updates, failing with 409 when not allowed. A version fails the "+
+			"operation with 412 unless it is the current one. The operations are applied in one transaction, "+
+			"failing with the error of the first that cannot be carried out, its fields prefixed with "+
+			"operations[i]; with continue_on_error each is applied on its own and the response reports "+
+			"every outcome. Viewers cannot use it, and only owners and admins can change tasks of a private "+
+			"repository.").
+		RequireUser().
+		Body(d.RequestSchemaOf(batchRequest{})).
+		Returns(http.StatusOK, "The outcome of every operation", envelope(map[string]*openapi.Schema{
+			"results":   openapi.ArrayOf(result),
+			"succeeded": openapi.Integer(),
+			"failed":    openapi.Integer(),
+		})).
+		Errors(http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict,
+			http.StatusPreconditionFailed)
+	d.Add(http.MethodPost, "/tasks/{id}/execute", "executeTask", "Carry out a task with the AI provider", "Tasks").
+		Describe("The task moves to in_progress at once, then to completed or failed when the run finishes. "+
+			taskWriteAccess).
+		RequireUser().
+		Returns(http.StatusAccepted, "The execution started", taskChange(task)).
+		Errors(http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusServiceUnavailable)
+}
+
+// taskWriteAccess describes who may change a task
+const taskWriteAccess = "Viewers cannot change tasks, and only owners and admins can change tasks of a private repository."
+
+// taskStatusChanges describes the status changes allowed on an existing task
+const taskStatusChanges = "Changing the status of a task fails with 409 unless it is allowed: pending and queued " +
+	"move between each other or to completed and failed, finished tasks go back to pending or queued, and " +
+	"in_progress is left to execution. Finishing sets completed_at, and going back clears started_at and " +
+	"completed_at. Keeping the status is always allowed."
+
+// repositoryWriteAccess describes who may change a repository
+const repositoryWriteAccess = "Only the owner of the repository and admins can change it."
+
+// workflowWriteAccess describes who may change or run a workflow
+const workflowWriteAccess = "Viewers cannot use it, and only the creator of the workflow and admins can change or run it."
+
+func describeRepositories(d *openapi.Document) {
+	repo := d.SchemaOf(entities.Repository{})
+	body := d.RequestSchemaOf(repositoryRequest{})
+	id := &openapi.Schema{Type: "integer", Format: "int64"}
+	change := func() *openapi.Schema {
+		return envelope(map[string]*openapi.Schema{
+			"message":       openapi.String(),
+			"repository_id": id,
+			"repository":    repo,
+		})
+	}
+	clone := d.NamedSchemaOf("WorkspaceStatus", workspace.Status{})
+	d.Property("WorkspaceStatus", "path", openapi.String().Describe("Directory of the clone on the server, only returned by clone"))
+	// local describes the state of a clone; only cloning, which is limited to
+	// owners and admins, tells where it is on the server
+	local := func(cloned bool) *openapi.Schema {
+		props := map[string]*openapi.Schema{
+			"repository_id": id,
+			"connected":     openapi.Boolean(),
+			"workspace":     clone,
+		}
+		if cloned {
+			props["message"] = openapi.String()
+			props["local_path"] = openapi.String()
+		}
+		return envelope(props)
+	}
+
+	listed(d, d.Add(http.MethodGet, "/repositories", "listRepositories", "List repositories", "Repositories"),
+		"RepositoryList", listResponse[entities.Repository]{}, "id, name, full_name, stars, forks, created_at, updated_at")
+	conditionalGet(d.Add(http.MethodGet, "/repositories/{id}", "getRepository", "Get a repository", "Repositories").
+		PathParam("id", id, "").
+		Returns(http.StatusOK, "The repository", envelope(map[string]*openapi.Schema{
+			"repository": repo,
+			"repo_id":    id,
+		})).
+		Errors(http.StatusBadRequest, http.StatusNotFound))
+	d.Add(http.MethodPost, "/repositories", "createRepository", "Connect a repository", "Repositories").
+		Describe("The caller owns the repository. Viewers cannot connect repositories.").
+		RequireUser().
+		Body(body).
+		Returns(http.StatusCreated, "The connected repository", change()).
+		ReturnsHeader(http.StatusCreated, HeaderETag, openapi.String(), "Version of the repository").
+		Errors(http.StatusBadRequest, http.StatusForbidden, http.StatusConflict)
+	conditionalWrite(d.Add(http.MethodPut, "/repositories/{id}", "updateRepository", "Replace the fields of a repository", "Repositories").
+		Describe(repositoryWriteAccess).
+		RequireUser().
+		PathParam("id", id, "").
+		Body(body).
+		Returns(http.StatusOK, "The updated repository", change()).
+		ReturnsHeader(http.StatusOK, HeaderETag, openapi.String(), "Version of the updated repository").
+		Errors(http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict))
+	conditionalWrite(patchBody(d.Add(http.MethodPatch, "/repositories/{id}", "patchRepository",
+		"Change some fields of a repository", "Repositories"), d.PatchSchemaOf("RepositoryPatch", repositoryRequest{})).
+		RequireUser().
+		PathParam("id", id, "").
+		Returns(http.StatusOK, "The updated repository", change()).
+		ReturnsHeader(http.StatusOK, HeaderETag, openapi.String(), "Version of the updated repository").
+		Errors(http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict,
+			http.StatusUnsupportedMediaType))
+	conditionalWrite(d.Add(http.MethodDelete, "/repositories/{id}", "deleteRepository", "Disconnect a repository", "Repositories").
+		Describe(repositoryWriteAccess).
+		RequireUser().
+		PathParam("id", id, "").
+		Returns(http.StatusOK, "The repository was disconnected", envelope(map[string]*openapi.Schema{
+			"message":       openapi.String(),
+			"repository_id": id,
+		})).
+		Errors(http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound))
+
+	cloneErrors := []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusBadGateway,
+		http.StatusGatewayTimeout}
+	d.Add(http.MethodPost, "/repositories/{id}/clone", "cloneRepository", "Clone or fetch into the workspace", "Repositories").
+		Describe(repositoryWriteAccess).
+		PathParam("id", id, "").
+		RequireUser().
+		Returns(http.StatusOK, "The repository was cloned or fetched", local(true)).
+		Errors(cloneErrors...)
+	d.Add(http.MethodGet, "/repositories/{id}/status", "getRepositoryStatus", "State of the local clone", "Repositories").
+		PathParam("id", id, "").
+		Returns(http.StatusOK, "The clone state", local(false)).
+		Errors(http.StatusBadRequest, http.StatusNotFound)
+
+	// Routes of the former standalone server
+	listed(d, d.Add(http.MethodGet, "/repos", "listReposDeprecated", "List repositories", "Repositories").
+		Deprecate().
+		RequireUser(),
+		"RepositoryList", listResponse[entities.Repository]{}, "id, name, full_name, stars, forks, created_at, updated_at")
+	d.Add(http.MethodPost, "/repos/clone", "cloneRepoDeprecated", "Clone or fetch into the workspace", "Repositories").
+		Describe(repositoryWriteAccess).
+		Deprecate().
+		RequireUser().
+		Body(d.RequestSchemaOf(cloneRequest{})).
+		Returns(http.StatusOK, "The repository was cloned or fetched", local(true)).
+		Errors(cloneErrors...)
+	d.Add(http.MethodGet, "/repos/{id}/status", "getRepoStatusDeprecated", "State of the local clone", "Repositories").
+		Deprecate().
+		PathParam("id", id, "").
+		RequireUser().
+		Returns(http.StatusOK, "The clone state", local(false)).
+		Errors(http.StatusBadRequest, http.StatusNotFound)
+}
+
+func describeAI(d *openapi.Document) {
+	d.Add(http.MethodPost, "/ai/process", "processPrompt", "Complete a prompt", "AI").
+		RequireUser().
+		Body(d.RequestSchemaOf(processRequest{})).
+		Returns(http.StatusOK, "The completion", envelope(map[string]*openapi.Schema{
+			"data": d.SchemaOf(ai.CompletionResponse{}),
+		})).
+		Errors(http.StatusBadRequest, http.StatusTooManyRequests, http.StatusBadGateway)
+	d.Add(http.MethodGet, "/ai/tokens/status", "getTokenStatus", "AI token usage of the caller", "AI").
+		RequireUser().
+		Returns(http.StatusOK, "Usage today and this month (UTC)", envelope(map[string]*openapi.Schema{
+			"data": d.NamedSchemaOf("AIUsageStatus", assistant.Status{}),
+		}))
+}
+
+func describeSearch(d *openapi.Document) {
+	d.Add(http.MethodGet, "/search", "search", "Search tasks and repositories", "Search").
+		RequiredQuery("q", openapi.String(), "Search terms").
+		Query("type", openapi.Enum(repositories.SearchTypeTask, repositories.SearchTypeRepository),
+			"Comma separated record types").
+		Query("limit", openapi.Integer(), "At most "+strconv.Itoa(search.MaxLimit)+", default "+
+			strconv.Itoa(search.DefaultLimit)).
+		Query("offset", openapi.Integer(), "").
+		Returns(http.StatusOK, "Hits ranked by relevance", envelope(map[string]*openapi.Schema{
+			"query":   openapi.String(),
+			"results": openapi.ArrayOf(d.NamedSchemaOf("SearchHit", search.Hit{})),
+			"facets":  openapi.MapOf(openapi.Integer()).Describe("Hits per record type"),
+			"total":   openapi.Integer(),
+			"limit":   openapi.Integer(),
+			"offset":  openapi.Integer(),
+		})).
+		Errors(http.StatusBadRequest)
+}
+
+func describeActivity(d *openapi.Document) {
+	filter := func(op *openapi.Operation) *openapi.Operation {
+		return op.Query("actor", openapi.String(), "Login of the user").
+			Query("action", openapi.String(), "Comma separated actions such as task.updated").
+			Query("resource_type", openapi.String(), "").
+			Query("resource_id", openapi.String(), "").
+			Query("request_id", openapi.String(), "").
+			Query("since", openapi.DateTime(), "RFC 3339 timestamp").
+			Query("until", openapi.DateTime(), "RFC 3339 timestamp")
+	}
+
+	listed(d, filter(d.Add(http.MethodGet, "/activity", "listActivity", "Audit log", "Activity").RequireAdmin()),
+		"ActivityList", listResponse[entities.ActivityLog]{}, "id, created_at")
+	filter(d.Add(http.MethodGet, "/activity/export", "exportActivity", "Export the audit log", "Activity")).
+		RequireAdmin().
+		Query("format", openapi.Enum("jsonl", "csv"), "Default jsonl").
+		Query("sort", openapi.String(), "id or created_at, - for descending").
+		ReturnsAs(http.StatusOK, "Every matching entry", "application/x-ndjson", d.SchemaOf(entities.ActivityLog{})).
+		ReturnsAs(http.StatusOK, "Every matching entry", "text/csv", openapi.String()).
+		Errors(http.StatusBadRequest)
+}
+
+func describeGitHub(d *openapi.Document) {
+	d.Add(http.MethodPost, "/github/webhook", "receiveGitHubWebhook", "Receive a GitHub webhook", "GitHub").
+		Describe("Verified with X-Hub-Signature-256. Starts the workflow runs the event triggers and applies "+
+			"issues events to the tasks mirroring them.").
+		BodyAs("application/json", openapi.Any()).
+		Returns(http.StatusOK, "Pong to a ping event", openapi.Object(map[string]*openapi.Schema{
+			"message": openapi.String(),
+			"status":  openapi.String(),
+		})).
+		Returns(http.StatusAccepted, "The event was received", openapi.Object(map[string]*openapi.Schema{
+			"message": openapi.String(),
+			"runs":    openapi.ArrayOf(openapi.String()).Describe("IDs of the runs started"),
+			"status":  openapi.String(),
+		})).
+		Errors(http.StatusBadRequest, http.StatusUnauthorized, http.StatusServiceUnavailable)
+	d.Add(http.MethodGet, "/github/repos", "listGitHubRepositories", "Repositories visible to the GitHub token", "GitHub").
+		Describe("Every repository the server's GitHub token can see, private ones included.").
+		RequireAdmin().
+		Returns(http.StatusOK, "The repositories", openapi.Object(map[string]*openapi.Schema{
+			"message": openapi.String(),
+			"repos":   openapi.ArrayOf(d.NamedSchemaOf("GitHubRepository", github.Repository{})),
+		})).
+		Errors(http.StatusBadGateway, http.StatusServiceUnavailable)
+
+	d.SchemaOf(entities.SyncConflict{})
+	d.Property("SyncConflict", "winner", openapi.Enum(entities.SyncSideTask, entities.SyncSideIssue))
+	listed(d, d.Add(http.MethodGet, "/issue-sync/conflicts", "listSyncConflicts", "Issue sync conflicts", "GitHub").
+		Describe("Tasks and issues that both changed since they were last synced. The side updated last "+
+			"overwrote the other.").
+		RequireAdmin().
+		Query("repository", openapi.String(), "Full name of the GitHub repository").
+		Query("task_id", openapi.String(), ""),
+		"SyncConflictList", listResponse[entities.SyncConflict]{}, "id, created_at")
+}
+
+func describeWorkflows(d *openapi.Document) {
+	wf := d.SchemaOf(entities.Workflow{})
+	run := d.SchemaOf(entities.WorkflowRun{})
+	d.Property("WorkflowRun", "status", openapi.Enum(entities.RunStatusQueued, entities.RunStatusRunning,
+		entities.RunStatusWaiting, entities.RunStatusSucceeded, entities.RunStatusFailed))
+	definition := d.SchemaOf(entities.WorkflowDefinition{})
+	id := &openapi.Schema{Type: "integer", Format: "int64"}
+	withBody := func(op *openapi.Operation) *openapi.Operation {
+		return op.BodyAs("application/yaml", definition).Body(definition).
+			Returns(http.StatusRequestEntityTooLarge, http.StatusText(http.StatusRequestEntityTooLarge),
+				openapi.Ref("Error"))
+	}
+	changed := func() *openapi.Schema {
+		return envelope(map[string]*openapi.Schema{"message": openapi.String(), "workflow": wf})
+	}
+	decided := func() *openapi.Schema {
+		return envelope(map[string]*openapi.Schema{"message": openapi.String(), "run": run})
+	}
+
+	listed(d, d.Add(http.MethodGet, "/workflows", "listWorkflows", "List workflows", "Workflows").RequireUser(),
+		"WorkflowList", listResponse[entities.Workflow]{}, "id, name, created_at, updated_at")
+	d.Add(http.MethodGet, "/workflows/{id}", "getWorkflow", "Get a workflow with its latest definition", "Workflows").
+		PathParam("id", id, "").
+		RequireUser().
+		Returns(http.StatusOK, "The workflow", envelope(map[string]*openapi.Schema{"workflow": wf})).
+		Errors(http.StatusBadRequest, http.StatusNotFound)
+	withBody(d.Add(http.MethodPost, "/workflows", "createWorkflow", "Create a workflow", "Workflows")).
+		Describe("The body is a YAML document, or JSON when sent as application/json. Viewers cannot create workflows.").
+		RequireUser().
+		Returns(http.StatusCreated, "The workflow", changed()).
+		Errors(http.StatusBadRequest, http.StatusForbidden, http.StatusConflict)
+	withBody(d.Add(http.MethodPut, "/workflows/{id}", "updateWorkflow", "Save a new version of a workflow", "Workflows")).
+		Describe(workflowWriteAccess).
+		PathParam("id", id, "").
+		RequireUser().
+		Returns(http.StatusOK, "The workflow", changed()).
+		Errors(http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict)
+	d.Add(http.MethodDelete, "/workflows/{id}", "deleteWorkflow", "Delete a workflow with its versions and runs", "Workflows").
+		Describe(workflowWriteAccess).
+		PathParam("id", id, "").
+		RequireUser().
+		Returns(http.StatusOK, "The workflow was deleted", envelope(map[string]*openapi.Schema{
+			"message":     openapi.String(),
+			"workflow_id": id,
+		})).
+		Errors(http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound)
+
+	version := d.SchemaOf(entities.WorkflowVersion{})
+	d.Add(http.MethodGet, "/workflows/{id}/versions", "listWorkflowVersions", "Versions of a workflow", "Workflows").
+		PathParam("id", id, "").
+		RequireUser().
+		Returns(http.StatusOK, "The versions, newest first", envelope(map[string]*openapi.Schema{
+			"versions": openapi.ArrayOf(version),
+			"count":    openapi.Integer(),
+		})).
+		Errors(http.StatusBadRequest, http.StatusNotFound)
+	d.Add(http.MethodGet, "/workflows/{id}/versions/{version}", "getWorkflowVersion", "A version of a workflow", "Workflows").
+		PathParam("id", id, "").
+		PathParam("version", openapi.Integer(), "").
+		RequireUser().
+		Returns(http.StatusOK, "The version with its source document", envelope(map[string]*openapi.Schema{
+			"version": version,
+		})).
+		Errors(http.StatusBadRequest, http.StatusNotFound)
+
+	listed(d, d.Add(http.MethodGet, "/workflows/{id}/runs", "listWorkflowRuns", "Runs of a workflow", "Workflows").
+		PathParam("id", id, "").
+		Query("status", openapi.String(), "Comma separated run statuses").
+		Query("trigger", openapi.String(), "Comma separated triggers").
+		RequireUser(),
+		"WorkflowRunList", listResponse[entities.WorkflowRun]{}, "id, status, created_at")
+	d.Add(http.MethodPost, "/workflows/{id}/runs", "startWorkflowRun", "Start a run", "Workflows").
+		Describe(workflowWriteAccess).
+		PathParam("id", id, "").
+		RequireUser().
+		Body(d.RequestSchemaOf(runRequest{})).
+		Returns(http.StatusCreated, "The run", decided()).
+		Errors(http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusServiceUnavailable)
+	d.Add(http.MethodGet, "/workflows/{id}/runs/{run_id}", "getWorkflowRun", "A run with its steps and audit trail", "Workflows").
+		PathParam("id", id, "").
+		RequireUser().
+		Returns(http.StatusOK, "The run", envelope(map[string]*openapi.Schema{"run": run})).
+		Errors(http.StatusBadRequest, http.StatusNotFound)
+	for _, decision := range []string{"approve", "reject"} {
+		d.Add(http.MethodPost, "/workflows/{id}/runs/{run_id}/steps/{step_id}/"+decision,
+			decision+"WorkflowStep", capitalize(decision)+" a step waiting for approval", "Workflows").
+			PathParam("id", id, "").
+			RequireUser().
+			Body(d.RequestSchemaOf(decisionRequest{})).
+			Returns(http.StatusOK, "The run after the decision", decided()).
+			Errors(http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict)
+	}
+}
+
+func describeNotifications(d *openapi.Document) {
+	sub := d.SchemaOf(entities.NotificationSubscription{})
+	delivery := d.NamedSchemaOf("NotificationDelivery", notification.Delivery{})
+	id := &openapi.Schema{Type: "integer", Format: "int64"}
+
+	d.Add(http.MethodGet, "/notifications/subscriptions", "listSubscriptions", "Subscriptions of the caller", "Notifications").
+		RequireUser().
+		Returns(http.StatusOK, "The subscriptions and the channels offered", envelope(map[string]*openapi.Schema{
+			"subscriptions": openapi.ArrayOf(sub),
+			"channels":      openapi.ArrayOf(openapi.String()),
+		}))
+	d.Add(http.MethodPost, "/notifications/subscribe", "subscribe", "Subscribe to events", "Notifications").
+		RequireUser().
+		Body(d.RequestSchemaOf(subscribeRequest{}, "event_types", "channel")).
+		Returns(http.StatusCreated, "The subscription", envelope(map[string]*openapi.Schema{
+			"message":      openapi.String(),
+			"subscription": sub,
+		})).
+		Errors(http.StatusBadRequest)
+	d.Add(http.MethodDelete, "/notifications/subscriptions/{id}", "unsubscribe", "Delete a subscription", "Notifications").
+		PathParam("id", id, "").
+		RequireUser().
+		Returns(http.StatusOK, "The subscription was deleted", message()).
+		Errors(http.StatusBadRequest, http.StatusNotFound)
+	d.Add(http.MethodPost, "/notifications/subscriptions/{id}/test", "testSubscription", "Send a test notification", "Notifications").
+		PathParam("id", id, "").
+		RequireUser().
+		Returns(http.StatusOK, "The delivery", envelope(map[string]*openapi.Schema{
+			"message":  openapi.String(),
+			"delivery": delivery,
+		})).
+		Errors(http.StatusBadRequest, http.StatusNotFound, http.StatusBadGateway)
+	d.Add(http.MethodPost, "/notifications/send", "sendNotification", "Deliver an event to its subscribers", "Notifications").
+		RequireAdmin().
+		Body(d.RequestSchemaOf(sendRequest{}, "type", "title")).
+		Returns(http.StatusOK, "The deliveries", envelope(map[string]*openapi.Schema{
+			"message":    openapi.String(),
+			"deliveries": openapi.ArrayOf(delivery),
+			"failed":     openapi.Integer(),
+		})).
+		Errors(http.StatusBadRequest)
+}
+
+func describePush(d *openapi.Document) {
+	sub := d.SchemaOf(entities.PushSubscription{})
+	body := d.RequestSchemaOf(pushSubscriptionRequest{}, "endpoint")
+	push := func(op *openapi.Operation) *openapi.Operation {
+		return op.Errors(http.StatusServiceUnavailable)
+	}
+
+	push(d.Add(http.MethodGet, "/push/vapid-public-key", "getVAPIDPublicKey", "The application server key", "Push")).
+		Returns(http.StatusOK, "The base64url public key", envelope(map[string]*openapi.Schema{
+			"public_key": openapi.String(),
+		}))
+	push(d.Add(http.MethodGet, "/push/subscriptions", "listPushSubscriptions", "Browsers of the caller", "Push")).
+		RequireUser().
+		Returns(http.StatusOK, "The subscriptions", envelope(map[string]*openapi.Schema{
+			"subscriptions": openapi.ArrayOf(sub),
+			"count":         openapi.Integer(),
+		}))
+	push(d.Add(http.MethodPost, "/push/subscriptions", "subscribePush", "Register a browser", "Push")).
+		RequireUser().
+		Body(body).
+		Returns(http.StatusCreated, "The subscription", envelope(map[string]*openapi.Schema{
+			"message":      openapi.String(),
+			"subscription": sub,
+		})).
+		Errors(http.StatusBadRequest)
+	push(d.Add(http.MethodPost, "/push/unsubscribe", "unsubscribePush", "Remove a browser", "Push")).
+		RequireUser().
+		Body(body).
+		Returns(http.StatusOK, "The subscription was removed", message()).
+		Errors(http.StatusBadRequest, http.StatusNotFound)
+	push(d.Add(http.MethodPost, "/push/test", "testPush", "Send a test message to every browser of the caller", "Push")).
+		RequireUser().
+		Returns(http.StatusOK, "The deliveries", envelope(map[string]*openapi.Schema{
+			"message":    openapi.String(),
+			"deliveries": openapi.ArrayOf(d.SchemaOf(notification.PushDelivery{})),
+			"failed":     openapi.Integer(),
+			"pruned":     openapi.Integer(),
+		}))
+}
+
+// listed describes the pagination parameters and list envelope of a list
+// operation. page is a listResponse of the item type.
+func listed(d *openapi.Document, op *openapi.Operation, name string, page any, sortFields string) {
+	op.Query("limit", openapi.Integer(), "Page size, at most "+strconv.Itoa(repositories.MaxPageSize)+
+		", default "+strconv.Itoa(repositories.DefaultPageSize)).
+		Query("cursor", openapi.String(), "next_cursor of the previous page").
+		Query("sort", openapi.String(), "Comma separated fields, - for descending: "+sortFields).
+		Returns(http.StatusOK, "A page", d.NamedSchemaOf(name, page)).
+		Errors(http.StatusBadRequest)
+}
+
+// envelope describes a success response holding properties next to "status"
+func envelope(properties map[string]*openapi.Schema) *openapi.Schema {
+	properties["status"] = openapi.Enum("success")
+	required := make([]string, 0, len(properties))
+	for name := range properties {
+		required = append(required, name)
+	}
+	sort.Strings(required)
+	return openapi.Object(properties, required...)
+}
+
+// conditionalGet documents the ETag of a record and the 304 answering an
+// If-None-Match that names it
+func conditionalGet(op *openapi.Operation) {
+	op.Header(HeaderIfNoneMatch, openapi.String(), "ETag of a copy held by the client").
+		ReturnsHeader(http.StatusOK, HeaderETag, openapi.String(), "Version of the record, such as \"3\"").
+		Returns(http.StatusNotModified, "The copy named by If-None-Match is current", nil)
+}
+
+// conditionalWrite documents the If-Match precondition of a change
+func conditionalWrite(op *openapi.Operation) {
+	op.Header(HeaderIfMatch, openapi.String(), "ETag the record must still have; the change fails with 412 otherwise").
+		Errors(http.StatusPreconditionFailed)
+}
+
+// patchBody documents the bodies a PATCH accepts: a merge patch, also as plain
+// JSON, or a JSON Patch of the request fields
+func patchBody(op *openapi.Operation, mergePatch *openapi.Schema) *openapi.Operation {
+	jsonPatch := openapi.ArrayOf(openapi.Object(map[string]*openapi.Schema{
+		"op":    openapi.Enum("add", "remove", "replace", "move", "copy", "test"),
+		"path":  openapi.String().Describe("JSON Pointer such as /metadata/key"),
+		"from":  openapi.String().Describe("Source of move and copy"),
+		"value": openapi.Any(),
+	}, "op", "path"))
+	return op.Describe("The body is applied to the current fields and the result is validated like a full update. "+
+		"In a merge patch, null removes a field or a metadata key; a failed JSON Patch test fails with 409. "+
+		"Members that are not fields of the body, such as id or version, fail with 422, and a patch that "+
+		"changes nothing returns the record without storing it. Bodies are limited in size like those sent "+
+		"with an Idempotency-Key (413).").
+		BodyAs(MIMEMergePatch, mergePatch).
+		BodyAs("application/json", mergePatch).
+		BodyAs(MIMEJSONPatch, jsonPatch).
+		Errors(http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity)
+}
+
+// message describes a success response with only a message
+func message() *openapi.Schema {
+	return envelope(map[string]*openapi.Schema{"message": openapi.String()})
+}
+
+// taskChange describes the response to creating or changing a task
+func taskChange(task *openapi.Schema) *openapi.Schema {
+	return envelope(map[string]*openapi.Schema{
+		"message": openapi.String(),
+		"task_id": openapi.String(),
+		"task":    task,
+	})
+}
+
+// capitalize upper-cases the first letter of an ASCII word
+func capitalize(word string) string {
+	if word == "" {
+		return word
+	}
+	return string(word[0]-'a'+'A') + word[1:]
+}
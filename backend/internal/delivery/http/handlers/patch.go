@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/delivery/http/middleware"
+)
+
+// Media types of PATCH bodies
+const (
+	// MIMEMergePatch is an RFC 7396 merge patch. Plain application/json is
+	// read as one too.
+	MIMEMergePatch = "application/merge-patch+json"
+	// MIMEJSONPatch is an RFC 6902 list of patch operations
+	MIMEJSONPatch = "application/json-patch+json"
+)
+
+// defaultMaxPatchBody bounds PATCH bodies when no Idempotency middleware set
+// a limit
+const defaultMaxPatchBody = 10 << 20
+
+// bindPatch applies the request body, a merge patch or a JSON Patch, to req,
+// a pointer to a request struct holding the current fields of the record,
+// and validates the result. Fields the patch removes are left at their zero
+// value, and members that are not fields of req, such as id or version, are
+// rejected with 422. Bodies above the limit of the Idempotency middleware
+// are rejected with 413.
+func bindPatch(c echo.Context, req interface{}) error {
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if mediaType != MIMEMergePatch && mediaType != MIMEJSONPatch && mediaType != echo.MIMEApplicationJSON {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType,
+			"Content-Type must be "+MIMEMergePatch+" or "+MIMEJSONPatch)
+	}
+
+	maxBody := middleware.GetMaxBody(c)
+	if maxBody <= 0 {
+		maxBody = defaultMaxPatchBody
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, maxBody))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return apierror.New(http.StatusRequestEntityTooLarge, "",
+			"Request body is larger than "+strconv.FormatInt(maxBody, 10)+" bytes")
+	}
+	if err != nil {
+		return bindError(c, err)
+	}
+	// Report malformed bodies the way other requests do
+	var patch interface{}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return bindError(c, err)
+	}
+
+	if mediaType != MIMEJSONPatch {
+		return mergePatch(c, req, body)
+	}
+
+	if _, ok := patch.([]interface{}); !ok {
+		return apierror.New(http.StatusBadRequest, apierror.CodeBadRequest, "A JSON Patch must be an array of operations")
+	}
+	operations, err := jsonpatch.DecodePatch(body)
+	if err != nil {
+		return apierror.New(http.StatusBadRequest, apierror.CodeBadRequest, "Invalid JSON Patch: "+err.Error())
+	}
+	current, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	patched, err := operations.Apply(current)
+	if err != nil {
+		// Such as a failed test or a path that does not exist (RFC 5789 section 2.2)
+		return apierror.New(http.StatusConflict, apierror.CodeConflict, "The patch could not be applied: "+err.Error())
+	}
+	return decodePatched(c, req, patched)
+}
+
+// mergePatch applies an RFC 7396 merge patch to req like bindPatch
+func mergePatch(c echo.Context, req interface{}, patch []byte) error {
+	// Any other value would replace the whole record
+	if !bytes.HasPrefix(bytes.TrimSpace(patch), []byte("{")) {
+		return apierror.New(http.StatusBadRequest, apierror.CodeBadRequest, "A merge patch must be a JSON object")
+	}
+	current, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	patched, err := jsonpatch.MergePatch(current, patch)
+	if err != nil {
+		return apierror.New(http.StatusBadRequest, apierror.CodeBadRequest, "Invalid merge patch: "+err.Error())
+	}
+	return decodePatched(c, req, patched)
+}
+
+// decodePatched replaces req with the patched document and validates it
+func decodePatched(c echo.Context, req interface{}, patched []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patched, &members); err != nil {
+		return bindError(c, err)
+	}
+	if field := unknownMember(reflect.TypeOf(req).Elem(), members); field != "" {
+		err := apierror.New(http.StatusUnprocessableEntity, "", field+" is not a field that can be changed")
+		err.Details = []apierror.Detail{{Field: field, Code: apierror.DetailInvalid, Message: err.Message}}
+		return err
+	}
+
+	// Decode onto a zero value so removed fields do not keep their old value
+	target := reflect.ValueOf(req).Elem()
+	target.Set(reflect.Zero(target.Type()))
+	if err := json.NewDecoder(bytes.NewReader(patched)).Decode(req); err != nil {
+		return bindError(c, err)
+	}
+	return c.Validate(req)
+}
+
+// unknownMember returns the first member, in name order, that is not the
+// JSON name of a field of the struct type t
+func unknownMember(t reflect.Type, members map[string]json.RawMessage) string {
+	fields := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+
+	var unknown []string
+	for name := range members {
+		if !fields[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return ""
+	}
+	sort.Strings(unknown)
+	return unknown[0]
+}
+
+// sameFields reports whether two requests hold the same fields, in which case
+// an update has nothing to store
+func sameFields(a, b interface{}) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := json.Marshal(b)
+	return err == nil && bytes.Equal(x, y)
+}
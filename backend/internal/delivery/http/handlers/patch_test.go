@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/delivery/http/middleware"
+	"ai-git-workbench/internal/delivery/http/validation"
+)
+
+func TestBindPatchLimitsTheBody(t *testing.T) {
+	e := echo.New()
+	e.Validator = validation.New()
+	for _, tc := range []struct {
+		name string
+		body string
+		want int
+	}{
+		{"within the limit", `{"title": "renamed"}`, 0},
+		{"above the limit", `{"title": "` + strings.Repeat("x", 64) + `"}`, http.StatusRequestEntityTooLarge},
+	} {
+		req := httptest.NewRequest(http.MethodPatch, "/tasks/1", strings.NewReader(tc.body))
+		req.Header.Set(echo.HeaderContentType, MIMEMergePatch)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.Set(middleware.MaxBodyContextKey, int64(32))
+
+		patch := &taskRequest{Title: "todo"}
+		err := bindPatch(c, patch)
+		switch {
+		case tc.want == 0 && err != nil:
+			t.Errorf("%s: bindPatch = %v, want nil", tc.name, err)
+		case tc.want == 0 && patch.Title != "renamed":
+			t.Errorf("%s: title = %q, want renamed", tc.name, patch.Title)
+		case tc.want != 0 && (err == nil || apierror.From(err).HTTPStatus != tc.want):
+			t.Errorf("%s: bindPatch = %v, want %d", tc.name, err, tc.want)
+		}
+	}
+}
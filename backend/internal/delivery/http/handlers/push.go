@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/usecase/auth"
+	"ai-git-workbench/internal/usecase/notification"
+)
+
+// PushHandler handles Web Push endpoints
+type PushHandler struct {
+	push *notification.PushChannel
+}
+
+// NewPushHandler creates a new PushHandler. A nil channel means Web Push is
+// disabled and every endpoint responds with 503.
+func NewPushHandler(push *notification.PushChannel) *PushHandler {
+	return &PushHandler{push: push}
+}
+
+// pushSubscriptionRequest is the JSON serialization of a browser
+// PushSubscription, as returned by PushSubscription.toJSON()
+type pushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// Enabled rejects requests while Web Push is disabled
+func (h *PushHandler) Enabled(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if h.push == nil {
+			return echo.NewHTTPError(http.StatusServiceUnavailable, "Push notifications are not enabled")
+		}
+		return next(c)
+	}
+}
+
+// GetPublicKey returns the VAPID public key to pass to pushManager.subscribe
+// as applicationServerKey
+func (h *PushHandler) GetPublicKey(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"public_key": h.push.PublicKey(),
+		"status":     "success",
+	})
+}
+
+// GetSubscriptions returns the browsers the caller registered
+func (h *PushHandler) GetSubscriptions(c echo.Context) error {
+	subs, err := h.push.Subscriptions(c.Request().Context(), auth.UserFromContext(c.Request().Context()))
+	if err != nil {
+		return storeError(c, err, "Push subscription not found")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"subscriptions": subs,
+		"count":         len(subs),
+		"status":        "success",
+	})
+}
+
+// Subscribe registers a browser of the caller. Registering an endpoint again
+// replaces its keys and owner.
+func (h *PushHandler) Subscribe(c echo.Context) error {
+	var req pushSubscriptionRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	user := auth.UserFromContext(c.Request().Context())
+	sub := &entities.PushSubscription{
+		Endpoint:  req.Endpoint,
+		P256dh:    req.Keys.P256dh,
+		Auth:      req.Keys.Auth,
+		UserAgent: truncate(c.Request().UserAgent(), 512),
+	}
+	if err := h.push.Register(c.Request().Context(), user, sub); err != nil {
+		return notificationError(c, err)
+	}
+
+	slog.InfoContext(c.Request().Context(), "push subscription registered",
+		"push_subscription_id", sub.ID, "user_id", user.ID)
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message":      "Push subscription registered",
+		"subscription": sub,
+		"status":       "success",
+	})
+}
+
+// Unsubscribe removes a browser of the caller by its endpoint
+func (h *PushHandler) Unsubscribe(c echo.Context) error {
+	var req pushSubscriptionRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.Endpoint) == "" {
+		return apierror.Required("endpoint")
+	}
+
+	user := auth.UserFromContext(c.Request().Context())
+	if err := h.push.Unregister(c.Request().Context(), user, req.Endpoint); err != nil {
+		return storeError(c, err, "Push subscription not found")
+	}
+
+	slog.InfoContext(c.Request().Context(), "push subscription removed", "user_id", user.ID)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Push subscription removed",
+		"status":  "success",
+	})
+}
+
+// Test sends a test message to every browser of the caller and reports each
+// delivery, including browsers pruned because they expired
+func (h *PushHandler) Test(c echo.Context) error {
+	deliveries, err := h.push.Test(c.Request().Context(), auth.UserFromContext(c.Request().Context()))
+	if err != nil {
+		return storeError(c, err, "Push subscription not found")
+	}
+
+	failed, pruned := 0, 0
+	for _, d := range deliveries {
+		switch {
+		case d.Error != "":
+			failed++
+		case d.Pruned:
+			pruned++
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":    "Test notification sent",
+		"deliveries": deliveries,
+		"failed":     failed,
+		"pruned":     pruned,
+		"status":     "success",
+	})
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
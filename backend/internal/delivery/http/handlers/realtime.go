@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/usecase/auth"
+	"ai-git-workbench/internal/usecase/realtime"
+)
+
+const (
+	// maxRealtimeMessage bounds a message sent by a client
+	maxRealtimeMessage = 16 << 10
+	// realtimeAuthTimeout is how long a connection may stay unauthenticated
+	realtimeAuthTimeout = 10 * time.Second
+	// realtimeWriteTimeout bounds writing one message to a client
+	realtimeWriteTimeout = 10 * time.Second
+)
+
+// Client actions on the realtime stream
+const (
+	actionAuth        = "auth"
+	actionSubscribe   = "subscribe"
+	actionUnsubscribe = "unsubscribe"
+	actionPing        = "ping"
+	actionPong        = "pong"
+)
+
+// RealtimeHandler streams task and repository changes over WebSocket
+type RealtimeHandler struct {
+	hub          *realtime.Hub
+	auth         *auth.Service
+	pingInterval time.Duration
+	sendBuffer   int
+}
+
+// NewRealtimeHandler creates a new RealtimeHandler. Clients are pinged every
+// pingInterval and closed when nothing is received for two intervals.
+func NewRealtimeHandler(hub *realtime.Hub, authService *auth.Service, pingInterval time.Duration, sendBuffer int) *RealtimeHandler {
+	return &RealtimeHandler{hub: hub, auth: authService, pingInterval: pingInterval, sendBuffer: sendBuffer}
+}
+
+// realtimeRequest is a message sent by a client
+type realtimeRequest struct {
+	Action string   `json:"action"`
+	Token  string   `json:"token,omitempty"`
+	Topics []string `json:"topics,omitempty"`
+}
+
+// realtimeReply is a control message sent to a client. Change events are
+// sent as entities.ChangeEvent, whose types contain a dot.
+type realtimeReply struct {
+	Type    string    `json:"type"`
+	Topics  []string  `json:"topics,omitempty"`
+	Message string    `json:"message,omitempty"`
+	User    string    `json:"user,omitempty"`
+	Time    time.Time `json:"time,omitzero"`
+}
+
+// Stream upgrades the request to a WebSocket. Clients without an
+// Authorization header authenticate with an auth message first, since
+// browsers cannot set headers on WebSocket requests.
+func (h *RealtimeHandler) Stream(c echo.Context) error {
+	ctx := c.Request().Context()
+	server := websocket.Server{
+		// Bearer tokens rather than cookies authenticate the stream, so
+		// pages on any origin may connect, like with CORS
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			ws.MaxPayloadBytes = maxRealtimeMessage
+			h.serve(ctx, ws, auth.UserFromContext(ctx))
+		},
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// serve runs one connection until either side closes it
+func (h *RealtimeHandler) serve(ctx context.Context, ws *websocket.Conn, user *entities.User) {
+	if user == nil {
+		if user = h.authenticate(ctx, ws); user == nil {
+			return
+		}
+	}
+
+	client := h.hub.Connect(user, h.sendBuffer)
+	defer h.hub.Disconnect(client)
+	slog.InfoContext(ctx, "realtime client connected", "user_id", user.ID, "clients", h.hub.Clients())
+
+	h.reply(client, realtimeReply{Type: "welcome", User: user.Login, Time: time.Now().UTC()})
+
+	done := make(chan struct{})
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		h.write(ws, client, done)
+	}()
+	h.read(ctx, ws, client)
+	close(done)
+	<-written
+
+	slog.InfoContext(ctx, "realtime client disconnected", "user_id", user.ID)
+}
+
+// authenticate waits for an auth message and resolves its token
+func (h *RealtimeHandler) authenticate(ctx context.Context, ws *websocket.Conn) *entities.User {
+	_ = ws.SetReadDeadline(time.Now().Add(realtimeAuthTimeout))
+	var req realtimeRequest
+	if err := websocket.JSON.Receive(ws, &req); err != nil || req.Action != actionAuth {
+		h.fail(ws, "Authentication required, send {\"action\": \"auth\", \"token\": \"...\"} first")
+		return nil
+	}
+
+	user, err := h.auth.Authenticate(ctx, strings.TrimSpace(req.Token))
+	if errors.Is(err, auth.ErrInvalidToken) {
+		h.fail(ws, "Invalid or expired token")
+		return nil
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to authenticate realtime client", "error", err)
+		h.fail(ws, "Internal server error")
+		return nil
+	}
+	return user
+}
+
+// read handles client messages. Any message counts as a sign of life; the
+// connection ends when none arrives within two ping intervals.
+func (h *RealtimeHandler) read(ctx context.Context, ws *websocket.Conn, client *realtime.Client) {
+	for {
+		_ = ws.SetReadDeadline(time.Now().Add(2 * h.pingInterval))
+		var req realtimeRequest
+		err := websocket.JSON.Receive(ws, &req)
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr), errors.As(err, &typeErr), errors.Is(err, websocket.ErrFrameTooLarge):
+			h.reply(client, realtimeReply{Type: "error", Message: "Invalid message"})
+			continue
+		case err != nil:
+			return
+		}
+
+		switch req.Action {
+		case actionSubscribe:
+			topics, err := client.Subscribe(req.Topics...)
+			if err != nil {
+				h.reply(client, realtimeReply{Type: "error", Message: err.Error()})
+				continue
+			}
+			slog.DebugContext(ctx, "realtime client subscribed", "user_id", client.User().ID, "topics", req.Topics)
+			h.reply(client, realtimeReply{Type: "subscribed", Topics: topics})
+		case actionUnsubscribe:
+			h.reply(client, realtimeReply{Type: "unsubscribed", Topics: client.Unsubscribe(req.Topics...)})
+		case actionPing:
+			h.reply(client, realtimeReply{Type: "pong", Time: time.Now().UTC()})
+		case actionPong:
+		case actionAuth:
+			h.reply(client, realtimeReply{Type: "error", Message: "Already authenticated"})
+		default:
+			h.reply(client, realtimeReply{Type: "error", Message: "Unknown action, use subscribe, unsubscribe or ping"})
+		}
+	}
+}
+
+// write sends queued messages and heartbeats until the reader stops or the
+// hub drops the client, then closes the connection
+func (h *RealtimeHandler) write(ws *websocket.Conn, client *realtime.Client, done <-chan struct{}) {
+	ticker := time.NewTicker(h.pingInterval)
+	defer ticker.Stop()
+	defer ws.Close()
+
+	for {
+		select {
+		case msg := <-client.Messages():
+			if err := h.send(ws, msg); err != nil {
+				return
+			}
+		case now := <-ticker.C:
+			ping, _ := json.Marshal(realtimeReply{Type: "ping", Time: now.UTC()})
+			if err := h.send(ws, ping); err != nil {
+				return
+			}
+		case <-client.Dropped():
+			message := "Server is shutting down, reconnect later"
+			if client.Reason() == realtime.DropSlow {
+				message = "Connection fell too far behind, reconnect and reload"
+			}
+			msg, _ := json.Marshal(realtimeReply{Type: "closing", Message: message})
+			_ = h.send(ws, msg)
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+// reply queues a control message for a client
+func (h *RealtimeHandler) reply(client *realtime.Client, reply realtimeReply) {
+	msg, err := json.Marshal(reply)
+	if err != nil {
+		return
+	}
+	client.Enqueue(msg)
+}
+
+// send writes one text message to the connection
+func (h *RealtimeHandler) send(ws *websocket.Conn, msg []byte) error {
+	_ = ws.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+	return websocket.Message.Send(ws, string(msg))
+}
+
+// fail tells an unauthenticated client why it is being disconnected
+func (h *RealtimeHandler) fail(ws *websocket.Conn, message string) {
+	msg, _ := json.Marshal(realtimeReply{Type: "error", Message: message})
+	_ = h.send(ws, msg)
+}
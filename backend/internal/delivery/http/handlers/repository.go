@@ -1,109 +1,102 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/usecase/auth"
+	"ai-git-workbench/internal/usecase/workspace"
 )
 
 // RepositoryHandler handles repository-related endpoints
-type RepositoryHandler struct{}
-
-// NewRepositoryHandler creates a new RepositoryHandler
-func NewRepositoryHandler() *RepositoryHandler {
-	return &RepositoryHandler{}
-}
-
-// Repository represents a repository entity for API responses
-type Repository struct {
-	ID            int      `json:"id"`
-	Name          string   `json:"name"`
-	FullName      string   `json:"full_name"`
-	Description   string   `json:"description,omitempty"`
-	Private       bool     `json:"private"`
-	Language      string   `json:"language,omitempty"`
-	URL           string   `json:"url"`
-	HTMLURL       string   `json:"html_url"`
-	CloneURL      string   `json:"clone_url"`
-	Stars         int      `json:"stars"`
-	Forks         int      `json:"forks"`
-	IsConnected   bool     `json:"is_connected"`
-	LastSync      string   `json:"last_sync,omitempty"`
-	CreatedAt     string   `json:"created_at"`
-	UpdatedAt     string   `json:"updated_at"`
-	Topics        []string `json:"topics,omitempty"`
-}
-
-// GetRepositories returns all repositories
+type RepositoryHandler struct {
+	repos     repositories.RepositoryRepository
+	workspace *workspace.Service
+	activity  ActivityRecorder
+}
+
+// NewRepositoryHandler creates a new RepositoryHandler cloning into workspace
+// and recording changes in activity
+func NewRepositoryHandler(repos repositories.RepositoryRepository, workspace *workspace.Service,
+	activity ActivityRecorder) *RepositoryHandler {
+	return &RepositoryHandler{repos: repos, workspace: workspace, activity: activity}
+}
+
+// repositoryRequest holds the fields a client may set on a repository
+type repositoryRequest struct {
+	Name        string     `json:"name" validate:"notblank,max=255"`
+	FullName    string     `json:"full_name" validate:"notblank,max=255"`
+	Description string     `json:"description" validate:"max=65535"`
+	Private     bool       `json:"private"`
+	Language    string     `json:"language" validate:"max=100"`
+	URL         string     `json:"url" validate:"omitempty,max=500,http_url"`
+	HTMLURL     string     `json:"html_url" validate:"omitempty,max=500,http_url"`
+	CloneURL    string     `json:"clone_url" validate:"omitempty,max=500,http_url"`
+	Stars       int        `json:"stars" validate:"gte=0"`
+	Forks       int        `json:"forks" validate:"gte=0"`
+	IsConnected bool       `json:"is_connected"`
+	LastSync    *time.Time `json:"last_sync"`
+	Topics      []string   `json:"topics" validate:"max=50,dive,notblank,max=50"`
+	IssueSync   bool       `json:"issue_sync"`
+}
+
+// apply copies the request fields onto repo
+func (r *repositoryRequest) apply(repo *entities.Repository) {
+	repo.Name = r.Name
+	repo.FullName = r.FullName
+	repo.Description = r.Description
+	repo.Private = r.Private
+	repo.Language = r.Language
+	repo.URL = r.URL
+	repo.HTMLURL = r.HTMLURL
+	repo.CloneURL = r.CloneURL
+	repo.Stars = r.Stars
+	repo.Forks = r.Forks
+	repo.IsConnected = r.IsConnected
+	repo.LastSync = r.LastSync
+	repo.Topics = r.Topics
+	repo.IssueSync = r.IssueSync
+}
+
+// GetRepositories returns a page of the repositories the caller can see
 func (h *RepositoryHandler) GetRepositories(c echo.Context) error {
-	// Mock data for testing
-	repositories := []Repository{
-		{
-			ID:          1,
-			Name:        "workflow",
-			FullName:    "JokerTrickster/workflow",
-			Description: "AI-powered workflow management system",
-			Private:     false,
-			Language:    "TypeScript",
-			URL:         "https://api.github.com/repos/JokerTrickster/workflow",
-			HTMLURL:     "https://github.com/JokerTrickster/workflow",
-			CloneURL:    "https://github.com/JokerTrickster/workflow.git",
-			Stars:       15,
-			Forks:       3,
-			IsConnected: true,
-			LastSync:    "2024-01-15T14:30:00Z",
-			CreatedAt:   "2024-01-10T10:00:00Z",
-			UpdatedAt:   "2024-01-15T14:30:00Z",
-			Topics:      []string{"workflow", "ai", "automation"},
-		},
-		{
-			ID:          2,
-			Name:        "backend-api",
-			FullName:    "JokerTrickster/backend-api",
-			Description: "Backend API for workflow management",
-			Private:     true,
-			Language:    "Go",
-			URL:         "https://api.github.com/repos/JokerTrickster/backend-api",
-			HTMLURL:     "https://github.com/JokerTrickster/backend-api",
-			CloneURL:    "https://github.com/JokerTrickster/backend-api.git",
-			Stars:       8,
-			Forks:       1,
-			IsConnected: false,
-			CreatedAt:   "2024-01-12T15:00:00Z",
-			UpdatedAt:   "2024-01-14T09:00:00Z",
-			Topics:      []string{"api", "golang", "backend"},
-		},
+	opts, err := parseListOptions(c)
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"repositories": repositories,
-		"total":        len(repositories),
-		"status":       "success",
-	})
+	scope := auth.Scope(c.Request().Context())
+	page, err := h.repos.List(c.Request().Context(), repositories.RepositoryFilter{Scope: &scope}, opts)
+	if err != nil {
+		return storeError(c, err, "Repository not found")
+	}
+
+	return c.JSON(http.StatusOK, newListResponse(page, opts))
 }
 
-// GetRepository returns a single repository by ID
+// GetRepository returns a single repository by ID with its version as ETag,
+// or 304 when If-None-Match names the current version
 func (h *RepositoryHandler) GetRepository(c echo.Context) error {
-	repoID := c.Param("id")
-	
-	// Mock repository data
-	repository := Repository{
-		ID:          1,
-		Name:        "workflow",
-		FullName:    "JokerTrickster/workflow",
-		Description: "AI-powered workflow management system",
-		Private:     false,
-		Language:    "TypeScript",
-		URL:         "https://api.github.com/repos/JokerTrickster/workflow",
-		HTMLURL:     "https://github.com/JokerTrickster/workflow",
-		CloneURL:    "https://github.com/JokerTrickster/workflow.git",
-		Stars:       15,
-		Forks:       3,
-		IsConnected: true,
-		LastSync:    "2024-01-15T14:30:00Z",
-		CreatedAt:   "2024-01-10T10:00:00Z",
-		UpdatedAt:   "2024-01-15T14:30:00Z",
-		Topics:      []string{"workflow", "ai", "automation"},
+	repoID, err := repositoryID(c)
+	if err != nil {
+		return err
+	}
+
+	repository, err := h.loadRepository(c, repoID)
+	if err != nil {
+		return err
+	}
+	setETag(c, repository.Version)
+	if notModified(c, repository.Version) {
+		return c.NoContent(http.StatusNotModified)
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -113,44 +106,300 @@ func (h *RepositoryHandler) GetRepository(c echo.Context) error {
 	})
 }
 
-// CreateRepository creates a new repository connection
+// CreateRepository creates a new repository connection owned by the caller.
+// Viewers cannot connect repositories.
 func (h *RepositoryHandler) CreateRepository(c echo.Context) error {
-	var req Repository
-	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	if err := h.authorizeRepository(c, nil); err != nil {
+		return err
+	}
+	var req repositoryRequest
+	if err := bind(c, &req); err != nil {
+		return err
 	}
 
-	// Mock creation response
+	var repo entities.Repository
+	req.apply(&repo)
+	repo.OwnerID = &auth.UserFromContext(c.Request().Context()).ID
+	if err := h.repos.Create(c.Request().Context(), &repo); err != nil {
+		return storeError(c, err, "Repository not found")
+	}
+
+	slog.InfoContext(c.Request().Context(), "repository connected", "repository_id", repo.ID, "full_name", repo.FullName)
+	setETag(c, repo.Version)
+	h.activity.Record(c.Request().Context(), entities.ActivityRepositoryConnected, entities.ResourceRepository,
+		strconv.FormatInt(repo.ID, 10), nil, repo)
+
 	return c.JSON(http.StatusCreated, map[string]interface{}{
 		"message":       "Repository connected successfully",
-		"repository_id": 123,
+		"repository_id": repo.ID,
+		"repository":    repo,
 		"status":        "success",
 	})
 }
 
-// UpdateRepository updates an existing repository
+// newRepositoryRequest holds the current fields of repo, for patching
+func newRepositoryRequest(repo *entities.Repository) *repositoryRequest {
+	return &repositoryRequest{
+		Name:        repo.Name,
+		FullName:    repo.FullName,
+		Description: repo.Description,
+		Private:     repo.Private,
+		Language:    repo.Language,
+		URL:         repo.URL,
+		HTMLURL:     repo.HTMLURL,
+		CloneURL:    repo.CloneURL,
+		Stars:       repo.Stars,
+		Forks:       repo.Forks,
+		IsConnected: repo.IsConnected,
+		LastSync:    repo.LastSync,
+		Topics:      repo.Topics,
+		IssueSync:   repo.IssueSync,
+	}
+}
+
+// UpdateRepository replaces the fields of an existing repository of the
+// caller. With If-Match, the repository must still be at the version it names.
 func (h *RepositoryHandler) UpdateRepository(c echo.Context) error {
-	repoID := c.Param("id")
-	
-	var req Repository
-	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	repoID, err := repositoryID(c)
+	if err != nil {
+		return err
 	}
 
+	var req repositoryRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	return h.update(c, repoID, func(*entities.Repository) (*repositoryRequest, error) { return &req, nil })
+}
+
+// PatchRepository changes the fields of an existing repository named by a
+// merge patch or JSON Patch, validating the result. With If-Match, the
+// repository must still be at the version it names.
+func (h *RepositoryHandler) PatchRepository(c echo.Context) error {
+	repoID, err := repositoryID(c)
+	if err != nil {
+		return err
+	}
+
+	return h.update(c, repoID, func(repo *entities.Repository) (*repositoryRequest, error) {
+		req := newRepositoryRequest(repo)
+		return req, bindPatch(c, req)
+	})
+}
+
+// update loads a repository, checks If-Match and that the caller may change
+// it, and stores the fields of the request change returns for it. A request
+// keeping every field is answered without a write.
+func (h *RepositoryHandler) update(c echo.Context, repoID int64,
+	change func(*entities.Repository) (*repositoryRequest, error)) error {
+	repo, err := h.loadRepository(c, repoID)
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, repo.Version); err != nil {
+		return err
+	}
+	if err := h.authorizeRepository(c, repo); err != nil {
+		return err
+	}
+	req, err := change(repo)
+	if err != nil {
+		return err
+	}
+	if sameFields(newRepositoryRequest(repo), req) {
+		setETag(c, repo.Version)
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"message":       "Repository unchanged",
+			"repository_id": repoID,
+			"repository":    repo,
+			"status":        "success",
+		})
+	}
+	before := *repo
+	req.apply(repo)
+	if err := h.repos.Update(c.Request().Context(), repo); err != nil {
+		return storeError(c, err, "Repository not found")
+	}
+
+	slog.InfoContext(c.Request().Context(), "repository updated", "repository_id", repoID, "version", repo.Version)
+	setETag(c, repo.Version)
+	h.activity.Record(c.Request().Context(), entities.ActivityRepositoryUpdated, entities.ResourceRepository,
+		strconv.FormatInt(repoID, 10), before, repo)
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"message":       "Repository updated successfully",
 		"repository_id": repoID,
+		"repository":    repo,
 		"status":        "success",
 	})
 }
 
-// DeleteRepository disconnects a repository
+// DeleteRepository disconnects a repository of the caller. With If-Match,
+// the repository must still be at the version it names.
 func (h *RepositoryHandler) DeleteRepository(c echo.Context) error {
-	repoID := c.Param("id")
+	repoID, err := repositoryID(c)
+	if err != nil {
+		return err
+	}
+
+	// Load the repository first so the activity log keeps its last state
+	repo, err := h.loadRepository(c, repoID)
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, repo.Version); err != nil {
+		return err
+	}
+	if err := h.authorizeRepository(c, repo); err != nil {
+		return err
+	}
+	if err := h.repos.Delete(c.Request().Context(), repoID, repo.Version); err != nil {
+		return storeError(c, err, "Repository not found")
+	}
+
+	slog.InfoContext(c.Request().Context(), "repository disconnected", "repository_id", repoID)
+	h.activity.Record(c.Request().Context(), entities.ActivityRepositoryDisconnected, entities.ResourceRepository,
+		strconv.FormatInt(repoID, 10), repo, nil)
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"message":       "Repository disconnected successfully",
 		"repository_id": repoID,
 		"status":        "success",
 	})
-}
\ No newline at end of file
+}
+
+// cloneRequest is the body of the deprecated POST /repos/clone
+type cloneRequest struct {
+	RepoID int64 `json:"repo_id" validate:"required,gt=0"`
+}
+
+// CloneRepository clones a repository into the workspace, or fetches it when
+// it was cloned before. The deprecated /repos/clone route passes the
+// repository in the body instead of the path.
+func (h *RepositoryHandler) CloneRepository(c echo.Context) error {
+	var repoID int64
+	if c.Param("id") != "" {
+		var err error
+		if repoID, err = repositoryID(c); err != nil {
+			return err
+		}
+	} else {
+		var req cloneRequest
+		if err := bind(c, &req); err != nil {
+			return err
+		}
+		repoID = req.RepoID
+	}
+
+	// Load the repository first so the activity log keeps its previous state
+	before, err := h.loadRepository(c, repoID)
+	if err != nil {
+		return err
+	}
+	// Syncing updates the repository, so it is a change like any other
+	if err := h.authorizeRepository(c, before); err != nil {
+		return err
+	}
+	repo, status, fetched, err := h.workspace.Clone(c.Request().Context(), repoID)
+	if err != nil {
+		return workspaceError(c, err)
+	}
+
+	h.activity.Record(c.Request().Context(), entities.ActivityRepositoryCloned, entities.ResourceRepository,
+		strconv.FormatInt(repoID, 10), before, repo)
+
+	message := "Repository cloned successfully"
+	if fetched {
+		message = "Repository fetched successfully"
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":       message,
+		"repository_id": repoID,
+		"connected":     repo.IsConnected,
+		"local_path":    status.Path,
+		"workspace":     status,
+		"status":        "success",
+	})
+}
+
+// GetRepositoryStatus returns the state of the local clone of a repository
+func (h *RepositoryHandler) GetRepositoryStatus(c echo.Context) error {
+	repoID, err := repositoryID(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.loadRepository(c, repoID); err != nil {
+		return err
+	}
+	repo, status, err := h.workspace.Status(c.Request().Context(), repoID)
+	if err != nil {
+		return workspaceError(c, err)
+	}
+	// Where the clone lives on the server is not for every reader
+	status.Path = ""
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"repository_id": repoID,
+		"connected":     repo.IsConnected && status.Cloned,
+		"workspace":     status,
+		"status":        "success",
+	})
+}
+
+// workspaceError maps a clone or status failure to an HTTP error
+func workspaceError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, workspace.ErrInvalidRepository):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, workspace.ErrSyncFailed) && errors.Is(err, context.DeadlineExceeded):
+		return echo.NewHTTPError(http.StatusGatewayTimeout, "Timed out syncing the repository")
+	case errors.Is(err, workspace.ErrSyncFailed):
+		slog.ErrorContext(c.Request().Context(), "repository sync failed", "error", err)
+		return echo.NewHTTPError(http.StatusBadGateway, "Failed to sync the repository")
+	default:
+		return storeError(c, err, "Repository not found")
+	}
+}
+
+// loadRepository returns the repository with the given id, reporting private
+// repositories hidden from the caller as not found
+func (h *RepositoryHandler) loadRepository(c echo.Context, id int64) (*entities.Repository, error) {
+	repo, err := h.repos.GetByID(c.Request().Context(), id)
+	if err == nil && !auth.Scope(c.Request().Context()).CanSee(repo) {
+		err = repositories.ErrNotFound
+	}
+	if err != nil {
+		return nil, storeError(c, err, "Repository not found")
+	}
+	return repo, nil
+}
+
+// authorizeRepository checks that the caller may change repo, or connect a
+// new repository when it is nil: viewers change nothing, and a repository
+// belongs to its owner and admins
+func (h *RepositoryHandler) authorizeRepository(c echo.Context, repo *entities.Repository) error {
+	user := auth.UserFromContext(c.Request().Context())
+	if user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+	}
+	if user.Role == entities.RoleViewer {
+		return echo.NewHTTPError(http.StatusForbidden, "Viewers cannot change repositories")
+	}
+	if repo == nil || user.IsAdmin() {
+		return nil
+	}
+	if repo.OwnerID == nil || *repo.OwnerID != user.ID {
+		return echo.NewHTTPError(http.StatusForbidden, "Only the owner and admins can change repository "+repo.FullName)
+	}
+	return nil
+}
+
+// repositoryID parses the :id path parameter
+func repositoryID(c echo.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid repository ID")
+	}
+	return id, nil
+}
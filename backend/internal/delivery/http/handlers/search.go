@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/usecase/search"
+)
+
+// SearchHandler handles full-text search
+type SearchHandler struct {
+	search *search.Service
+}
+
+// NewSearchHandler creates a new SearchHandler
+func NewSearchHandler(search *search.Service) *SearchHandler {
+	return &SearchHandler{search: search}
+}
+
+// Search finds tasks and repositories matching ?q=, ranked by relevance.
+// ?type= restricts the record types (task, repository) and ?limit= / ?offset=
+// page through the hits.
+func (h *SearchHandler) Search(c echo.Context) error {
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		return apierror.Required("q")
+	}
+
+	types := splitList(c.QueryParam("type"))
+	for _, t := range types {
+		if t != repositories.SearchTypeTask && t != repositories.SearchTypeRepository {
+			return apierror.InvalidField("type", "must be task or repository")
+		}
+	}
+
+	limit, err := intParam(c, "limit", search.DefaultLimit)
+	if err != nil {
+		return err
+	}
+	if limit < 1 || limit > search.MaxLimit {
+		return apierror.InvalidField("limit", "must be between 1 and "+strconv.Itoa(search.MaxLimit))
+	}
+	offset, err := intParam(c, "offset", 0)
+	if err != nil {
+		return err
+	}
+	if offset < 0 {
+		return apierror.InvalidField("offset", "must not be negative")
+	}
+
+	result, err := h.search.Search(c.Request().Context(), query, types, limit, offset)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "search failed", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"query":   result.Query,
+		"results": result.Hits,
+		"facets":  result.Facets,
+		"total":   result.Total,
+		"limit":   limit,
+		"offset":  offset,
+		"status":  "success",
+	})
+}
+
+// intParam reads an integer query parameter, returning def when it is absent
+func intParam(c echo.Context, name string, def int) (int, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, apierror.InvalidField(name, "must be an integer")
+	}
+	return n, nil
+}
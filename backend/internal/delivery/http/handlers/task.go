@@ -1,137 +1,345 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/usecase/auth"
+	"ai-git-workbench/internal/usecase/execution"
 )
 
+// TaskStatusListener is notified after a task changes status
+type TaskStatusListener interface {
+	TaskStatusChanged(ctx context.Context, task *entities.Task, previous string)
+}
+
 // TaskHandler handles task-related endpoints
-type TaskHandler struct{}
-
-// NewTaskHandler creates a new TaskHandler
-func NewTaskHandler() *TaskHandler {
-	return &TaskHandler{}
-}
-
-// Task represents a task entity for API responses
-type Task struct {
-	ID          string            `json:"id"`
-	Title       string            `json:"title"`
-	Description string            `json:"description"`
-	Status      string            `json:"status"`
-	Repository  string            `json:"repository"`
-	Epic        string            `json:"epic"`
-	Branch      string            `json:"branch,omitempty"`
-	CreatedAt   string            `json:"created_at"`
-	UpdatedAt   string            `json:"updated_at"`
-	StartedAt   string            `json:"started_at,omitempty"`
-	CompletedAt string            `json:"completed_at,omitempty"`
-	TokensUsed  int               `json:"tokens_used"`
-	Metadata    map[string]string `json:"metadata,omitempty"`
-}
-
-// GetTasks returns all tasks
+type TaskHandler struct {
+	tasks     repositories.TaskRepository
+	repos     repositories.RepositoryRepository
+	executor  *execution.Executor
+	activity  ActivityRecorder
+	listeners []TaskStatusListener
+}
+
+// NewTaskHandler creates a new TaskHandler running tasks with executor,
+// checking access to them against the repositories in repos, recording
+// changes in activity and notifying listeners of status changes
+func NewTaskHandler(tasks repositories.TaskRepository, repos repositories.RepositoryRepository,
+	executor *execution.Executor, activity ActivityRecorder, listeners ...TaskStatusListener) *TaskHandler {
+	return &TaskHandler{tasks: tasks, repos: repos, executor: executor, activity: activity, listeners: listeners}
+}
+
+// taskRequest holds the fields a client may set on a task
+type taskRequest struct {
+	Title       string            `json:"title" validate:"notblank,max=255"`
+	Description string            `json:"description" validate:"max=65535"`
+	Status      string            `json:"status" validate:"omitempty,task_status"` // empty means pending
+	Repository  string            `json:"repository" validate:"max=255"`
+	Epic        string            `json:"epic" validate:"max=255"`
+	Branch      string            `json:"branch" validate:"omitempty,max=255,git_branch"`
+	Assignee    string            `json:"assignee" validate:"max=255"`
+	TokensUsed  int               `json:"tokens_used" validate:"gte=0"`
+	Metadata    map[string]string `json:"metadata" validate:"max=100"`
+}
+
+// apply copies the request fields onto task
+func (r *taskRequest) apply(task *entities.Task) {
+	task.Title = r.Title
+	task.Description = r.Description
+	task.Status = r.Status
+	task.Repository = r.Repository
+	task.Epic = r.Epic
+	task.Branch = r.Branch
+	task.Assignee = r.Assignee
+	task.TokensUsed = r.TokensUsed
+	task.Metadata = r.Metadata
+}
+
+// GetTasks returns a page of tasks matching the query filters
 func (h *TaskHandler) GetTasks(c echo.Context) error {
-	// Mock data for testing
-	tasks := []Task{
-		{
-			ID:          "task-1",
-			Title:       "Implement user authentication",
-			Description: "Add JWT-based authentication to the API",
-			Status:      "in_progress",
-			Repository:  "workflow",
-			Epic:        "authentication",
-			Branch:      "feature/auth",
-			CreatedAt:   "2024-01-15T10:00:00Z",
-			UpdatedAt:   "2024-01-15T14:30:00Z",
-			StartedAt:   "2024-01-15T11:00:00Z",
-			TokensUsed:  1500,
-		},
-		{
-			ID:          "task-2", 
-			Title:       "Setup database migrations",
-			Description: "Create initial database schema and migration system",
-			Status:      "completed",
-			Repository:  "workflow",
-			Epic:        "infrastructure", 
-			Branch:      "feature/db-setup",
-			CreatedAt:   "2024-01-14T09:00:00Z",
-			UpdatedAt:   "2024-01-15T16:00:00Z",
-			StartedAt:   "2024-01-14T10:00:00Z",
-			CompletedAt: "2024-01-15T16:00:00Z",
-			TokensUsed:  2300,
-		},
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		return err
+	}
+	opts, err := parseListOptions(c)
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"tasks": tasks,
-		"total": len(tasks),
-		"status": "success",
-	})
+	scope := auth.Scope(c.Request().Context())
+	filter.Scope = &scope
+	page, err := h.tasks.List(c.Request().Context(), filter, opts)
+	if err != nil {
+		return storeError(c, err, "Task not found")
+	}
+
+	return c.JSON(http.StatusOK, newListResponse(page, opts))
 }
 
-// GetTask returns a single task by ID
+// GetTask returns a single task by ID with its version as ETag, or 304 when
+// If-None-Match names the current version
 func (h *TaskHandler) GetTask(c echo.Context) error {
-	taskID := c.Param("id")
-	
-	// Mock task data
-	task := Task{
-		ID:          taskID,
-		Title:       "Sample Task",
-		Description: "This is a sample task for testing",
-		Status:      "pending",
-		Repository:  "workflow",
-		Epic:        "development",
-		CreatedAt:   "2024-01-15T10:00:00Z",
-		UpdatedAt:   "2024-01-15T10:00:00Z",
-		TokensUsed:  0,
+	task, err := h.loadTask(c, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	setETag(c, task.Version)
+	if notModified(c, task.Version) {
+		return c.NoContent(http.StatusNotModified)
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"task": task,
+		"task":   task,
 		"status": "success",
 	})
 }
 
-// CreateTask creates a new task
+// CreateTask creates a new task. Viewers cannot create tasks, and only
+// owners and admins can add them to a private repository.
 func (h *TaskHandler) CreateTask(c echo.Context) error {
-	var req Task
-	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	var req taskRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	var task entities.Task
+	req.apply(&task)
+	if err := h.authorizeTask(c, &task); err != nil {
+		return err
+	}
+	if err := h.tasks.Create(c.Request().Context(), &task); err != nil {
+		return storeError(c, err, "Task not found")
 	}
 
-	// Mock creation response
+	slog.InfoContext(c.Request().Context(), "task created", "task_id", task.ID, "title", task.Title)
+	setETag(c, task.Version)
+	h.activity.Record(c.Request().Context(), entities.ActivityTaskCreated, entities.ResourceTask, task.ID, nil, task)
+
 	return c.JSON(http.StatusCreated, map[string]interface{}{
 		"message": "Task created successfully",
-		"task_id": "new-task-123",
-		"status": "success",
+		"task_id": task.ID,
+		"task":    task,
+		"status":  "success",
 	})
 }
 
-// UpdateTask updates an existing task
+// newTaskRequest holds the current fields of task, for patching
+func newTaskRequest(task *entities.Task) *taskRequest {
+	return &taskRequest{
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Repository:  task.Repository,
+		Epic:        task.Epic,
+		Branch:      task.Branch,
+		Assignee:    task.Assignee,
+		TokensUsed:  task.TokensUsed,
+		Metadata:    task.Metadata,
+	}
+}
+
+// UpdateTask replaces the fields of an existing task. With If-Match, the task
+// must still be at the version it names.
 func (h *TaskHandler) UpdateTask(c echo.Context) error {
+	var req taskRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	return h.update(c, func(*entities.Task) (*taskRequest, error) { return &req, nil })
+}
+
+// PatchTask changes the fields of an existing task named by a merge patch or
+// JSON Patch, validating the result. With If-Match, the task must still be at
+// the version it names.
+func (h *TaskHandler) PatchTask(c echo.Context) error {
+	return h.update(c, func(task *entities.Task) (*taskRequest, error) {
+		req := newTaskRequest(task)
+		return req, bindPatch(c, req)
+	})
+}
+
+// update loads a task, checks If-Match and that the caller may change it, and
+// stores the fields of the request change returns for it. A request keeping
+// every field is answered without a write.
+func (h *TaskHandler) update(c echo.Context, change func(*entities.Task) (*taskRequest, error)) error {
 	taskID := c.Param("id")
-	
-	var req Task
-	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+
+	task, err := h.loadTask(c, taskID)
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, task.Version); err != nil {
+		return err
+	}
+	if err := h.authorizeTask(c, task); err != nil {
+		return err
+	}
+	req, err := change(task)
+	if err != nil {
+		return err
+	}
+	if sameFields(newTaskRequest(task), req) {
+		setETag(c, task.Version)
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"message": "Task unchanged",
+			"task_id": taskID,
+			"task":    task,
+			"status":  "success",
+		})
+	}
+	before, previous := *task, task.Status
+	if err := changeStatus(task, req.Status); err != nil {
+		return err
+	}
+	req.apply(task)
+	// Moving the task must be allowed too
+	if task.Repository != before.Repository {
+		if err := h.authorizeTask(c, task); err != nil {
+			return err
+		}
+	}
+	if err := h.tasks.Update(c.Request().Context(), task); err != nil {
+		return storeError(c, err, "Task not found")
+	}
+
+	slog.InfoContext(c.Request().Context(), "task updated", "task_id", taskID, "version", task.Version)
+	setETag(c, task.Version)
+	h.activity.Record(c.Request().Context(), entities.ActivityTaskUpdated, entities.ResourceTask, taskID, before, task)
+
+	if task.Status != previous {
+		for _, listener := range h.listeners {
+			listener.TaskStatusChanged(c.Request().Context(), task, previous)
+		}
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"message": "Task updated successfully",
 		"task_id": taskID,
-		"status": "success",
+		"task":    task,
+		"status":  "success",
 	})
 }
 
-// DeleteTask deletes a task
+// changeStatus moves task to status, an empty one meaning pending, through
+// the allowed transitions so its timestamps stay in step. Keeping the current
+// status is always allowed.
+func changeStatus(task *entities.Task, status string) error {
+	if status == "" {
+		status = entities.TaskStatusPending
+	}
+	if status == task.Status {
+		return nil
+	}
+	if !task.CanTransition(status) {
+		return apierror.New(http.StatusConflict, "", "Task cannot move from "+task.Status+" to "+status)
+	}
+	task.Transition(status, time.Now().UTC())
+	return nil
+}
+
+// DeleteTask deletes a task. With If-Match, the task must still be at the
+// version it names.
 func (h *TaskHandler) DeleteTask(c echo.Context) error {
 	taskID := c.Param("id")
 
+	// Load the task first so the activity log keeps its last state
+	task, err := h.loadTask(c, taskID)
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, task.Version); err != nil {
+		return err
+	}
+	if err := h.authorizeTask(c, task); err != nil {
+		return err
+	}
+	if err := h.tasks.Delete(c.Request().Context(), taskID, task.Version); err != nil {
+		return storeError(c, err, "Task not found")
+	}
+
+	slog.InfoContext(c.Request().Context(), "task deleted", "task_id", taskID)
+	h.activity.Record(c.Request().Context(), entities.ActivityTaskDeleted, entities.ResourceTask, taskID, task, nil)
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"message": "Task deleted successfully", 
+		"message": "Task deleted successfully",
 		"task_id": taskID,
-		"status": "success",
+		"status":  "success",
 	})
-}
\ No newline at end of file
+}
+
+// ExecuteTask starts carrying out a task with the AI provider. The outcome is
+// stored on the task when the run finishes.
+func (h *TaskHandler) ExecuteTask(c echo.Context) error {
+	taskID := c.Param("id")
+
+	// Load the task first so the activity log keeps its previous state
+	before, err := h.loadTask(c, taskID)
+	if err != nil {
+		return err
+	}
+	if err := h.authorizeTask(c, before); err != nil {
+		return err
+	}
+
+	task, err := h.executor.Execute(c.Request().Context(), taskID, auth.UserFromContext(c.Request().Context()))
+	switch {
+	case errors.Is(err, execution.ErrAlreadyRunning):
+		return echo.NewHTTPError(http.StatusConflict, "Task is already running")
+	case errors.Is(err, execution.ErrDraining):
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Server is shutting down")
+	case err != nil:
+		return storeError(c, err, "Task not found")
+	}
+
+	h.activity.Record(c.Request().Context(), entities.ActivityTaskExecuted, entities.ResourceTask, taskID, before, task)
+
+	return c.JSON(http.StatusAccepted, map[string]interface{}{
+		"message": "Task execution started",
+		"task_id": taskID,
+		"task":    task,
+		"status":  "success",
+	})
+}
+
+// loadTask returns the task with the given id, reporting tasks of private
+// repositories hidden from the caller as not found
+func (h *TaskHandler) loadTask(c echo.Context, id string) (*entities.Task, error) {
+	ctx := c.Request().Context()
+	task, err := h.tasks.GetByID(ctx, id)
+	if err == nil {
+		var visible bool
+		if visible, err = h.canSee(ctx, task); err == nil && !visible {
+			err = repositories.ErrNotFound
+		}
+	}
+	if err != nil {
+		return nil, storeError(c, err, "Task not found")
+	}
+	return task, nil
+}
+
+// canSee reports whether the caller may see task: tasks of a private
+// repository are hidden from everyone but its owner and admins
+func (h *TaskHandler) canSee(ctx context.Context, task *entities.Task) (bool, error) {
+	scope := auth.Scope(ctx)
+	if scope.Admin || task.Repository == "" {
+		return true, nil
+	}
+	repo, err := h.repos.GetByName(ctx, task.Repository)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return scope.CanSee(repo), nil
+}
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/usecase/auth"
+)
+
+// MaxBatchSize is the most operations one batch may carry
+const MaxBatchSize = 100
+
+// Batch operations
+const (
+	batchCreate     = "create"
+	batchUpdate     = "update"
+	batchDelete     = "delete"
+	batchTransition = "transition"
+)
+
+// batchRequest is the body of POST /tasks:batch
+type batchRequest struct {
+	Operations []batchOperation `json:"operations" validate:"required,min=1,max=100"` // max is MaxBatchSize
+	// ContinueOnError carries out every operation on its own and reports each
+	// outcome; by default the batch is applied in full or not at all
+	ContinueOnError bool `json:"continue_on_error"`
+}
+
+// batchOperation is one change of a batch
+type batchOperation struct {
+	Op string `json:"op" validate:"required,oneof=create update delete transition"`
+	// ID names the task of every operation but create
+	ID string `json:"id,omitempty"`
+	// Version, when set, must be the current version of the task, like If-Match
+	Version int64 `json:"version,omitempty" validate:"gte=0"`
+	// Task holds the fields of the task to create. It is validated on its own
+	// so its fields are reported under task.
+	Task *taskRequest `json:"task,omitempty" validate:"-"`
+	// Patch is a merge patch of the task to update; null removes a field
+	Patch json.RawMessage `json:"patch,omitempty"`
+	// Status is the status to move the task to in a transition
+	Status string `json:"status,omitempty" validate:"omitempty,task_status"`
+}
+
+// batchResult is the outcome of one operation of a batch
+type batchResult struct {
+	Index  int             `json:"index"`
+	Op     string          `json:"op"`
+	Status int             `json:"status"` // the HTTP status the operation would have on its own
+	TaskID string          `json:"task_id,omitempty"`
+	Task   *entities.Task  `json:"task,omitempty"`
+	Error  *apierror.Error `json:"error,omitempty"`
+}
+
+// batchItem is an operation checked and ready to be stored
+type batchItem struct {
+	write  repositories.TaskWrite
+	before *entities.Task // nil for created tasks
+}
+
+// BatchTasks carries out up to MaxBatchSize create, update, delete and
+// transition operations. By default they are applied in one transaction and
+// the first failing operation fails the request; with continue_on_error each
+// is applied on its own and the response reports every outcome. The caller
+// must be allowed to change every task involved.
+func (h *TaskHandler) BatchTasks(c echo.Context) error {
+	var req batchRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	results := make([]batchResult, len(req.Operations))
+	for i, op := range req.Operations {
+		results[i] = batchResult{Index: i, Op: op.Op, TaskID: op.ID}
+	}
+
+	if req.ContinueOnError {
+		for i, op := range req.Operations {
+			item, err := h.prepareBatchItem(c, op)
+			if err == nil {
+				err = h.tasks.ApplyBatch(c.Request().Context(), []repositories.TaskWrite{item.write})
+			}
+			if err != nil {
+				results[i].fail(c, err)
+				continue
+			}
+			h.finishBatchItem(c, item, &results[i])
+		}
+		return c.JSON(http.StatusOK, newBatchResponse(results))
+	}
+
+	// Every task is checked against its state before the batch, so each may
+	// only appear once
+	items := make([]*batchItem, len(req.Operations))
+	seen := make(map[string]int)
+	for i, op := range req.Operations {
+		if first, ok := seen[op.ID]; ok && op.ID != "" {
+			return batchError(i, apierror.InvalidField("id", "already appears in operation "+strconv.Itoa(first)))
+		}
+		seen[op.ID] = i
+
+		item, err := h.prepareBatchItem(c, op)
+		if err != nil {
+			return batchError(i, err)
+		}
+		items[i] = item
+	}
+
+	writes := make([]repositories.TaskWrite, len(items))
+	for i, item := range items {
+		writes[i] = item.write
+	}
+	if err := h.tasks.ApplyBatch(c.Request().Context(), writes); err != nil {
+		var failed *repositories.BatchError
+		if errors.As(err, &failed) {
+			return batchError(failed.Index, storeError(c, failed.Err, "Task not found"))
+		}
+		return storeError(c, err, "Task not found")
+	}
+	for i, item := range items {
+		h.finishBatchItem(c, item, &results[i])
+	}
+	return c.JSON(http.StatusOK, newBatchResponse(results))
+}
+
+// prepareBatchItem checks an operation and builds the write carrying it out
+func (h *TaskHandler) prepareBatchItem(c echo.Context, op batchOperation) (*batchItem, error) {
+	if err := c.Validate(&op); err != nil {
+		return nil, err
+	}
+
+	if op.Op == batchCreate {
+		if op.Task == nil {
+			return nil, apierror.Required("task")
+		}
+		if err := c.Validate(op.Task); err != nil {
+			return nil, prefixDetails(err, "task.")
+		}
+		var task entities.Task
+		op.Task.apply(&task)
+		if err := h.authorizeTask(c, &task); err != nil {
+			return nil, err
+		}
+		return &batchItem{write: repositories.TaskWrite{Op: repositories.WriteCreate, Task: &task}}, nil
+	}
+
+	if op.ID == "" {
+		return nil, apierror.Required("id")
+	}
+	task, err := h.loadTask(c, op.ID)
+	if err != nil {
+		return nil, err
+	}
+	if op.Version != 0 && op.Version != task.Version {
+		return nil, apierror.New(http.StatusPreconditionFailed, "",
+			"The task was modified since it was read (current version "+strconv.FormatInt(task.Version, 10)+")")
+	}
+	if err := h.authorizeTask(c, task); err != nil {
+		return nil, err
+	}
+	before := *task
+
+	switch op.Op {
+	case batchDelete:
+		return &batchItem{write: repositories.TaskWrite{Op: repositories.WriteDelete, Task: task}, before: &before}, nil
+	case batchTransition:
+		if op.Status == "" {
+			return nil, apierror.Required("status")
+		}
+		if err := changeStatus(task, op.Status); err != nil {
+			return nil, err
+		}
+	default: // batchUpdate
+		if len(op.Patch) == 0 {
+			return nil, apierror.Required("patch")
+		}
+		req := newTaskRequest(task)
+		if err := mergePatch(c, req, op.Patch); err != nil {
+			return nil, prefixDetails(err, "patch.")
+		}
+		if err := changeStatus(task, req.Status); err != nil {
+			return nil, err
+		}
+		req.apply(task)
+		// Moving the task must be allowed too
+		if task.Repository != before.Repository {
+			if err := h.authorizeTask(c, task); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &batchItem{write: repositories.TaskWrite{Op: repositories.WriteUpdate, Task: task}, before: &before}, nil
+}
+
+// authorizeTask checks that the caller may change task: viewers change
+// nothing, and tasks of a private repository belong to its owner and admins
+func (h *TaskHandler) authorizeTask(c echo.Context, task *entities.Task) error {
+	ctx := c.Request().Context()
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+	}
+	if user.Role == entities.RoleViewer {
+		return echo.NewHTTPError(http.StatusForbidden, "Viewers cannot change tasks")
+	}
+	if user.IsAdmin() || task.Repository == "" {
+		return nil
+	}
+
+	repo, err := h.repos.GetByName(ctx, task.Repository)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return storeError(c, err, "Repository not found")
+	}
+	if repo.Private && (repo.OwnerID == nil || *repo.OwnerID != user.ID) {
+		return echo.NewHTTPError(http.StatusForbidden, "Task belongs to private repository "+task.Repository)
+	}
+	return nil
+}
+
+// finishBatchItem records a stored operation and fills in its result
+func (h *TaskHandler) finishBatchItem(c echo.Context, item *batchItem, result *batchResult) {
+	ctx := c.Request().Context()
+	task := item.write.Task
+	result.TaskID = task.ID
+
+	switch item.write.Op {
+	case repositories.WriteCreate:
+		result.Status, result.Task = http.StatusCreated, task
+		h.activity.Record(ctx, entities.ActivityTaskCreated, entities.ResourceTask, task.ID, nil, task)
+	case repositories.WriteDelete:
+		result.Status = http.StatusOK
+		h.activity.Record(ctx, entities.ActivityTaskDeleted, entities.ResourceTask, task.ID, item.before, nil)
+	default:
+		result.Status, result.Task = http.StatusOK, task
+		h.activity.Record(ctx, entities.ActivityTaskUpdated, entities.ResourceTask, task.ID, item.before, task)
+		if previous := item.before.Status; task.Status != previous {
+			for _, listener := range h.listeners {
+				listener.TaskStatusChanged(ctx, task, previous)
+			}
+		}
+	}
+	slog.InfoContext(ctx, "batch task operation applied", "op", result.Op, "task_id", task.ID)
+}
+
+// fail reports err as the outcome of the operation
+func (r *batchResult) fail(c echo.Context, err error) {
+	var failed *repositories.BatchError
+	if errors.As(err, &failed) {
+		err = storeError(c, failed.Err, "Task not found")
+	}
+	apiErr := apierror.From(err)
+	r.Status, r.Error = apiErr.HTTPStatus, apiErr
+}
+
+// newBatchResponse counts the outcomes of a batch
+func newBatchResponse(results []batchResult) map[string]interface{} {
+	failed := 0
+	for _, result := range results {
+		if result.Error != nil {
+			failed++
+		}
+	}
+	return map[string]interface{}{
+		"results":   results,
+		"succeeded": len(results) - failed,
+		"failed":    failed,
+		"status":    "success",
+	}
+}
+
+// batchError fails a whole batch because of the operation at index
+func batchError(index int, err error) error {
+	apiErr := *apierror.From(err)
+	operation := "operations[" + strconv.Itoa(index) + "]"
+	apiErr.Message = operation + ": " + apiErr.Message
+	return prefixDetails(&apiErr, operation+".")
+}
+
+// prefixDetails qualifies the fields of the details of an *apierror.Error
+// with prefix; other errors are returned unchanged
+func prefixDetails(err error, prefix string) error {
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) || len(apiErr.Details) == 0 {
+		return err
+	}
+	prefixed := *apiErr
+	prefixed.Details = make([]apierror.Detail, len(apiErr.Details))
+	for i, detail := range apiErr.Details {
+		if detail.Field != "" {
+			detail.Field = prefix + detail.Field
+		}
+		prefixed.Details[i] = detail
+	}
+	return &prefixed
+}
@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/delivery/http/validation"
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/infrastructure/config"
+	"ai-git-workbench/internal/infrastructure/database"
+	"ai-git-workbench/internal/usecase/auth"
+)
+
+var (
+	alice  = &entities.User{ID: 1, Login: "alice", Role: entities.RoleMember}
+	bob    = &entities.User{ID: 2, Login: "bob", Role: entities.RoleMember}
+	viewer = &entities.User{ID: 3, Login: "vic", Role: entities.RoleViewer}
+	admin  = &entities.User{ID: 4, Login: "root", Role: entities.RoleAdmin}
+)
+
+// nopActivity discards activity records
+type nopActivity struct{}
+
+func (nopActivity) Record(context.Context, string, string, string, interface{}, interface{}) {}
+
+// racingTasks changes a task right after the handler loads it, as a writer
+// racing the batch would, so storing the batch fails on that task
+type racingTasks struct {
+	*database.TaskStore
+	raceID string
+}
+
+func (r *racingTasks) GetByID(ctx context.Context, id string) (*entities.Task, error) {
+	task, err := r.TaskStore.GetByID(ctx, id)
+	if err != nil || id != r.raceID {
+		return task, err
+	}
+	other := *task
+	other.Title = "changed concurrently"
+	if err := r.TaskStore.Update(ctx, &other); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// batchServer serves POST /tasks:batch and /tasks/import from a fresh SQLite
+// database holding a private repository of alice
+type batchServer struct {
+	e     *echo.Echo
+	tasks *racingTasks
+}
+
+func newBatchServer(t *testing.T) *batchServer {
+	t.Helper()
+	ctx := context.Background()
+	db, err := database.Open(ctx, &config.DatabaseConfig{
+		Driver: "sqlite", Path: filepath.Join(t.TempDir(), "test.db"), MaxOpenConns: 1, MaxIdleConns: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	repos := database.NewRepositoryStore(db)
+	if err := repos.Create(ctx, &entities.Repository{
+		Name: "secret", FullName: "alice/secret", Private: true, OwnerID: &alice.ID,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &batchServer{e: echo.New(), tasks: &racingTasks{TaskStore: database.NewTaskStore(db)}}
+	s.e.HTTPErrorHandler = apierror.Handler
+	s.e.Validator = validation.New()
+	h := NewTaskHandler(s.tasks, repos, nil, nopActivity{})
+	s.e.POST("/tasks\\:batch", h.BatchTasks)
+	s.e.POST("/tasks/import", h.ImportTasks)
+	return s
+}
+
+// create stores a task directly
+func (s *batchServer) create(t *testing.T, task entities.Task) *entities.Task {
+	t.Helper()
+	if err := s.tasks.Create(context.Background(), &task); err != nil {
+		t.Fatal(err)
+	}
+	return &task
+}
+
+// get loads a task directly, nil when it does not exist
+func (s *batchServer) get(t *testing.T, id string) *entities.Task {
+	t.Helper()
+	task, err := s.tasks.TaskStore.GetByID(context.Background(), id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+// count returns the number of stored tasks
+func (s *batchServer) count(t *testing.T) int {
+	t.Helper()
+	page, err := s.tasks.List(context.Background(), repositories.TaskFilter{}, repositories.ListOptions{Limit: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(page.Items)
+}
+
+// batchReply is the decoded response to a batch
+type batchReply struct {
+	Results []struct {
+		Status int             `json:"status"`
+		TaskID string          `json:"task_id"`
+		Task   *entities.Task  `json:"task"`
+		Error  *apierror.Error `json:"error"`
+	} `json:"results"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Message   string            `json:"message"`
+	Details   []apierror.Detail `json:"details"`
+}
+
+// post sends body as user and decodes the response
+func (s *batchServer) post(t *testing.T, user *entities.User, body string) (int, batchReply) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/tasks:batch", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req = req.WithContext(auth.WithUser(req.Context(), user))
+	rec := httptest.NewRecorder()
+	s.e.ServeHTTP(rec, req)
+
+	var reply batchReply
+	if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+		t.Fatalf("response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, reply
+}
+
+func TestBatchTasksRollsBackOnFailure(t *testing.T) {
+	s := newBatchServer(t)
+	kept := s.create(t, entities.Task{Title: "kept"})
+	raced := s.create(t, entities.Task{Title: "raced"})
+	s.tasks.raceID = raced.ID
+
+	code, reply := s.post(t, alice, `{"operations": [
+		{"op": "create", "task": {"title": "new"}},
+		{"op": "update", "id": "`+kept.ID+`", "patch": {"title": "renamed"}},
+		{"op": "delete", "id": "`+raced.ID+`"}]}`)
+	if code != http.StatusPreconditionFailed || !strings.HasPrefix(reply.Message, "operations[2]: ") {
+		t.Fatalf("status %d %q, want 412 for operations[2]", code, reply.Message)
+	}
+	if n := s.count(t); n != 2 {
+		t.Errorf("%d tasks stored, want the 2 from before the batch", n)
+	}
+	if got := s.get(t, kept.ID); got.Title != "kept" || got.Version != kept.Version {
+		t.Errorf("kept task is %q at version %d, want it unchanged", got.Title, got.Version)
+	}
+	if s.get(t, raced.ID) == nil {
+		t.Error("raced task was deleted")
+	}
+}
+
+func TestBatchTasksRejectsBeforeWriting(t *testing.T) {
+	s := newBatchServer(t)
+	task := s.create(t, entities.Task{Title: "task"})
+
+	for _, tc := range []struct {
+		name   string
+		body   string
+		status int
+		field  string
+	}{
+		{name: "invalid create", status: http.StatusBadRequest, field: "operations[1].task.title",
+			body: `{"operations": [{"op": "create", "task": {"title": "ok"}}, {"op": "create", "task": {"title": " "}}]}`},
+		{name: "unknown task", status: http.StatusNotFound,
+			body: `{"operations": [{"op": "create", "task": {"title": "ok"}}, {"op": "delete", "id": "missing"}]}`},
+		{name: "task twice", status: http.StatusBadRequest, field: "operations[1].id",
+			body: `{"operations": [{"op": "update", "id": "` + task.ID + `", "patch": {"title": "a"}},
+				{"op": "delete", "id": "` + task.ID + `"}]}`},
+		{name: "stale version", status: http.StatusPreconditionFailed,
+			body: `{"operations": [{"op": "create", "task": {"title": "ok"}}, {"op": "delete", "id": "` + task.ID + `", "version": 9}]}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			code, reply := s.post(t, alice, tc.body)
+			if code != tc.status {
+				t.Fatalf("status = %d (%s), want %d", code, reply.Message, tc.status)
+			}
+			if tc.field != "" && (len(reply.Details) == 0 || reply.Details[0].Field != tc.field) {
+				t.Errorf("details = %+v, want field %s", reply.Details, tc.field)
+			}
+			if n := s.count(t); n != 1 {
+				t.Errorf("%d tasks stored, want 1", n)
+			}
+		})
+	}
+}
+
+func TestBatchTasksSize(t *testing.T) {
+	s := newBatchServer(t)
+	operations := func(n int) string {
+		ops := make([]string, n)
+		for i := range ops {
+			ops[i] = `{"op": "create", "task": {"title": "task"}}`
+		}
+		return `{"operations": [` + strings.Join(ops, ",") + `]}`
+	}
+
+	if code, reply := s.post(t, alice, `{"operations": []}`); code != http.StatusBadRequest {
+		t.Errorf("empty batch: status = %d (%s), want 400", code, reply.Message)
+	}
+	code, reply := s.post(t, alice, operations(MaxBatchSize+1))
+	if code != http.StatusBadRequest || len(reply.Details) == 0 || reply.Details[0].Field != "operations" {
+		t.Errorf("%d operations: status = %d, details = %+v, want 400 on operations", MaxBatchSize+1, code, reply.Details)
+	}
+	if n := s.count(t); n != 0 {
+		t.Fatalf("%d tasks stored by rejected batches", n)
+	}
+
+	code, reply = s.post(t, alice, operations(MaxBatchSize))
+	if code != http.StatusOK || reply.Succeeded != MaxBatchSize {
+		t.Errorf("%d operations: status = %d, %d succeeded (%s)", MaxBatchSize, code, reply.Succeeded, reply.Message)
+	}
+}
+
+func TestBatchTasksChecksEachOperation(t *testing.T) {
+	s := newBatchServer(t)
+	private := s.create(t, entities.Task{Title: "private", Repository: "alice/secret"})
+	public := s.create(t, entities.Task{Title: "public"})
+
+	for _, tc := range []struct {
+		name     string
+		user     *entities.User
+		body     string
+		statuses []int
+	}{
+		{name: "viewer", user: viewer, statuses: []int{http.StatusForbidden},
+			body: `{"operations": [{"op": "create", "task": {"title": "x"}}]}`},
+		{name: "create in a private repository", user: bob, statuses: []int{http.StatusCreated, http.StatusForbidden},
+			body: `{"operations": [{"op": "create", "task": {"title": "x"}},
+				{"op": "create", "task": {"title": "x", "repository": "alice/secret"}}]}`},
+		{name: "move into a private repository", user: bob, statuses: []int{http.StatusForbidden},
+			body: `{"operations": [{"op": "update", "id": "` + public.ID + `", "patch": {"repository": "alice/secret"}}]}`},
+		{name: "hidden task", user: bob, statuses: []int{http.StatusNotFound},
+			body: `{"operations": [{"op": "delete", "id": "` + private.ID + `"}]}`},
+		{name: "owner", user: alice, statuses: []int{http.StatusOK},
+			body: `{"operations": [{"op": "update", "id": "` + private.ID + `", "patch": {"epic": "owner"}}]}`},
+		{name: "admin", user: admin, statuses: []int{http.StatusOK},
+			body: `{"operations": [{"op": "update", "id": "` + private.ID + `", "patch": {"epic": "admin"}}]}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			before := s.count(t)
+			allowed := 0
+			for _, status := range tc.statuses {
+				if status < http.StatusBadRequest {
+					allowed++
+				}
+			}
+
+			// All or nothing fails on the first refused operation
+			code, reply := s.post(t, tc.user, tc.body)
+			if allowed == len(tc.statuses) {
+				if code != http.StatusOK {
+					t.Fatalf("status = %d (%s), want 200", code, reply.Message)
+				}
+			} else if code != tc.statuses[allowed] {
+				t.Fatalf("status = %d (%s), want %d", code, reply.Message, tc.statuses[allowed])
+			}
+			if n := s.count(t); allowed < len(tc.statuses) && n != before {
+				t.Errorf("%d tasks stored after a refused batch, want %d", n, before)
+			}
+			if got := s.get(t, public.ID); got.Repository != "" {
+				t.Errorf("public task moved to %q", got.Repository)
+			}
+
+			// Operation by operation reports each outcome
+			body := strings.Replace(tc.body, "{", `{"continue_on_error": true, `, 1)
+			if code, reply = s.post(t, tc.user, body); code != http.StatusOK {
+				t.Fatalf("continue_on_error: status = %d (%s), want 200", code, reply.Message)
+			}
+			for i, result := range reply.Results {
+				if result.Status != tc.statuses[i] {
+					t.Errorf("continue_on_error: operation %d status = %d, want %d", i, result.Status, tc.statuses[i])
+				}
+			}
+			if reply.Succeeded != allowed || reply.Failed != len(tc.statuses)-allowed {
+				t.Errorf("continue_on_error: %d succeeded and %d failed, want %d and %d",
+					reply.Succeeded, reply.Failed, allowed, len(tc.statuses)-allowed)
+			}
+		})
+	}
+}
+
+func TestBatchTasksStatusChanges(t *testing.T) {
+	s := newBatchServer(t)
+	for _, tc := range []struct {
+		name      string
+		from      string
+		op        string
+		to        string
+		status    int
+		started   bool
+		completed bool
+	}{
+		{name: "finish", from: entities.TaskStatusPending, op: `"op": "transition", "status": "completed"`,
+			status: http.StatusOK, to: entities.TaskStatusCompleted, started: true, completed: true},
+		{name: "finish by update", from: entities.TaskStatusQueued, op: `"op": "update", "patch": {"status": "failed"}`,
+			status: http.StatusOK, to: entities.TaskStatusFailed, started: true, completed: true},
+		{name: "reopen", from: entities.TaskStatusCompleted, op: `"op": "transition", "status": "queued"`,
+			status: http.StatusOK, to: entities.TaskStatusQueued},
+		{name: "same status", from: entities.TaskStatusCompleted, op: `"op": "transition", "status": "completed"`,
+			status: http.StatusOK, to: entities.TaskStatusCompleted, started: true, completed: true},
+		{name: "start", from: entities.TaskStatusPending, op: `"op": "transition", "status": "in_progress"`,
+			status: http.StatusConflict, to: entities.TaskStatusPending, started: true, completed: true},
+		{name: "start by update", from: entities.TaskStatusPending, op: `"op": "update", "patch": {"status": "in_progress"}`,
+			status: http.StatusConflict, to: entities.TaskStatusPending, started: true, completed: true},
+		{name: "stop a run", from: entities.TaskStatusInProgress, op: `"op": "transition", "status": "completed"`,
+			status: http.StatusConflict, to: entities.TaskStatusInProgress, started: true, completed: true},
+		{name: "finished to finished", from: entities.TaskStatusFailed, op: `"op": "update", "patch": {"status": "completed"}`,
+			status: http.StatusConflict, to: entities.TaskStatusFailed, started: true, completed: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Timestamps set beforehand show whether they were kept or cleared
+			stamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			task := s.create(t, entities.Task{Title: tc.name, Status: tc.from, StartedAt: &stamp, CompletedAt: &stamp})
+
+			code, reply := s.post(t, alice, `{"operations": [{"id": "`+task.ID+`", `+tc.op+`}]}`)
+			if code != tc.status {
+				t.Fatalf("status = %d (%s), want %d", code, reply.Message, tc.status)
+			}
+			got := s.get(t, task.ID)
+			if got.Status != tc.to {
+				t.Errorf("status = %s, want %s", got.Status, tc.to)
+			}
+			if (got.StartedAt != nil) != tc.started || (got.CompletedAt != nil) != tc.completed {
+				t.Errorf("started_at = %v, completed_at = %v, want set: %v, %v",
+					got.StartedAt, got.CompletedAt, tc.started, tc.completed)
+			}
+			if tc.status == http.StatusOK && tc.to != tc.from && tc.completed && !got.CompletedAt.After(stamp) {
+				t.Errorf("completed_at = %v, want the time of the change", got.CompletedAt)
+			}
+		})
+	}
+}
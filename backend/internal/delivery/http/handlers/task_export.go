@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/usecase/auth"
+)
+
+// Formats of task exports and imports
+const (
+	formatCSV      = "csv"
+	formatJSONL    = "jsonl"
+	formatMarkdown = "markdown"
+)
+
+// taskFormats maps the task file formats to their media types
+var taskFormats = map[string]string{
+	formatCSV:      "text/csv; charset=utf-8",
+	formatJSONL:    "application/x-ndjson",
+	formatMarkdown: "text/markdown; charset=utf-8",
+}
+
+// taskFormatList names the formats in error messages
+const taskFormatList = "csv, jsonl or markdown"
+
+// taskCSVHeader is the first row of a CSV export. Imports read the same
+// columns and ignore id, created_at and updated_at.
+var taskCSVHeader = []string{
+	"id", "title", "description", "status", "repository", "epic", "branch", "assignee", "tokens_used",
+	"metadata", "created_at", "updated_at",
+}
+
+// ExportTasks streams every task matching the query filters that the
+// caller can see as CSV, JSON Lines or a Markdown checklist, selected with
+// ?format=csv|jsonl|markdown
+func (h *TaskHandler) ExportTasks(c echo.Context) error {
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		return err
+	}
+	scope := auth.Scope(c.Request().Context())
+	filter.Scope = &scope
+	opts, err := parseListOptions(c)
+	if err != nil {
+		return err
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = formatJSONL
+	}
+	contentType, ok := taskFormats[format]
+	if !ok {
+		return apierror.InvalidField("format", "must be "+taskFormatList)
+	}
+
+	// Validate the filter and sort before the response is committed
+	ctx := c.Request().Context()
+	if _, err := h.tasks.List(ctx, filter, repositories.ListOptions{Limit: 1, Sort: opts.Sort}); err != nil {
+		return storeError(c, err, "Task not found")
+	}
+
+	extension := format
+	if format == formatMarkdown {
+		extension = "md"
+	}
+	resp := c.Response()
+	resp.Header().Set(echo.HeaderContentType, contentType)
+	resp.Header().Set(echo.HeaderContentDisposition,
+		`attachment; filename="tasks-`+time.Now().UTC().Format("20060102T150405Z")+"."+extension+`"`)
+	resp.WriteHeader(http.StatusOK)
+
+	var write func(*entities.Task) error
+	switch format {
+	case formatCSV:
+		w := csv.NewWriter(resp)
+		if err := w.Write(taskCSVHeader); err != nil {
+			return nil
+		}
+		write = func(task *entities.Task) error {
+			if err := w.Write(taskCSVRow(task)); err != nil {
+				return err
+			}
+			w.Flush()
+			return w.Error()
+		}
+	case formatMarkdown:
+		write = func(task *entities.Task) error {
+			return writeMarkdownTask(resp, task)
+		}
+	default:
+		enc := json.NewEncoder(resp)
+		write = func(task *entities.Task) error {
+			return enc.Encode(task)
+		}
+	}
+
+	count := 0
+	opts = repositories.ListOptions{Limit: repositories.MaxPageSize, Sort: opts.Sort}
+	for {
+		page, err := h.tasks.List(ctx, filter, opts)
+		if err == nil {
+			for i := range page.Items {
+				if err = write(&page.Items[i]); err != nil {
+					break
+				}
+				count++
+			}
+		}
+		if err != nil {
+			// The status line is already sent; the truncated body signals the failure
+			slog.ErrorContext(ctx, "task export failed", "format", format, "exported", count, "error", err)
+			return nil
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+	slog.InfoContext(ctx, "tasks exported", "format", format, "exported", count)
+	return nil
+}
+
+// taskCSVRow renders a task as a CSV export row
+func taskCSVRow(task *entities.Task) []string {
+	metadata := ""
+	if len(task.Metadata) > 0 {
+		if b, err := json.Marshal(task.Metadata); err == nil {
+			metadata = string(b)
+		}
+	}
+	return []string{
+		task.ID, task.Title, task.Description, task.Status, task.Repository, task.Epic, task.Branch,
+		task.Assignee, strconv.Itoa(task.TokensUsed), metadata, task.CreatedAt.UTC().Format(time.RFC3339),
+		task.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// writeMarkdownTask renders a task as a checklist item, checked when it is
+// completed. The description follows as indented lines, then the other set
+// fields as a nested "- field: value" list.
+func writeMarkdownTask(w io.Writer, task *entities.Task) error {
+	check := " "
+	if task.Status == entities.TaskStatusCompleted {
+		check = "x"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "- [%s] %s\n", check, singleLine(task.Title))
+	if description := strings.Trim(task.Description, "\r\n"); description != "" {
+		for _, line := range strings.Split(description, "\n") {
+			if line = strings.TrimRight(line, "\r"); line == "" {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("  " + line + "\n")
+			}
+		}
+	}
+
+	// The checkbox already tells pending and completed tasks apart
+	status := task.Status
+	if status == entities.TaskStatusPending || status == entities.TaskStatusCompleted {
+		status = ""
+	}
+	metadata := ""
+	if len(task.Metadata) > 0 {
+		if encoded, err := json.Marshal(task.Metadata); err == nil {
+			metadata = string(encoded)
+		}
+	}
+	tokens := ""
+	if task.TokensUsed != 0 {
+		tokens = strconv.Itoa(task.TokensUsed)
+	}
+	for _, field := range []struct{ name, value string }{
+		{"status", status},
+		{"repository", task.Repository},
+		{"epic", task.Epic},
+		{"branch", task.Branch},
+		{"assignee", task.Assignee},
+		{"tokens_used", tokens},
+		{"metadata", metadata},
+	} {
+		if field.value != "" {
+			b.WriteString("  - " + field.name + ": " + singleLine(field.value) + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// lineBreaks turns line breaks into spaces
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+// singleLine joins the lines of s with spaces
+func singleLine(s string) string {
+	return lineBreaks.Replace(s)
+}
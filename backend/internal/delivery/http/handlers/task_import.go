@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// MaxImportRows is the most tasks one import may create
+const MaxImportRows = 1000
+
+// maxImportSize bounds the body of an import
+const maxImportSize = 10 << 20
+
+// utf8BOM starts files saved by some spreadsheet programs
+var utf8BOM = []byte("\ufeff")
+
+// importMediaTypes maps the media types of import bodies to their format
+var importMediaTypes = map[string]string{
+	"text/csv":             formatCSV,
+	"application/x-ndjson": formatJSONL,
+	"application/jsonl":    formatJSONL,
+	"text/markdown":        formatMarkdown,
+}
+
+var (
+	// markdownItem matches a top-level checklist item: "- [ ] title"
+	markdownItem = regexp.MustCompile(`^[-*+] \[([ xX])\] (.*)$`)
+	// markdownField matches a nested "- field: value" line of an item
+	markdownField = regexp.MustCompile(`^\s+[-*+] (status|repository|epic|branch|assignee|tokens_used|metadata):\s*(.*)$`)
+)
+
+// importRow is a task read from an import file
+type importRow struct {
+	line int // where the task starts in the file, from 1
+	req  taskRequest
+	err  error // the row could not be read into req
+}
+
+// importError is a problem with one row of an import
+type importError struct {
+	Row     int    `json:"row"` // index of the task in the file, from 0
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ImportTasks creates the tasks of a CSV, JSON Lines or Markdown checklist
+// body, in the format of ?format= or else of the Content-Type. Every row is
+// validated and checked like a batch create first, and may not be
+// in_progress; the tasks are created in one transaction only when all rows
+// are valid. With ?dry_run=true nothing is created and the response reports
+// the problems of every row.
+func (h *TaskHandler) ImportTasks(c echo.Context) error {
+	format, err := importFormat(c)
+	if err != nil {
+		return err
+	}
+	dryRun := false
+	if raw := c.QueryParam("dry_run"); raw != "" {
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			return apierror.InvalidField("dry_run", "must be true or false")
+		}
+	}
+	// A task without repository only needs a caller who may change tasks
+	if err := h.authorizeTask(c, &entities.Task{}); err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxImportSize+1))
+	if err != nil {
+		return bindError(c, err)
+	}
+	if len(body) > maxImportSize {
+		return apierror.New(http.StatusRequestEntityTooLarge, "",
+			"An import must not exceed "+strconv.Itoa(maxImportSize>>20)+" MiB")
+	}
+	var rows []importRow
+	switch format {
+	case formatCSV:
+		rows, err = readTaskCSV(body)
+	case formatMarkdown:
+		rows, err = readTaskMarkdown(body)
+	default:
+		rows, err = readTaskJSONL(body)
+	}
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return apierror.New(http.StatusBadRequest, "", "The file holds no tasks")
+	}
+	if len(rows) > MaxImportRows {
+		return apierror.New(http.StatusRequestEntityTooLarge, "",
+			"An import may create at most "+strconv.Itoa(MaxImportRows)+" tasks")
+	}
+
+	problems := []importError{}
+	writes := make([]repositories.TaskWrite, 0, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		err := row.err
+		if err == nil {
+			err = c.Validate(&row.req)
+		}
+		var task entities.Task
+		if err == nil {
+			row.req.apply(&task)
+			err = importStatus(&task, row.req.Status)
+		}
+		if err == nil {
+			err = h.authorizeTask(c, &task)
+		}
+		if err != nil {
+			apiErr := apierror.From(err)
+			if apiErr.HTTPStatus >= http.StatusInternalServerError {
+				return err
+			}
+			problems = append(problems, importErrors(i, row.line, apiErr)...)
+			continue
+		}
+		writes = append(writes, repositories.TaskWrite{Op: repositories.WriteCreate, Task: &task})
+	}
+
+	invalid := len(rows) - len(writes)
+	if dryRun {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"dry_run": true,
+			"format":  format,
+			"total":   len(rows),
+			"valid":   len(writes),
+			"invalid": invalid,
+			"errors":  problems,
+			"status":  "success",
+		})
+	}
+	if invalid > 0 {
+		details := make([]apierror.Detail, len(problems))
+		for i, problem := range problems {
+			field := "rows[" + strconv.Itoa(problem.Row) + "]"
+			if problem.Field != "" {
+				field += "." + problem.Field
+			}
+			details[i] = apierror.Detail{
+				Field:   field,
+				Code:    problem.Code,
+				Message: "line " + strconv.Itoa(problem.Line) + ": " + problem.Message,
+			}
+		}
+		apiErr := apierror.Invalid(details...)
+		apiErr.Message = fmt.Sprintf("%d of %d rows are invalid; no task was imported", invalid, len(rows))
+		return apiErr
+	}
+
+	ctx := c.Request().Context()
+	if err := h.tasks.ApplyBatch(ctx, writes); err != nil {
+		var failed *repositories.BatchError
+		if errors.As(err, &failed) {
+			err = failed.Err
+		}
+		return storeError(c, err, "Task not found")
+	}
+	tasks := make([]*entities.Task, len(writes))
+	for i, write := range writes {
+		tasks[i] = write.Task
+		h.activity.Record(ctx, entities.ActivityTaskCreated, entities.ResourceTask, write.Task.ID, nil, write.Task)
+	}
+	slog.InfoContext(ctx, "tasks imported", "format", format, "imported", len(tasks))
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message":  "Tasks imported successfully",
+		"imported": len(tasks),
+		"tasks":    tasks,
+		"status":   "success",
+	})
+}
+
+// importStatus moves a new task from pending to the status of its row like
+// any status change, so finished tasks get completed_at and none is put in
+// in_progress without running
+func importStatus(task *entities.Task, status string) error {
+	task.Status = entities.TaskStatusPending
+	if err := changeStatus(task, status); err != nil {
+		return apierror.InvalidField("status", "cannot be imported as "+status+", tasks only start by running them")
+	}
+	return nil
+}
+
+// importFormat returns the format of an import body
+func importFormat(c echo.Context) (string, error) {
+	if format := c.QueryParam("format"); format != "" {
+		if _, ok := taskFormats[format]; !ok {
+			return "", apierror.InvalidField("format", "must be "+taskFormatList)
+		}
+		return format, nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if format, ok := importMediaTypes[mediaType]; ok {
+		return format, nil
+	}
+	return "", echo.NewHTTPError(http.StatusUnsupportedMediaType,
+		"Content-Type must be text/csv, application/x-ndjson or text/markdown, or set ?format=")
+}
+
+// importErrors lists the problems of the row at index, one per field
+func importErrors(index, line int, err *apierror.Error) []importError {
+	if len(err.Details) == 0 {
+		return []importError{{Row: index, Line: line, Code: err.Code, Message: err.Message}}
+	}
+	problems := make([]importError, len(err.Details))
+	for i, detail := range err.Details {
+		problems[i] = importError{
+			Row: index, Line: line, Field: detail.Field, Code: detail.Code, Message: detail.Message,
+		}
+	}
+	return problems
+}
+
+// readTaskCSV reads a CSV file with a header row naming the columns of
+// taskCSVHeader. Only title is required; empty rows are skipped.
+func readTaskCSV(body []byte) ([]importRow, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, utf8BOM)))
+	r.FieldsPerRecord = -1 // rows with a wrong field count are reported, not fatal
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apierror.New(http.StatusBadRequest, "", "Malformed CSV: "+err.Error())
+	}
+	columns := make([]string, len(header))
+	hasTitle := false
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "id", "created_at", "updated_at":
+			// Imported tasks are new
+		case "title", "description", "status", "repository", "epic", "branch", "assignee", "tokens_used", "metadata":
+			columns[i] = name
+			hasTitle = hasTitle || name == "title"
+		default:
+			return nil, apierror.New(http.StatusBadRequest, "", "Unknown CSV column "+strconv.Quote(name))
+		}
+	}
+	if !hasTitle {
+		return nil, apierror.New(http.StatusBadRequest, "", "The CSV header must have a title column")
+	}
+
+	var rows []importRow
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, apierror.New(http.StatusBadRequest, "", "Malformed CSV: "+err.Error())
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		line, _ := r.FieldPos(0)
+		row := importRow{line: line}
+		if len(record) != len(header) {
+			row.err = apierror.Invalid(apierror.Detail{
+				Code:    apierror.DetailMalformed,
+				Message: fmt.Sprintf("row has %d fields but the header %d", len(record), len(header)),
+			})
+		} else {
+			for i, value := range record {
+				if columns[i] == "" {
+					continue
+				}
+				if err := setTaskField(&row.req, columns[i], value); err != nil {
+					row.err = err
+					break
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// readTaskJSONL reads one task object per line, such as a JSON Lines export.
+// Fields other than those of a task request are ignored.
+func readTaskJSONL(body []byte) ([]importRow, error) {
+	var rows []importRow
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(nil, maxImportSize)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		row := importRow{line: line}
+		if err := json.Unmarshal(text, &row.req); err != nil {
+			row.err = apierror.FromBind(err)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, apierror.New(http.StatusBadRequest, "", "Malformed JSON Lines: "+err.Error())
+	}
+	return rows, nil
+}
+
+// readTaskMarkdown reads a checklist in the form of writeMarkdownTask.
+// Checked items without a status field are completed, others pending; lines
+// outside items, such as headings, are ignored.
+func readTaskMarkdown(body []byte) ([]importRow, error) {
+	var (
+		rows        []importRow
+		row         *importRow
+		description []string
+	)
+	finish := func() {
+		if row == nil {
+			return
+		}
+		row.req.Description = strings.Trim(strings.Join(description, "\n"), "\n")
+		rows = append(rows, *row)
+		row, description = nil, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(body, utf8BOM)))
+	scanner.Buffer(nil, maxImportSize)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if match := markdownItem.FindStringSubmatch(text); match != nil {
+			finish()
+			row = &importRow{line: line, req: taskRequest{Title: strings.TrimSpace(match[2])}}
+			if match[1] != " " {
+				row.req.Status = entities.TaskStatusCompleted
+			}
+			continue
+		}
+		if row == nil {
+			continue
+		}
+		switch {
+		case strings.TrimSpace(text) == "":
+			description = append(description, "")
+		case text[0] != ' ' && text[0] != '\t':
+			// Back at the top level: the item ended
+			finish()
+		default:
+			if match := markdownField.FindStringSubmatch(text); match != nil {
+				if err := setTaskField(&row.req, match[1], match[2]); err != nil && row.err == nil {
+					row.err = err
+				}
+				continue
+			}
+			description = append(description, strings.TrimPrefix(strings.TrimPrefix(text, "  "), "\t"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, apierror.New(http.StatusBadRequest, "", "Malformed Markdown: "+err.Error())
+	}
+	finish()
+	return rows, nil
+}
+
+// setTaskField sets a field of req from its text in a CSV cell or Markdown
+// field. tokens_used is an integer and metadata a JSON object of strings;
+// empty values leave the field unset.
+func setTaskField(req *taskRequest, field, value string) error {
+	switch field {
+	case "title":
+		req.Title = value
+	case "description":
+		req.Description = value
+	case "status":
+		req.Status = strings.TrimSpace(value)
+	case "repository":
+		req.Repository = strings.TrimSpace(value)
+	case "epic":
+		req.Epic = value
+	case "branch":
+		req.Branch = strings.TrimSpace(value)
+	case "assignee":
+		req.Assignee = strings.TrimSpace(value)
+	case "tokens_used":
+		if value = strings.TrimSpace(value); value == "" {
+			return nil
+		}
+		tokens, err := strconv.Atoi(value)
+		if err != nil {
+			return apierror.Invalid(apierror.Detail{
+				Field: field, Code: apierror.DetailInvalidType, Message: field + " must be an integer",
+			})
+		}
+		req.TokensUsed = tokens
+	case "metadata":
+		if value = strings.TrimSpace(value); value == "" {
+			return nil
+		}
+		if err := json.Unmarshal([]byte(value), &req.Metadata); err != nil {
+			return apierror.Invalid(apierror.Detail{
+				Field: field, Code: apierror.DetailInvalidType, Message: field + " must be a JSON object of strings",
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/usecase/auth"
+)
+
+// importTasks sends a JSON Lines import as alice and returns the status and
+// the decoded response
+func (s *batchServer) importTasks(t *testing.T, body string) (int, map[string]json.RawMessage) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/tasks/import", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, "application/x-ndjson")
+	req = req.WithContext(auth.WithUser(req.Context(), alice))
+	rec := httptest.NewRecorder()
+	s.e.ServeHTTP(rec, req)
+
+	var reply map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+		t.Fatalf("response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, reply
+}
+
+func TestImportTasksStatuses(t *testing.T) {
+	s := newBatchServer(t)
+	code, reply := s.importTasks(t, `{"title": "todo"}
+{"title": "done", "status": "completed"}
+{"title": "broken", "status": "failed"}
+{"title": "waiting", "status": "queued"}`)
+	if code != http.StatusCreated {
+		t.Fatalf("status %d %s, want 201", code, reply["message"])
+	}
+	var tasks []entities.Task
+	if err := json.Unmarshal(reply["tasks"], &tasks); err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range tasks {
+		if finished := task.IsFinished(); (task.CompletedAt != nil) != finished {
+			t.Errorf("%s task has completed_at %v", task.Status, task.CompletedAt)
+		}
+		if task.StartedAt != nil {
+			t.Errorf("%s task has started_at %v", task.Status, task.StartedAt)
+		}
+	}
+
+	code, reply = s.importTasks(t, `{"title": "ok"}
+{"title": "running", "status": "in_progress"}`)
+	if code != http.StatusBadRequest || !strings.Contains(string(reply["details"]), `"rows[1].status"`) {
+		t.Fatalf("status %d %s, want 400 naming rows[1].status", code, reply["details"])
+	}
+	if n := s.count(t); n != 4 {
+		t.Errorf("%d tasks stored, want the 4 of the first import", n)
+	}
+}
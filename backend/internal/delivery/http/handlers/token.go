@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/usecase/auth"
+)
+
+// TokenHandler handles API token endpoints
+type TokenHandler struct {
+	auth     *auth.Service
+	maxTTL   time.Duration
+	activity ActivityRecorder
+}
+
+// NewTokenHandler creates a new TokenHandler issuing tokens that expire
+// within maxTTL and recording them in activity
+func NewTokenHandler(auth *auth.Service, maxTTL time.Duration, activity ActivityRecorder) *TokenHandler {
+	return &TokenHandler{auth: auth, maxTTL: maxTTL, activity: activity}
+}
+
+// createTokenRequest is the body of POST /tokens
+type createTokenRequest struct {
+	Name      string `json:"name"`
+	ExpiresIn string `json:"expires_in"` // Go duration such as "720h"; empty is the longest allowed
+}
+
+// CreateToken issues a new API token for the caller. The secret is only
+// returned in this response. Tokens expire after expires_in, which may not
+// exceed the maximum lifetime that applies when it is left out.
+func (h *TokenHandler) CreateToken(c echo.Context) error {
+	var req createTokenRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	ttl := h.maxTTL
+	if req.ExpiresIn != "" {
+		var err error
+		ttl, err = time.ParseDuration(req.ExpiresIn)
+		if err != nil || ttl <= 0 {
+			return apierror.InvalidField("expires_in", "must be a positive duration such as 720h")
+		}
+		if ttl > h.maxTTL {
+			return apierror.InvalidField("expires_in", "must be at most "+h.maxTTL.String())
+		}
+	}
+
+	user := auth.UserFromContext(c.Request().Context())
+	raw, token, err := h.auth.IssueToken(c.Request().Context(), user, req.Name, ttl)
+	if err != nil {
+		return storeError(c, err, "User not found")
+	}
+
+	slog.InfoContext(c.Request().Context(), "api token created",
+		"user_id", user.ID, "token_id", token.ID, "token_prefix", token.Prefix)
+	h.activity.Record(c.Request().Context(), entities.ActivityTokenCreated, entities.ResourceToken,
+		strconv.FormatInt(token.ID, 10), nil, token)
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Token created successfully",
+		"token":   raw,
+		"details": token,
+		"status":  "success",
+	})
+}
@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/usecase/auth"
+	"ai-git-workbench/internal/usecase/execution"
+	"ai-git-workbench/internal/usecase/workflow"
+)
+
+// maxDefinitionSize bounds the size of a submitted workflow definition
+const maxDefinitionSize = 1 << 20
+
+// WorkflowHandler handles workflow definition and run endpoints
+type WorkflowHandler struct {
+	workflows *workflow.Service
+	engine    *workflow.Engine
+	activity  ActivityRecorder
+}
+
+// NewWorkflowHandler creates a new WorkflowHandler recording changes and
+// decisions in activity
+func NewWorkflowHandler(workflows *workflow.Service, engine *workflow.Engine, activity ActivityRecorder) *WorkflowHandler {
+	return &WorkflowHandler{workflows: workflows, engine: engine, activity: activity}
+}
+
+// runRequest holds the parameters of a manually started run
+type runRequest struct {
+	Version int               `json:"version"`
+	Inputs  map[string]string `json:"inputs"`
+}
+
+// GetWorkflows returns a page of workflows with their latest definitions
+func (h *WorkflowHandler) GetWorkflows(c echo.Context) error {
+	opts, err := parseListOptions(c)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.workflows.List(c.Request().Context(), opts)
+	if err != nil {
+		return storeError(c, err, "Workflow not found")
+	}
+
+	return c.JSON(http.StatusOK, newListResponse(page, opts))
+}
+
+// GetWorkflow returns a workflow with its latest definition
+func (h *WorkflowHandler) GetWorkflow(c echo.Context) error {
+	workflowID, err := workflowID(c)
+	if err != nil {
+		return err
+	}
+
+	wf, err := h.workflows.Get(c.Request().Context(), workflowID)
+	if err != nil {
+		return storeError(c, err, "Workflow not found")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"workflow": wf,
+		"status":   "success",
+	})
+}
+
+// CreateWorkflow stores a YAML or JSON definition as a new workflow
+func (h *WorkflowHandler) CreateWorkflow(c echo.Context) error {
+	if err := authorizeWorkflow(c, nil); err != nil {
+		return err
+	}
+	source, format, err := readDefinition(c)
+	if err != nil {
+		return err
+	}
+
+	wf, err := h.workflows.Create(c.Request().Context(), source, format, callerID(c))
+	if err != nil {
+		return workflowError(c, err)
+	}
+
+	slog.InfoContext(c.Request().Context(), "workflow created", "workflow_id", wf.ID, "name", wf.Name)
+	h.activity.Record(c.Request().Context(), entities.ActivityWorkflowCreated, entities.ResourceWorkflow,
+		strconv.FormatInt(wf.ID, 10), nil, wf)
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message":  "Workflow created successfully",
+		"workflow": wf,
+		"status":   "success",
+	})
+}
+
+// UpdateWorkflow stores a YAML or JSON definition as the next version of a workflow
+func (h *WorkflowHandler) UpdateWorkflow(c echo.Context) error {
+	workflowID, err := workflowID(c)
+	if err != nil {
+		return err
+	}
+	source, format, err := readDefinition(c)
+	if err != nil {
+		return err
+	}
+
+	before, err := h.workflows.Get(c.Request().Context(), workflowID)
+	if err != nil {
+		return storeError(c, err, "Workflow not found")
+	}
+	if err := authorizeWorkflow(c, before); err != nil {
+		return err
+	}
+	wf, err := h.workflows.Update(c.Request().Context(), workflowID, source, format, callerID(c))
+	if err != nil {
+		return workflowError(c, err)
+	}
+
+	slog.InfoContext(c.Request().Context(), "workflow updated", "workflow_id", wf.ID, "version", wf.Version)
+	h.activity.Record(c.Request().Context(), entities.ActivityWorkflowUpdated, entities.ResourceWorkflow,
+		strconv.FormatInt(wf.ID, 10), before, wf)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":  "Workflow updated successfully",
+		"workflow": wf,
+		"status":   "success",
+	})
+}
+
+// DeleteWorkflow removes a workflow with its versions and runs
+func (h *WorkflowHandler) DeleteWorkflow(c echo.Context) error {
+	workflowID, err := workflowID(c)
+	if err != nil {
+		return err
+	}
+
+	// Load the workflow first so the activity log keeps its last state
+	wf, err := h.workflows.Get(c.Request().Context(), workflowID)
+	if err != nil {
+		return storeError(c, err, "Workflow not found")
+	}
+	if err := authorizeWorkflow(c, wf); err != nil {
+		return err
+	}
+	if err := h.workflows.Delete(c.Request().Context(), workflowID); err != nil {
+		return storeError(c, err, "Workflow not found")
+	}
+
+	slog.InfoContext(c.Request().Context(), "workflow deleted", "workflow_id", workflowID)
+	h.activity.Record(c.Request().Context(), entities.ActivityWorkflowDeleted, entities.ResourceWorkflow,
+		strconv.FormatInt(workflowID, 10), wf, nil)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":     "Workflow deleted successfully",
+		"workflow_id": workflowID,
+		"status":      "success",
+	})
+}
+
+// GetWorkflowVersions returns every version of a workflow, newest first
+func (h *WorkflowHandler) GetWorkflowVersions(c echo.Context) error {
+	workflowID, err := workflowID(c)
+	if err != nil {
+		return err
+	}
+
+	versions, err := h.workflows.Versions(c.Request().Context(), workflowID)
+	if err != nil {
+		return storeError(c, err, "Workflow not found")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"versions": versions,
+		"count":    len(versions),
+		"status":   "success",
+	})
+}
+
+// GetWorkflowVersion returns one version of a workflow with its original source
+func (h *WorkflowHandler) GetWorkflowVersion(c echo.Context) error {
+	workflowID, err := workflowID(c)
+	if err != nil {
+		return err
+	}
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil || number < 1 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid workflow version")
+	}
+
+	version, err := h.workflows.Version(c.Request().Context(), workflowID, number)
+	if err != nil {
+		return storeError(c, err, "Workflow version not found")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"version": version,
+		"status":  "success",
+	})
+}
+
+// StartRun starts a run of a workflow with the given inputs
+func (h *WorkflowHandler) StartRun(c echo.Context) error {
+	workflowID, err := workflowID(c)
+	if err != nil {
+		return err
+	}
+
+	var req runRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	if req.Version < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid workflow version")
+	}
+	wf, err := h.workflows.Get(c.Request().Context(), workflowID)
+	if err != nil {
+		return storeError(c, err, "Workflow not found")
+	}
+	if err := authorizeWorkflow(c, wf); err != nil {
+		return err
+	}
+
+	run, err := h.engine.Start(c.Request().Context(), workflowID, workflow.RunRequest{
+		Version:   req.Version,
+		Inputs:    req.Inputs,
+		Trigger:   entities.TriggerManual,
+		CreatedBy: callerID(c),
+	})
+	if errors.Is(err, execution.ErrDraining) {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Server is shutting down")
+	}
+	if err != nil {
+		return runError(c, err)
+	}
+
+	slog.InfoContext(c.Request().Context(), "workflow run started", "workflow_id", workflowID, "run_id", run.ID)
+	h.activity.Record(c.Request().Context(), entities.ActivityWorkflowRunStarted, entities.ResourceWorkflowRun,
+		run.ID, nil, run)
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Workflow run started",
+		"run":     run,
+		"status":  "success",
+	})
+}
+
+// GetRuns returns a page of the runs of a workflow, newest first.
+// Supports ?status= and ?trigger= (comma separated).
+func (h *WorkflowHandler) GetRuns(c echo.Context) error {
+	workflowID, err := workflowID(c)
+	if err != nil {
+		return err
+	}
+	opts, err := parseListOptions(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.workflows.Get(c.Request().Context(), workflowID); err != nil {
+		return storeError(c, err, "Workflow not found")
+	}
+	page, err := h.engine.Runs(c.Request().Context(), repositories.WorkflowRunFilter{
+		WorkflowID: workflowID,
+		Status:     splitList(c.QueryParam("status")),
+		Trigger:    splitList(c.QueryParam("trigger")),
+	}, opts)
+	if err != nil {
+		return storeError(c, err, "Workflow not found")
+	}
+
+	return c.JSON(http.StatusOK, newListResponse(page, opts))
+}
+
+// GetRun returns a run of a workflow with the state and outputs of its steps
+func (h *WorkflowHandler) GetRun(c echo.Context) error {
+	workflowID, err := workflowID(c)
+	if err != nil {
+		return err
+	}
+
+	run, err := h.engine.Run(c.Request().Context(), c.Param("run_id"))
+	if err == nil && run.WorkflowID != workflowID {
+		err = repositories.ErrNotFound
+	}
+	if err != nil {
+		return storeError(c, err, "Workflow run not found")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"run":    run,
+		"status": "success",
+	})
+}
+
+// decisionRequest is the body of the approve and reject endpoints
+type decisionRequest struct {
+	Comment string `json:"comment"`
+}
+
+// stepDecision is the state of an approval step recorded in the activity log
+type stepDecision struct {
+	StepID  string `json:"step_id"`
+	Status  string `json:"status"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// ApproveStep approves a run step waiting for approval and resumes the run
+func (h *WorkflowHandler) ApproveStep(c echo.Context) error {
+	return h.decideStep(c, workflow.DecisionApprove)
+}
+
+// RejectStep rejects a run step waiting for approval, failing the run
+func (h *WorkflowHandler) RejectStep(c echo.Context) error {
+	return h.decideStep(c, workflow.DecisionReject)
+}
+
+// decideStep records the decision of the authenticated user on an approval step
+func (h *WorkflowHandler) decideStep(c echo.Context, decision string) error {
+	ctx := c.Request().Context()
+	workflowID, err := workflowID(c)
+	if err != nil {
+		return err
+	}
+
+	var req decisionRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	run, err := h.engine.Run(ctx, c.Param("run_id"))
+	if err == nil && run.WorkflowID != workflowID {
+		err = repositories.ErrNotFound
+	}
+	if err != nil {
+		return storeError(c, err, "Workflow run not found")
+	}
+
+	user := auth.UserFromContext(ctx)
+	decide, message, action := h.engine.Approve, "Workflow step approved", entities.ActivityWorkflowStepApproved
+	if decision == workflow.DecisionReject {
+		decide, message, action = h.engine.Reject, "Workflow step rejected", entities.ActivityWorkflowStepRejected
+	}
+	stepID, comment := c.Param("step_id"), strings.TrimSpace(req.Comment)
+	before := stepDecision{StepID: stepID, Status: stepStatus(run, stepID)}
+	run, err = decide(ctx, run.ID, stepID, user, comment)
+	switch {
+	case errors.Is(err, workflow.ErrNotApprover):
+		return echo.NewHTTPError(http.StatusForbidden, "You are not an approver of this step")
+	case errors.Is(err, workflow.ErrStepNotWaiting):
+		return echo.NewHTTPError(http.StatusConflict, "Step is not waiting for approval")
+	case err != nil:
+		return storeError(c, err, "Workflow step not found")
+	}
+
+	slog.InfoContext(ctx, "workflow step decided",
+		"run_id", run.ID, "step_id", stepID, "decision", decision, "user", user.Login)
+	h.activity.Record(ctx, action, entities.ResourceWorkflowRun, run.ID,
+		before, stepDecision{StepID: stepID, Status: stepStatus(run, stepID), Comment: comment})
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": message,
+		"run":     run,
+		"status":  "success",
+	})
+}
+
+// stepStatus returns the status of a step of run, or an empty string when
+// the run has no such step
+func stepStatus(run *entities.WorkflowRun, stepID string) string {
+	for _, step := range run.Steps {
+		if step.StepID == stepID {
+			return step.Status
+		}
+	}
+	return ""
+}
+
+// readDefinition reads the request body as a workflow definition. JSON is
+// selected by an application/json content type, anything else is read as YAML.
+func readDefinition(c echo.Context) ([]byte, string, error) {
+	format := workflow.FormatYAML
+	if mediaType, _, err := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType)); err == nil &&
+		mediaType == echo.MIMEApplicationJSON {
+		format = workflow.FormatJSON
+	}
+
+	source, err := io.ReadAll(io.LimitReader(c.Request().Body, maxDefinitionSize+1))
+	if err != nil {
+		slog.WarnContext(c.Request().Context(), "invalid request body", "error", err)
+		return nil, "", echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if len(source) > maxDefinitionSize {
+		return nil, "", echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Workflow definition is too large")
+	}
+	if len(source) == 0 {
+		return nil, "", echo.NewHTTPError(http.StatusBadRequest, "Workflow definition is required")
+	}
+	return source, format, nil
+}
+
+// workflowError reports definition problems as a 400 detailing each of them
+func workflowError(c echo.Context, err error) error {
+	var invalid *workflow.ValidationError
+	if errors.As(err, &invalid) {
+		return problemsError("Invalid workflow definition", "definition", invalid.Problems)
+	}
+	return storeError(c, err, "Workflow not found")
+}
+
+// runError reports invalid run inputs as a 400 detailing each problem
+func runError(c echo.Context, err error) error {
+	var invalid *workflow.ValidationError
+	if errors.As(err, &invalid) {
+		return problemsError("Invalid workflow inputs", "inputs", invalid.Problems)
+	}
+	return storeError(c, err, "Workflow not found")
+}
+
+// problemsError reports every problem found in field as a detail of one 400
+func problemsError(message, field string, problems []string) error {
+	err := apierror.Invalid()
+	err.Message = message
+	for _, problem := range problems {
+		err.Details = append(err.Details, apierror.Detail{Field: field, Code: apierror.DetailInvalid, Message: problem})
+	}
+	return err
+}
+
+// authorizeWorkflow checks that the caller may change or run wf, or create a
+// new workflow when it is nil: viewers change nothing, and a workflow belongs
+// to its creator and admins
+func authorizeWorkflow(c echo.Context, wf *entities.Workflow) error {
+	user := auth.UserFromContext(c.Request().Context())
+	if user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+	}
+	if user.Role == entities.RoleViewer {
+		return echo.NewHTTPError(http.StatusForbidden, "Viewers cannot change or run workflows")
+	}
+	if wf == nil || user.IsAdmin() {
+		return nil
+	}
+	if wf.CreatedBy == nil || *wf.CreatedBy != user.ID {
+		return echo.NewHTTPError(http.StatusForbidden, "Only the creator and admins can change or run workflow "+wf.Name)
+	}
+	return nil
+}
+
+// callerID returns the ID of the authenticated user, if any
+func callerID(c echo.Context) *int64 {
+	if user := auth.UserFromContext(c.Request().Context()); user != nil {
+		return &user.ID
+	}
+	return nil
+}
+
+// workflowID parses the :id path parameter
+func workflowID(c echo.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid workflow ID")
+	}
+	return id, nil
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/usecase/auth"
+)
+
+// UserContextKey is the echo.Context key holding the authenticated user
+const UserContextKey = "user"
+
+// Authenticate resolves an "Authorization: Bearer <token>" header into the
+// calling user. Requests without the header continue anonymously; a header
+// with an unknown or expired token is rejected.
+func Authenticate(svc *auth.Service) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			if header == "" {
+				return next(c)
+			}
+
+			raw, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Authorization header must use the Bearer scheme")
+			}
+
+			req := c.Request()
+			user, err := svc.Authenticate(req.Context(), strings.TrimSpace(raw))
+			if errors.Is(err, auth.ErrInvalidToken) {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired token")
+			}
+			if err != nil {
+				slog.ErrorContext(req.Context(), "failed to authenticate request", "error", err)
+				return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+			}
+
+			c.Set(UserContextKey, user)
+			c.SetRequest(req.WithContext(auth.WithUser(req.Context(), user)))
+			return next(c)
+		}
+	}
+}
+
+// RequireUser rejects anonymous requests. It must run after Authenticate.
+func RequireUser() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if auth.UserFromContext(c.Request().Context()) == nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequireAdmin rejects requests not made by an admin. It must run after Authenticate.
+func RequireAdmin() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user := auth.UserFromContext(c.Request().Context())
+			if user == nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+			}
+			if !user.IsAdmin() {
+				return echo.NewHTTPError(http.StatusForbidden, "Admin role required")
+			}
+			return next(c)
+		}
+	}
+}
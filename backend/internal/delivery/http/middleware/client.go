@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/usecase/activity"
+)
+
+// ClientInfo stores the caller's IP address and user agent in the request
+// context for the activity log. The IP comes from the echo IPExtractor, so
+// forwarded headers are only honored when the server trusts its proxy.
+func ClientInfo() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			c.SetRequest(req.WithContext(activity.WithClient(req.Context(), c.RealIP(), req.UserAgent())))
+			return next(c)
+		}
+	}
+}
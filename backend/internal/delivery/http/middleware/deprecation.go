@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Deprecated marks responses of a route kept for old clients, pointing them
+// at its replacement. A ":id" in successor is filled from the path parameter.
+func Deprecated(successor string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			link := strings.Replace(successor, ":id", c.Param("id"), 1)
+			h := c.Response().Header()
+			h.Set("Deprecation", "true")
+			h.Set("Link", "<"+link+`>; rel="successor-version"`)
+			return next(c)
+		}
+	}
+}
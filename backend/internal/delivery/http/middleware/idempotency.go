@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/usecase/auth"
+	"ai-git-workbench/internal/usecase/idempotency"
+)
+
+// Idempotency headers
+const (
+	// HeaderIdempotencyKey names a request so retries of it are not carried out twice
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed marks a response replayed from an earlier request
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength bounds keys, which are stored
+const maxIdempotencyKeyLength = 255
+
+// MaxBodyContextKey is the echo.Context key holding the body limit of
+// Idempotency, for handlers reading whole bodies themselves
+const MaxBodyContextKey = "max_body"
+
+// idempotentMethods are the methods keys are honored on
+var idempotentMethods = map[string]bool{
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// replayedHeaders are the response headers stored and replayed with the body
+var replayedHeaders = []string{echo.HeaderContentType, echo.HeaderLocation, "ETag"}
+
+// Idempotency carries out a POST, PUT, PATCH or DELETE sent with an
+// Idempotency-Key header once per key. Retries with the same method, URI and
+// body get the first response again; another request with the key is
+// rejected with 422, and a retry while the first is running with 409.
+// Bodies above maxBody bytes are rejected with 413; GetMaxBody returns the
+// limit to handlers that read bodies without a key. Server errors are not
+// stored, so requests failing with them may be retried. Keys are scoped to
+// the caller, or to the client IP for anonymous requests, and must run after
+// Authenticate.
+func Idempotency(svc *idempotency.Service, maxBody int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(MaxBodyContextKey, maxBody)
+			req := c.Request()
+			key := req.Header.Get(HeaderIdempotencyKey)
+			if key == "" || !idempotentMethods[req.Method] {
+				return next(c)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return apierror.Invalid(apierror.Detail{
+					Field:   HeaderIdempotencyKey,
+					Code:    apierror.DetailOutOfRange,
+					Message: HeaderIdempotencyKey + " must be at most " + strconv.Itoa(maxIdempotencyKeyLength) + " characters",
+				})
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(c.Response(), req.Body, maxBody))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return apierror.New(http.StatusRequestEntityTooLarge, "",
+					"Request body is larger than "+strconv.FormatInt(maxBody, 10)+" bytes")
+			}
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Failed to read the request body").SetInternal(err)
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			reservation, replay, err := svc.Begin(req.Context(), idempotencyScope(c), key, requestHash(req, body))
+			switch {
+			case errors.Is(err, idempotency.ErrKeyReused):
+				return echo.NewHTTPError(http.StatusUnprocessableEntity,
+					"Idempotency-Key was already used for a different request")
+			case errors.Is(err, idempotency.ErrInProgress):
+				return echo.NewHTTPError(http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+			case err != nil:
+				slog.ErrorContext(req.Context(), "failed to reserve idempotency key", "error", err)
+				return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+			}
+
+			if replay {
+				for name, value := range reservation.Header {
+					c.Response().Header().Set(name, value)
+				}
+				c.Response().Header().Set(HeaderIdempotentReplayed, "true")
+				c.Response().WriteHeader(reservation.StatusCode)
+				_, err := c.Response().Write(reservation.Body)
+				return err
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+			finished := false
+			defer func() {
+				// A panic leaves no response to store
+				if !finished {
+					svc.Abandon(req.Context(), reservation)
+				}
+			}()
+
+			// Write errors here so their response is stored too; the error is
+			// still returned for the request log
+			err = next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			res := c.Response()
+			if res.Committed && res.Status < http.StatusInternalServerError {
+				reservation.StatusCode = res.Status
+				reservation.Header = make(map[string]string)
+				for _, name := range replayedHeaders {
+					if value := res.Header().Get(name); value != "" {
+						reservation.Header[name] = value
+					}
+				}
+				reservation.Body = recorder.body.Bytes()
+				svc.Finish(req.Context(), reservation)
+				finished = true
+			}
+			return err
+		}
+	}
+}
+
+// idempotencyScope names whose key a request carries
+func idempotencyScope(c echo.Context) string {
+	if user := auth.UserFromContext(c.Request().Context()); user != nil {
+		return "user:" + strconv.FormatInt(user.ID, 10)
+	}
+	return "ip:" + c.RealIP()
+}
+
+// requestHash identifies a request by its method, URI and body
+func requestHash(req *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + req.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder copies the response body while it is written
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// GetMaxBody returns the body limit of the Idempotency middleware the request
+// went through, or 0 without one
+func GetMaxBody(c echo.Context) int64 {
+	maxBody, _ := c.Get(MaxBodyContextKey).(int64)
+	return maxBody
+}
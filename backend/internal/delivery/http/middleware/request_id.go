@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	echomw "github.com/labstack/echo/v4/middleware"
+
+	"ai-git-workbench/internal/infrastructure/logger"
+)
+
+// maxRequestIDLength caps client supplied request IDs so they cannot flood the logs
+const maxRequestIDLength = 128
+
+// RequestIDContextKey is the echo.Context key holding the request ID
+const RequestIDContextKey = "request_id"
+
+// RequestID propagates the X-Request-ID header into the response, the echo
+// context and the request context so every log line can carry it
+func RequestID() echo.MiddlewareFunc {
+	return echomw.RequestIDWithConfig(echomw.RequestIDConfig{
+		RequestIDHandler: func(c echo.Context, requestID string) {
+			if len(requestID) > maxRequestIDLength {
+				requestID = requestID[:maxRequestIDLength]
+				c.Response().Header().Set(echo.HeaderXRequestID, requestID)
+			}
+			c.Set(RequestIDContextKey, requestID)
+			req := c.Request()
+			c.SetRequest(req.WithContext(logger.WithRequestID(req.Context(), requestID)))
+		},
+	})
+}
+
+// GetRequestID returns the request ID assigned to the current request
+func GetRequestID(c echo.Context) string {
+	if requestID, ok := c.Get(RequestIDContextKey).(string); ok {
+		return requestID
+	}
+	return c.Response().Header().Get(echo.HeaderXRequestID)
+}
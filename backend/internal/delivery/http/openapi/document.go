@@ -0,0 +1,288 @@
+// Package openapi builds the OpenAPI 3.1 document describing the HTTP API
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+)
+
+// Version is the OpenAPI version documents are written in
+const Version = "3.1.0"
+
+// BearerAuth names the security scheme of API tokens
+const BearerAuth = "bearerAuth"
+
+// errorSchema names the component describing error bodies
+const errorSchema = "Error"
+
+// pathParamPattern matches the {name} segments of a path template
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+	Security   []map[string][]string `json:"security,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+
+	schemas schemaRegistry
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is a base URL the paths are relative to
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag groups operations in the docs
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path keyed by lower-case HTTP method
+type PathItem map[string]*Operation
+
+// Components holds the reusable parts of a document
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how callers authenticate
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Operation describes a single API operation on a path
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+// Parameter is a path, query or header parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body an operation accepts
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// Response describes a response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]*Header   `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Header describes a response header
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType describes a body in one media type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// New creates an empty document. Every operation accepts an optional bearer
+// token unless it requires one.
+func New(info Info) *Document {
+	d := &Document{
+		OpenAPI:  Version,
+		Info:     info,
+		Security: []map[string][]string{{}, {BearerAuth: {}}},
+		Paths:    make(map[string]PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]SecurityScheme{
+				BearerAuth: {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "API token",
+					Description:  "An API token issued by POST /tokens or by signing in with GitHub",
+				},
+			},
+		},
+	}
+	d.schemas = schemaRegistry{components: d.Components.Schemas, names: make(map[string]string)}
+	// Named first so the error schema references it by this name
+	d.NamedSchemaOf("ErrorDetail", apierror.Detail{})
+	d.Property("ErrorDetail", "code", String().Describe("Such as "+strings.Join([]string{apierror.DetailRequired,
+		apierror.DetailInvalid, apierror.DetailOutOfRange, apierror.DetailInvalidType, apierror.DetailMalformed}, ", ")))
+	d.NamedSchemaOf(errorSchema, apierror.Error{})
+	d.Property(errorSchema, "status", Enum("error"))
+	d.Property(errorSchema, "code", Enum(apierror.Codes...))
+	return d
+}
+
+// Add registers an operation on a path template such as /tasks/{id} and
+// returns it for further description. Path parameters are declared as
+// required strings; use PathParam to refine them.
+func (d *Document) Add(method, path, id, summary, tag string) *Operation {
+	op := &Operation{
+		OperationID: id,
+		Summary:     summary,
+		Tags:        []string{tag},
+		Responses:   make(map[string]*Response),
+	}
+	for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, Parameter{Name: match[1], In: "path", Required: true, Schema: String()})
+	}
+
+	item := d.Paths[path]
+	if item == nil {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = op
+	return op
+}
+
+// Operation returns the operation registered for method on path, or nil
+func (d *Document) Operation(method, path string) *Operation {
+	return d.Paths[path][strings.ToLower(method)]
+}
+
+// Describe sets the long description of the operation
+func (o *Operation) Describe(description string) *Operation {
+	o.Description = description
+	return o
+}
+
+// PathParam refines the schema and description of a path parameter
+func (o *Operation) PathParam(name string, schema *Schema, description string) *Operation {
+	for i := range o.Parameters {
+		if o.Parameters[i].In == "path" && o.Parameters[i].Name == name {
+			o.Parameters[i].Schema = schema
+			o.Parameters[i].Description = description
+		}
+	}
+	return o
+}
+
+// Query adds an optional query parameter
+func (o *Operation) Query(name string, schema *Schema, description string) *Operation {
+	o.Parameters = append(o.Parameters, Parameter{Name: name, In: "query", Description: description, Schema: schema})
+	return o
+}
+
+// RequiredQuery adds a required query parameter
+func (o *Operation) RequiredQuery(name string, schema *Schema, description string) *Operation {
+	o.Parameters = append(o.Parameters, Parameter{
+		Name: name, In: "query", Description: description, Required: true, Schema: schema,
+	})
+	return o
+}
+
+// Header adds an optional request header
+func (o *Operation) Header(name string, schema *Schema, description string) *Operation {
+	o.Parameters = append(o.Parameters, Parameter{Name: name, In: "header", Description: description, Schema: schema})
+	return o
+}
+
+// ReturnsHeader documents a header sent with a response added before
+func (o *Operation) ReturnsHeader(status int, name string, schema *Schema, description string) *Operation {
+	resp := o.Responses[strconv.Itoa(status)]
+	if resp == nil {
+		return o
+	}
+	if resp.Headers == nil {
+		resp.Headers = make(map[string]*Header)
+	}
+	resp.Headers[name] = &Header{Description: description, Schema: schema}
+	return o
+}
+
+// Body sets the JSON body the operation requires
+func (o *Operation) Body(schema *Schema) *Operation {
+	return o.BodyAs("application/json", schema)
+}
+
+// BodyAs adds a media type the operation accepts its body in
+func (o *Operation) BodyAs(mediaType string, schema *Schema) *Operation {
+	if o.RequestBody == nil {
+		o.RequestBody = &RequestBody{Required: true, Content: make(map[string]MediaType)}
+	}
+	o.RequestBody.Content[mediaType] = MediaType{Schema: schema}
+	return o
+}
+
+// Returns adds a JSON response. A nil schema describes a response without a body.
+func (o *Operation) Returns(status int, description string, schema *Schema) *Operation {
+	if schema == nil {
+		o.Responses[strconv.Itoa(status)] = &Response{Description: description}
+		return o
+	}
+	return o.ReturnsAs(status, description, "application/json", schema)
+}
+
+// ReturnsAs adds a response in the given media type
+func (o *Operation) ReturnsAs(status int, description, mediaType string, schema *Schema) *Operation {
+	resp := o.Responses[strconv.Itoa(status)]
+	if resp == nil {
+		resp = &Response{Description: description, Content: make(map[string]MediaType)}
+		o.Responses[strconv.Itoa(status)] = resp
+	}
+	resp.Content[mediaType] = MediaType{Schema: schema}
+	return o
+}
+
+// Errors adds error responses with the shared error body
+func (o *Operation) Errors(statuses ...int) *Operation {
+	for _, status := range statuses {
+		o.Returns(status, http.StatusText(status), Ref(errorSchema))
+	}
+	return o
+}
+
+// RequireUser marks the operation as requiring an API token
+func (o *Operation) RequireUser() *Operation {
+	o.Security = []map[string][]string{{BearerAuth: {}}}
+	return o.Errors(http.StatusUnauthorized)
+}
+
+// RequireAdmin marks the operation as requiring the API token of an admin
+func (o *Operation) RequireAdmin() *Operation {
+	o.RequireUser()
+	return o.Errors(http.StatusForbidden)
+}
+
+// Deprecate marks the operation as deprecated
+func (o *Operation) Deprecate() *Operation {
+	o.Deprecated = true
+	return o
+}
@@ -0,0 +1,317 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Schema is a JSON Schema as used by OpenAPI 3.1
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"` // a type name, or a list of them
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	MaxProperties        *int               `json:"maxProperties,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+}
+
+// String returns a string schema
+func String() *Schema { return &Schema{Type: "string"} }
+
+// Integer returns an integer schema
+func Integer() *Schema { return &Schema{Type: "integer"} }
+
+// Number returns a number schema
+func Number() *Schema { return &Schema{Type: "number"} }
+
+// Boolean returns a boolean schema
+func Boolean() *Schema { return &Schema{Type: "boolean"} }
+
+// DateTime returns an RFC 3339 timestamp schema
+func DateTime() *Schema { return &Schema{Type: "string", Format: "date-time"} }
+
+// Any returns a schema accepting every value
+func Any() *Schema { return &Schema{} }
+
+// Enum returns a string schema restricted to values
+func Enum(values ...string) *Schema { return &Schema{Type: "string", Enum: values} }
+
+// ArrayOf returns an array schema of items
+func ArrayOf(items *Schema) *Schema { return &Schema{Type: "array", Items: items} }
+
+// MapOf returns an object schema whose values all match values
+func MapOf(values *Schema) *Schema { return &Schema{Type: "object", AdditionalProperties: values} }
+
+// componentPrefix starts the references to component schemas
+const componentPrefix = "#/components/schemas/"
+
+// Ref returns a reference to a component schema
+func Ref(name string) *Schema { return &Schema{Ref: componentPrefix + name} }
+
+// Object returns an object schema with the given properties
+func Object(properties map[string]*Schema, required ...string) *Schema {
+	sort.Strings(required)
+	return &Schema{Type: "object", Properties: properties, Required: required}
+}
+
+// Describe sets the description of the schema. Schemas referencing a
+// component are wrapped so the component itself is left untouched.
+func (s *Schema) Describe(description string) *Schema {
+	if s.Ref != "" {
+		return &Schema{AnyOf: []*Schema{s}, Description: description}
+	}
+	s.Description = description
+	return s
+}
+
+// Nullable allows the value to also be null
+func (s *Schema) Nullable() *Schema {
+	if names, ok := s.Type.([]string); ok && slices.Contains(names, "null") {
+		return s
+	}
+	if name, ok := s.Type.(string); ok {
+		s.Type = []string{name, "null"}
+		return s
+	}
+	return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+}
+
+// SchemaOf returns the schema of the JSON encoding of v. Named structs are
+// added to the components under their Go type name and referenced.
+func (d *Document) SchemaOf(v any) *Schema {
+	return d.schemas.of(reflect.TypeOf(v))
+}
+
+// NamedSchemaOf is SchemaOf with the component name of v's type set to name,
+// for types whose Go name is ambiguous or unexported
+func (d *Document) NamedSchemaOf(name string, v any) *Schema {
+	t := reflect.TypeOf(v)
+	d.schemas.names[typeKey(t)] = name
+	return d.schemas.of(t)
+}
+
+// RequestSchemaOf is SchemaOf for request bodies. Bodies are decoded onto
+// zero values, so only the properties listed in required or validated as
+// required or notblank are required.
+func (d *Document) RequestSchemaOf(v any, required ...string) *Schema {
+	ref := d.SchemaOf(v)
+	if component := d.Components.Schemas[strings.TrimPrefix(ref.Ref, componentPrefix)]; component != nil {
+		t := reflect.TypeOf(v)
+		for i := range t.NumField() {
+			field := t.Field(i)
+			rules, _, _ := strings.Cut(field.Tag.Get("validate"), ",dive")
+			for _, rule := range strings.Split(rules, ",") {
+				if rule == "required" || rule == "notblank" {
+					name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+					required = append(required, name)
+				}
+			}
+		}
+		sort.Strings(required)
+		component.Required = slices.Compact(required)
+	}
+	return ref
+}
+
+// PatchSchemaOf describes an RFC 7396 merge patch of the request body v as
+// the component name. Every property is optional, and null removes a
+// property or a key of a map property.
+func (d *Document) PatchSchemaOf(name string, v any) *Schema {
+	request := d.Components.Schemas[strings.TrimPrefix(d.SchemaOf(v).Ref, componentPrefix)]
+	if request == nil {
+		return Object(nil)
+	}
+	properties := make(map[string]*Schema, len(request.Properties))
+	for property, schema := range request.Properties {
+		patch := *schema
+		if patch.AdditionalProperties != nil {
+			values := *patch.AdditionalProperties
+			patch.AdditionalProperties = values.Nullable()
+		}
+		properties[property] = patch.Nullable()
+	}
+	d.Components.Schemas[name] = Object(properties).Describe(request.Description)
+	return Ref(name)
+}
+
+// Property replaces the schema of a property of a component, for values
+// the Go type does not constrain such as enumerations
+func (d *Document) Property(component, name string, schema *Schema) {
+	if c := d.Components.Schemas[component]; c != nil && c.Properties[name] != nil {
+		c.Properties[name] = schema
+	}
+}
+
+// schemaRegistry derives schemas from Go types by reflection
+type schemaRegistry struct {
+	components map[string]*Schema
+	names      map[string]string // package path and type name -> component name
+}
+
+func (r *schemaRegistry) of(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return DateTime()
+	case t == rawMessageType:
+		return Any()
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return Any()
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return String()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Boolean()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32:
+		return Integer()
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return Number()
+	case reflect.String:
+		return String()
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return ArrayOf(r.of(t.Elem()))
+	case reflect.Map:
+		return MapOf(r.of(t.Elem()))
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.object(t)
+		}
+		name := r.name(t)
+		if _, ok := r.components[name]; !ok {
+			// Reserve the name first so self-referencing types terminate
+			r.components[name] = &Schema{}
+			*r.components[name] = *r.object(t)
+		}
+		return Ref(name)
+	default:
+		return Any()
+	}
+}
+
+// object describes the exported fields of a struct the way encoding/json
+// writes them. Fields without omitempty or omitzero are required.
+func (r *schemaRegistry) object(t reflect.Type) *Schema {
+	properties := make(map[string]*Schema)
+	var required []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				flat := r.object(embedded)
+				for key, schema := range flat.Properties {
+					properties[key] = schema
+				}
+				required = append(required, flat.Required...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := r.of(field.Type)
+		if schema.Ref == "" {
+			constrain(schema, field.Tag.Get("validate"))
+		}
+		optional := strings.Contains(opts, "omitempty") || strings.Contains(opts, "omitzero")
+		if field.Type.Kind() == reflect.Pointer && !optional {
+			schema = schema.Nullable()
+		}
+		properties[name] = schema
+		if !optional {
+			required = append(required, name)
+		}
+	}
+	return Object(properties, required...)
+}
+
+// constrain describes the `validate` rules of a field that JSON Schema can
+// express. Rules on the elements of a collection, after dive, are left out.
+func constrain(s *Schema, rules string) {
+	rules, _, _ = strings.Cut(rules, ",dive")
+	for _, rule := range strings.Split(rules, ",") {
+		tag, param, _ := strings.Cut(rule, "=")
+		n, err := strconv.Atoi(param)
+		limit := float64(n)
+		switch {
+		case tag == "http_url" || tag == "url":
+			s.Format = "uri"
+		case err != nil:
+		case (tag == "max" || tag == "lte") && s.Type == "string":
+			s.MaxLength = &n
+		case (tag == "max" || tag == "lte") && s.Type == "array":
+			s.MaxItems = &n
+		case (tag == "max" || tag == "lte") && s.Type == "object":
+			s.MaxProperties = &n
+		case tag == "max" || tag == "lte":
+			s.Maximum = &limit
+		case (tag == "min" || tag == "gte") && s.Type != "string" && s.Type != "array" && s.Type != "object":
+			s.Minimum = &limit
+		case tag == "gt":
+			s.ExclusiveMinimum = &limit
+		}
+	}
+}
+
+// name returns the component name of a named struct type
+func (r *schemaRegistry) name(t reflect.Type) string {
+	if name, ok := r.names[typeKey(t)]; ok {
+		return name
+	}
+	runes := []rune(t.Name())
+	runes[0] = unicode.ToUpper(runes[0])
+	name := string(runes)
+	r.names[typeKey(t)] = name
+	return name
+}
+
+// typeKey identifies a named type across packages
+func typeKey(t reflect.Type) string {
+	return t.PkgPath() + "." + t.Name()
+}
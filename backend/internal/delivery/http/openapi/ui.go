@@ -0,0 +1,9 @@
+package openapi
+
+import _ "embed"
+
+// UI is a self-contained HTML page rendering the document served next to it
+// at openapi.json, so the docs work without access to a CDN
+//
+//go:embed ui.html
+var UI []byte
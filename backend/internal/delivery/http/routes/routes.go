@@ -2,21 +2,85 @@ package routes
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
-	
+
 	"ai-git-workbench/internal/delivery/http/handlers"
+	"ai-git-workbench/internal/delivery/http/middleware"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/infrastructure/github"
+	"ai-git-workbench/internal/usecase/activity"
+	"ai-git-workbench/internal/usecase/assistant"
+	"ai-git-workbench/internal/usecase/auth"
+	"ai-git-workbench/internal/usecase/execution"
+	"ai-git-workbench/internal/usecase/idempotency"
+	"ai-git-workbench/internal/usecase/issuesync"
+	"ai-git-workbench/internal/usecase/notification"
+	"ai-git-workbench/internal/usecase/realtime"
+	"ai-git-workbench/internal/usecase/search"
+	"ai-git-workbench/internal/usecase/workflow"
+	"ai-git-workbench/internal/usecase/workspace"
 )
 
+// Dependencies holds the services the handlers are built from
+type Dependencies struct {
+	GitHub        *github.Client
+	Tasks         repositories.TaskRepository
+	Repositories  repositories.RepositoryRepository
+	Auth          *auth.Service
+	Assistant     *assistant.Service
+	Executor      *execution.Executor
+	Workspace     *workspace.Service
+	Search        *search.Service
+	Workflows     *workflow.Service
+	Engine        *workflow.Engine
+	Triggers      *workflow.Dispatcher
+	Notifications *notification.Service
+	Activity      *activity.Service
+	// IssueSync mirrors the tasks of opted-in repositories to GitHub issues
+	IssueSync *issuesync.Service
+	// Idempotency replays responses to retried requests carrying an Idempotency-Key
+	Idempotency        *idempotency.Service
+	IdempotencyMaxBody int64
+	// TokenMaxTTL is the default and longest lifetime of tokens issued through the API
+	TokenMaxTTL time.Duration
+	// OAuth signs users in with GitHub; nil disables GitHub sign-in
+	OAuth  *github.OAuth
+	SignIn handlers.SignInOptions
+	// Push delivers Web Push messages; nil disables the push endpoints
+	Push *notification.PushChannel
+	// Realtime streams task and repository changes over WebSocket
+	Realtime             *realtime.Hub
+	RealtimePingInterval time.Duration
+	RealtimeSendBuffer   int
+	// WebhookSecret verifies GitHub webhooks; empty disables them
+	WebhookSecret string
+}
+
 // SetupRoutes configures all the routes for the application
-func SetupRoutes(e *echo.Echo) {
+func SetupRoutes(e *echo.Echo, deps Dependencies) {
 	// Initialize handlers
 	healthHandler := handlers.NewHealthHandler()
-	taskHandler := handlers.NewTaskHandler()
-	repositoryHandler := handlers.NewRepositoryHandler()
+	authHandler := handlers.NewAuthHandler(deps.Auth, deps.OAuth, deps.GitHub, deps.SignIn, deps.Activity)
+	aiHandler := handlers.NewAIHandler(deps.Assistant)
+	taskHandler := handlers.NewTaskHandler(deps.Tasks, deps.Repositories, deps.Executor, deps.Activity, deps.Triggers, deps.Notifications)
+	repositoryHandler := handlers.NewRepositoryHandler(deps.Repositories, deps.Workspace, deps.Activity)
+	githubHandler := handlers.NewGitHubHandler(deps.GitHub, deps.Triggers, deps.WebhookSecret, deps.Notifications, deps.IssueSync)
+	searchHandler := handlers.NewSearchHandler(deps.Search)
+	tokenHandler := handlers.NewTokenHandler(deps.Auth, deps.TokenMaxTTL, deps.Activity)
+	workflowHandler := handlers.NewWorkflowHandler(deps.Workflows, deps.Engine, deps.Activity)
+	notificationHandler := handlers.NewNotificationHandler(deps.Notifications)
+	pushHandler := handlers.NewPushHandler(deps.Push)
+	activityHandler := handlers.NewActivityHandler(deps.Activity)
+	issueSyncHandler := handlers.NewIssueSyncHandler(deps.IssueSync)
+	docsHandler := handlers.NewDocsHandler()
+	realtimeHandler := handlers.NewRealtimeHandler(deps.Realtime, deps.Auth, deps.RealtimePingInterval, deps.RealtimeSendBuffer)
 
-	// API versioning group
-	v1 := e.Group("/api/v1")
+	// API versioning group; callers are identified by an optional bearer token
+	// and retried changes sent with an Idempotency-Key are carried out once
+	v1 := e.Group("/api/v1", middleware.Authenticate(deps.Auth),
+		middleware.Idempotency(deps.Idempotency, deps.IdempotencyMaxBody))
 
 	// Health endpoints
 	v1.GET("/health", healthHandler.HealthCheck)
@@ -27,14 +91,32 @@ func SetupRoutes(e *echo.Echo) {
 		})
 	})
 
+	// API description; every route registered here must be described in handlers.OpenAPI
+	v1.GET("/openapi.json", docsHandler.Spec)
+	v1.GET("/docs", docsHandler.UI)
+
+	// Sign-in endpoints; GitHub redirects the browser back to the callback
+	authGroup := v1.Group("/auth")
+	{
+		authGroup.GET("/github", authHandler.GitHubLogin)
+		authGroup.GET("/github/callback", authHandler.GitHubCallback)
+		authGroup.POST("/logout", authHandler.Logout, middleware.RequireUser())
+		authGroup.GET("/me", authHandler.Me, middleware.RequireUser())
+	}
+
 	// Task endpoints
 	taskGroup := v1.Group("/tasks")
 	{
 		taskGroup.GET("", taskHandler.GetTasks)
+		taskGroup.GET("/export", taskHandler.ExportTasks, middleware.RequireUser())
+		taskGroup.POST("/import", taskHandler.ImportTasks, middleware.RequireUser())
 		taskGroup.GET("/:id", taskHandler.GetTask)
-		taskGroup.POST("", taskHandler.CreateTask)
-		taskGroup.PUT("/:id", taskHandler.UpdateTask)
-		taskGroup.DELETE("/:id", taskHandler.DeleteTask)
+		taskGroup.POST("", taskHandler.CreateTask, middleware.RequireUser())
+		taskGroup.POST("\\:batch", taskHandler.BatchTasks, middleware.RequireUser())
+		taskGroup.PUT("/:id", taskHandler.UpdateTask, middleware.RequireUser())
+		taskGroup.PATCH("/:id", taskHandler.PatchTask, middleware.RequireUser())
+		taskGroup.DELETE("/:id", taskHandler.DeleteTask, middleware.RequireUser())
+		taskGroup.POST("/:id/execute", taskHandler.ExecuteTask, middleware.RequireUser())
 	}
 
 	// Repository endpoints
@@ -42,42 +124,94 @@ func SetupRoutes(e *echo.Echo) {
 	{
 		repoGroup.GET("", repositoryHandler.GetRepositories)
 		repoGroup.GET("/:id", repositoryHandler.GetRepository)
-		repoGroup.POST("", repositoryHandler.CreateRepository)
-		repoGroup.PUT("/:id", repositoryHandler.UpdateRepository)
-		repoGroup.DELETE("/:id", repositoryHandler.DeleteRepository)
+		repoGroup.POST("", repositoryHandler.CreateRepository, middleware.RequireUser())
+		repoGroup.PUT("/:id", repositoryHandler.UpdateRepository, middleware.RequireUser())
+		repoGroup.PATCH("/:id", repositoryHandler.PatchRepository, middleware.RequireUser())
+		repoGroup.DELETE("/:id", repositoryHandler.DeleteRepository, middleware.RequireUser())
+		repoGroup.POST("/:id/clone", repositoryHandler.CloneRepository, middleware.RequireUser())
+		repoGroup.GET("/:id/status", repositoryHandler.GetRepositoryStatus)
 	}
 
-	// GitHub integration endpoints
+	// Deprecated repository routes of the former standalone server
+	legacyRepoGroup := v1.Group("/repos")
+	{
+		legacyRepoGroup.GET("", repositoryHandler.GetRepositories,
+			middleware.Deprecated("/api/v1/repositories"), middleware.RequireUser())
+		legacyRepoGroup.POST("/clone", repositoryHandler.CloneRepository,
+			middleware.Deprecated("/api/v1/repositories/{id}/clone"), middleware.RequireUser())
+		legacyRepoGroup.GET("/:id/status", repositoryHandler.GetRepositoryStatus,
+			middleware.Deprecated("/api/v1/repositories/:id/status"), middleware.RequireUser())
+	}
+
+	// AI assistant endpoints; tokens are counted against the caller
+	aiGroup := v1.Group("/ai", middleware.RequireUser())
+	{
+		aiGroup.POST("/process", aiHandler.Process)
+		aiGroup.GET("/tokens/status", aiHandler.GetTokenStatus)
+	}
+
+	// Realtime change stream; clients may also authenticate after connecting
+	v1.GET("/ws", realtimeHandler.Stream)
+
+	// Search endpoint
+	v1.GET("/search", searchHandler.Search)
+
+	// API token endpoints
+	v1.POST("/tokens", tokenHandler.CreateToken, middleware.RequireUser())
+
+	// Audit log endpoints
+	activityGroup := v1.Group("/activity", middleware.RequireAdmin())
+	{
+		activityGroup.GET("", activityHandler.GetActivity)
+		activityGroup.GET("/export", activityHandler.ExportActivity)
+	}
+
+	// GitHub integration endpoints; the repositories of the server's token are
+	// reserved to admins since they include private ones
 	githubGroup := v1.Group("/github")
 	{
-		githubGroup.POST("/webhook", func(c echo.Context) error {
-			return c.JSON(http.StatusOK, map[string]string{
-				"message": "GitHub webhook received",
-				"status":  "OK",
-			})
-		})
-		githubGroup.GET("/repos", func(c echo.Context) error {
-			return c.JSON(http.StatusOK, map[string]interface{}{
-				"message": "GitHub repositories",
-				"repos":   []string{},
-			})
-		})
+		githubGroup.POST("/webhook", githubHandler.Webhook)
+		githubGroup.GET("/repos", githubHandler.GetRepos, middleware.RequireAdmin())
 	}
 
-	// Workflow endpoints
-	workflowGroup := v1.Group("/workflows")
+	// GitHub issue sync endpoints
+	v1.GET("/issue-sync/conflicts", issueSyncHandler.GetConflicts, middleware.RequireAdmin())
+
+	// Workflow endpoints; definitions and runs hold sources and step outputs,
+	// so reading them requires a signed-in user
+	workflowGroup := v1.Group("/workflows", middleware.RequireUser())
 	{
-		workflowGroup.GET("", func(c echo.Context) error {
-			return c.JSON(http.StatusOK, map[string]interface{}{
-				"message":   "Workflows list",
-				"workflows": []string{},
-			})
-		})
-		workflowGroup.POST("", func(c echo.Context) error {
-			return c.JSON(http.StatusCreated, map[string]string{
-				"message": "Workflow created",
-				"status":  "OK",
-			})
-		})
+		workflowGroup.GET("", workflowHandler.GetWorkflows)
+		workflowGroup.GET("/:id", workflowHandler.GetWorkflow)
+		workflowGroup.POST("", workflowHandler.CreateWorkflow)
+		workflowGroup.PUT("/:id", workflowHandler.UpdateWorkflow)
+		workflowGroup.DELETE("/:id", workflowHandler.DeleteWorkflow)
+		workflowGroup.GET("/:id/versions", workflowHandler.GetWorkflowVersions)
+		workflowGroup.GET("/:id/versions/:version", workflowHandler.GetWorkflowVersion)
+		workflowGroup.GET("/:id/runs", workflowHandler.GetRuns)
+		workflowGroup.POST("/:id/runs", workflowHandler.StartRun)
+		workflowGroup.GET("/:id/runs/:run_id", workflowHandler.GetRun)
+		workflowGroup.POST("/:id/runs/:run_id/steps/:step_id/approve", workflowHandler.ApproveStep)
+		workflowGroup.POST("/:id/runs/:run_id/steps/:step_id/reject", workflowHandler.RejectStep)
+	}
+
+	// Notification endpoints; sending arbitrary events is reserved to admins
+	notificationGroup := v1.Group("/notifications", middleware.RequireUser())
+	{
+		notificationGroup.GET("/subscriptions", notificationHandler.GetSubscriptions)
+		notificationGroup.POST("/subscribe", notificationHandler.Subscribe)
+		notificationGroup.DELETE("/subscriptions/:id", notificationHandler.Unsubscribe)
+		notificationGroup.POST("/subscriptions/:id/test", notificationHandler.TestSubscription)
+		notificationGroup.POST("/send", notificationHandler.Send, middleware.RequireAdmin())
+	}
+
+	// Web Push endpoints; the public key is needed before signing in
+	pushGroup := v1.Group("/push", pushHandler.Enabled)
+	{
+		pushGroup.GET("/vapid-public-key", pushHandler.GetPublicKey)
+		pushGroup.GET("/subscriptions", pushHandler.GetSubscriptions, middleware.RequireUser())
+		pushGroup.POST("/subscriptions", pushHandler.Subscribe, middleware.RequireUser())
+		pushGroup.POST("/unsubscribe", pushHandler.Unsubscribe, middleware.RequireUser())
+		pushGroup.POST("/test", pushHandler.Test, middleware.RequireUser())
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,113 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"ai-git-workbench/internal/delivery/http/handlers"
+)
+
+const apiPrefix = "/api/v1"
+
+// echoParamPattern matches the :name segments of an Echo route path; an
+// escaped \: is a literal colon, as in /tasks\:batch
+var echoParamPattern = regexp.MustCompile(`(^|[^\\]):([^/]+)`)
+
+// registeredRoutes returns the method and OpenAPI path template of every
+// /api/v1 route, keyed as "GET /tasks/{id}"
+func registeredRoutes(t *testing.T) map[string]bool {
+	t.Helper()
+	e := echo.New()
+	SetupRoutes(e, Dependencies{})
+
+	routes := make(map[string]bool)
+	for _, route := range e.Routes() {
+		// Echo registers a catch-all per group for its not-found handling
+		if !strings.HasPrefix(route.Path, apiPrefix) || strings.HasSuffix(route.Path, "*") {
+			continue
+		}
+		if route.Method == echo.RouteNotFound {
+			continue
+		}
+		path := strings.TrimPrefix(route.Path, apiPrefix)
+		path = echoParamPattern.ReplaceAllString(path, "$1{$2}")
+		routes[route.Method+" "+strings.ReplaceAll(path, `\:`, ":")] = true
+	}
+	if len(routes) == 0 {
+		t.Fatal("no routes registered under " + apiPrefix)
+	}
+	return routes
+}
+
+func TestEveryRouteIsDescribed(t *testing.T) {
+	spec := handlers.OpenAPI()
+	for route := range registeredRoutes(t) {
+		method, path, _ := strings.Cut(route, " ")
+		if spec.Operation(method, path) == nil {
+			t.Errorf("%s %s%s is registered but missing from handlers.OpenAPI", method, apiPrefix, path)
+		}
+	}
+}
+
+func TestEveryDescribedOperationIsRegistered(t *testing.T) {
+	routes := registeredRoutes(t)
+	for path, item := range handlers.OpenAPI().Paths {
+		for method := range item {
+			if !routes[strings.ToUpper(method)+" "+path] {
+				t.Errorf("%s %s%s is described in handlers.OpenAPI but not registered", strings.ToUpper(method),
+					apiPrefix, path)
+			}
+		}
+	}
+}
+
+func TestOperationsAreUniqueAndAnswered(t *testing.T) {
+	ids := make(map[string]string)
+	for path, item := range handlers.OpenAPI().Paths {
+		for method, op := range item {
+			route := strings.ToUpper(method) + " " + path
+			if other, ok := ids[op.OperationID]; ok {
+				t.Errorf("operationId %q is used by %s and %s", op.OperationID, other, route)
+			}
+			ids[op.OperationID] = route
+
+			success := false
+			for status := range op.Responses {
+				if status[0] == '1' || status[0] == '2' || status == "302" {
+					success = true
+				}
+			}
+			if !success {
+				t.Errorf("%s has no successful response", route)
+			}
+			if strings.Contains(path, "{") && len(op.Parameters) == 0 {
+				t.Errorf("%s does not declare its path parameters", route)
+			}
+		}
+	}
+}
+
+func TestDocsServed(t *testing.T) {
+	e := echo.New()
+	SetupRoutes(e, Dependencies{})
+
+	for path, contentType := range map[string]string{
+		apiPrefix + "/openapi.json": echo.MIMEApplicationJSON,
+		apiPrefix + "/docs":         echo.MIMETextHTMLCharsetUTF8,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: status %d, want 200", path, rec.Code)
+		}
+		if got := rec.Header().Get(echo.HeaderContentType); got != contentType {
+			t.Errorf("GET %s: Content-Type %q, want %q", path, got, contentType)
+		}
+	}
+}
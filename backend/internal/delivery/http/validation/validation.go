@@ -0,0 +1,142 @@
+// Package validation checks request bodies against their `validate` struct
+// tags and reports every invalid field as a detail of an API error
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"ai-git-workbench/internal/delivery/http/apierror"
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// taskStatuses lists the statuses a task may be given, for messages
+var taskStatuses = strings.Join([]string{entities.TaskStatusPending, entities.TaskStatusQueued,
+	entities.TaskStatusInProgress, entities.TaskStatusCompleted, entities.TaskStatusFailed}, ", ")
+
+// Validator validates request bodies. It implements echo.Validator.
+//
+// Besides the tags of github.com/go-playground/validator it understands
+// notblank (a string with more than whitespace), task_status (a task status)
+// and git_branch (a branch name git check-ref-format accepts).
+type Validator struct {
+	validate *validator.Validate
+}
+
+// New creates a Validator
+func New() *Validator {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by the names clients send
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	for tag, fn := range map[string]validator.Func{
+		"notblank":    func(fl validator.FieldLevel) bool { return strings.TrimSpace(fl.Field().String()) != "" },
+		"task_status": func(fl validator.FieldLevel) bool { return entities.ValidTaskStatus(fl.Field().String()) },
+		"git_branch":  func(fl validator.FieldLevel) bool { return ValidBranchName(fl.Field().String()) },
+	} {
+		// Registering only fails for empty tags
+		_ = validate.RegisterValidation(tag, fn)
+	}
+	return &Validator{validate: validate}
+}
+
+// Validate checks i, a pointer to a struct, returning an *apierror.Error
+// with a detail per invalid field
+func (v *Validator) Validate(i interface{}) error {
+	err := v.validate.Struct(i)
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		// nil, or a programming error such as validating a non-struct
+		return err
+	}
+
+	details := make([]apierror.Detail, 0, len(invalid))
+	for _, fe := range invalid {
+		details = append(details, detail(fe))
+	}
+	return apierror.Invalid(details...)
+}
+
+// detail describes a failed check of a field
+func detail(fe validator.FieldError) apierror.Detail {
+	// The namespace starts with the Go name of the validated struct
+	field := fe.Namespace()
+	if _, rest, ok := strings.Cut(field, "."); ok {
+		field = rest
+	}
+
+	code, message := apierror.DetailInvalid, "is invalid"
+	switch fe.Tag() {
+	case "required", "notblank":
+		code, message = apierror.DetailRequired, "is required"
+	case "max", "lte":
+		code, message = apierror.DetailOutOfRange, "must be at most "+fe.Param()+unit(fe)
+	case "min", "gte":
+		code, message = apierror.DetailOutOfRange, "must be at least "+fe.Param()+unit(fe)
+	case "lt":
+		code, message = apierror.DetailOutOfRange, "must be less than "+fe.Param()+unit(fe)
+	case "gt":
+		code, message = apierror.DetailOutOfRange, "must be greater than "+fe.Param()+unit(fe)
+	case "url":
+		message = "must be an absolute URL"
+	case "http_url":
+		message = "must be an http or https URL"
+	case "oneof":
+		message = "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "task_status":
+		message = "must be one of " + taskStatuses
+	case "git_branch":
+		message = "must be a valid git branch name"
+	}
+	return apierror.Detail{Field: field, Code: code, Message: field + " " + message}
+}
+
+// unit names what a length limit counts
+func unit(fe validator.FieldError) string {
+	switch fe.Kind() {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items"
+	default:
+		return ""
+	}
+}
+
+// ValidBranchName reports whether name is a branch name git accepts, following
+// the rules of git check-ref-format --branch
+func ValidBranchName(name string) bool {
+	if name == "" || name == "@" || name == "HEAD" || strings.HasPrefix(name, "-") {
+		return false
+	}
+	if strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".") {
+		return false
+	}
+	if strings.Contains(name, "..") || strings.Contains(name, "//") || strings.Contains(name, "@{") {
+		return false
+	}
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return false
+		}
+	}
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package validation
+
+import "testing"
+
+func TestValidBranchName(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		valid bool
+	}{
+		{"main", true},
+		{"feature/login", true},
+		{"release-1.2", true},
+		{"fix/issue_42", true},
+		{"user@host", true},
+		{"한글-브랜치", true},
+		{"a/b.c/d", true},
+
+		{"", false},
+		{"@", false},
+		{"HEAD", false},
+		{"-main", false},
+		{"/main", false},
+		{"main/", false},
+		{"main.", false},
+		{"a..b", false},
+		{"a//b", false},
+		{"a@{1}", false},
+		{"has space", false},
+		{"tab\there", false},
+		{"del\x7f", false},
+		{"tilde~1", false},
+		{"caret^", false},
+		{"colon:x", false},
+		{"what?", false},
+		{"star*", false},
+		{"open[", false},
+		{`back\slash`, false},
+		{".hidden", false},
+		{"feature/.hidden", false},
+		{"main.lock", false},
+		{"refs/main.lock/x", false},
+	} {
+		if got := ValidBranchName(tc.name); got != tc.valid {
+			t.Errorf("ValidBranchName(%q) = %v, want %v", tc.name, got, tc.valid)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Actions recorded in the activity log
+const (
+	ActivityTaskCreated            = "task.created"
+	ActivityTaskUpdated            = "task.updated"
+	ActivityTaskDeleted            = "task.deleted"
+	ActivityTaskExecuted           = "task.executed"
+	ActivityRepositoryConnected    = "repository.connected"
+	ActivityRepositoryUpdated      = "repository.updated"
+	ActivityRepositoryDisconnected = "repository.disconnected"
+	ActivityRepositoryCloned       = "repository.cloned"
+	ActivityTokenCreated           = "token.created"
+	ActivityWorkflowCreated        = "workflow.created"
+	ActivityWorkflowUpdated        = "workflow.updated"
+	ActivityWorkflowDeleted        = "workflow.deleted"
+	ActivityWorkflowRunStarted     = "workflow_run.started"
+	ActivityWorkflowStepApproved   = "workflow_run.step_approved"
+	ActivityWorkflowStepRejected   = "workflow_run.step_rejected"
+)
+
+// Resource types of activity log entries
+const (
+	ResourceTask        = "task"
+	ResourceRepository  = "repository"
+	ResourceToken       = "token"
+	ResourceWorkflow    = "workflow"
+	ResourceWorkflowRun = "workflow_run"
+)
+
+// ActivityLog is an entry of the append-only audit log. ActorID and Actor are
+// empty for unauthenticated requests. Changes holds the top-level fields that
+// differ between the resource before and after the action.
+type ActivityLog struct {
+	ID           int64                  `json:"id"`
+	Action       string                 `json:"action"`
+	ResourceType string                 `json:"resource_type"`
+	ResourceID   string                 `json:"resource_id"`
+	ActorID      *int64                 `json:"actor_id,omitempty"`
+	Actor        string                 `json:"actor,omitempty"`
+	Changes      map[string]FieldChange `json:"changes,omitempty"`
+	IP           string                 `json:"ip,omitempty"`
+	RequestID    string                 `json:"request_id,omitempty"`
+	UserAgent    string                 `json:"user_agent,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// FieldChange is the JSON value of a field before and after an action. Before
+// is omitted for created resources and After for deleted ones.
+type FieldChange struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
@@ -0,0 +1,30 @@
+package entities
+
+import "time"
+
+// Sources of AI usage
+const (
+	AIUsageProcess = "process"
+	AIUsageTask    = "task"
+)
+
+// AIUsage records the tokens spent on one AI completion. UserID is the user
+// who requested it and TaskID is set for task executions.
+type AIUsage struct {
+	ID           int64     `json:"id"`
+	UserID       *int64    `json:"user_id,omitempty"`
+	Source       string    `json:"source"`
+	TaskID       string    `json:"task_id,omitempty"`
+	Model        string    `json:"model"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AIUsageTotals sums the usage of a period
+type AIUsageTotals struct {
+	Requests     int `json:"requests"`
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
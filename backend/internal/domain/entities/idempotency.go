@@ -0,0 +1,22 @@
+package entities
+
+import "time"
+
+// IdempotencyKey is the response to the first request sent with an
+// Idempotency-Key header, replayed to retries of the same request. A key
+// without a status code is still being processed.
+type IdempotencyKey struct {
+	Scope       string            // whose key it is, such as "user:42"
+	Key         string            // the header value
+	RequestHash string            // SHA-256 of the method, URI and body of the request
+	StatusCode  int               // 0 until the response is stored
+	Header      map[string]string // response headers replayed with the body
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Completed reports whether the response of the request is stored
+func (k *IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
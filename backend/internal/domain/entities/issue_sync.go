@@ -0,0 +1,52 @@
+package entities
+
+import "time"
+
+// IssueLink ties a task of a repository with issue sync enabled to the
+// GitHub issue mirroring it
+type IssueLink struct {
+	TaskID      string `json:"task_id"`
+	Repository  string `json:"repository"`   // full name of the GitHub repository
+	IssueNumber int    `json:"issue_number"` // 0 until the issue is created
+	IssueURL    string `json:"issue_url,omitempty"`
+	// SyncedHash identifies the mirrored fields as of the last time task and
+	// issue agreed; a side whose fields hash differently changed since
+	SyncedHash     string     `json:"-"`
+	IssueUpdatedAt *time.Time `json:"issue_updated_at,omitempty"` // as of the last sync
+	// Pending is set while a change of the task waits to be pushed to GitHub
+	Pending   bool       `json:"pending"`
+	Error     string     `json:"error,omitempty"` // of the last failed push
+	SyncedAt  *time.Time `json:"synced_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Sides of an issue sync conflict
+const (
+	SyncSideTask  = "task"
+	SyncSideIssue = "issue"
+)
+
+// IssueFields are the task fields mirrored to an issue: the title, the
+// description as body, the status as state and labels, and the epic as label
+type IssueFields struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	Epic        string `json:"epic"`
+}
+
+// SyncConflict records a task and its issue that both changed since they
+// were last synced. The side changed last won and overwrote the other.
+type SyncConflict struct {
+	ID             int64       `json:"id"`
+	TaskID         string      `json:"task_id"`
+	Repository     string      `json:"repository"`
+	IssueNumber    int         `json:"issue_number"`
+	Winner         string      `json:"winner"` // SyncSideTask or SyncSideIssue
+	Task           IssueFields `json:"task"`
+	Issue          IssueFields `json:"issue"`
+	TaskUpdatedAt  time.Time   `json:"task_updated_at"`
+	IssueUpdatedAt time.Time   `json:"issue_updated_at"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
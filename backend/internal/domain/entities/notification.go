@@ -0,0 +1,55 @@
+package entities
+
+import "time"
+
+// Notification event types users can subscribe to
+const (
+	EventTaskStatusChanged = "task_status_changed"
+	EventPRReviewDone      = "pr_review_done"
+	EventBudgetExceeded    = "budget_exceeded"
+	EventApprovalRequested = "approval_requested"
+)
+
+// Notification delivery channels
+const (
+	ChannelEmail   = "email"
+	ChannelSlack   = "slack"
+	ChannelWebhook = "webhook"
+)
+
+// ValidEventType reports whether t is a known notification event type
+func ValidEventType(t string) bool {
+	switch t {
+	case EventTaskStatusChanged, EventPRReviewDone, EventBudgetExceeded, EventApprovalRequested:
+		return true
+	}
+	return false
+}
+
+// NotificationSubscription delivers the events of the given types to a
+// channel. An empty Repository matches events of every repository.
+type NotificationSubscription struct {
+	ID         int64    `json:"id"`
+	UserID     int64    `json:"user_id"`
+	EventTypes []string `json:"event_types"`
+	Repository string   `json:"repository,omitempty"`
+	Channel    string   `json:"channel"`
+	// Target is the email address or webhook URL deliveries are sent to
+	Target string `json:"target"`
+	// Secret signs generic webhook deliveries; it is never returned
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Notification is an event delivered to subscribers
+type Notification struct {
+	Type       string            `json:"type"`
+	Repository string            `json:"repository,omitempty"`
+	Title      string            `json:"title"`
+	Message    string            `json:"message,omitempty"`
+	URL        string            `json:"url,omitempty"`
+	Data       map[string]string `json:"data,omitempty"`
+	// Recipients, when set, limits delivery to the subscriptions of these logins
+	Recipients []string  `json:"recipients,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
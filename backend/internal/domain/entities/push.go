@@ -0,0 +1,27 @@
+package entities
+
+import "time"
+
+// ChannelPush delivers notifications to every browser the user enabled push on
+const ChannelPush = "push"
+
+// PushSubscription is a browser registered for Web Push. P256dh and Auth are
+// the keys messages are encrypted with and are never returned.
+type PushSubscription struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Endpoint   string     `json:"endpoint"`
+	P256dh     string     `json:"-"`
+	Auth       string     `json:"-"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// VAPIDKeyPair is the base64url encoded application server key pair shared
+// by every server instance
+type VAPIDKeyPair struct {
+	PublicKey  string
+	PrivateKey string
+	CreatedAt  time.Time
+}
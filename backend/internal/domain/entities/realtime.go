@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Change event types streamed to WebSocket clients
+const (
+	EventTaskCreated       = "task.created"
+	EventTaskUpdated       = "task.updated"
+	EventTaskDeleted       = "task.deleted"
+	EventRepositoryCreated = "repository.created"
+	EventRepositoryUpdated = "repository.updated"
+	EventRepositoryDeleted = "repository.deleted"
+)
+
+// ChangeEvent records a created, updated or deleted task or repository. Data
+// is the record after the change, or before it for deletions. Private and
+// OwnerID carry the visibility of the repository the record belongs to.
+type ChangeEvent struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	EntityID   string          `json:"entity_id"`
+	Repository string          `json:"repository,omitempty"`
+	Data       json.RawMessage `json:"data"`
+	Private    bool            `json:"-"`
+	OwnerID    *int64          `json:"-"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package entities
+
+import "time"
+
+// Repository is a GitHub repository connected to the workbench
+type Repository struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	FullName    string     `json:"full_name"`
+	Description string     `json:"description,omitempty"`
+	Private     bool       `json:"private"`
+	Language    string     `json:"language,omitempty"`
+	URL         string     `json:"url"`
+	HTMLURL     string     `json:"html_url"`
+	CloneURL    string     `json:"clone_url"`
+	Stars       int        `json:"stars"`
+	Forks       int        `json:"forks"`
+	IsConnected bool       `json:"is_connected"`
+	OwnerID     *int64     `json:"owner_id,omitempty"`
+	LastSync    *time.Time `json:"last_sync,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Topics      []string   `json:"topics,omitempty"`
+	IssueSync   bool       `json:"issue_sync"` // mirror its tasks to GitHub issues
+	Version     int64      `json:"version"`    // incremented by every update
+}
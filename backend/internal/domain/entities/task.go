@@ -0,0 +1,76 @@
+package entities
+
+import "time"
+
+// Task statuses
+const (
+	TaskStatusPending    = "pending"
+	TaskStatusQueued     = "queued"
+	TaskStatusInProgress = "in_progress"
+	TaskStatusCompleted  = "completed"
+	TaskStatusFailed     = "failed"
+)
+
+// Task is a unit of work executed against a repository
+type Task struct {
+	ID          string            `json:"id"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Status      string            `json:"status"`
+	Repository  string            `json:"repository"`
+	Epic        string            `json:"epic"`
+	Branch      string            `json:"branch,omitempty"`
+	Assignee    string            `json:"assignee,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	TokensUsed  int               `json:"tokens_used"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Version     int64             `json:"version"` // incremented by every update
+}
+
+// IsFinished reports whether the task reached a terminal status
+func (t *Task) IsFinished() bool {
+	return t.Status == TaskStatusCompleted || t.Status == TaskStatusFailed
+}
+
+// taskTransitions lists the statuses a task may be moved to from each status.
+// in_progress is left to the executor, which starts and finishes runs.
+var taskTransitions = map[string][]string{
+	TaskStatusPending:   {TaskStatusQueued, TaskStatusCompleted, TaskStatusFailed},
+	TaskStatusQueued:    {TaskStatusPending, TaskStatusCompleted, TaskStatusFailed},
+	TaskStatusCompleted: {TaskStatusPending, TaskStatusQueued},
+	TaskStatusFailed:    {TaskStatusPending, TaskStatusQueued},
+}
+
+// CanTransition reports whether the task may be moved to status
+func (t *Task) CanTransition(status string) bool {
+	for _, next := range taskTransitions[t.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves the task to status, keeping StartedAt and CompletedAt in
+// step: finishing stamps CompletedAt, and going back to pending or queued
+// clears both since the task has yet to run.
+func (t *Task) Transition(status string, now time.Time) {
+	t.Status = status
+	if t.IsFinished() {
+		t.CompletedAt = &now
+		return
+	}
+	t.StartedAt, t.CompletedAt = nil, nil
+}
+
+// ValidTaskStatus reports whether status is one of the task statuses
+func ValidTaskStatus(status string) bool {
+	switch status {
+	case TaskStatusPending, TaskStatusQueued, TaskStatusInProgress, TaskStatusCompleted, TaskStatusFailed:
+		return true
+	}
+	return false
+}
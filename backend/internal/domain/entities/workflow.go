@@ -0,0 +1,198 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+)
+
+// Workflow step types
+const (
+	StepTypeGitCheckout = "git_checkout"
+	StepTypeAIPrompt    = "ai_prompt"
+	StepTypeShell       = "shell"
+	StepTypeOpenPR      = "open_pr"
+	StepTypeApproval    = "wait_for_approval"
+)
+
+// Workflow run statuses
+const (
+	RunStatusQueued    = "queued"
+	RunStatusRunning   = "running"
+	RunStatusWaiting   = "waiting"
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+)
+
+// Workflow step statuses
+const (
+	StepStatusPending   = "pending"
+	StepStatusRunning   = "running"
+	StepStatusWaiting   = "waiting"
+	StepStatusSucceeded = "succeeded"
+	StepStatusFailed    = "failed"
+	StepStatusSkipped   = "skipped"
+)
+
+// Workflow run triggers
+const (
+	TriggerManual     = "manual"
+	TriggerCron       = "cron"
+	TriggerGitHub     = "github"
+	TriggerTaskStatus = "task_status"
+)
+
+// Workflow run audit event types
+const (
+	RunEventApprovalRequested = "approval_requested"
+	RunEventApproved          = "approved"
+	RunEventRejected          = "rejected"
+	RunEventApprovalTimedOut  = "approval_timed_out"
+)
+
+// Workflow is a named DAG of steps. Every change to its definition is stored
+// as a new version; Version and Definition describe the latest one.
+type Workflow struct {
+	ID          int64               `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Version     int                 `json:"version"`
+	Definition  *WorkflowDefinition `json:"definition,omitempty"`
+	CreatedBy   *int64              `json:"created_by,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// WorkflowVersion is an immutable revision of a workflow definition. Source
+// keeps the YAML or JSON document as submitted.
+type WorkflowVersion struct {
+	WorkflowID int64              `json:"workflow_id"`
+	Version    int                `json:"version"`
+	Definition WorkflowDefinition `json:"definition"`
+	Source     string             `json:"source"`
+	CreatedBy  *int64             `json:"created_by,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+// WorkflowDefinition is the document describing a workflow
+type WorkflowDefinition struct {
+	Name        string            `json:"name" yaml:"name"`
+	Description string            `json:"description,omitempty" yaml:"description"`
+	Inputs      []WorkflowInput   `json:"inputs,omitempty" yaml:"inputs"`
+	Triggers    []WorkflowTrigger `json:"triggers,omitempty" yaml:"triggers"`
+	Steps       []WorkflowStep    `json:"steps" yaml:"steps"`
+}
+
+// WorkflowInput is a parameter supplied when a run starts
+type WorkflowInput struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description"`
+	Required    bool   `json:"required,omitempty" yaml:"required"`
+	Default     string `json:"default,omitempty" yaml:"default"`
+}
+
+// WorkflowTrigger starts runs automatically. Which fields apply depends on
+// Type: Schedule for cron; Event, Actions, Branches and Repositories for
+// github; Statuses and Repositories for task_status. Empty filters match
+// everything. Inputs may reference ${{ event.<key> }}.
+type WorkflowTrigger struct {
+	Type         string            `json:"type" yaml:"type"`
+	Schedule     string            `json:"schedule,omitempty" yaml:"schedule"`
+	Event        string            `json:"event,omitempty" yaml:"event"`
+	Actions      []string          `json:"actions,omitempty" yaml:"actions"`
+	Branches     []string          `json:"branches,omitempty" yaml:"branches"`
+	Repositories []string          `json:"repositories,omitempty" yaml:"repositories"`
+	Statuses     []string          `json:"statuses,omitempty" yaml:"statuses"`
+	Inputs       map[string]string `json:"inputs,omitempty" yaml:"inputs"`
+}
+
+// WorkflowStep is a node of the workflow DAG. With holds the step type's
+// parameters and may reference ${{ inputs.<name> }} and
+// ${{ steps.<id>.outputs.<key> }}.
+type WorkflowStep struct {
+	ID         string            `json:"id" yaml:"id"`
+	Name       string            `json:"name,omitempty" yaml:"name"`
+	Type       string            `json:"type" yaml:"type"`
+	Needs      []string          `json:"needs,omitempty" yaml:"needs"`
+	With       map[string]string `json:"with,omitempty" yaml:"with"`
+	Retries    int               `json:"retries,omitempty" yaml:"retries"`
+	RetryDelay Duration          `json:"retry_delay,omitempty" yaml:"retry_delay"`
+	Timeout    Duration          `json:"timeout,omitempty" yaml:"timeout"`
+}
+
+// WorkflowRun is one execution of a workflow version
+type WorkflowRun struct {
+	ID         string             `json:"id"`
+	WorkflowID int64              `json:"workflow_id"`
+	Version    int                `json:"version"`
+	Status     string             `json:"status"`
+	Inputs     map[string]string  `json:"inputs,omitempty"`
+	Trigger    string             `json:"trigger"`
+	Event      map[string]string  `json:"event,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	Steps      []StepRun          `json:"steps,omitempty"`
+	Audit      []WorkflowRunEvent `json:"audit,omitempty"`
+	CreatedBy  *int64             `json:"created_by,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+	StartedAt  *time.Time         `json:"started_at,omitempty"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+}
+
+// IsFinished reports whether the run reached a terminal status
+func (r *WorkflowRun) IsFinished() bool {
+	return r.Status == RunStatusSucceeded || r.Status == RunStatusFailed
+}
+
+// Step returns the state of the step with the given id, or nil
+func (r *WorkflowRun) Step(id string) *StepRun {
+	for i := range r.Steps {
+		if r.Steps[i].StepID == id {
+			return &r.Steps[i]
+		}
+	}
+	return nil
+}
+
+// StepRun is the state of one step within a run
+type StepRun struct {
+	StepID     string            `json:"step_id"`
+	Type       string            `json:"type"`
+	Status     string            `json:"status"`
+	Attempts   int               `json:"attempts"`
+	Outputs    map[string]string `json:"outputs,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+	// ExpiresAt is when a waiting approval step times out
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// WorkflowRunEvent is an entry of a run's audit trail, such as an approval
+// decision. ActorID and Actor are empty for decisions taken by the system.
+type WorkflowRunEvent struct {
+	ID        int64     `json:"id"`
+	RunID     string    `json:"run_id"`
+	StepID    string    `json:"step_id,omitempty"`
+	Type      string    `json:"type"`
+	ActorID   *int64    `json:"actor_id,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Duration is a time.Duration written as a string such as "30s" in YAML and JSON
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", text)
+	}
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// ActivityFilter narrows an activity log list. Zero values match everything;
+// Action matches any of the given actions.
+type ActivityFilter struct {
+	Actor        string
+	Action       []string
+	ResourceType string
+	ResourceID   string
+	RequestID    string
+	Since        *time.Time
+	Until        *time.Time
+}
+
+// ActivityRepository is the append-only audit log. Entries are never updated
+// or deleted.
+type ActivityRepository interface {
+	// Append stores an entry, setting its ID and creation time
+	Append(ctx context.Context, entry *entities.ActivityLog) error
+	// List returns a page of entries, newest first by default
+	List(ctx context.Context, filter ActivityFilter, opts ListOptions) (Page[entities.ActivityLog], error)
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// AIUsageRepository persists the tokens spent on AI completions
+type AIUsageRepository interface {
+	// Append stores a usage record, setting its ID and creation time
+	Append(ctx context.Context, usage *entities.AIUsage) error
+	// Totals sums the usage of a user since t
+	Totals(ctx context.Context, userID int64, since time.Time) (entities.AIUsageTotals, error)
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// ChangeEventRepository is the log of change events shared by all server
+// instances. IDs increase in insertion order.
+type ChangeEventRepository interface {
+	// Append stores an event and sets its ID. Events of tasks take the
+	// visibility of the task's repository.
+	Append(ctx context.Context, event *entities.ChangeEvent) error
+	// ListAfter returns up to limit events with an ID above afterID, oldest first
+	ListAfter(ctx context.Context, afterID int64, limit int) ([]entities.ChangeEvent, error)
+	// LatestID returns the highest event ID, or 0 when the log is empty
+	LatestID(ctx context.Context) (int64, error)
+	// DeleteBefore removes events created before t and returns how many
+	DeleteBefore(ctx context.Context, t time.Time) (int64, error)
+}
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a record violates a uniqueness constraint
+	ErrConflict = errors.New("record already exists")
+	// ErrStale is returned when a record changed since the version being saved was read
+	ErrStale = errors.New("record was modified concurrently")
+	// ErrInvalidQuery is returned for unknown sort fields or malformed cursors
+	ErrInvalidQuery = errors.New("invalid list query")
+)
+
+// BatchError reports the write of a batch that failed, undoing the batch
+type BatchError struct {
+	Index int // position of the write in the batch
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("write %d of batch: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error of the write
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// IdempotencyKeyRepository stores the responses of requests sent with an
+// Idempotency-Key header, shared by all server instances
+type IdempotencyKeyRepository interface {
+	// Reserve stores a key without a response. When the scope already holds
+	// the key and it has not expired, the stored key is returned with
+	// ErrConflict instead.
+	Reserve(ctx context.Context, key *entities.IdempotencyKey) (*entities.IdempotencyKey, error)
+	// Complete stores the response of a reserved key
+	Complete(ctx context.Context, key *entities.IdempotencyKey) error
+	// Delete removes a key so the request may be retried
+	Delete(ctx context.Context, scope, key string) error
+	// DeleteExpired removes keys that expired before t and returns how many
+	DeleteExpired(ctx context.Context, t time.Time) (int64, error)
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// SyncConflictFilter narrows a sync conflict list. Zero values match everything.
+type SyncConflictFilter struct {
+	Repository string
+	TaskID     string
+}
+
+// IssueSyncRepository stores the links between tasks and GitHub issues and
+// the log of conflicts between them
+type IssueSyncRepository interface {
+	// GetLink returns the link of a task
+	GetLink(ctx context.Context, taskID string) (*entities.IssueLink, error)
+	// GetLinkByIssue returns the link of an issue of repository
+	GetLinkByIssue(ctx context.Context, repository string, number int) (*entities.IssueLink, error)
+	// MarkPending flags the link of a task as having a change to push,
+	// creating it in repository when the task has none
+	MarkPending(ctx context.Context, taskID, repository string) error
+	// SaveLink stores every field of a link, creating it when needed
+	SaveLink(ctx context.Context, link *entities.IssueLink) error
+	// DeleteLink removes the link of a task
+	DeleteLink(ctx context.Context, taskID string) error
+	// ListPending returns up to limit links with a change to push, least
+	// recently attempted first
+	ListPending(ctx context.Context, limit int) ([]entities.IssueLink, error)
+
+	// AddConflict appends a conflict to the log, setting its ID and creation time
+	AddConflict(ctx context.Context, conflict *entities.SyncConflict) error
+	// ListConflicts returns a page of conflicts, newest first by default
+	ListConflicts(ctx context.Context, filter SyncConflictFilter, opts ListOptions) (Page[entities.SyncConflict], error)
+}
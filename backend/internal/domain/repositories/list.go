@@ -0,0 +1,65 @@
+package repositories
+
+import "time"
+
+// Page size limits applied by the stores
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// SortField orders a list by one field
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ListOptions selects a page of a list. Cursor is the opaque NextCursor of
+// the previous page and is only valid with the same Sort.
+type ListOptions struct {
+	Limit  int
+	Cursor string
+	Sort   []SortField
+}
+
+// PageSize returns the limit clamped to the supported range
+func (o ListOptions) PageSize() int {
+	switch {
+	case o.Limit <= 0:
+		return DefaultPageSize
+	case o.Limit > MaxPageSize:
+		return MaxPageSize
+	default:
+		return o.Limit
+	}
+}
+
+// Page is one page of a list. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T
+	NextCursor string
+}
+
+// TaskFilter narrows a task list. Zero values match everything; Status
+// matches any of the given statuses.
+type TaskFilter struct {
+	Status        []string
+	Repository    string
+	Epic          string
+	Branch        string
+	Assignee      string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	// Scope hides tasks of private repositories the caller cannot see; nil
+	// lists every task
+	Scope *AccessScope
+}
+
+// RepositoryFilter narrows a repository list
+type RepositoryFilter struct {
+	// Scope hides private repositories the caller cannot see; nil lists
+	// every repository
+	Scope *AccessScope
+}
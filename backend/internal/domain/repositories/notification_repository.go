@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// SubscriberFilter selects the subscriptions an event is delivered to
+type SubscriberFilter struct {
+	EventType  string
+	Repository string
+	// Logins restricts the result to the subscriptions of these users
+	Logins []string
+}
+
+// NotificationSubscriptionRepository persists notification subscriptions
+type NotificationSubscriptionRepository interface {
+	// ListByUser returns the subscriptions of a user, oldest first
+	ListByUser(ctx context.Context, userID int64) ([]entities.NotificationSubscription, error)
+	GetByID(ctx context.Context, id int64) (*entities.NotificationSubscription, error)
+	Create(ctx context.Context, sub *entities.NotificationSubscription) error
+	Delete(ctx context.Context, id int64) error
+	// ListSubscribers returns the subscriptions matching an event, including
+	// their secrets. Subscribers who may not see the event's repository are
+	// never returned.
+	ListSubscribers(ctx context.Context, filter SubscriberFilter) ([]entities.NotificationSubscription, error)
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// PushSubscriptionRepository persists browser push subscriptions
+type PushSubscriptionRepository interface {
+	// ListByUser returns the subscriptions of a user, including their keys
+	ListByUser(ctx context.Context, userID int64) ([]entities.PushSubscription, error)
+	// Save stores a subscription, replacing the one with the same endpoint
+	Save(ctx context.Context, sub *entities.PushSubscription) error
+	// DeleteByEndpoint removes the subscription of an endpoint, returning
+	// ErrNotFound if userID (when not 0) does not own it
+	DeleteByEndpoint(ctx context.Context, endpoint string, userID int64) error
+	// TouchLastUsed records a successful delivery
+	TouchLastUsed(ctx context.Context, id int64) error
+}
+
+// VAPIDKeyRepository stores the Web Push application server key pair
+type VAPIDKeyRepository interface {
+	// Get returns the stored key pair or ErrNotFound
+	Get(ctx context.Context) (*entities.VAPIDKeyPair, error)
+	// Create stores the key pair, returning ErrConflict if one already exists
+	Create(ctx context.Context, keys *entities.VAPIDKeyPair) error
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// RepositoryRepository persists connected GitHub repositories
+type RepositoryRepository interface {
+	List(ctx context.Context, filter RepositoryFilter, opts ListOptions) (Page[entities.Repository], error)
+	GetByID(ctx context.Context, id int64) (*entities.Repository, error)
+	// GetByName returns the repository a task names, matching its full name
+	// or else its name
+	GetByName(ctx context.Context, name string) (*entities.Repository, error)
+	Create(ctx context.Context, repo *entities.Repository) error
+	// Update saves the record and increments its version. A non-zero version
+	// must still be the stored one, or ErrStale is returned.
+	Update(ctx context.Context, repo *entities.Repository) error
+	// Delete removes the record. A non-zero version must still be the stored
+	// one, or ErrStale is returned.
+	Delete(ctx context.Context, id int64, version int64) error
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// Searchable record types
+const (
+	SearchTypeTask       = "task"
+	SearchTypeRepository = "repository"
+)
+
+// AccessScope limits queries to what a caller may see. Admins see every
+// record; everyone else sees public repositories, private repositories they
+// own and tasks that do not belong to a private repository hidden from them.
+// A zero UserID is an anonymous caller.
+type AccessScope struct {
+	UserID int64
+	Admin  bool
+}
+
+// CanSee reports whether the caller may see repo and its tasks
+func (s AccessScope) CanSee(repo *entities.Repository) bool {
+	return s.Admin || !repo.Private || (s.UserID != 0 && repo.OwnerID != nil && *repo.OwnerID == s.UserID)
+}
+
+// SearchQuery is a full-text query over tasks and repositories
+type SearchQuery struct {
+	// Terms are the words to match; records matching more terms rank higher
+	Terms []string
+	// Types restricts the search to the given record types, all when empty
+	Types  []string
+	Limit  int
+	Offset int
+	Scope  AccessScope
+}
+
+// SearchHit is one matching record. Exactly one of Task and Repository is set.
+type SearchHit struct {
+	Type       string
+	Score      float64
+	Task       *entities.Task
+	Repository *entities.Repository
+}
+
+// SearchResult holds the ranked hits and the number of matches per type
+type SearchResult struct {
+	Hits   []SearchHit
+	Facets map[string]int
+}
+
+// SearchRepository runs full-text queries against the stored records
+type SearchRepository interface {
+	Search(ctx context.Context, query SearchQuery) (*SearchResult, error)
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// TaskRepository persists tasks
+type TaskRepository interface {
+	List(ctx context.Context, filter TaskFilter, opts ListOptions) (Page[entities.Task], error)
+	GetByID(ctx context.Context, id string) (*entities.Task, error)
+	Create(ctx context.Context, task *entities.Task) error
+	// Update saves the record and increments its version. A non-zero version
+	// must still be the stored one, or ErrStale is returned.
+	Update(ctx context.Context, task *entities.Task) error
+	// Delete removes the record. A non-zero version must still be the stored
+	// one, or ErrStale is returned.
+	Delete(ctx context.Context, id string, version int64) error
+	// ApplyBatch carries out writes in one transaction: all of them or, when
+	// one fails, none. The error of a failed write is a *BatchError.
+	ApplyBatch(ctx context.Context, writes []TaskWrite) error
+}
+
+// Kinds of writes of a batch
+const (
+	WriteCreate = "create"
+	WriteUpdate = "update"
+	WriteDelete = "delete"
+)
+
+// TaskWrite is one write of a batch. Task is the record to create or save,
+// or the one to delete, of which only the ID and version are used.
+type TaskWrite struct {
+	Op   string
+	Task *entities.Task
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// UserRepository persists API users
+type UserRepository interface {
+	GetByID(ctx context.Context, id int64) (*entities.User, error)
+	GetByLogin(ctx context.Context, login string) (*entities.User, error)
+	Create(ctx context.Context, user *entities.User) error
+}
+
+// TokenRepository persists hashed API tokens
+type TokenRepository interface {
+	Create(ctx context.Context, token *entities.APIToken) error
+	GetByHash(ctx context.Context, hash string) (*entities.APIToken, error)
+	TouchLastUsed(ctx context.Context, id int64) error
+	// DeleteByHash revokes the token whose secret hashes to hash
+	DeleteByHash(ctx context.Context, hash string) error
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// WorkflowRepository persists workflows and their versioned definitions
+type WorkflowRepository interface {
+	List(ctx context.Context, opts ListOptions) (Page[entities.Workflow], error)
+	// GetByID returns the workflow with its latest definition
+	GetByID(ctx context.Context, id int64) (*entities.Workflow, error)
+	// Create stores a new workflow and its first version
+	Create(ctx context.Context, workflow *entities.Workflow, version *entities.WorkflowVersion) error
+	// AddVersion stores version as the next version of its workflow and sets its number
+	AddVersion(ctx context.Context, version *entities.WorkflowVersion) error
+	GetVersion(ctx context.Context, workflowID int64, version int) (*entities.WorkflowVersion, error)
+	ListVersions(ctx context.Context, workflowID int64) ([]entities.WorkflowVersion, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// WorkflowRunFilter narrows a run list. Empty fields match every run.
+type WorkflowRunFilter struct {
+	WorkflowID int64
+	Status     []string
+	Trigger    []string
+}
+
+// StepRef identifies a step of a run
+type StepRef struct {
+	RunID  string
+	StepID string
+}
+
+// WorkflowRunRepository persists workflow runs and the state of their steps
+type WorkflowRunRepository interface {
+	// Create stores a new run with its steps, assigning an id when missing
+	Create(ctx context.Context, run *entities.WorkflowRun) error
+	// List returns a page of runs without their steps, newest first by default
+	List(ctx context.Context, filter WorkflowRunFilter, opts ListOptions) (Page[entities.WorkflowRun], error)
+	// GetByID returns the run with its steps
+	GetByID(ctx context.Context, id string) (*entities.WorkflowRun, error)
+	// UpdateStatus saves the run status, error and timestamps
+	UpdateStatus(ctx context.Context, run *entities.WorkflowRun) error
+	// UpdateStep saves the state of one step of the run
+	UpdateStep(ctx context.Context, runID string, step *entities.StepRun) error
+	// TransitionStep saves the state of a step only if its stored status is
+	// still from, returning ErrConflict otherwise
+	TransitionStep(ctx context.Context, runID string, step *entities.StepRun, from string) error
+	// ListExpiredSteps returns the waiting steps whose ExpiresAt is not after now
+	ListExpiredSteps(ctx context.Context, now time.Time) ([]StepRef, error)
+	// AddEvent appends an entry to the audit trail of a run
+	AddEvent(ctx context.Context, event *entities.WorkflowRunEvent) error
+	// ListEvents returns the audit trail of a run, oldest first
+	ListEvents(ctx context.Context, runID string) ([]entities.WorkflowRunEvent, error)
+	// ListUnfinished returns the ids of queued and running runs
+	ListUnfinished(ctx context.Context) ([]string, error)
+	// Requeue marks interrupted runs as queued and their running steps as pending
+	Requeue(ctx context.Context, runIDs []string) error
+}
@@ -0,0 +1,151 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"ai-git-workbench/internal/infrastructure/config"
+	"ai-git-workbench/internal/infrastructure/tracing"
+)
+
+const (
+	tracerName          = "ai-git-workbench/internal/infrastructure/ai"
+	anthropicAPIVersion = "2023-06-01"
+	defaultMaxTokens    = 1024
+)
+
+// AnthropicProvider calls the Anthropic Messages API
+type AnthropicProvider struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+}
+
+// NewAnthropicProvider creates a new AnthropicProvider
+func NewAnthropicProvider(cfg config.AIConfig) *AnthropicProvider {
+	return &AnthropicProvider{
+		httpClient: tracing.NewHTTPClient(2 * time.Minute),
+		baseURL:    strings.TrimSuffix(cfg.BaseURL, "/"),
+		apiKey:     cfg.APIKey,
+		model:      cfg.Model,
+	}
+}
+
+// Name returns the provider name
+func (p *AnthropicProvider) Name() string {
+	return "anthropic"
+}
+
+// Complete sends the prompt to the model inside a client span
+func (p *AnthropicProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "ai.complete "+p.model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gen_ai.system", p.Name()),
+			attribute.String("gen_ai.operation.name", "chat"),
+			attribute.String("gen_ai.request.model", p.model),
+		),
+	)
+	defer span.End()
+
+	resp, err := p.complete(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.String("gen_ai.response.model", resp.Model),
+		attribute.Int("gen_ai.usage.input_tokens", resp.InputTokens),
+		attribute.Int("gen_ai.usage.output_tokens", resp.OutputTokens),
+	)
+	return resp, nil
+}
+
+// complete performs the HTTP call to the Messages API
+func (p *AnthropicProvider) complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	if p.apiKey == "" {
+		return nil, fmt.Errorf("AI provider API key is not configured")
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxTokens
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"model":      p.model,
+		"system":     req.System,
+		"messages":   req.Messages,
+		"max_tokens": maxTokens,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("error calling AI provider: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	var body struct {
+		Model   string `json:"model"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+		Usage      struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("error decoding AI provider response: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		message := httpResp.Status
+		if body.Error != nil {
+			message = body.Error.Message
+		}
+		return nil, fmt.Errorf("AI provider error: status %d: %s", httpResp.StatusCode, message)
+	}
+
+	var text strings.Builder
+	for _, block := range body.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	return &CompletionResponse{
+		Content:      text.String(),
+		Model:        body.Model,
+		InputTokens:  body.Usage.InputTokens,
+		OutputTokens: body.Usage.OutputTokens,
+		StopReason:   body.StopReason,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"ai-git-workbench/internal/infrastructure/config"
+)
+
+// Message is a single turn in a conversation with the model
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// CompletionRequest describes a prompt sent to an AI provider
+type CompletionRequest struct {
+	System    string    `json:"system,omitempty"`
+	Messages  []Message `json:"messages"`
+	MaxTokens int       `json:"max_tokens,omitempty"`
+}
+
+// CompletionResponse holds the model output and token usage
+type CompletionResponse struct {
+	Content      string `json:"content"`
+	Model        string `json:"model"`
+	InputTokens  int    `json:"input_tokens"`
+	OutputTokens int    `json:"output_tokens"`
+	StopReason   string `json:"stop_reason,omitempty"`
+}
+
+// Provider is implemented by AI backends that can complete a prompt
+type Provider interface {
+	Name() string
+	Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error)
+}
+
+// NewProvider creates the provider selected by the configuration
+func NewProvider(cfg config.AIConfig) (Provider, error) {
+	switch cfg.Provider {
+	case "", "anthropic":
+		return NewAnthropicProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown AI provider %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package command
+
+import "os/exec"
+
+// killProcessGroup is a no-op where process groups are not available
+func killProcessGroup(cmd *exec.Cmd) {}
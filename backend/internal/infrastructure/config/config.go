@@ -1,72 +1,460 @@
 package config
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig   `json:"server"`
-	Database DatabaseConfig `json:"database"`
-	GitHub   GitHubConfig   `json:"github"`
+	Server    ServerConfig    `json:"server" yaml:"server"`
+	Database  DatabaseConfig  `json:"database" yaml:"database"`
+	GitHub    GitHubConfig    `json:"github" yaml:"github"`
+	Auth      AuthConfig      `json:"auth" yaml:"auth"`
+	Log       LogConfig       `json:"log" yaml:"log"`
+	Tracing   TracingConfig   `json:"tracing" yaml:"tracing"`
+	AI        AIConfig        `json:"ai" yaml:"ai"`
+	Workflow  WorkflowConfig  `json:"workflow" yaml:"workflow"`
+	Workspace WorkspaceConfig `json:"workspace" yaml:"workspace"`
+	Notify    NotifyConfig    `json:"notify" yaml:"notify"`
+	Realtime  RealtimeConfig  `json:"realtime" yaml:"realtime"`
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Port string `json:"port"`
-	Host string `json:"host"`
+	Port            string        `json:"port" yaml:"port"`
+	Host            string        `json:"host" yaml:"host"`
+	ShutdownTimeout time.Duration `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	// TrustProxy takes the client IP from X-Forwarded-For; enable only
+	// behind a reverse proxy that sets the header
+	TrustProxy bool `json:"trust_proxy" yaml:"trust_proxy"`
+	// IdempotencyTTL is how long responses to requests sent with an
+	// Idempotency-Key header are replayed to retries
+	IdempotencyTTL time.Duration `json:"idempotency_ttl" yaml:"idempotency_ttl"`
+	// IdempotencyMaxBody bounds, in bytes, the bodies of requests sent with
+	// an Idempotency-Key header, which are read whole to be hashed
+	IdempotencyMaxBody int `json:"idempotency_max_body" yaml:"idempotency_max_body"`
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	Host     string `json:"host"`
-	Port     string `json:"port"`
-	User     string `json:"user"`
-	Password string `json:"password"`
-	Name     string `json:"name"`
-	Charset  string `json:"charset"`
+	Driver   string `json:"driver" yaml:"driver"` // mysql, postgres or sqlite
+	Path     string `json:"path" yaml:"path"`     // database file for sqlite
+	Host     string `json:"host" yaml:"host"`
+	Port     string `json:"port" yaml:"port"`
+	User     string `json:"user" yaml:"user"`
+	Password string `json:"password" yaml:"password"`
+	Name     string `json:"name" yaml:"name"`
+	Charset  string `json:"charset" yaml:"charset"`
+	Timezone string `json:"timezone" yaml:"timezone"`
+
+	// Connection pool
+	MaxOpenConns    int           `json:"max_open_conns" yaml:"max_open_conns"`
+	MaxIdleConns    int           `json:"max_idle_conns" yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime" yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time" yaml:"conn_max_idle_time"`
+
+	// Network timeouts
+	ConnectTimeout time.Duration `json:"connect_timeout" yaml:"connect_timeout"`
+	ReadTimeout    time.Duration `json:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout   time.Duration `json:"write_timeout" yaml:"write_timeout"`
+
+	// TLS (disable, preferred, required, skip-verify)
+	TLSMode       string `json:"tls_mode" yaml:"tls_mode"`
+	TLSCAFile     string `json:"tls_ca_file" yaml:"tls_ca_file"`
+	TLSServerName string `json:"tls_server_name" yaml:"tls_server_name"`
+
+	// Startup retry
+	ConnectRetries  int           `json:"connect_retries" yaml:"connect_retries"`
+	RetryBackoff    time.Duration `json:"retry_backoff" yaml:"retry_backoff"`
+	RetryMaxBackoff time.Duration `json:"retry_max_backoff" yaml:"retry_max_backoff"`
 }
 
 // GitHubConfig holds GitHub configuration
 type GitHubConfig struct {
-	Token      string `json:"token"`
-	WebhookURL string `json:"webhook_url"`
+	Token           string `json:"token" yaml:"token"`
+	WebhookURL      string `json:"webhook_url" yaml:"webhook_url"`
+	WebhooksEnabled bool   `json:"webhooks_enabled" yaml:"webhooks_enabled"`
+	WebhookSecret   string `json:"webhook_secret" yaml:"webhook_secret"`
+	APIURL          string `json:"api_url" yaml:"api_url"`
 }
 
-// Load loads configuration from environment variables
-func Load() *Config {
-	// Try to load .env file if it exists
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
-	}
+// AuthConfig holds sign-in configuration. Signing in with GitHub is enabled
+// by setting the client ID and secret of a GitHub OAuth app.
+type AuthConfig struct {
+	GitHubClientID     string `json:"github_client_id" yaml:"github_client_id"`
+	GitHubClientSecret string `json:"github_client_secret" yaml:"github_client_secret"`
+	GitHubURL          string `json:"github_url" yaml:"github_url"`     // where users authorize the app
+	CallbackURL        string `json:"callback_url" yaml:"callback_url"` // .../api/v1/auth/github/callback, as registered with the app
+	// LoginRedirectURL receives the issued token as #token=; empty responds with JSON
+	LoginRedirectURL string        `json:"login_redirect_url" yaml:"login_redirect_url"`
+	AllowSignup      bool          `json:"allow_signup" yaml:"allow_signup"` // create unknown users on first sign-in
+	SignupRole       string        `json:"signup_role" yaml:"signup_role"`
+	SessionTTL       time.Duration `json:"session_ttl" yaml:"session_ttl"` // lifetime of tokens issued at sign-in
+	// TokenMaxTTL is the default and longest lifetime of tokens issued
+	// through the API
+	TokenMaxTTL time.Duration `json:"token_max_ttl" yaml:"token_max_ttl"`
+}
+
+// LogConfig holds logging configuration
+type LogConfig struct {
+	Level  string `json:"level" yaml:"level"`
+	Format string `json:"format" yaml:"format"`
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Endpoint is the base URL of the OTLP/HTTP collector, such as
+	// http://localhost:4318, to which /v1/traces is appended. A bare
+	// host:port is also accepted and uses http when Insecure is set.
+	Endpoint    string  `json:"endpoint" yaml:"endpoint"`
+	Insecure    bool    `json:"insecure" yaml:"insecure"`
+	ServiceName string  `json:"service_name" yaml:"service_name"`
+	SampleRatio float64 `json:"sample_ratio" yaml:"sample_ratio"`
+}
+
+// AIConfig holds AI provider configuration
+type AIConfig struct {
+	Provider string `json:"provider" yaml:"provider"`
+	APIKey   string `json:"api_key" yaml:"api_key"`
+	BaseURL  string `json:"base_url" yaml:"base_url"`
+	Model    string `json:"model" yaml:"model"`
+	// DailyTokenLimit caps the tokens each user may spend per UTC day; 0 is unlimited
+	DailyTokenLimit int `json:"daily_token_limit" yaml:"daily_token_limit"`
+}
+
+// WorkflowConfig holds workflow runner configuration
+type WorkflowConfig struct {
+	WorkDir    string `json:"work_dir" yaml:"work_dir"`       // parent of the per-run directories
+	AllowShell bool   `json:"allow_shell" yaml:"allow_shell"` // shell steps run arbitrary commands
+}
+
+// WorkspaceConfig holds where connected repositories are cloned
+type WorkspaceConfig struct {
+	Dir          string        `json:"dir" yaml:"dir"`                     // clones live in <dir>/<owner>/<name>
+	CloneTimeout time.Duration `json:"clone_timeout" yaml:"clone_timeout"` // per clone or fetch
+}
+
+// NotifyConfig holds notification delivery configuration
+type NotifyConfig struct {
+	Timeout time.Duration `json:"timeout" yaml:"timeout"` // per delivery attempt
+	SMTP    SMTPConfig    `json:"smtp" yaml:"smtp"`
+	Push    PushConfig    `json:"push" yaml:"push"`
+}
+
+// SMTPConfig holds the mail server used by email notifications. An empty
+// host disables email.
+type SMTPConfig struct {
+	Host     string `json:"host" yaml:"host"`
+	Port     string `json:"port" yaml:"port"`
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+	From     string `json:"from" yaml:"from"`
+	TLS      string `json:"tls" yaml:"tls"` // none, starttls or tls
+}
+
+// PushConfig holds Web Push delivery configuration. Without a private key the
+// VAPID key pair is generated once and stored in the database.
+type PushConfig struct {
+	Enabled         bool          `json:"enabled" yaml:"enabled"`
+	Subject         string        `json:"subject" yaml:"subject"` // mailto: or https: contact for push services
+	VAPIDPrivateKey string        `json:"vapid_private_key" yaml:"vapid_private_key"`
+	TTL             time.Duration `json:"ttl" yaml:"ttl"` // how long push services keep undelivered messages
+}
 
+// RealtimeConfig holds WebSocket change stream configuration. Instances share
+// changes through the database, so every replica polls for new events.
+type RealtimeConfig struct {
+	PollInterval time.Duration `json:"poll_interval" yaml:"poll_interval"` // how often the event log is read
+	Retention    time.Duration `json:"retention" yaml:"retention"`         // how long events are kept
+	PingInterval time.Duration `json:"ping_interval" yaml:"ping_interval"` // clients missing two pings are closed
+	SendBuffer   int           `json:"send_buffer" yaml:"send_buffer"`     // messages queued per client before it is dropped
+}
+
+// Default returns the built-in configuration used before any source is applied.
+// Credentials have no defaults and must be provided explicitly.
+func Default() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Host: getEnv("SERVER_HOST", "localhost"),
+			Port:            "8080",
+			Host:            "localhost",
+			ShutdownTimeout: 30 * time.Second,
+			IdempotencyTTL:  24 * time.Hour,
+			// As large as task imports
+			IdempotencyMaxBody: 10 << 20,
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "3306"),
-			User:     getEnv("DB_USER", "root"),
-			Password: getEnv("DB_PASSWORD", ""),
-			Name:     getEnv("DB_NAME", "workflow"),
-			Charset:  getEnv("DB_CHARSET", "utf8mb4"),
+			Driver:   "mysql",
+			Path:     "workflow.db",
+			Host:     "localhost",
+			Name:     "workflow",
+			Charset:  "utf8mb4",
+			Timezone: "UTC",
+
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+
+			ConnectTimeout: 10 * time.Second,
+			ReadTimeout:    30 * time.Second,
+			WriteTimeout:   30 * time.Second,
+
+			TLSMode: "disable",
+
+			ConnectRetries:  5,
+			RetryBackoff:    time.Second,
+			RetryMaxBackoff: 30 * time.Second,
 		},
 		GitHub: GitHubConfig{
-			Token:      getEnv("GITHUB_TOKEN", ""),
-			WebhookURL: getEnv("GITHUB_WEBHOOK_URL", ""),
+			APIURL: "https://api.github.com",
+		},
+		Auth: AuthConfig{
+			GitHubURL:   "https://github.com",
+			SignupRole:  "member",
+			SessionTTL:  30 * 24 * time.Hour,
+			TokenMaxTTL: 90 * 24 * time.Hour,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
 		},
+		Tracing: TracingConfig{
+			Endpoint:    "http://localhost:4318",
+			Insecure:    true,
+			ServiceName: "workflow-backend",
+			SampleRatio: 1.0,
+		},
+		AI: AIConfig{
+			Provider: "anthropic",
+			BaseURL:  "https://api.anthropic.com",
+			Model:    "claude-sonnet-4-5",
+		},
+		Workflow: WorkflowConfig{
+			WorkDir: filepath.Join(os.TempDir(), "workflow-runs"),
+		},
+		Workspace: WorkspaceConfig{
+			Dir:          "workspace",
+			CloneTimeout: 10 * time.Minute,
+		},
+		Notify: NotifyConfig{
+			Timeout: 10 * time.Second,
+			SMTP: SMTPConfig{
+				Port: "587",
+				TLS:  "starttls",
+			},
+			Push: PushConfig{
+				TTL: 24 * time.Hour,
+			},
+		},
+		Realtime: RealtimeConfig{
+			PollInterval: time.Second,
+			Retention:    time.Hour,
+			PingInterval: 30 * time.Second,
+			SendBuffer:   256,
+		},
+	}
+}
+
+// Load builds the configuration from, in increasing order of precedence,
+// built-in defaults, an optional YAML/TOML config file, environment
+// variables and command line flags. It does not validate the result.
+func Load(args []string) (*Config, error) {
+	// Try to load .env file if it exists
+	if err := godotenv.Load(); err != nil {
+		slog.Info("no .env file found, using environment variables")
+	}
+
+	flags, err := parseFlags(args)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+
+	path := flags.configFile
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
 	}
+	if path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			return nil, err
+		}
+		slog.Info("loaded config file", "path", path)
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+	flags.apply(cfg)
+
+	return cfg, nil
+}
+
+// applyEnv overrides cfg with the environment variables that are set
+func applyEnv(cfg *Config) error {
+	env := &envReader{}
+
+	cfg.Server.Port = env.get("SERVER_PORT", cfg.Server.Port)
+	cfg.Server.Port = env.get("PORT", cfg.Server.Port)
+	cfg.Server.Host = env.get("SERVER_HOST", cfg.Server.Host)
+	cfg.Server.ShutdownTimeout = env.getDuration("SERVER_SHUTDOWN_TIMEOUT", cfg.Server.ShutdownTimeout)
+	cfg.Server.TrustProxy = env.getBool("SERVER_TRUST_PROXY", cfg.Server.TrustProxy)
+	cfg.Server.IdempotencyTTL = env.getDuration("SERVER_IDEMPOTENCY_TTL", cfg.Server.IdempotencyTTL)
+	cfg.Server.IdempotencyMaxBody = env.getInt("SERVER_IDEMPOTENCY_MAX_BODY", cfg.Server.IdempotencyMaxBody)
+
+	cfg.Database.Driver = env.get("DB_DRIVER", cfg.Database.Driver)
+	cfg.Database.Path = env.get("DB_PATH", cfg.Database.Path)
+	cfg.Database.Host = env.get("DB_HOST", cfg.Database.Host)
+	cfg.Database.Port = env.get("DB_PORT", cfg.Database.Port)
+	cfg.Database.User = env.get("DB_USER", cfg.Database.User)
+	cfg.Database.Password = env.get("DB_PASSWORD", cfg.Database.Password)
+	cfg.Database.Name = env.get("DB_NAME", cfg.Database.Name)
+	cfg.Database.Charset = env.get("DB_CHARSET", cfg.Database.Charset)
+	cfg.Database.Timezone = env.get("DB_TIMEZONE", cfg.Database.Timezone)
+	cfg.Database.MaxOpenConns = env.getInt("DB_MAX_OPEN_CONNS", cfg.Database.MaxOpenConns)
+	cfg.Database.MaxIdleConns = env.getInt("DB_MAX_IDLE_CONNS", cfg.Database.MaxIdleConns)
+	cfg.Database.ConnMaxLifetime = env.getDuration("DB_CONN_MAX_LIFETIME", cfg.Database.ConnMaxLifetime)
+	cfg.Database.ConnMaxIdleTime = env.getDuration("DB_CONN_MAX_IDLE_TIME", cfg.Database.ConnMaxIdleTime)
+	cfg.Database.ConnectTimeout = env.getDuration("DB_CONNECT_TIMEOUT", cfg.Database.ConnectTimeout)
+	cfg.Database.ReadTimeout = env.getDuration("DB_READ_TIMEOUT", cfg.Database.ReadTimeout)
+	cfg.Database.WriteTimeout = env.getDuration("DB_WRITE_TIMEOUT", cfg.Database.WriteTimeout)
+	cfg.Database.TLSMode = env.get("DB_TLS_MODE", cfg.Database.TLSMode)
+	cfg.Database.TLSCAFile = env.get("DB_TLS_CA_FILE", cfg.Database.TLSCAFile)
+	cfg.Database.TLSServerName = env.get("DB_TLS_SERVER_NAME", cfg.Database.TLSServerName)
+	cfg.Database.ConnectRetries = env.getInt("DB_CONNECT_RETRIES", cfg.Database.ConnectRetries)
+	cfg.Database.RetryBackoff = env.getDuration("DB_RETRY_BACKOFF", cfg.Database.RetryBackoff)
+	cfg.Database.RetryMaxBackoff = env.getDuration("DB_RETRY_MAX_BACKOFF", cfg.Database.RetryMaxBackoff)
+
+	cfg.GitHub.Token = env.get("GITHUB_TOKEN", cfg.GitHub.Token)
+	cfg.GitHub.WebhookURL = env.get("GITHUB_WEBHOOK_URL", cfg.GitHub.WebhookURL)
+	cfg.GitHub.WebhooksEnabled = env.getBool("GITHUB_WEBHOOKS_ENABLED", cfg.GitHub.WebhooksEnabled)
+	cfg.GitHub.WebhookSecret = env.get("GITHUB_WEBHOOK_SECRET", cfg.GitHub.WebhookSecret)
+	cfg.GitHub.APIURL = env.get("GITHUB_API_URL", cfg.GitHub.APIURL)
+
+	cfg.Auth.GitHubClientID = env.get("AUTH_GITHUB_CLIENT_ID", cfg.Auth.GitHubClientID)
+	cfg.Auth.GitHubClientSecret = env.get("AUTH_GITHUB_CLIENT_SECRET", cfg.Auth.GitHubClientSecret)
+	cfg.Auth.GitHubURL = env.get("AUTH_GITHUB_URL", cfg.Auth.GitHubURL)
+	cfg.Auth.CallbackURL = env.get("AUTH_CALLBACK_URL", cfg.Auth.CallbackURL)
+	cfg.Auth.LoginRedirectURL = env.get("AUTH_LOGIN_REDIRECT_URL", cfg.Auth.LoginRedirectURL)
+	cfg.Auth.AllowSignup = env.getBool("AUTH_ALLOW_SIGNUP", cfg.Auth.AllowSignup)
+	cfg.Auth.SignupRole = env.get("AUTH_SIGNUP_ROLE", cfg.Auth.SignupRole)
+	cfg.Auth.SessionTTL = env.getDuration("AUTH_SESSION_TTL", cfg.Auth.SessionTTL)
+	cfg.Auth.TokenMaxTTL = env.getDuration("AUTH_TOKEN_MAX_TTL", cfg.Auth.TokenMaxTTL)
+
+	cfg.Log.Level = env.get("LOG_LEVEL", cfg.Log.Level)
+	cfg.Log.Format = env.get("LOG_FORMAT", cfg.Log.Format)
+
+	cfg.Tracing.Enabled = env.getBool("TRACING_ENABLED", cfg.Tracing.Enabled)
+	cfg.Tracing.Endpoint = env.get("OTEL_EXPORTER_OTLP_ENDPOINT", cfg.Tracing.Endpoint)
+	cfg.Tracing.Insecure = env.getBool("OTEL_EXPORTER_OTLP_INSECURE", cfg.Tracing.Insecure)
+	cfg.Tracing.ServiceName = env.get("OTEL_SERVICE_NAME", cfg.Tracing.ServiceName)
+	cfg.Tracing.SampleRatio = env.getFloat("TRACING_SAMPLE_RATIO", cfg.Tracing.SampleRatio)
+
+	cfg.AI.Provider = env.get("AI_PROVIDER", cfg.AI.Provider)
+	cfg.AI.APIKey = env.get("AI_API_KEY", cfg.AI.APIKey)
+	cfg.AI.BaseURL = env.get("AI_BASE_URL", cfg.AI.BaseURL)
+	cfg.AI.Model = env.get("AI_MODEL", cfg.AI.Model)
+	cfg.AI.DailyTokenLimit = env.getInt("AI_DAILY_TOKEN_LIMIT", cfg.AI.DailyTokenLimit)
+
+	cfg.Workflow.WorkDir = env.get("WORKFLOW_WORK_DIR", cfg.Workflow.WorkDir)
+	cfg.Workflow.AllowShell = env.getBool("WORKFLOW_ALLOW_SHELL", cfg.Workflow.AllowShell)
+
+	cfg.Workspace.Dir = env.get("WORKSPACE_DIR", cfg.Workspace.Dir)
+	cfg.Workspace.CloneTimeout = env.getDuration("WORKSPACE_CLONE_TIMEOUT", cfg.Workspace.CloneTimeout)
+
+	cfg.Notify.Timeout = env.getDuration("NOTIFY_TIMEOUT", cfg.Notify.Timeout)
+	cfg.Notify.SMTP.Host = env.get("SMTP_HOST", cfg.Notify.SMTP.Host)
+	cfg.Notify.SMTP.Port = env.get("SMTP_PORT", cfg.Notify.SMTP.Port)
+	cfg.Notify.SMTP.Username = env.get("SMTP_USERNAME", cfg.Notify.SMTP.Username)
+	cfg.Notify.SMTP.Password = env.get("SMTP_PASSWORD", cfg.Notify.SMTP.Password)
+	cfg.Notify.SMTP.From = env.get("SMTP_FROM", cfg.Notify.SMTP.From)
+	cfg.Notify.SMTP.TLS = env.get("SMTP_TLS", cfg.Notify.SMTP.TLS)
+	cfg.Notify.Push.Enabled = env.getBool("PUSH_ENABLED", cfg.Notify.Push.Enabled)
+	cfg.Notify.Push.Subject = env.get("PUSH_SUBJECT", cfg.Notify.Push.Subject)
+	cfg.Notify.Push.VAPIDPrivateKey = env.get("PUSH_VAPID_PRIVATE_KEY", cfg.Notify.Push.VAPIDPrivateKey)
+	cfg.Notify.Push.TTL = env.getDuration("PUSH_TTL", cfg.Notify.Push.TTL)
+	cfg.Realtime.PollInterval = env.getDuration("REALTIME_POLL_INTERVAL", cfg.Realtime.PollInterval)
+	cfg.Realtime.Retention = env.getDuration("REALTIME_RETENTION", cfg.Realtime.Retention)
+	cfg.Realtime.PingInterval = env.getDuration("REALTIME_PING_INTERVAL", cfg.Realtime.PingInterval)
+	cfg.Realtime.SendBuffer = env.getInt("REALTIME_SEND_BUFFER", cfg.Realtime.SendBuffer)
+
+	return errors.Join(env.errs...)
+}
+
+// envReader reads environment variables and collects values that fail to parse
+type envReader struct {
+	errs []error
 }
 
-// getEnv gets environment variable with fallback
-func getEnv(key, defaultValue string) string {
+// get gets environment variable with fallback
+func (r *envReader) get(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// getBool gets a boolean environment variable with fallback
+func (r *envReader) getBool(key string, defaultValue bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s: invalid boolean %q", key, raw))
+		return defaultValue
+	}
+	return value
+}
+
+// getInt gets an integer environment variable with fallback
+func (r *envReader) getInt(key string, defaultValue int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s: invalid integer %q", key, raw))
+		return defaultValue
+	}
+	return value
+}
+
+// getFloat gets a float environment variable with fallback
+func (r *envReader) getFloat(key string, defaultValue float64) float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s: invalid number %q", key, raw))
+		return defaultValue
+	}
+	return value
+}
+
+// getDuration gets a duration environment variable (e.g. "30s") with fallback
+func (r *envReader) getDuration(key string, defaultValue time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s: invalid duration %q", key, raw))
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+// flagValues holds the command line flags, applied on top of file and env values
+type flagValues struct {
+	configFile string
+	set        map[string]bool
+
+	port            string
+	host            string
+	shutdownTimeout time.Duration
+	dbDriver        string
+	dbPath          string
+	dbHost          string
+	dbPort          string
+	dbName          string
+	logLevel        string
+	logFormat       string
+	tracingEnabled  bool
+}
+
+// parseFlags parses command line arguments such as -config and -port
+func parseFlags(args []string) (*flagValues, error) {
+	f := &flagValues{set: make(map[string]bool)}
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.StringVar(&f.configFile, "config", "", "path to a YAML or TOML config file")
+	fs.StringVar(&f.port, "port", "", "HTTP port to listen on")
+	fs.StringVar(&f.host, "host", "", "HTTP host to listen on")
+	fs.DurationVar(&f.shutdownTimeout, "shutdown-timeout", 0, "time to wait for requests and tasks on shutdown")
+	fs.StringVar(&f.dbDriver, "db-driver", "", "database driver (mysql, postgres, sqlite)")
+	fs.StringVar(&f.dbPath, "db-path", "", "database file for the sqlite driver")
+	fs.StringVar(&f.dbHost, "db-host", "", "database host")
+	fs.StringVar(&f.dbPort, "db-port", "", "database port")
+	fs.StringVar(&f.dbName, "db-name", "", "database name")
+	fs.StringVar(&f.logLevel, "log-level", "", "log level (debug, info, warn, error)")
+	fs.StringVar(&f.logFormat, "log-format", "", "log format (json, text)")
+	fs.BoolVar(&f.tracingEnabled, "tracing", false, "enable OpenTelemetry tracing")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("error parsing flags: %w", err)
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	fs.Visit(func(fl *flag.Flag) {
+		f.set[fl.Name] = true
+	})
+
+	return f, nil
+}
+
+// apply overrides cfg with the flags that were passed explicitly
+func (f *flagValues) apply(cfg *Config) {
+	if f.set["port"] {
+		cfg.Server.Port = f.port
+	}
+	if f.set["host"] {
+		cfg.Server.Host = f.host
+	}
+	if f.set["shutdown-timeout"] {
+		cfg.Server.ShutdownTimeout = f.shutdownTimeout
+	}
+	if f.set["db-driver"] {
+		cfg.Database.Driver = f.dbDriver
+	}
+	if f.set["db-path"] {
+		cfg.Database.Path = f.dbPath
+	}
+	if f.set["db-host"] {
+		cfg.Database.Host = f.dbHost
+	}
+	if f.set["db-port"] {
+		cfg.Database.Port = f.dbPort
+	}
+	if f.set["db-name"] {
+		cfg.Database.Name = f.dbName
+	}
+	if f.set["log-level"] {
+		cfg.Log.Level = f.logLevel
+	}
+	if f.set["log-format"] {
+		cfg.Log.Format = f.logFormat
+	}
+	if f.set["tracing"] {
+		cfg.Tracing.Enabled = f.tracingEnabled
+	}
+}
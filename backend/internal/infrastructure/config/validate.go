@@ -0,0 +1,246 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redactedValue replaces secrets when the configuration is printed
+const redactedValue = "[REDACTED]"
+
+// Validate checks the configuration and reports every problem at once
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if !validPort(c.Server.Port) {
+		add("server.port: %q is not a valid port", c.Server.Port)
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		add("server.shutdown_timeout: must be positive")
+	}
+	if c.Server.IdempotencyTTL < time.Minute {
+		add("server.idempotency_ttl: must be at least 1m")
+	}
+	if c.Server.IdempotencyMaxBody <= 0 {
+		add("server.idempotency_max_body: must be positive")
+	}
+
+	switch c.Database.Driver {
+	case "mysql", "postgres":
+		if c.Database.Host == "" {
+			add("database.host: is required")
+		}
+		if c.Database.Port != "" && !validPort(c.Database.Port) {
+			add("database.port: %q is not a valid port", c.Database.Port)
+		}
+		if c.Database.User == "" {
+			add("database.user: is required (DB_USER)")
+		}
+		if c.Database.Password == "" {
+			add("database.password: is required (DB_PASSWORD)")
+		}
+		if c.Database.Name == "" {
+			add("database.name: is required")
+		}
+	case "sqlite":
+		if c.Database.Path == "" {
+			add("database.path: is required for the sqlite driver (DB_PATH)")
+		}
+	default:
+		add("database.driver: %q must be one of mysql, postgres, sqlite", c.Database.Driver)
+	}
+	if _, err := time.LoadLocation(c.Database.Timezone); err != nil {
+		add("database.timezone: %q is not a known time zone", c.Database.Timezone)
+	}
+	if c.Database.MaxOpenConns < 0 {
+		add("database.max_open_conns: must not be negative")
+	}
+	if c.Database.MaxIdleConns < 0 {
+		add("database.max_idle_conns: must not be negative")
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		add("database.max_idle_conns: %d exceeds max_open_conns %d", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	}
+	if c.Database.ConnectTimeout < 0 || c.Database.ReadTimeout < 0 || c.Database.WriteTimeout < 0 {
+		add("database: timeouts must not be negative")
+	}
+	switch c.Database.TLSMode {
+	case "disable":
+		if c.Database.TLSCAFile != "" {
+			add("database.tls_ca_file: is set but tls_mode is disable")
+		}
+	case "preferred", "required", "skip-verify":
+	default:
+		add("database.tls_mode: %q must be one of disable, preferred, required, skip-verify", c.Database.TLSMode)
+	}
+	if c.Database.ConnectRetries < 0 {
+		add("database.connect_retries: must not be negative")
+	}
+	if c.Database.RetryBackoff <= 0 || c.Database.RetryMaxBackoff < c.Database.RetryBackoff {
+		add("database.retry_backoff: must be positive and not exceed retry_max_backoff")
+	}
+
+	if c.GitHub.WebhooksEnabled && c.GitHub.WebhookSecret == "" {
+		add("github.webhook_secret: is required when webhooks are enabled (GITHUB_WEBHOOK_SECRET)")
+	}
+	if !validURL(c.GitHub.APIURL) {
+		add("github.api_url: %q is not a valid URL", c.GitHub.APIURL)
+	}
+
+	if c.Auth.GitHubClientID != "" {
+		if c.Auth.GitHubClientSecret == "" {
+			add("auth.github_client_secret: is required when a client ID is set (AUTH_GITHUB_CLIENT_SECRET)")
+		}
+		if !validURL(c.Auth.CallbackURL) {
+			add("auth.callback_url: %q is not a valid URL (AUTH_CALLBACK_URL)", c.Auth.CallbackURL)
+		}
+		if !validURL(c.Auth.GitHubURL) {
+			add("auth.github_url: %q is not a valid URL", c.Auth.GitHubURL)
+		}
+		if c.Auth.LoginRedirectURL != "" && !validURL(c.Auth.LoginRedirectURL) {
+			add("auth.login_redirect_url: %q is not a valid URL", c.Auth.LoginRedirectURL)
+		}
+	}
+	switch c.Auth.SignupRole {
+	case "admin", "member", "viewer":
+	default:
+		add("auth.signup_role: %q must be one of admin, member, viewer", c.Auth.SignupRole)
+	}
+	if c.Auth.SessionTTL <= 0 {
+		add("auth.session_ttl: must be positive")
+	}
+	if c.Auth.TokenMaxTTL <= 0 {
+		add("auth.token_max_ttl: must be positive")
+	}
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		add("log.level: %q must be one of debug, info, warn, error", c.Log.Level)
+	}
+	switch c.Log.Format {
+	case "json", "text":
+	default:
+		add("log.format: %q must be one of json, text", c.Log.Format)
+	}
+
+	if c.Tracing.Enabled && c.Tracing.Endpoint == "" {
+		add("tracing.endpoint: is required when tracing is enabled")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio: %v must be between 0 and 1", c.Tracing.SampleRatio)
+	}
+
+	if c.AI.Provider != "anthropic" {
+		add("ai.provider: %q is not supported", c.AI.Provider)
+	}
+	if !validURL(c.AI.BaseURL) {
+		add("ai.base_url: %q is not a valid URL", c.AI.BaseURL)
+	}
+	if c.AI.DailyTokenLimit < 0 {
+		add("ai.daily_token_limit: must not be negative")
+	}
+
+	if c.Workflow.WorkDir == "" {
+		add("workflow.work_dir: is required (WORKFLOW_WORK_DIR)")
+	}
+
+	if c.Workspace.Dir == "" {
+		add("workspace.dir: is required (WORKSPACE_DIR)")
+	}
+	if c.Workspace.CloneTimeout <= 0 {
+		add("workspace.clone_timeout: must be positive")
+	}
+
+	if c.Notify.Timeout <= 0 {
+		add("notify.timeout: must be positive")
+	}
+	if c.Notify.SMTP.Host != "" {
+		if c.Notify.SMTP.From == "" {
+			add("notify.smtp.from: is required when an SMTP host is set (SMTP_FROM)")
+		}
+		if _, err := strconv.Atoi(c.Notify.SMTP.Port); err != nil {
+			add("notify.smtp.port: %q is not a valid port", c.Notify.SMTP.Port)
+		}
+		switch c.Notify.SMTP.TLS {
+		case "none", "starttls", "tls":
+		default:
+			add("notify.smtp.tls: %q must be one of none, starttls, tls", c.Notify.SMTP.TLS)
+		}
+	}
+	if c.Notify.Push.Enabled {
+		subject := c.Notify.Push.Subject
+		if !strings.HasPrefix(subject, "mailto:") && !strings.HasPrefix(subject, "https://") {
+			add("notify.push.subject: %q must be a mailto: or https: URI (PUSH_SUBJECT)", subject)
+		}
+		if c.Notify.Push.TTL < 0 {
+			add("notify.push.ttl: must not be negative")
+		}
+	}
+
+	if c.Realtime.PollInterval <= 0 {
+		add("realtime.poll_interval: must be positive")
+	}
+	if c.Realtime.Retention < time.Minute {
+		add("realtime.retention: must be at least 1m")
+	}
+	if c.Realtime.PingInterval < time.Second {
+		add("realtime.ping_interval: must be at least 1s")
+	}
+	if c.Realtime.SendBuffer < 1 {
+		add("realtime.send_buffer: must be positive")
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of the configuration with secrets masked
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.Database.Password = redact(c.Database.Password)
+	redacted.GitHub.Token = redact(c.GitHub.Token)
+	redacted.GitHub.WebhookSecret = redact(c.GitHub.WebhookSecret)
+	redacted.Auth.GitHubClientSecret = redact(c.Auth.GitHubClientSecret)
+	redacted.AI.APIKey = redact(c.AI.APIKey)
+	redacted.Notify.SMTP.Password = redact(c.Notify.SMTP.Password)
+	redacted.Notify.Push.VAPIDPrivateKey = redact(c.Notify.Push.VAPIDPrivateKey)
+	return &redacted
+}
+
+// String renders the configuration as YAML with secrets masked
+func (c *Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("<invalid config: %v>", err)
+	}
+	return string(out)
+}
+
+// redact masks a secret while keeping whether it was set visible
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+// validPort reports whether port is a TCP port number
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// validURL reports whether raw is an absolute http(s) URL
+func validURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+const activityColumns = `id, action, resource_type, resource_id, actor_id, actor, changes, ip, request_id,
+	user_agent, created_at`
+
+var activityKeyset = keyset[entities.ActivityLog]{
+	columns: map[string]sortColumn{
+		"id":         {"id", kindInt},
+		"created_at": {"created_at", kindTime},
+	},
+	defaults: []repositories.SortField{{Field: "id", Desc: true}},
+	value: func(a *entities.ActivityLog, field string) interface{} {
+		switch field {
+		case "id":
+			return a.ID
+		case "created_at":
+			return a.CreatedAt
+		}
+		return nil
+	},
+}
+
+// ActivityStore persists the audit log in the activity_logs table
+type ActivityStore struct {
+	db *DB
+}
+
+var _ repositories.ActivityRepository = (*ActivityStore)(nil)
+
+// NewActivityStore creates a new ActivityStore
+func NewActivityStore(db *DB) *ActivityStore {
+	return &ActivityStore{db: db}
+}
+
+// Append stores an entry, assigning an id and creation time
+func (s *ActivityStore) Append(ctx context.Context, entry *entities.ActivityLog) error {
+	var changes sql.NullString
+	if len(entry.Changes) > 0 {
+		var err error
+		if changes, err = marshalJSON(entry.Changes); err != nil {
+			return err
+		}
+	}
+
+	entry.CreatedAt = now()
+	id, err := s.db.dialect.InsertID(ctx, s.db, `INSERT INTO activity_logs
+		(action, resource_type, resource_id, actor_id, actor, changes, ip, request_id, user_agent, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Action, entry.ResourceType, entry.ResourceID, entry.ActorID, entry.Actor, changes, entry.IP,
+		entry.RequestID, entry.UserAgent, entry.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error appending activity log: %w", err)
+	}
+	entry.ID = id
+	return nil
+}
+
+// List returns a page of entries matching filter
+func (s *ActivityStore) List(ctx context.Context, filter repositories.ActivityFilter, opts repositories.ListOptions) (repositories.Page[entities.ActivityLog], error) {
+	p, err := activityKeyset.resolve(opts)
+	if err != nil {
+		return repositories.Page[entities.ActivityLog]{}, err
+	}
+
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if len(filter.Action) > 0 {
+		conds = append(conds, "action IN ("+placeholders(len(filter.Action))+")")
+		for _, action := range filter.Action {
+			args = append(args, action)
+		}
+	}
+	for _, eq := range []struct{ column, value string }{
+		{"actor", filter.Actor},
+		{"resource_type", filter.ResourceType},
+		{"resource_id", filter.ResourceID},
+		{"request_id", filter.RequestID},
+	} {
+		if eq.value != "" {
+			conds = append(conds, eq.column+" = ?")
+			args = append(args, eq.value)
+		}
+	}
+	for _, bound := range []struct {
+		cond  string
+		value *time.Time
+	}{
+		{"created_at >= ?", filter.Since},
+		{"created_at < ?", filter.Until},
+	} {
+		if bound.value != nil {
+			conds = append(conds, bound.cond)
+			args = append(args, bound.value.UTC())
+		}
+	}
+	if cond, condArgs := activityKeyset.where(p); cond != "" {
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+	}
+
+	query := "SELECT " + activityColumns + " FROM activity_logs" +
+		whereClause(conds) + activityKeyset.orderBy(p) + " LIMIT ?"
+	args = append(args, p.limit+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return repositories.Page[entities.ActivityLog]{}, fmt.Errorf("error listing activity logs: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []entities.ActivityLog{}
+	for rows.Next() {
+		var (
+			entry   entities.ActivityLog
+			actorID sql.NullInt64
+			changes sql.NullString
+		)
+		if err := rows.Scan(&entry.ID, &entry.Action, &entry.ResourceType, &entry.ResourceID, &actorID,
+			&entry.Actor, &changes, &entry.IP, &entry.RequestID, &entry.UserAgent, &entry.CreatedAt); err != nil {
+			return repositories.Page[entities.ActivityLog]{}, fmt.Errorf("error scanning activity log: %w", err)
+		}
+		entry.ActorID = nullInt64(actorID)
+		if err := unmarshalJSON(changes, &entry.Changes); err != nil {
+			return repositories.Page[entities.ActivityLog]{}, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return repositories.Page[entities.ActivityLog]{}, fmt.Errorf("error listing activity logs: %w", err)
+	}
+	return activityKeyset.finish(p, entries)
+}
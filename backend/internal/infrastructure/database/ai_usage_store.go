@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// AIUsageStore persists AI usage in the ai_usage table
+type AIUsageStore struct {
+	db *DB
+}
+
+var _ repositories.AIUsageRepository = (*AIUsageStore)(nil)
+
+// NewAIUsageStore creates a new AIUsageStore
+func NewAIUsageStore(db *DB) *AIUsageStore {
+	return &AIUsageStore{db: db}
+}
+
+// Append stores a usage record, assigning an id and creation time
+func (s *AIUsageStore) Append(ctx context.Context, usage *entities.AIUsage) error {
+	usage.CreatedAt = now()
+	id, err := s.db.dialect.InsertID(ctx, s.db, `INSERT INTO ai_usage
+		(user_id, source, task_id, model, input_tokens, output_tokens, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		usage.UserID, usage.Source, usage.TaskID, usage.Model, usage.InputTokens, usage.OutputTokens, usage.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error recording ai usage: %w", err)
+	}
+	usage.ID = id
+	return nil
+}
+
+// Totals sums the usage of a user since t
+func (s *AIUsageStore) Totals(ctx context.Context, userID int64, since time.Time) (entities.AIUsageTotals, error) {
+	var totals entities.AIUsageTotals
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*), COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0)
+		FROM ai_usage WHERE user_id = ? AND created_at >= ?`, userID, since.UTC(),
+	).Scan(&totals.Requests, &totals.InputTokens, &totals.OutputTokens)
+	if err != nil {
+		return totals, fmt.Errorf("error summing ai usage: %w", err)
+	}
+	totals.TotalTokens = totals.InputTokens + totals.OutputTokens
+	return totals, nil
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// ChangeEventStore persists change events in the change_events table
+type ChangeEventStore struct {
+	db *DB
+}
+
+var _ repositories.ChangeEventRepository = (*ChangeEventStore)(nil)
+
+// NewChangeEventStore creates a new ChangeEventStore
+func NewChangeEventStore(db *DB) *ChangeEventStore {
+	return &ChangeEventStore{db: db}
+}
+
+// Append stores an event and sets its ID and creation time. A task event is
+// private when a private repository matches the task's repository by full
+// name or name, the same rule search uses to hide tasks.
+func (s *ChangeEventStore) Append(ctx context.Context, event *entities.ChangeEvent) error {
+	event.CreatedAt = now()
+
+	if strings.HasPrefix(event.Type, "task.") && event.Repository != "" {
+		var ownerID sql.NullInt64
+		err := s.db.QueryRowContext(ctx, `SELECT private, owner_id FROM repositories
+			WHERE full_name = ? OR name = ? ORDER BY private DESC, id LIMIT 1`,
+			event.Repository, event.Repository).Scan(&event.Private, &ownerID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("error resolving change event visibility: %w", err)
+		}
+		event.OwnerID = nullInt64(ownerID)
+	}
+
+	id, err := s.db.dialect.InsertID(ctx, s.db, `INSERT INTO change_events
+		(type, entity_id, repository, data, private, owner_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		event.Type, event.EntityID, event.Repository, string(event.Data), event.Private, event.OwnerID, event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error appending change event: %w", err)
+	}
+	event.ID = id
+	return nil
+}
+
+// ListAfter returns up to limit events with an ID above afterID, oldest first
+func (s *ChangeEventStore) ListAfter(ctx context.Context, afterID int64, limit int) ([]entities.ChangeEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, type, entity_id, repository, data, private, owner_id, created_at
+		FROM change_events WHERE id > ? ORDER BY id LIMIT ?`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing change events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []entities.ChangeEvent{}
+	for rows.Next() {
+		var (
+			event   entities.ChangeEvent
+			data    string
+			ownerID sql.NullInt64
+		)
+		if err := rows.Scan(&event.ID, &event.Type, &event.EntityID, &event.Repository, &data,
+			&event.Private, &ownerID, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning change event: %w", err)
+		}
+		event.Data = []byte(data)
+		event.OwnerID = nullInt64(ownerID)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// LatestID returns the highest event ID, or 0 when the log is empty
+func (s *ChangeEventStore) LatestID(ctx context.Context) (int64, error) {
+	var id sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, "SELECT MAX(id) FROM change_events").Scan(&id); err != nil {
+		return 0, fmt.Errorf("error getting latest change event: %w", err)
+	}
+	return id.Int64, nil
+}
+
+// DeleteBefore removes events created before t
+func (s *ChangeEventStore) DeleteBefore(ctx context.Context, t time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM change_events WHERE created_at < ?", t.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error deleting change events: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ai-git-workbench/internal/infrastructure/config"
+)
+
+// DB holds the database connection
+type DB struct {
+	*sql.DB
+
+	dialect Dialect
+	name    string
+}
+
+// Open connects to the database selected by cfg.Driver and applies pending migrations
+func Open(ctx context.Context, cfg *config.DatabaseConfig) (*DB, error) {
+	var (
+		db  *DB
+		err error
+	)
+	switch cfg.Driver {
+	case "", "mysql":
+		db, err = NewMySQLConnection(ctx, cfg)
+	case "postgres":
+		db, err = NewPostgresConnection(ctx, cfg)
+	case "sqlite":
+		db, err = NewSQLiteConnection(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// connect configures the pool of an opened *sql.DB and waits for the server
+// to answer. The first ping is retried with exponential backoff so the server
+// can start before the database is ready.
+func connect(ctx context.Context, sqlDB *sql.DB, dialect Dialect, cfg *config.DatabaseConfig) (*DB, error) {
+	// Configure connection pool
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	// Test the connection
+	if err := pingWithRetry(ctx, sqlDB, cfg); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+
+	name := cfg.Name
+	if dialect.Name() == "sqlite" {
+		name = cfg.Path
+	}
+
+	slog.Info("connected to database",
+		"driver", dialect.Name(),
+		"user", cfg.User,
+		"host", cfg.Host,
+		"database", name,
+		"tls_mode", cfg.TLSMode,
+		"max_open_conns", cfg.MaxOpenConns,
+		"max_idle_conns", cfg.MaxIdleConns,
+	)
+
+	return &DB{DB: sqlDB, dialect: dialect, name: name}, nil
+}
+
+// pingWithRetry pings the database until it answers, the retries are
+// exhausted or ctx is cancelled
+func pingWithRetry(ctx context.Context, db *sql.DB, cfg *config.DatabaseConfig) error {
+	backoff := cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		pingCtx, cancel := ctx, context.CancelFunc(func() {})
+		if cfg.ConnectTimeout > 0 {
+			pingCtx, cancel = context.WithTimeout(ctx, cfg.ConnectTimeout)
+		}
+		err := db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt >= cfg.ConnectRetries {
+			return err
+		}
+
+		slog.Warn("database not ready, retrying",
+			"attempt", attempt+1,
+			"max_retries", cfg.ConnectRetries,
+			"backoff", backoff.String(),
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > cfg.RetryMaxBackoff {
+			backoff = cfg.RetryMaxBackoff
+		}
+	}
+}
+
+// port returns the configured port or the driver default
+func port(cfg *config.DatabaseConfig, defaultPort string) string {
+	if cfg.Port != "" {
+		return cfg.Port
+	}
+	return defaultPort
+}
+
+// Dialect returns the SQL dialect of the connected database
+func (db *DB) Dialect() Dialect {
+	return db.dialect
+}
+
+// Close closes the database connection
+func (db *DB) Close() error {
+	return db.DB.Close()
+}
+
+// Ping tests the database connection
+func (db *DB) Ping() error {
+	return db.DB.Ping()
+}
+
+// GetStats returns database connection statistics
+func (db *DB) GetStats() sql.DBStats {
+	return db.DB.Stats()
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Dialect hides the SQL differences between the supported databases
+type Dialect interface {
+	// Name returns the driver name used in configuration (mysql, postgres, sqlite)
+	Name() string
+	// Rebind converts ? placeholders into the dialect's placeholder syntax
+	Rebind(query string) string
+	// InsertID runs an INSERT into a table with an auto-increment id column and returns the new id
+	InsertID(ctx context.Context, q querier, query string, args ...interface{}) (int64, error)
+	// IsUniqueViolation reports whether err is a unique constraint violation
+	IsUniqueViolation(err error) bool
+	// Schema returns the column types and options used to render migrations
+	Schema() SchemaTypes
+}
+
+// SchemaTypes are dialect specific fragments substituted into migrations
+type SchemaTypes struct {
+	Dialect       string
+	AutoIncrement string
+	Timestamp     string
+	Bool          string
+	Text          string
+	TableOptions  string
+}
+
+// mysqlDialect implements Dialect for MySQL 8
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return "mysql" }
+
+func (mysqlDialect) Rebind(query string) string { return query }
+
+func (mysqlDialect) InsertID(ctx context.Context, q querier, query string, args ...interface{}) (int64, error) {
+	return execInsertID(ctx, q, query, args...)
+}
+
+func (mysqlDialect) IsUniqueViolation(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+func (mysqlDialect) Schema() SchemaTypes {
+	return SchemaTypes{
+		Dialect:       "mysql",
+		AutoIncrement: "BIGINT AUTO_INCREMENT PRIMARY KEY",
+		Timestamp:     "DATETIME(6)",
+		Bool:          "BOOLEAN",
+		Text:          "TEXT",
+		TableOptions:  "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci",
+	}
+}
+
+// postgresDialect implements Dialect for PostgreSQL
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+// Rebind replaces each ? with $1, $2, ... outside of quoted strings
+func (postgresDialect) Rebind(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	inQuote := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+			b.WriteRune(r)
+		case r == '?' && !inQuote:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (postgresDialect) InsertID(ctx context.Context, q querier, query string, args ...interface{}) (int64, error) {
+	var id int64
+	err := q.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&id)
+	return id, err
+}
+
+func (postgresDialect) IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (postgresDialect) Schema() SchemaTypes {
+	return SchemaTypes{
+		Dialect:       "postgres",
+		AutoIncrement: "BIGSERIAL PRIMARY KEY",
+		Timestamp:     "TIMESTAMPTZ",
+		Bool:          "BOOLEAN",
+		Text:          "TEXT",
+	}
+}
+
+// sqliteDialect implements Dialect for SQLite
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return "sqlite" }
+
+func (sqliteDialect) Rebind(query string) string { return query }
+
+func (sqliteDialect) InsertID(ctx context.Context, q querier, query string, args ...interface{}) (int64, error) {
+	return execInsertID(ctx, q, query, args...)
+}
+
+func (sqliteDialect) IsUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+func (sqliteDialect) Schema() SchemaTypes {
+	return SchemaTypes{
+		Dialect:       "sqlite",
+		AutoIncrement: "INTEGER PRIMARY KEY AUTOINCREMENT",
+		Timestamp:     "TIMESTAMP",
+		Bool:          "BOOLEAN",
+		Text:          "TEXT",
+	}
+}
+
+// execInsertID runs an INSERT and returns the driver's last insert id
+func execInsertID(ctx context.Context, q querier, query string, args ...interface{}) (int64, error) {
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
@@ -0,0 +1,32 @@
+package database
+
+import "testing"
+
+func TestPostgresRebind(t *testing.T) {
+	for _, tc := range []struct {
+		name, query, want string
+	}{
+		{"no placeholders", "SELECT 1", "SELECT 1"},
+		{"one", "SELECT * FROM tasks WHERE id = ?", "SELECT * FROM tasks WHERE id = $1"},
+		{"numbered in order", "UPDATE tasks SET title = ?, status = ? WHERE id = ? AND version = ?",
+			"UPDATE tasks SET title = $1, status = $2 WHERE id = $3 AND version = $4"},
+		{"more than nine", "VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)"},
+		{"quoted question mark", "SELECT '?' FROM tasks WHERE id = ?", "SELECT '?' FROM tasks WHERE id = $1"},
+		{"escaped quote", "SELECT 'it''s ?' WHERE a = ? AND b = 'x?'", "SELECT 'it''s ?' WHERE a = $1 AND b = 'x?'"},
+		{"multibyte text", "SELECT '태스크?' WHERE title = ?", "SELECT '태스크?' WHERE title = $1"},
+	} {
+		if got := (postgresDialect{}).Rebind(tc.query); got != tc.want {
+			t.Errorf("%s: Rebind(%q) = %q, want %q", tc.name, tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestOtherDialectsKeepPlaceholders(t *testing.T) {
+	const query = "SELECT * FROM tasks WHERE id = ? AND title = '?'"
+	for _, d := range []Dialect{mysqlDialect{}, sqliteDialect{}} {
+		if got := d.Rebind(query); got != query {
+			t.Errorf("%s: Rebind(%q) = %q, want it unchanged", d.Name(), query, got)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// IdempotencyStore persists idempotency keys in the idempotency_keys table
+type IdempotencyStore struct {
+	db *DB
+}
+
+var _ repositories.IdempotencyKeyRepository = (*IdempotencyStore)(nil)
+
+// NewIdempotencyStore creates a new IdempotencyStore
+func NewIdempotencyStore(db *DB) *IdempotencyStore {
+	return &IdempotencyStore{db: db}
+}
+
+// Reserve stores a key without a response. A key past its expiry that has
+// not been deleted yet is replaced.
+func (s *IdempotencyStore) Reserve(ctx context.Context, key *entities.IdempotencyKey) (*entities.IdempotencyKey, error) {
+	key.CreatedAt = now()
+	for {
+		_, err := s.db.ExecContext(ctx, `INSERT INTO idempotency_keys
+			(scope, idempotency_key, request_hash, created_at, expires_at) VALUES (?, ?, ?, ?, ?)`,
+			key.Scope, key.Key, key.RequestHash, key.CreatedAt, key.ExpiresAt.UTC())
+		if err == nil {
+			return nil, nil
+		}
+		if !s.db.dialect.IsUniqueViolation(err) {
+			return nil, fmt.Errorf("error reserving idempotency key: %w", err)
+		}
+
+		existing, err := s.get(ctx, key.Scope, key.Key)
+		if errors.Is(err, repositories.ErrNotFound) {
+			// Deleted in the meantime
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if existing.ExpiresAt.After(key.CreatedAt) {
+			return existing, repositories.ErrConflict
+		}
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys
+			WHERE scope = ? AND idempotency_key = ? AND expires_at <= ?`, key.Scope, key.Key, key.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error deleting expired idempotency key: %w", err)
+		}
+	}
+}
+
+// get returns a stored key
+func (s *IdempotencyStore) get(ctx context.Context, scope, key string) (*entities.IdempotencyKey, error) {
+	var (
+		stored       entities.IdempotencyKey
+		header, body sql.NullString
+	)
+	err := s.db.QueryRowContext(ctx, `SELECT scope, idempotency_key, request_hash, status_code, header, body,
+		created_at, expires_at FROM idempotency_keys WHERE scope = ? AND idempotency_key = ?`, scope, key).
+		Scan(&stored.Scope, &stored.Key, &stored.RequestHash, &stored.StatusCode, &header, &body,
+			&stored.CreatedAt, &stored.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositories.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting idempotency key: %w", err)
+	}
+	if header.Valid {
+		if err := json.Unmarshal([]byte(header.String), &stored.Header); err != nil {
+			return nil, fmt.Errorf("error decoding idempotency key headers: %w", err)
+		}
+	}
+	stored.Body = []byte(body.String)
+	return &stored, nil
+}
+
+// Complete stores the response of a reserved key
+func (s *IdempotencyStore) Complete(ctx context.Context, key *entities.IdempotencyKey) error {
+	header, err := json.Marshal(key.Header)
+	if err != nil {
+		return fmt.Errorf("error encoding idempotency key headers: %w", err)
+	}
+	result, err := s.db.ExecContext(ctx, `UPDATE idempotency_keys SET status_code = ?, header = ?, body = ?
+		WHERE scope = ? AND idempotency_key = ?`, key.StatusCode, string(header), string(key.Body), key.Scope, key.Key)
+	if err != nil {
+		return fmt.Errorf("error completing idempotency key: %w", err)
+	}
+	return requireAffected(result)
+}
+
+// Delete removes a key
+func (s *IdempotencyStore) Delete(ctx context.Context, scope, key string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE scope = ? AND idempotency_key = ?", scope, key)
+	if err != nil {
+		return fmt.Errorf("error deleting idempotency key: %w", err)
+	}
+	return requireAffected(result)
+}
+
+// DeleteExpired removes keys that expired before t
+func (s *IdempotencyStore) DeleteExpired(ctx context.Context, t time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < ?", t.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error deleting expired idempotency keys: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,276 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+const issueLinkColumns = `task_id, repository, issue_number, issue_url, synced_hash, issue_updated_at, pending,
+	error, synced_at, created_at, updated_at`
+
+const syncConflictColumns = `id, task_id, repository, issue_number, winner, task_fields, issue_fields,
+	task_updated_at, issue_updated_at, created_at`
+
+var syncConflictKeyset = keyset[entities.SyncConflict]{
+	columns: map[string]sortColumn{
+		"id":         {"id", kindInt},
+		"created_at": {"created_at", kindTime},
+	},
+	defaults: []repositories.SortField{{Field: "id", Desc: true}},
+	value: func(c *entities.SyncConflict, field string) interface{} {
+		switch field {
+		case "id":
+			return c.ID
+		case "created_at":
+			return c.CreatedAt
+		}
+		return nil
+	},
+}
+
+// IssueSyncStore persists task and issue links in the issue_links table and
+// their conflicts in the sync_conflicts table
+type IssueSyncStore struct {
+	db *DB
+}
+
+var _ repositories.IssueSyncRepository = (*IssueSyncStore)(nil)
+
+// NewIssueSyncStore creates a new IssueSyncStore
+func NewIssueSyncStore(db *DB) *IssueSyncStore {
+	return &IssueSyncStore{db: db}
+}
+
+// GetLink returns the link of a task
+func (s *IssueSyncStore) GetLink(ctx context.Context, taskID string) (*entities.IssueLink, error) {
+	return s.getLink(ctx, "SELECT "+issueLinkColumns+" FROM issue_links WHERE task_id = ?", taskID)
+}
+
+// GetLinkByIssue returns the link of an issue of repository
+func (s *IssueSyncStore) GetLinkByIssue(ctx context.Context, repository string, number int) (*entities.IssueLink, error) {
+	return s.getLink(ctx, "SELECT "+issueLinkColumns+` FROM issue_links
+		WHERE repository = ? AND issue_number = ? ORDER BY created_at LIMIT 1`, repository, number)
+}
+
+func (s *IssueSyncStore) getLink(ctx context.Context, query string, args ...interface{}) (*entities.IssueLink, error) {
+	link, err := scanIssueLink(s.db.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositories.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting issue link: %w", err)
+	}
+	return link, nil
+}
+
+// MarkPending flags the link of a task as having a change to push, creating
+// it in repository when the task has none
+func (s *IssueSyncStore) MarkPending(ctx context.Context, taskID, repository string) error {
+	for {
+		updated := now()
+		result, err := s.db.ExecContext(ctx, "UPDATE issue_links SET pending = ?, updated_at = ? WHERE task_id = ?",
+			true, updated, taskID)
+		if err != nil {
+			return fmt.Errorf("error marking issue link pending: %w", err)
+		}
+		if err := requireAffected(result); !errors.Is(err, repositories.ErrNotFound) {
+			return err
+		}
+
+		_, err = s.db.ExecContext(ctx, `INSERT INTO issue_links (task_id, repository, pending, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?)`, taskID, repository, true, updated, updated)
+		if s.db.dialect.IsUniqueViolation(err) {
+			// Created in the meantime
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error creating issue link: %w", err)
+		}
+		return nil
+	}
+}
+
+// SaveLink stores every field of a link, creating it when needed
+func (s *IssueSyncStore) SaveLink(ctx context.Context, link *entities.IssueLink) error {
+	for {
+		link.UpdatedAt = now()
+		result, err := s.db.ExecContext(ctx, `UPDATE issue_links SET repository = ?, issue_number = ?,
+			issue_url = ?, synced_hash = ?, issue_updated_at = ?, pending = ?, error = ?, synced_at = ?,
+			updated_at = ? WHERE task_id = ?`,
+			link.Repository, link.IssueNumber, link.IssueURL, link.SyncedHash, utcTime(link.IssueUpdatedAt),
+			link.Pending, link.Error, utcTime(link.SyncedAt), link.UpdatedAt, link.TaskID,
+		)
+		if err != nil {
+			return fmt.Errorf("error saving issue link: %w", err)
+		}
+		if err := requireAffected(result); !errors.Is(err, repositories.ErrNotFound) {
+			return err
+		}
+
+		link.CreatedAt = link.UpdatedAt
+		_, err = s.db.ExecContext(ctx, `INSERT INTO issue_links (task_id, repository, issue_number, issue_url,
+			synced_hash, issue_updated_at, pending, error, synced_at, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			link.TaskID, link.Repository, link.IssueNumber, link.IssueURL, link.SyncedHash,
+			utcTime(link.IssueUpdatedAt), link.Pending, link.Error, utcTime(link.SyncedAt), link.CreatedAt,
+			link.UpdatedAt,
+		)
+		if s.db.dialect.IsUniqueViolation(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error creating issue link: %w", err)
+		}
+		return nil
+	}
+}
+
+// DeleteLink removes the link of a task
+func (s *IssueSyncStore) DeleteLink(ctx context.Context, taskID string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM issue_links WHERE task_id = ?", taskID)
+	if err != nil {
+		return fmt.Errorf("error deleting issue link: %w", err)
+	}
+	return requireAffected(result)
+}
+
+// ListPending returns up to limit links with a change to push, least
+// recently attempted first
+func (s *IssueSyncStore) ListPending(ctx context.Context, limit int) ([]entities.IssueLink, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+issueLinkColumns+` FROM issue_links
+		WHERE pending = ? ORDER BY updated_at LIMIT ?`, true, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pending issue links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []entities.IssueLink{}
+	for rows.Next() {
+		link, err := scanIssueLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning issue link: %w", err)
+		}
+		links = append(links, *link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing pending issue links: %w", err)
+	}
+	return links, nil
+}
+
+// AddConflict appends a conflict to the log, setting its ID and creation time
+func (s *IssueSyncStore) AddConflict(ctx context.Context, conflict *entities.SyncConflict) error {
+	task, err := json.Marshal(conflict.Task)
+	if err != nil {
+		return fmt.Errorf("error encoding sync conflict: %w", err)
+	}
+	issue, err := json.Marshal(conflict.Issue)
+	if err != nil {
+		return fmt.Errorf("error encoding sync conflict: %w", err)
+	}
+
+	conflict.CreatedAt = now()
+	id, err := s.db.dialect.InsertID(ctx, s.db, `INSERT INTO sync_conflicts (task_id, repository, issue_number,
+		winner, task_fields, issue_fields, task_updated_at, issue_updated_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		conflict.TaskID, conflict.Repository, conflict.IssueNumber, conflict.Winner, string(task), string(issue),
+		conflict.TaskUpdatedAt.UTC(), conflict.IssueUpdatedAt.UTC(), conflict.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error adding sync conflict: %w", err)
+	}
+	conflict.ID = id
+	return nil
+}
+
+// ListConflicts returns a page of conflicts matching filter
+func (s *IssueSyncStore) ListConflicts(ctx context.Context, filter repositories.SyncConflictFilter, opts repositories.ListOptions) (repositories.Page[entities.SyncConflict], error) {
+	p, err := syncConflictKeyset.resolve(opts)
+	if err != nil {
+		return repositories.Page[entities.SyncConflict]{}, err
+	}
+
+	var (
+		conds []string
+		args  []interface{}
+	)
+	for _, eq := range []struct{ column, value string }{
+		{"repository", filter.Repository},
+		{"task_id", filter.TaskID},
+	} {
+		if eq.value != "" {
+			conds = append(conds, eq.column+" = ?")
+			args = append(args, eq.value)
+		}
+	}
+	if cond, condArgs := syncConflictKeyset.where(p); cond != "" {
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+	}
+
+	query := "SELECT " + syncConflictColumns + " FROM sync_conflicts" +
+		whereClause(conds) + syncConflictKeyset.orderBy(p) + " LIMIT ?"
+	args = append(args, p.limit+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return repositories.Page[entities.SyncConflict]{}, fmt.Errorf("error listing sync conflicts: %w", err)
+	}
+	defer rows.Close()
+
+	conflicts := []entities.SyncConflict{}
+	for rows.Next() {
+		var (
+			conflict    entities.SyncConflict
+			task, issue sql.NullString
+		)
+		if err := rows.Scan(&conflict.ID, &conflict.TaskID, &conflict.Repository, &conflict.IssueNumber,
+			&conflict.Winner, &task, &issue, &conflict.TaskUpdatedAt, &conflict.IssueUpdatedAt,
+			&conflict.CreatedAt); err != nil {
+			return repositories.Page[entities.SyncConflict]{}, fmt.Errorf("error scanning sync conflict: %w", err)
+		}
+		if err := unmarshalJSON(task, &conflict.Task); err != nil {
+			return repositories.Page[entities.SyncConflict]{}, err
+		}
+		if err := unmarshalJSON(issue, &conflict.Issue); err != nil {
+			return repositories.Page[entities.SyncConflict]{}, err
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	if err := rows.Err(); err != nil {
+		return repositories.Page[entities.SyncConflict]{}, fmt.Errorf("error listing sync conflicts: %w", err)
+	}
+	return syncConflictKeyset.finish(p, conflicts)
+}
+
+// scanIssueLink reads a row selected with issueLinkColumns
+func scanIssueLink(row rowScanner) (*entities.IssueLink, error) {
+	var (
+		link                     entities.IssueLink
+		issueUpdatedAt, syncedAt sql.NullTime
+		syncErr                  sql.NullString
+	)
+	if err := row.Scan(&link.TaskID, &link.Repository, &link.IssueNumber, &link.IssueURL, &link.SyncedHash,
+		&issueUpdatedAt, &link.Pending, &syncErr, &syncedAt, &link.CreatedAt, &link.UpdatedAt); err != nil {
+		return nil, err
+	}
+	link.IssueUpdatedAt = nullTime(issueUpdatedAt)
+	link.SyncedAt = nullTime(syncedAt)
+	link.Error = syncErr.String
+	return &link, nil
+}
+
+// utcTime converts an optional time into a nullable column value in UTC
+func utcTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
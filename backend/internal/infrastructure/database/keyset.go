@@ -0,0 +1,229 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// columnKind tells how a sort column's cursor value is encoded
+type columnKind int
+
+const (
+	kindString columnKind = iota
+	kindInt
+	kindTime
+)
+
+// sortColumn is a column a list may be ordered by
+type sortColumn struct {
+	column string
+	kind   columnKind
+}
+
+// keyset implements cursor pagination over a fixed set of sortable columns.
+// The id column is always appended as a tie-breaker so every row has a unique
+// position, and the cursor stores the sort values of the last row returned.
+type keyset[T any] struct {
+	columns  map[string]sortColumn
+	defaults []repositories.SortField
+	value    func(item *T, field string) interface{}
+}
+
+// cursor is the decoded form of ListOptions.Cursor
+type cursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+}
+
+// page is a resolved ListOptions ready to be rendered into SQL
+type page struct {
+	sort   []repositories.SortField
+	key    string
+	limit  int
+	after  []interface{}
+	hasKey bool
+}
+
+// resolve validates the sort fields and decodes the cursor
+func (k keyset[T]) resolve(opts repositories.ListOptions) (*page, error) {
+	sort := opts.Sort
+	if len(sort) == 0 {
+		sort = k.defaults
+	}
+
+	p := &page{limit: opts.PageSize()}
+	seen := make(map[string]bool)
+	for _, f := range sort {
+		if _, ok := k.columns[f.Field]; !ok {
+			return nil, fmt.Errorf("%w: cannot sort by %q", repositories.ErrInvalidQuery, f.Field)
+		}
+		if seen[f.Field] {
+			return nil, fmt.Errorf("%w: %q is sorted more than once", repositories.ErrInvalidQuery, f.Field)
+		}
+		seen[f.Field] = true
+		p.sort = append(p.sort, f)
+	}
+	if !seen["id"] {
+		p.sort = append(p.sort, repositories.SortField{Field: "id"})
+	}
+	p.key = sortKey(p.sort)
+
+	if opts.Cursor == "" {
+		return p, nil
+	}
+	after, err := k.decode(opts.Cursor, p)
+	if err != nil {
+		return nil, err
+	}
+	p.after = after
+	p.hasKey = true
+	return p, nil
+}
+
+// where returns the condition selecting rows after the cursor
+func (k keyset[T]) where(p *page) (string, []interface{}) {
+	if !p.hasKey {
+		return "", nil
+	}
+
+	var (
+		ors  []string
+		args []interface{}
+	)
+	for i, f := range p.sort {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, k.columns[p.sort[j].Field].column+" = ?")
+			args = append(args, p.after[j])
+		}
+		op := " > ?"
+		if f.Desc {
+			op = " < ?"
+		}
+		ands = append(ands, k.columns[f.Field].column+op)
+		args = append(args, p.after[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+// orderBy returns the ORDER BY clause for the page
+func (k keyset[T]) orderBy(p *page) string {
+	parts := make([]string, len(p.sort))
+	for i, f := range p.sort {
+		parts[i] = k.columns[f.Field].column
+		if f.Desc {
+			parts[i] += " DESC"
+		}
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// finish trims the extra row fetched to detect a next page and builds its cursor
+func (k keyset[T]) finish(p *page, items []T) (repositories.Page[T], error) {
+	if len(items) <= p.limit {
+		return repositories.Page[T]{Items: items}, nil
+	}
+	items = items[:p.limit]
+
+	last := &items[len(items)-1]
+	c := cursor{Sort: p.key}
+	for _, f := range p.sort {
+		v := k.value(last, f.Field)
+		if t, ok := v.(time.Time); ok {
+			v = t.UTC().Format(time.RFC3339Nano)
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return repositories.Page[T]{}, err
+		}
+		c.Values = append(c.Values, raw)
+	}
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		return repositories.Page[T]{}, err
+	}
+	return repositories.Page[T]{
+		Items:      items,
+		NextCursor: base64.RawURLEncoding.EncodeToString(encoded),
+	}, nil
+}
+
+// decode parses a cursor produced by finish for the same sort order
+func (k keyset[T]) decode(raw string, p *page) ([]interface{}, error) {
+	invalid := fmt.Errorf("%w: malformed cursor", repositories.ErrInvalidQuery)
+
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, invalid
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, invalid
+	}
+	if c.Sort != p.key {
+		return nil, fmt.Errorf("%w: cursor was issued for a different sort order", repositories.ErrInvalidQuery)
+	}
+	if len(c.Values) != len(p.sort) {
+		return nil, invalid
+	}
+
+	values := make([]interface{}, len(p.sort))
+	for i, f := range p.sort {
+		switch k.columns[f.Field].kind {
+		case kindString:
+			var s string
+			if err := json.Unmarshal(c.Values[i], &s); err != nil {
+				return nil, invalid
+			}
+			values[i] = s
+		case kindInt:
+			var n int64
+			if err := json.Unmarshal(c.Values[i], &n); err != nil {
+				return nil, invalid
+			}
+			values[i] = n
+		case kindTime:
+			var s string
+			if err := json.Unmarshal(c.Values[i], &s); err != nil {
+				return nil, invalid
+			}
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, invalid
+			}
+			values[i] = t.UTC()
+		}
+	}
+	return values, nil
+}
+
+// sortKey renders a sort order as "-field,field" to tie cursors to it
+func sortKey(sort []repositories.SortField) string {
+	parts := make([]string, len(sort))
+	for i, f := range sort {
+		parts[i] = f.Field
+		if f.Desc {
+			parts[i] = "-" + f.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// whereClause joins conditions with AND into a WHERE clause
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+// placeholders returns n comma separated ? placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
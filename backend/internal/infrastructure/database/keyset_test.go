@@ -0,0 +1,230 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+type keysetItem struct {
+	ID      int64
+	Name    string
+	Created time.Time
+}
+
+var testKeyset = keyset[keysetItem]{
+	columns: map[string]sortColumn{
+		"id":         {"id", kindInt},
+		"name":       {"name", kindString},
+		"created_at": {"created_at", kindTime},
+	},
+	defaults: []repositories.SortField{{Field: "created_at", Desc: true}},
+	value: func(item *keysetItem, field string) interface{} {
+		switch field {
+		case "id":
+			return item.ID
+		case "name":
+			return item.Name
+		case "created_at":
+			return item.Created
+		}
+		return nil
+	},
+}
+
+func TestKeysetResolveSort(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		sort    []repositories.SortField
+		wantKey string
+		wantErr bool
+	}{
+		{name: "defaults", wantKey: "-created_at,id"},
+		{name: "id appended", sort: []repositories.SortField{{Field: "name"}}, wantKey: "name,id"},
+		{name: "descending id kept", sort: []repositories.SortField{{Field: "id", Desc: true}}, wantKey: "-id"},
+		{name: "id before others", sort: []repositories.SortField{{Field: "id"}, {Field: "name"}}, wantKey: "id,name"},
+		{name: "unknown field", sort: []repositories.SortField{{Field: "title"}}, wantErr: true},
+		{name: "field twice", sort: []repositories.SortField{{Field: "name"}, {Field: "name", Desc: true}}, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := testKeyset.resolve(repositories.ListOptions{Sort: tc.sort})
+			if tc.wantErr {
+				if !errors.Is(err, repositories.ErrInvalidQuery) {
+					t.Errorf("err = %v, want ErrInvalidQuery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.key != tc.wantKey {
+				t.Errorf("key = %q, want %q", p.key, tc.wantKey)
+			}
+		})
+	}
+}
+
+func TestKeysetCursorRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.FixedZone("KST", 9*60*60))
+	items := []keysetItem{
+		{ID: 3, Name: "a", Created: created.Add(time.Hour)},
+		{ID: 7, Name: "b", Created: created},
+		{ID: 9, Name: "c", Created: created.Add(-time.Hour)},
+	}
+	opts := repositories.ListOptions{Limit: 2, Sort: []repositories.SortField{{Field: "created_at", Desc: true}, {Field: "name"}}}
+
+	p, err := testKeyset.resolve(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := testKeyset.finish(p, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Items) != 2 || first.NextCursor == "" {
+		t.Fatalf("first page has %d items and cursor %q, want 2 and a cursor", len(first.Items), first.NextCursor)
+	}
+
+	opts.Cursor = first.NextCursor
+	next, err := testKeyset.resolve(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{created.UTC(), "b", int64(7)}
+	if !reflect.DeepEqual(next.after, want) {
+		t.Errorf("cursor values = %#v, want %#v", next.after, want)
+	}
+
+	last, err := testKeyset.finish(next, items[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.NextCursor != "" {
+		t.Errorf("last page cursor = %q, want none", last.NextCursor)
+	}
+}
+
+func TestKeysetDecodeRejects(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	byName := []repositories.SortField{{Field: "name"}}
+	for _, tc := range []struct {
+		name   string
+		sort   []repositories.SortField
+		cursor string
+	}{
+		{"not base64", byName, "!!!"},
+		{"not JSON", byName, encode("cursor")},
+		{"other sort order", byName, encode(`{"s":"-name,id","v":["b",7]}`)},
+		{"missing tie-breaker", byName, encode(`{"s":"name,id","v":["b"]}`)},
+		{"string for an int", byName, encode(`{"s":"name,id","v":["b","7"]}`)},
+		{"int for a string", byName, encode(`{"s":"name,id","v":[1,7]}`)},
+		{"bad time", []repositories.SortField{{Field: "created_at"}}, encode(`{"s":"created_at,id","v":["yesterday",7]}`)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := testKeyset.resolve(repositories.ListOptions{Sort: tc.sort, Cursor: tc.cursor})
+			if !errors.Is(err, repositories.ErrInvalidQuery) {
+				t.Errorf("err = %v, want ErrInvalidQuery", err)
+			}
+		})
+	}
+}
+
+func TestKeysetWhereBreaksTiesOnID(t *testing.T) {
+	p := &page{
+		sort:   []repositories.SortField{{Field: "name", Desc: true}, {Field: "id"}},
+		after:  []interface{}{"b", int64(7)},
+		hasKey: true,
+	}
+	cond, args := testKeyset.where(p)
+	if want := "((name < ?) OR (name = ? AND id > ?))"; cond != want {
+		t.Errorf("where = %q, want %q", cond, want)
+	}
+	if want := []interface{}{"b", "b", int64(7)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %#v, want %#v", args, want)
+	}
+	if got, want := testKeyset.orderBy(p), " ORDER BY name DESC, id"; got != want {
+		t.Errorf("orderBy = %q, want %q", got, want)
+	}
+}
+
+// TestKeysetPagesVisitEveryRowOnce walks rows with repeated sort values a
+// page at a time, so only the id tie-breaker tells them apart
+func TestKeysetPagesVisitEveryRowOnce(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"b", "a", "b", "c", "a", "b", "b", "a"}
+	for i, name := range names {
+		if _, err := db.ExecContext(ctx, "INSERT INTO items (id, name) VALUES (?, ?)", i+1, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, sort := range [][]repositories.SortField{
+		{{Field: "name"}},
+		{{Field: "name", Desc: true}},
+		{{Field: "name"}, {Field: "id", Desc: true}},
+	} {
+		t.Run(sortKey(sort), func(t *testing.T) {
+			seen := make(map[int64]bool)
+			opts := repositories.ListOptions{Limit: 3, Sort: sort}
+			for pages := 0; ; pages++ {
+				if pages > len(names) {
+					t.Fatal("pagination does not end")
+				}
+				p, err := testKeyset.resolve(opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				query, args := "SELECT id, name FROM items", []interface{}{}
+				if cond, condArgs := testKeyset.where(p); cond != "" {
+					query += " WHERE " + cond
+					args = condArgs
+				}
+				rows, err := db.QueryContext(ctx, query+testKeyset.orderBy(p)+" LIMIT ?", append(args, p.limit+1)...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var items []keysetItem
+				for rows.Next() {
+					var item keysetItem
+					if err := rows.Scan(&item.ID, &item.Name); err != nil {
+						t.Fatal(err)
+					}
+					items = append(items, item)
+				}
+				rows.Close()
+
+				result, err := testKeyset.finish(p, items)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, item := range result.Items {
+					if seen[item.ID] {
+						t.Errorf("row %d returned twice", item.ID)
+					}
+					seen[item.ID] = true
+				}
+				if result.NextCursor == "" {
+					break
+				}
+				opts.Cursor = result.NextCursor
+			}
+			if len(seen) != len(names) {
+				t.Errorf("visited %d rows, want %d", len(seen), len(names))
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// migrations holds the schema shared by every dialect. Each file is a
+// text/template rendered with the dialect's SchemaTypes and applied once, in
+// file name order.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrate applies the migrations that have not been recorded in schema_migrations yet
+func (db *DB) Migrate(ctx context.Context) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version VARCHAR(255) NOT NULL PRIMARY KEY,
+		applied_at `+db.dialect.Schema().Timestamp+` NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version := strings.TrimSuffix(path.Base(file), ".sql")
+		if applied[version] {
+			continue
+		}
+		if err := db.applyMigration(ctx, file, version); err != nil {
+			return fmt.Errorf("error applying migration %s: %w", version, err)
+		}
+		slog.InfoContext(ctx, "applied database migration", "version", version, "driver", db.dialect.Name())
+	}
+	return nil
+}
+
+// appliedMigrations returns the versions recorded in schema_migrations
+func (db *DB) appliedMigrations(ctx context.Context) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// applyMigration renders a migration for the dialect and runs its statements
+// in a transaction. MySQL commits DDL implicitly, so a failed migration there
+// may need manual cleanup.
+func (db *DB) applyMigration(ctx context.Context, file, version string) error {
+	statements, err := renderMigration(file, db.dialect.Schema())
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		db.dialect.Rebind("INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)"),
+		version, time.Now().UTC(),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// renderMigration executes the migration template and splits it into statements
+func renderMigration(file string, types SchemaTypes) ([]string, error) {
+	tmpl, err := template.ParseFS(migrations, file)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, types); err != nil {
+		return nil, err
+	}
+
+	return splitStatements(buf.String()), nil
+}
+
+// splitStatements splits a script on semicolons, keeping the semicolons
+// inside CREATE TRIGGER ... BEGIN ... END bodies
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		pending    string
+	)
+	for _, part := range strings.Split(script, ";") {
+		stmt := part
+		if pending != "" {
+			stmt = pending + ";" + part
+		}
+		trimmed := strings.TrimSpace(stmt)
+		upper := strings.ToUpper(trimmed)
+		if strings.HasPrefix(upper, "CREATE TRIGGER") && !strings.HasSuffix(upper, "END") {
+			pending = stmt
+			continue
+		}
+		pending = ""
+		if trimmed != "" {
+			statements = append(statements, trimmed)
+		}
+	}
+	if pending = strings.TrimSpace(pending); pending != "" {
+		statements = append(statements, pending)
+	}
+	return statements
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"empty", "", nil},
+		{"only separators", " ;\n; ", nil},
+		{"one without semicolon", "CREATE TABLE a (id INT)", []string{"CREATE TABLE a (id INT)"}},
+		{"several", "CREATE TABLE a (id INT);\nCREATE INDEX a_id ON a (id);\n",
+			[]string{"CREATE TABLE a (id INT)", "CREATE INDEX a_id ON a (id)"}},
+		{"trigger body",
+			"CREATE TRIGGER t AFTER INSERT ON a BEGIN\n  INSERT INTO b VALUES (1);\n  DELETE FROM c;\nEND;\nSELECT 1;",
+			[]string{"CREATE TRIGGER t AFTER INSERT ON a BEGIN\n  INSERT INTO b VALUES (1);\n  DELETE FROM c;\nEND", "SELECT 1"}},
+		{"lower case trigger", "create trigger t after delete on a begin delete from b; end;",
+			[]string{"create trigger t after delete on a begin delete from b; end"}},
+		{"consecutive triggers",
+			"CREATE TRIGGER t1 AFTER INSERT ON a BEGIN SELECT 1; END;CREATE TRIGGER t2 AFTER DELETE ON a BEGIN SELECT 2; END;",
+			[]string{"CREATE TRIGGER t1 AFTER INSERT ON a BEGIN SELECT 1; END", "CREATE TRIGGER t2 AFTER DELETE ON a BEGIN SELECT 2; END"}},
+		{"unterminated trigger", "CREATE TRIGGER t AFTER INSERT ON a BEGIN SELECT 1;",
+			[]string{"CREATE TRIGGER t AFTER INSERT ON a BEGIN SELECT 1;"}},
+	} {
+		if got := splitStatements(tc.script); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: splitStatements = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS repositories (
+    id {{.AutoIncrement}},
+    name VARCHAR(255) NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    description {{.Text}},
+    private {{.Bool}} NOT NULL DEFAULT FALSE,
+    language VARCHAR(100),
+    url VARCHAR(500) NOT NULL DEFAULT '',
+    html_url VARCHAR(500) NOT NULL DEFAULT '',
+    clone_url VARCHAR(500) NOT NULL DEFAULT '',
+    stars INTEGER NOT NULL DEFAULT 0,
+    forks INTEGER NOT NULL DEFAULT 0,
+    is_connected {{.Bool}} NOT NULL DEFAULT FALSE,
+    topics {{.Text}},
+    last_sync {{.Timestamp}} NULL,
+    created_at {{.Timestamp}} NOT NULL,
+    updated_at {{.Timestamp}} NOT NULL,
+    CONSTRAINT uq_repositories_full_name UNIQUE (full_name)
+) {{.TableOptions}};
+
+CREATE TABLE IF NOT EXISTS tasks (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    description {{.Text}},
+    status VARCHAR(32) NOT NULL,
+    repository VARCHAR(255) NOT NULL DEFAULT '',
+    epic VARCHAR(255) NOT NULL DEFAULT '',
+    branch VARCHAR(255) NOT NULL DEFAULT '',
+    tokens_used INTEGER NOT NULL DEFAULT 0,
+    metadata {{.Text}},
+    created_at {{.Timestamp}} NOT NULL,
+    updated_at {{.Timestamp}} NOT NULL,
+    started_at {{.Timestamp}} NULL,
+    completed_at {{.Timestamp}} NULL
+) {{.TableOptions}};
+
+CREATE INDEX idx_tasks_status ON tasks (status);
+
+CREATE INDEX idx_tasks_repository ON tasks (repository);
@@ -0,0 +1,7 @@
+ALTER TABLE tasks ADD COLUMN assignee VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_tasks_assignee ON tasks (assignee);
+
+CREATE INDEX idx_tasks_updated_at ON tasks (updated_at);
+
+CREATE INDEX idx_tasks_created_at ON tasks (created_at);
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS users (
+    id {{.AutoIncrement}},
+    login VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    role VARCHAR(32) NOT NULL,
+    created_at {{.Timestamp}} NOT NULL,
+    updated_at {{.Timestamp}} NOT NULL,
+    CONSTRAINT uq_users_login UNIQUE (login)
+) {{.TableOptions}};
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id {{.AutoIncrement}},
+    user_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    prefix VARCHAR(16) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    created_at {{.Timestamp}} NOT NULL,
+    last_used_at {{.Timestamp}} NULL,
+    expires_at {{.Timestamp}} NULL,
+    CONSTRAINT uq_api_tokens_hash UNIQUE (token_hash),
+    CONSTRAINT fk_api_tokens_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) {{.TableOptions}};
+
+ALTER TABLE repositories ADD COLUMN owner_id BIGINT NULL;
+
+CREATE INDEX idx_repositories_owner ON repositories (owner_id);
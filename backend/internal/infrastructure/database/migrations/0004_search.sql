@@ -0,0 +1,45 @@
+{{- if eq .Dialect "mysql"}}
+ALTER TABLE tasks ADD FULLTEXT INDEX ft_tasks_text (title, description);
+
+ALTER TABLE repositories ADD FULLTEXT INDEX ft_repositories_text (description, topics);
+{{- else if eq .Dialect "postgres"}}
+CREATE INDEX ft_tasks_text ON tasks
+    USING GIN (to_tsvector('simple', coalesce(title, '') || ' ' || coalesce(description, '')));
+
+CREATE INDEX ft_repositories_text ON repositories
+    USING GIN (to_tsvector('simple', coalesce(description, '') || ' ' || coalesce(topics, '')));
+{{- else}}
+CREATE VIRTUAL TABLE tasks_fts USING fts5(title, description, content='tasks');
+
+CREATE TRIGGER tasks_fts_insert AFTER INSERT ON tasks BEGIN
+    INSERT INTO tasks_fts (rowid, title, description) VALUES (new.rowid, new.title, new.description);
+END;
+
+CREATE TRIGGER tasks_fts_delete AFTER DELETE ON tasks BEGIN
+    INSERT INTO tasks_fts (tasks_fts, rowid, title, description) VALUES ('delete', old.rowid, old.title, old.description);
+END;
+
+CREATE TRIGGER tasks_fts_update AFTER UPDATE ON tasks BEGIN
+    INSERT INTO tasks_fts (tasks_fts, rowid, title, description) VALUES ('delete', old.rowid, old.title, old.description);
+    INSERT INTO tasks_fts (rowid, title, description) VALUES (new.rowid, new.title, new.description);
+END;
+
+INSERT INTO tasks_fts (tasks_fts) VALUES ('rebuild');
+
+CREATE VIRTUAL TABLE repositories_fts USING fts5(description, topics, content='repositories', content_rowid='id');
+
+CREATE TRIGGER repositories_fts_insert AFTER INSERT ON repositories BEGIN
+    INSERT INTO repositories_fts (rowid, description, topics) VALUES (new.id, new.description, new.topics);
+END;
+
+CREATE TRIGGER repositories_fts_delete AFTER DELETE ON repositories BEGIN
+    INSERT INTO repositories_fts (repositories_fts, rowid, description, topics) VALUES ('delete', old.id, old.description, old.topics);
+END;
+
+CREATE TRIGGER repositories_fts_update AFTER UPDATE ON repositories BEGIN
+    INSERT INTO repositories_fts (repositories_fts, rowid, description, topics) VALUES ('delete', old.id, old.description, old.topics);
+    INSERT INTO repositories_fts (rowid, description, topics) VALUES (new.id, new.description, new.topics);
+END;
+
+INSERT INTO repositories_fts (repositories_fts) VALUES ('rebuild');
+{{- end}}
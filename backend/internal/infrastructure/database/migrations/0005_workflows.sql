@@ -0,0 +1,54 @@
+CREATE TABLE IF NOT EXISTS workflows (
+    id {{.AutoIncrement}},
+    name VARCHAR(255) NOT NULL,
+    description {{.Text}},
+    version INTEGER NOT NULL,
+    created_by BIGINT NULL,
+    created_at {{.Timestamp}} NOT NULL,
+    updated_at {{.Timestamp}} NOT NULL,
+    CONSTRAINT uq_workflows_name UNIQUE (name)
+) {{.TableOptions}};
+
+CREATE TABLE IF NOT EXISTS workflow_versions (
+    workflow_id BIGINT NOT NULL,
+    version INTEGER NOT NULL,
+    definition {{.Text}} NOT NULL,
+    source {{.Text}} NOT NULL,
+    created_by BIGINT NULL,
+    created_at {{.Timestamp}} NOT NULL,
+    PRIMARY KEY (workflow_id, version),
+    CONSTRAINT fk_workflow_versions_workflow FOREIGN KEY (workflow_id) REFERENCES workflows (id) ON DELETE CASCADE
+) {{.TableOptions}};
+
+CREATE TABLE IF NOT EXISTS workflow_runs (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    workflow_id BIGINT NOT NULL,
+    version INTEGER NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    inputs {{.Text}},
+    error {{.Text}},
+    created_by BIGINT NULL,
+    created_at {{.Timestamp}} NOT NULL,
+    started_at {{.Timestamp}} NULL,
+    finished_at {{.Timestamp}} NULL,
+    CONSTRAINT fk_workflow_runs_workflow FOREIGN KEY (workflow_id) REFERENCES workflows (id) ON DELETE CASCADE
+) {{.TableOptions}};
+
+CREATE INDEX idx_workflow_runs_workflow ON workflow_runs (workflow_id, created_at);
+
+CREATE INDEX idx_workflow_runs_status ON workflow_runs (status);
+
+CREATE TABLE IF NOT EXISTS workflow_step_runs (
+    run_id VARCHAR(64) NOT NULL,
+    step_id VARCHAR(255) NOT NULL,
+    position INTEGER NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    outputs {{.Text}},
+    error {{.Text}},
+    started_at {{.Timestamp}} NULL,
+    finished_at {{.Timestamp}} NULL,
+    PRIMARY KEY (run_id, step_id),
+    CONSTRAINT fk_workflow_step_runs_run FOREIGN KEY (run_id) REFERENCES workflow_runs (id) ON DELETE CASCADE
+) {{.TableOptions}};
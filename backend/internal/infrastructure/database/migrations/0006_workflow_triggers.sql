@@ -0,0 +1,5 @@
+ALTER TABLE workflow_runs ADD COLUMN trigger_type VARCHAR(32) NOT NULL DEFAULT 'manual';
+
+ALTER TABLE workflow_runs ADD COLUMN trigger_event {{.Text}};
+
+CREATE INDEX idx_workflow_runs_trigger ON workflow_runs (workflow_id, trigger_type);
@@ -0,0 +1,17 @@
+ALTER TABLE workflow_step_runs ADD COLUMN expires_at {{.Timestamp}} NULL;
+
+CREATE INDEX idx_workflow_step_runs_expires ON workflow_step_runs (status, expires_at);
+
+CREATE TABLE IF NOT EXISTS workflow_run_events (
+    id {{.AutoIncrement}},
+    run_id VARCHAR(64) NOT NULL,
+    step_id VARCHAR(255) NOT NULL DEFAULT '',
+    type VARCHAR(64) NOT NULL,
+    actor_id BIGINT NULL,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    comment {{.Text}},
+    created_at {{.Timestamp}} NOT NULL,
+    CONSTRAINT fk_workflow_run_events_run FOREIGN KEY (run_id) REFERENCES workflow_runs (id) ON DELETE CASCADE
+) {{.TableOptions}};
+
+CREATE INDEX idx_workflow_run_events_run ON workflow_run_events (run_id, id);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS notification_subscriptions (
+    id {{.AutoIncrement}},
+    user_id BIGINT NOT NULL,
+    event_types {{.Text}} NOT NULL,
+    repository VARCHAR(255) NOT NULL DEFAULT '',
+    channel VARCHAR(32) NOT NULL,
+    target VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL DEFAULT '',
+    created_at {{.Timestamp}} NOT NULL,
+    CONSTRAINT fk_notification_subscriptions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) {{.TableOptions}};
+
+CREATE INDEX idx_notification_subscriptions_user ON notification_subscriptions (user_id);
+
+CREATE INDEX idx_notification_subscriptions_repository ON notification_subscriptions (repository);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id {{.AutoIncrement}},
+    user_id BIGINT NOT NULL,
+    endpoint {{.Text}} NOT NULL,
+    endpoint_hash CHAR(64) NOT NULL,
+    p256dh VARCHAR(255) NOT NULL,
+    auth VARCHAR(255) NOT NULL,
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    created_at {{.Timestamp}} NOT NULL,
+    last_used_at {{.Timestamp}} NULL,
+    CONSTRAINT uq_push_subscriptions_endpoint UNIQUE (endpoint_hash),
+    CONSTRAINT fk_push_subscriptions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) {{.TableOptions}};
+
+CREATE INDEX idx_push_subscriptions_user ON push_subscriptions (user_id);
+
+CREATE TABLE IF NOT EXISTS vapid_keys (
+    id INTEGER NOT NULL PRIMARY KEY,
+    public_key VARCHAR(255) NOT NULL,
+    private_key VARCHAR(255) NOT NULL,
+    created_at {{.Timestamp}} NOT NULL
+) {{.TableOptions}};
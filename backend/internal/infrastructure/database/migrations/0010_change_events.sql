@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS change_events (
+    id {{.AutoIncrement}},
+    type VARCHAR(64) NOT NULL,
+    entity_id VARCHAR(255) NOT NULL,
+    repository VARCHAR(255) NOT NULL DEFAULT '',
+    data {{.Text}} NOT NULL,
+    private {{.Bool}} NOT NULL DEFAULT FALSE,
+    owner_id BIGINT NULL,
+    created_at {{.Timestamp}} NOT NULL
+) {{.TableOptions}};
+
+CREATE INDEX idx_change_events_created_at ON change_events (created_at);
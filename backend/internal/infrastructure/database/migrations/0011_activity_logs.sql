@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS activity_logs (
+    id {{.AutoIncrement}},
+    action VARCHAR(64) NOT NULL,
+    resource_type VARCHAR(32) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL,
+    actor_id BIGINT NULL,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    changes {{.Text}} NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    created_at {{.Timestamp}} NOT NULL
+) {{.TableOptions}};
+
+CREATE INDEX idx_activity_logs_created_at ON activity_logs (created_at);
+CREATE INDEX idx_activity_logs_resource ON activity_logs (resource_type, resource_id);
+CREATE INDEX idx_activity_logs_actor ON activity_logs (actor);
+CREATE INDEX idx_activity_logs_request_id ON activity_logs (request_id);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS ai_usage (
+    id {{.AutoIncrement}},
+    user_id BIGINT NULL,
+    source VARCHAR(32) NOT NULL,
+    task_id VARCHAR(36) NOT NULL DEFAULT '',
+    model VARCHAR(128) NOT NULL DEFAULT '',
+    input_tokens INTEGER NOT NULL DEFAULT 0,
+    output_tokens INTEGER NOT NULL DEFAULT 0,
+    created_at {{.Timestamp}} NOT NULL
+) {{.TableOptions}};
+
+CREATE INDEX idx_ai_usage_user_created_at ON ai_usage (user_id, created_at);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id {{.AutoIncrement}},
+    scope VARCHAR(128) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    header {{.Text}} NULL,
+    body {{if eq .Dialect "mysql"}}MEDIUMTEXT{{else}}{{.Text}}{{end}} NULL,
+    created_at {{.Timestamp}} NOT NULL,
+    expires_at {{.Timestamp}} NOT NULL,
+    CONSTRAINT uq_idempotency_keys_scope_key UNIQUE (scope, idempotency_key)
+) {{.TableOptions}};
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
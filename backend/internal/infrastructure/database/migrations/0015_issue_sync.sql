@@ -0,0 +1,36 @@
+ALTER TABLE repositories ADD COLUMN issue_sync {{.Bool}} NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS issue_links (
+    task_id VARCHAR(64) NOT NULL PRIMARY KEY,
+    repository VARCHAR(255) NOT NULL,
+    issue_number INTEGER NOT NULL DEFAULT 0,
+    issue_url VARCHAR(500) NOT NULL DEFAULT '',
+    synced_hash VARCHAR(64) NOT NULL DEFAULT '',
+    issue_updated_at {{.Timestamp}} NULL,
+    pending {{.Bool}} NOT NULL DEFAULT FALSE,
+    error {{.Text}} NULL,
+    synced_at {{.Timestamp}} NULL,
+    created_at {{.Timestamp}} NOT NULL,
+    updated_at {{.Timestamp}} NOT NULL
+) {{.TableOptions}};
+
+CREATE INDEX idx_issue_links_issue ON issue_links (repository, issue_number);
+
+CREATE INDEX idx_issue_links_pending ON issue_links (pending, updated_at);
+
+CREATE TABLE IF NOT EXISTS sync_conflicts (
+    id {{.AutoIncrement}},
+    task_id VARCHAR(64) NOT NULL,
+    repository VARCHAR(255) NOT NULL,
+    issue_number INTEGER NOT NULL,
+    winner VARCHAR(16) NOT NULL,
+    task_fields {{.Text}} NOT NULL,
+    issue_fields {{.Text}} NOT NULL,
+    task_updated_at {{.Timestamp}} NOT NULL,
+    issue_updated_at {{.Timestamp}} NOT NULL,
+    created_at {{.Timestamp}} NOT NULL
+) {{.TableOptions}};
+
+CREATE INDEX idx_sync_conflicts_task_id ON sync_conflicts (task_id);
+
+CREATE INDEX idx_sync_conflicts_repository ON sync_conflicts (repository);
@@ -1,65 +1,100 @@
 package database
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
 	"fmt"
-	"log"
+	"net"
+	"os"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 
 	"ai-git-workbench/internal/infrastructure/config"
 )
 
-// DB holds the database connection
-type DB struct {
-	*sql.DB
-}
-
 // NewMySQLConnection creates a new MySQL database connection
-func NewMySQLConnection(cfg *config.DatabaseConfig) (*DB, error) {
-	// Build MySQL connection string
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=True&loc=Local",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.Name,
-		cfg.Charset,
-	)
+func NewMySQLConnection(ctx context.Context, cfg *config.DatabaseConfig) (*DB, error) {
+	mysqlCfg, err := mysqlConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	// Open database connection
-	db, err := sql.Open("mysql", dsn)
+	connector, err := mysql.NewConnector(mysqlCfg)
 	if err != nil {
-		return nil, fmt.Errorf("error opening database: %w", err)
+		return nil, fmt.Errorf("error configuring database: %w", err)
 	}
 
-	// Configure connection pool
-	db.SetMaxOpenConns(25)                 // Maximum number of open connections
-	db.SetMaxIdleConns(25)                 // Maximum number of idle connections
-	db.SetConnMaxLifetime(5 * time.Minute) // Connection lifetime
+	return connect(ctx, sql.OpenDB(connector), mysqlDialect{}, cfg)
+}
 
-	// Test the connection
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("error connecting to database: %w", err)
+// mysqlConfig translates DatabaseConfig into driver options
+func mysqlConfig(cfg *config.DatabaseConfig) (*mysql.Config, error) {
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("error loading database timezone: %w", err)
 	}
 
-	log.Printf("🔌 Connected to MySQL database: %s@%s:%s/%s", cfg.User, cfg.Host, cfg.Port, cfg.Name)
+	mysqlCfg := mysql.NewConfig()
+	mysqlCfg.User = cfg.User
+	mysqlCfg.Passwd = cfg.Password
+	mysqlCfg.Net = "tcp"
+	mysqlCfg.Addr = net.JoinHostPort(cfg.Host, port(cfg, "3306"))
+	mysqlCfg.DBName = cfg.Name
+	mysqlCfg.ParseTime = true
+	mysqlCfg.Loc = loc
+	mysqlCfg.Timeout = cfg.ConnectTimeout
+	mysqlCfg.ReadTimeout = cfg.ReadTimeout
+	mysqlCfg.WriteTimeout = cfg.WriteTimeout
+	if err := mysqlCfg.Apply(mysql.Charset(cfg.Charset, "")); err != nil {
+		return nil, fmt.Errorf("error configuring charset: %w", err)
+	}
 
-	return &DB{db}, nil
-}
+	tlsCfg, err := tlsConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.TLSMode {
+	case "", "disable":
+	case "preferred":
+		mysqlCfg.TLS = tlsCfg
+		mysqlCfg.AllowFallbackToPlaintext = true
+	default:
+		mysqlCfg.TLS = tlsCfg
+	}
 
-// Close closes the database connection
-func (db *DB) Close() error {
-	return db.DB.Close()
+	return mysqlCfg, nil
 }
 
-// Ping tests the database connection
-func (db *DB) Ping() error {
-	return db.DB.Ping()
-}
+// tlsConfig builds the TLS settings for the TLS mode, trusting a custom CA if given
+func tlsConfig(cfg *config.DatabaseConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.TLSServerName,
+	}
+	if tlsCfg.ServerName == "" {
+		tlsCfg.ServerName = cfg.Host
+	}
 
-// GetStats returns database connection statistics
-func (db *DB) GetStats() sql.DBStats {
-	return db.DB.Stats()
-}
\ No newline at end of file
+	switch cfg.TLSMode {
+	case "preferred", "skip-verify":
+		// Encrypt without verifying the server certificate
+		tlsCfg.InsecureSkipVerify = true
+	}
+
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading database CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in database CA file %s", cfg.TLSCAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	return tlsCfg, nil
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+const subscriptionColumns = `s.id, s.user_id, s.event_types, s.repository, s.channel, s.target, s.secret, s.created_at`
+
+// NotificationStore persists subscriptions in the notification_subscriptions table
+type NotificationStore struct {
+	db *DB
+}
+
+var _ repositories.NotificationSubscriptionRepository = (*NotificationStore)(nil)
+
+// NewNotificationStore creates a new NotificationStore
+func NewNotificationStore(db *DB) *NotificationStore {
+	return &NotificationStore{db: db}
+}
+
+// ListByUser returns the subscriptions of a user, oldest first
+func (s *NotificationStore) ListByUser(ctx context.Context, userID int64) ([]entities.NotificationSubscription, error) {
+	return s.query(ctx, "SELECT "+subscriptionColumns+
+		" FROM notification_subscriptions s WHERE s.user_id = ? ORDER BY s.id", userID)
+}
+
+// GetByID returns a single subscription
+func (s *NotificationStore) GetByID(ctx context.Context, id int64) (*entities.NotificationSubscription, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+subscriptionColumns+
+		" FROM notification_subscriptions s WHERE s.id = ?", id)
+	sub, err := scanSubscription(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositories.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting notification subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// Create inserts the subscription and sets its generated id and creation
+// time. Repositories are stored lower case so matching ignores case.
+func (s *NotificationStore) Create(ctx context.Context, sub *entities.NotificationSubscription) error {
+	sub.CreatedAt = now()
+	sub.Repository = strings.ToLower(sub.Repository)
+
+	eventTypes, err := marshalJSON(sub.EventTypes)
+	if err != nil {
+		return err
+	}
+	id, err := s.db.dialect.InsertID(ctx, s.db, `INSERT INTO notification_subscriptions
+		(user_id, event_types, repository, channel, target, secret, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		sub.UserID, eventTypes, sub.Repository, sub.Channel, sub.Target, sub.Secret, sub.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating notification subscription: %w", err)
+	}
+	sub.ID = id
+	return nil
+}
+
+// Delete removes a subscription
+func (s *NotificationStore) Delete(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM notification_subscriptions WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting notification subscription: %w", err)
+	}
+	return requireAffected(result)
+}
+
+// ListSubscribers returns the subscriptions for the event type on the
+// repository or on every repository. Subscribers who cannot see a private
+// repository are left out, with the rules of repositories.AccessScope.
+func (s *NotificationStore) ListSubscribers(ctx context.Context, filter repositories.SubscriberFilter) ([]entities.NotificationSubscription, error) {
+	repo := strings.ToLower(filter.Repository)
+	conds := []string{"(s.repository = '' OR s.repository = ?)"}
+	args := []interface{}{repo}
+	if repo != "" {
+		conds = append(conds, `(u.role = ? OR NOT EXISTS (SELECT 1 FROM repositories r
+			WHERE (LOWER(r.full_name) = ? OR LOWER(r.name) = ?) AND r.private = ?
+			AND (r.owner_id IS NULL OR r.owner_id <> s.user_id)))`)
+		args = append(args, entities.RoleAdmin, repo, repo, true)
+	}
+	if len(filter.Logins) > 0 {
+		conds = append(conds, "LOWER(u.login) IN ("+placeholders(len(filter.Logins))+")")
+		for _, login := range filter.Logins {
+			args = append(args, strings.ToLower(login))
+		}
+	}
+
+	subs, err := s.query(ctx, "SELECT "+subscriptionColumns+
+		" FROM notification_subscriptions s JOIN users u ON u.id = s.user_id"+
+		whereClause(conds)+" ORDER BY s.id", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Event types are a JSON list, so they are matched here rather than in SQL
+	matched := subs[:0]
+	for _, sub := range subs {
+		for _, t := range sub.EventTypes {
+			if t == filter.EventType {
+				matched = append(matched, sub)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+// query runs a SELECT of subscriptionColumns
+func (s *NotificationStore) query(ctx context.Context, query string, args ...interface{}) ([]entities.NotificationSubscription, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing notification subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []entities.NotificationSubscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning notification subscription: %w", err)
+		}
+		subs = append(subs, *sub)
+	}
+	return subs, rows.Err()
+}
+
+// scanSubscription reads a row selected with subscriptionColumns
+func scanSubscription(row rowScanner) (*entities.NotificationSubscription, error) {
+	var (
+		sub        entities.NotificationSubscription
+		eventTypes sql.NullString
+	)
+	if err := row.Scan(
+		&sub.ID, &sub.UserID, &eventTypes, &sub.Repository, &sub.Channel, &sub.Target, &sub.Secret, &sub.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := unmarshalJSON(eventTypes, &sub.EventTypes); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
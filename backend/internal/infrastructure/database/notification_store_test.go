@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/infrastructure/config"
+)
+
+func TestListSubscribersHidesPrivateRepositories(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(ctx, &config.DatabaseConfig{
+		Driver: "sqlite", Path: filepath.Join(t.TempDir(), "test.db"), MaxOpenConns: 1, MaxIdleConns: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	users := NewUserStore(db)
+	logins := map[int64]string{}
+	user := func(login, role string) *entities.User {
+		u := &entities.User{Login: login, Role: role}
+		if err := users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		logins[u.ID] = login
+		return u
+	}
+	alice := user("alice", entities.RoleMember)
+	bob := user("bob", entities.RoleMember)
+	root := user("root", entities.RoleAdmin)
+
+	if err := NewRepositoryStore(db).Create(ctx, &entities.Repository{
+		Name: "secret", FullName: "Alice/Secret", Private: true, OwnerID: &alice.ID,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewNotificationStore(db)
+	for _, sub := range []struct {
+		user *entities.User
+		repo string
+	}{{alice, ""}, {bob, ""}, {bob, "alice/secret"}, {root, ""}} {
+		if err := store.Create(ctx, &entities.NotificationSubscription{
+			UserID: sub.user.ID, EventTypes: []string{"task.completed"}, Repository: sub.repo,
+			Channel: "webhook", Target: "https://hooks.example.com/x",
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		repo string
+		want []string
+	}{
+		{"alice/secret", []string{"alice", "root"}},
+		{"secret", []string{"alice", "root"}},
+		{"bob/public", []string{"alice", "bob", "root"}},
+		{"", []string{"alice", "bob", "root"}},
+	} {
+		subs, err := store.ListSubscribers(ctx, repositories.SubscriberFilter{EventType: "task.completed", Repository: tc.repo})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, sub := range subs {
+			got = append(got, logins[sub.UserID])
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: subscribers = %v, want %v", tc.repo, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"ai-git-workbench/internal/infrastructure/config"
+)
+
+// NewPostgresConnection creates a new PostgreSQL database connection
+func NewPostgresConnection(ctx context.Context, cfg *config.DatabaseConfig) (*DB, error) {
+	connConfig, err := pgxConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return connect(ctx, stdlib.OpenDB(*connConfig), postgresDialect{}, cfg)
+}
+
+// pgxConfig translates DatabaseConfig into a pgx connection config.
+// Read and write timeouts are MySQL only; use statement_timeout on the server instead.
+func pgxConfig(cfg *config.DatabaseConfig) (*pgx.ConnConfig, error) {
+	query := url.Values{}
+	query.Set("sslmode", postgresSSLMode(cfg.TLSMode))
+	if cfg.TLSCAFile != "" {
+		query.Set("sslrootcert", cfg.TLSCAFile)
+	}
+	if cfg.ConnectTimeout > 0 {
+		query.Set("connect_timeout", strconv.Itoa(int(cfg.ConnectTimeout.Seconds())))
+	}
+	query.Set("timezone", cfg.Timezone)
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, port(cfg, "5432")),
+		Path:     "/" + cfg.Name,
+		RawQuery: query.Encode(),
+	}
+
+	connConfig, err := pgx.ParseConfig(dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("error configuring database: %w", err)
+	}
+	if cfg.TLSServerName != "" && connConfig.TLSConfig != nil {
+		connConfig.TLSConfig.ServerName = cfg.TLSServerName
+	}
+	return connConfig, nil
+}
+
+// postgresSSLMode maps the shared TLS modes onto libpq sslmode values
+func postgresSSLMode(tlsMode string) string {
+	switch tlsMode {
+	case "preferred":
+		return "prefer"
+	case "skip-verify":
+		return "require"
+	case "required":
+		return "verify-full"
+	default:
+		return "disable"
+	}
+}
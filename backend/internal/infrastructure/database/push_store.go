@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// vapidKeyID is the id of the single vapid_keys row
+const vapidKeyID = 1
+
+// PushStore persists browser push subscriptions and the VAPID key pair
+type PushStore struct {
+	db *DB
+}
+
+var (
+	_ repositories.PushSubscriptionRepository = (*PushStore)(nil)
+	_ repositories.VAPIDKeyRepository         = (*PushStore)(nil)
+)
+
+// NewPushStore creates a new PushStore
+func NewPushStore(db *DB) *PushStore {
+	return &PushStore{db: db}
+}
+
+// ListByUser returns the subscriptions of a user, oldest first
+func (s *PushStore) ListByUser(ctx context.Context, userID int64) ([]entities.PushSubscription, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, user_id, endpoint, p256dh, auth, user_agent, created_at,
+		last_used_at FROM push_subscriptions WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing push subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []entities.PushSubscription{}
+	for rows.Next() {
+		var (
+			sub        entities.PushSubscription
+			lastUsedAt sql.NullTime
+		)
+		if err := rows.Scan(&sub.ID, &sub.UserID, &sub.Endpoint, &sub.P256dh, &sub.Auth, &sub.UserAgent,
+			&sub.CreatedAt, &lastUsedAt); err != nil {
+			return nil, fmt.Errorf("error scanning push subscription: %w", err)
+		}
+		sub.LastUsedAt = nullTime(lastUsedAt)
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// Save stores a subscription. A browser subscribing again, possibly as
+// another user, replaces its previous subscription.
+func (s *PushStore) Save(ctx context.Context, sub *entities.PushSubscription) error {
+	sub.CreatedAt = now()
+	hash := endpointHash(sub.Endpoint)
+
+	err := s.db.WithTx(ctx, func(tx *Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM push_subscriptions WHERE endpoint_hash = ?", hash); err != nil {
+			return err
+		}
+		id, err := s.db.dialect.InsertID(ctx, tx, `INSERT INTO push_subscriptions
+			(user_id, endpoint, endpoint_hash, p256dh, auth, user_agent, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			sub.UserID, sub.Endpoint, hash, sub.P256dh, sub.Auth, sub.UserAgent, sub.CreatedAt,
+		)
+		sub.ID = id
+		return err
+	})
+	if s.db.dialect.IsUniqueViolation(err) {
+		return repositories.ErrConflict
+	}
+	if err != nil {
+		return fmt.Errorf("error saving push subscription: %w", err)
+	}
+	return nil
+}
+
+// DeleteByEndpoint removes the subscription of an endpoint
+func (s *PushStore) DeleteByEndpoint(ctx context.Context, endpoint string, userID int64) error {
+	query, args := "DELETE FROM push_subscriptions WHERE endpoint_hash = ?", []interface{}{endpointHash(endpoint)}
+	if userID != 0 {
+		query += " AND user_id = ?"
+		args = append(args, userID)
+	}
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error deleting push subscription: %w", err)
+	}
+	return requireAffected(result)
+}
+
+// TouchLastUsed records a successful delivery
+func (s *PushStore) TouchLastUsed(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, "UPDATE push_subscriptions SET last_used_at = ? WHERE id = ?", now(), id); err != nil {
+		return fmt.Errorf("error updating push subscription: %w", err)
+	}
+	return nil
+}
+
+// Get returns the stored VAPID key pair
+func (s *PushStore) Get(ctx context.Context) (*entities.VAPIDKeyPair, error) {
+	var keys entities.VAPIDKeyPair
+	err := s.db.QueryRowContext(ctx, "SELECT public_key, private_key, created_at FROM vapid_keys WHERE id = ?",
+		vapidKeyID).Scan(&keys.PublicKey, &keys.PrivateKey, &keys.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositories.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting vapid keys: %w", err)
+	}
+	return &keys, nil
+}
+
+// Create stores the VAPID key pair. Instances starting together race on the
+// primary key, so only one pair is ever stored.
+func (s *PushStore) Create(ctx context.Context, keys *entities.VAPIDKeyPair) error {
+	keys.CreatedAt = now()
+	_, err := s.db.ExecContext(ctx, "INSERT INTO vapid_keys (id, public_key, private_key, created_at) VALUES (?, ?, ?, ?)",
+		vapidKeyID, keys.PublicKey, keys.PrivateKey, keys.CreatedAt)
+	if s.db.dialect.IsUniqueViolation(err) {
+		return repositories.ErrConflict
+	}
+	if err != nil {
+		return fmt.Errorf("error creating vapid keys: %w", err)
+	}
+	return nil
+}
+
+// endpointHash indexes endpoints, which are too long for a unique key on MySQL
+func endpointHash(endpoint string) string {
+	sum := sha256.Sum256([]byte(endpoint))
+	return hex.EncodeToString(sum[:])
+}
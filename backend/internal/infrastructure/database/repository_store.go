@@ -0,0 +1,253 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+const repositoryColumns = `id, name, full_name, description, private, language, url, html_url, clone_url,
+	stars, forks, is_connected, owner_id, topics, last_sync, issue_sync, created_at, updated_at, version`
+
+// repositoryKeyset lists the fields repositories can be sorted by, by full name by default
+var repositoryKeyset = keyset[entities.Repository]{
+	columns: map[string]sortColumn{
+		"id":         {"id", kindInt},
+		"name":       {"name", kindString},
+		"full_name":  {"full_name", kindString},
+		"stars":      {"stars", kindInt},
+		"forks":      {"forks", kindInt},
+		"created_at": {"created_at", kindTime},
+		"updated_at": {"updated_at", kindTime},
+	},
+	defaults: []repositories.SortField{{Field: "full_name"}},
+	value: func(r *entities.Repository, field string) interface{} {
+		switch field {
+		case "id":
+			return r.ID
+		case "name":
+			return r.Name
+		case "full_name":
+			return r.FullName
+		case "stars":
+			return r.Stars
+		case "forks":
+			return r.Forks
+		case "created_at":
+			return r.CreatedAt
+		case "updated_at":
+			return r.UpdatedAt
+		}
+		return nil
+	},
+}
+
+// RepositoryStore persists connected repositories in the repositories table
+type RepositoryStore struct {
+	db *DB
+}
+
+var _ repositories.RepositoryRepository = (*RepositoryStore)(nil)
+
+// NewRepositoryStore creates a new RepositoryStore
+func NewRepositoryStore(db *DB) *RepositoryStore {
+	return &RepositoryStore{db: db}
+}
+
+// List returns a page of the repositories matching filter
+func (s *RepositoryStore) List(ctx context.Context, filter repositories.RepositoryFilter, opts repositories.ListOptions) (repositories.Page[entities.Repository], error) {
+	p, err := repositoryKeyset.resolve(opts)
+	if err != nil {
+		return repositories.Page[entities.Repository]{}, err
+	}
+
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if filter.Scope != nil {
+		if scope, scopeArgs := repositoryScope("repositories", *filter.Scope); scope != "" {
+			conds = append(conds, scope)
+			args = append(args, scopeArgs...)
+		}
+	}
+	if cond, after := repositoryKeyset.where(p); cond != "" {
+		conds = append(conds, cond)
+		args = append(args, after...)
+	}
+	query := "SELECT " + repositoryColumns + " FROM repositories" + whereClause(conds) + repositoryKeyset.orderBy(p) + " LIMIT ?"
+	args = append(args, p.limit+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return repositories.Page[entities.Repository]{}, fmt.Errorf("error listing repositories: %w", err)
+	}
+	defer rows.Close()
+
+	repos := []entities.Repository{}
+	for rows.Next() {
+		repo, err := scanRepository(rows)
+		if err != nil {
+			return repositories.Page[entities.Repository]{}, fmt.Errorf("error scanning repository: %w", err)
+		}
+		repos = append(repos, *repo)
+	}
+	if err := rows.Err(); err != nil {
+		return repositories.Page[entities.Repository]{}, fmt.Errorf("error listing repositories: %w", err)
+	}
+	return repositoryKeyset.finish(p, repos)
+}
+
+// GetByID returns the repository with the given id
+func (s *RepositoryStore) GetByID(ctx context.Context, id int64) (*entities.Repository, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+repositoryColumns+" FROM repositories WHERE id = ?", id)
+	repo, err := scanRepository(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositories.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting repository: %w", err)
+	}
+	return repo, nil
+}
+
+// GetByName returns the repository tasks name as name: the one with that
+// full name, or else with that name. When several match, a private one is
+// returned so access checks err on the side of caution.
+func (s *RepositoryStore) GetByName(ctx context.Context, name string) (*entities.Repository, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+repositoryColumns+` FROM repositories
+		WHERE full_name = ? OR name = ? ORDER BY full_name = ? DESC, private DESC, id LIMIT 1`, name, name, name)
+	repo, err := scanRepository(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositories.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting repository: %w", err)
+	}
+	return repo, nil
+}
+
+// Create inserts the repository and sets its generated id and timestamps
+func (s *RepositoryStore) Create(ctx context.Context, repo *entities.Repository) error {
+	topics, err := marshalJSON(repo.Topics)
+	if err != nil {
+		return err
+	}
+	created := now()
+	repo.CreatedAt = created
+	repo.UpdatedAt = created
+	repo.Version = 1
+
+	id, err := s.db.dialect.InsertID(ctx, s.db, `INSERT INTO repositories (name, full_name, description,
+		private, language, url, html_url, clone_url, stars, forks, is_connected, owner_id, topics,
+		last_sync, issue_sync, created_at, updated_at, version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		repo.Name, repo.FullName, repo.Description, repo.Private, repo.Language, repo.URL, repo.HTMLURL,
+		repo.CloneURL, repo.Stars, repo.Forks, repo.IsConnected, repo.OwnerID, topics, repo.LastSync,
+		repo.IssueSync, repo.CreatedAt, repo.UpdatedAt, repo.Version,
+	)
+	if s.db.dialect.IsUniqueViolation(err) {
+		return repositories.ErrConflict
+	}
+	if err != nil {
+		return fmt.Errorf("error creating repository: %w", err)
+	}
+	repo.ID = id
+	return nil
+}
+
+// Update saves every field of the repository except its id and creation
+// time. A non-zero version must match the stored one; it is incremented on
+// success.
+func (s *RepositoryStore) Update(ctx context.Context, repo *entities.Repository) error {
+	topics, err := marshalJSON(repo.Topics)
+	if err != nil {
+		return err
+	}
+	updated := now()
+
+	query := `UPDATE repositories SET name = ?, full_name = ?, description = ?,
+		private = ?, language = ?, url = ?, html_url = ?, clone_url = ?, stars = ?, forks = ?,
+		is_connected = ?, owner_id = ?, topics = ?, last_sync = ?, issue_sync = ?, updated_at = ?,
+		version = version + 1
+		WHERE id = ?`
+	args := []interface{}{
+		repo.Name, repo.FullName, repo.Description, repo.Private, repo.Language, repo.URL, repo.HTMLURL,
+		repo.CloneURL, repo.Stars, repo.Forks, repo.IsConnected, repo.OwnerID, topics, repo.LastSync,
+		repo.IssueSync, updated, repo.ID,
+	}
+	if repo.Version > 0 {
+		query += " AND version = ?"
+		args = append(args, repo.Version)
+	}
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if s.db.dialect.IsUniqueViolation(err) {
+		return repositories.ErrConflict
+	}
+	if err != nil {
+		return fmt.Errorf("error updating repository: %w", err)
+	}
+	if err := requireVersion(ctx, s.db, result, "repositories", repo.ID, repo.Version); err != nil {
+		return err
+	}
+	if repo.Version > 0 {
+		repo.Version++
+	} else if err := s.db.QueryRowContext(ctx, "SELECT version FROM repositories WHERE id = ?", repo.ID).
+		Scan(&repo.Version); err != nil {
+		return fmt.Errorf("error reading repository version: %w", err)
+	}
+	repo.UpdatedAt = updated
+	return nil
+}
+
+// Delete removes the repository with the given id. A non-zero version must
+// match the stored one.
+func (s *RepositoryStore) Delete(ctx context.Context, id int64, version int64) error {
+	query := "DELETE FROM repositories WHERE id = ?"
+	args := []interface{}{id}
+	if version > 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error deleting repository: %w", err)
+	}
+	return requireVersion(ctx, s.db, result, "repositories", id, version)
+}
+
+// scanRepository reads a row selected with repositoryColumns
+func scanRepository(row rowScanner) (*entities.Repository, error) {
+	var (
+		repo        entities.Repository
+		description sql.NullString
+		language    sql.NullString
+		ownerID     sql.NullInt64
+		topics      sql.NullString
+		lastSync    sql.NullTime
+	)
+	if err := row.Scan(
+		&repo.ID, &repo.Name, &repo.FullName, &description, &repo.Private, &language, &repo.URL,
+		&repo.HTMLURL, &repo.CloneURL, &repo.Stars, &repo.Forks, &repo.IsConnected, &ownerID, &topics, &lastSync,
+		&repo.IssueSync, &repo.CreatedAt, &repo.UpdatedAt, &repo.Version,
+	); err != nil {
+		return nil, err
+	}
+
+	repo.Description = description.String
+	repo.Language = language.String
+	repo.LastSync = nullTime(lastSync)
+	if ownerID.Valid {
+		repo.OwnerID = &ownerID.Int64
+	}
+	if err := unmarshalJSON(topics, &repo.Topics); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
@@ -0,0 +1,260 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// searchTable describes a table with a full-text index. Queries alias the
+// table as x so scope conditions can refer to its columns.
+type searchTable struct {
+	name    string
+	fts     string
+	columns []string
+}
+
+var (
+	taskSearch       = searchTable{name: "tasks", fts: "tasks_fts", columns: []string{"title", "description"}}
+	repositorySearch = searchTable{name: "repositories", fts: "repositories_fts", columns: []string{"description", "topics"}}
+)
+
+// textMatch is the dialect specific SQL that matches and ranks a searchTable
+type textMatch struct {
+	from      string
+	cond      string
+	condArgs  []interface{}
+	score     string
+	scoreArgs []interface{}
+}
+
+// SearchStore runs full-text queries using MySQL FULLTEXT indexes,
+// PostgreSQL text search or SQLite FTS5 tables, depending on the dialect
+type SearchStore struct {
+	db *DB
+}
+
+var _ repositories.SearchRepository = (*SearchStore)(nil)
+
+// NewSearchStore creates a new SearchStore
+func NewSearchStore(db *DB) *SearchStore {
+	return &SearchStore{db: db}
+}
+
+// Search returns the best matching records of the requested types, ranked by
+// relevance, and the number of matches of each type
+func (s *SearchStore) Search(ctx context.Context, query repositories.SearchQuery) (*repositories.SearchResult, error) {
+	result := &repositories.SearchResult{Facets: make(map[string]int)}
+	if len(query.Terms) == 0 {
+		return result, nil
+	}
+
+	// Each type contributes at most offset+limit hits to the merged ranking
+	window := query.Offset + query.Limit
+
+	if searchesType(query, repositories.SearchTypeTask) {
+		hits, total, err := s.searchTasks(ctx, query, window)
+		if err != nil {
+			return nil, err
+		}
+		result.Hits = append(result.Hits, hits...)
+		result.Facets[repositories.SearchTypeTask] = total
+	}
+	if searchesType(query, repositories.SearchTypeRepository) {
+		hits, total, err := s.searchRepositories(ctx, query, window)
+		if err != nil {
+			return nil, err
+		}
+		result.Hits = append(result.Hits, hits...)
+		result.Facets[repositories.SearchTypeRepository] = total
+	}
+
+	sort.SliceStable(result.Hits, func(i, j int) bool {
+		return result.Hits[i].Score > result.Hits[j].Score
+	})
+	if query.Offset >= len(result.Hits) {
+		result.Hits = nil
+	} else {
+		result.Hits = result.Hits[query.Offset:min(window, len(result.Hits))]
+	}
+	return result, nil
+}
+
+// searchTasks returns the top matching tasks and the number of matches
+func (s *SearchStore) searchTasks(ctx context.Context, query repositories.SearchQuery, limit int) ([]repositories.SearchHit, int, error) {
+	m := s.match(taskSearch, query.Terms)
+	scope, scopeArgs := taskScope(query.Scope)
+
+	rows, total, err := s.run(ctx, m, qualify("x", taskColumns), scope, scopeArgs, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error searching tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []repositories.SearchHit
+	for rows.Next() {
+		var score float64
+		task, err := scanTask(scoredRow{rows, &score})
+		if err != nil {
+			return nil, 0, fmt.Errorf("error scanning task: %w", err)
+		}
+		hits = append(hits, repositories.SearchHit{Type: repositories.SearchTypeTask, Score: score, Task: task})
+	}
+	return hits, total, rows.Err()
+}
+
+// searchRepositories returns the top matching repositories and the number of matches
+func (s *SearchStore) searchRepositories(ctx context.Context, query repositories.SearchQuery, limit int) ([]repositories.SearchHit, int, error) {
+	m := s.match(repositorySearch, query.Terms)
+	scope, scopeArgs := repositoryScope("x", query.Scope)
+
+	rows, total, err := s.run(ctx, m, qualify("x", repositoryColumns), scope, scopeArgs, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error searching repositories: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []repositories.SearchHit
+	for rows.Next() {
+		var score float64
+		repo, err := scanRepository(scoredRow{rows, &score})
+		if err != nil {
+			return nil, 0, fmt.Errorf("error scanning repository: %w", err)
+		}
+		hits = append(hits, repositories.SearchHit{Type: repositories.SearchTypeRepository, Score: score, Repository: repo})
+	}
+	return hits, total, rows.Err()
+}
+
+// run counts the matches and selects the best ones with their score as the last column
+func (s *SearchStore) run(ctx context.Context, m textMatch, columns, scope string, scopeArgs []interface{}, limit int) (rowsCloser, int, error) {
+	conds := []string{m.cond}
+	args := append([]interface{}{}, m.condArgs...)
+	if scope != "" {
+		conds = append(conds, scope)
+		args = append(args, scopeArgs...)
+	}
+	where := whereClause(conds)
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+m.from+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	selectArgs := append(append([]interface{}{}, m.scoreArgs...), args...)
+	selectArgs = append(selectArgs, limit)
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+columns+", "+m.score+" AS score FROM "+m.from+where+" ORDER BY score DESC LIMIT ?",
+		selectArgs...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+// match builds the dialect specific match condition and score for terms.
+// Records matching any term are returned; the score favours records that
+// match more terms and rarer terms.
+func (s *SearchStore) match(t searchTable, terms []string) textMatch {
+	switch s.db.dialect.Name() {
+	case "mysql":
+		expr := "MATCH(" + qualify("x", strings.Join(t.columns, ", ")) + ") AGAINST (? IN NATURAL LANGUAGE MODE)"
+		q := strings.Join(terms, " ")
+		return textMatch{
+			from: t.name + " x", cond: expr, condArgs: []interface{}{q},
+			score: expr, scoreArgs: []interface{}{q},
+		}
+	case "postgres":
+		parts := make([]string, len(t.columns))
+		for i, c := range t.columns {
+			parts[i] = "coalesce(x." + c + ", '')"
+		}
+		vector := "to_tsvector('simple', " + strings.Join(parts, " || ' ' || ") + ")"
+		q := strings.Join(terms, " | ")
+		return textMatch{
+			from: t.name + " x", cond: vector + " @@ to_tsquery('simple', ?)", condArgs: []interface{}{q},
+			score: "ts_rank(" + vector + ", to_tsquery('simple', ?))", scoreArgs: []interface{}{q},
+		}
+	default:
+		quoted := make([]string, len(terms))
+		for i, term := range terms {
+			quoted[i] = `"` + term + `"`
+		}
+		rowid := "rowid"
+		if t.name == repositorySearch.name {
+			rowid = "id"
+		}
+		return textMatch{
+			from: t.fts + " JOIN " + t.name + " x ON x." + rowid + " = " + t.fts + ".rowid",
+			cond: t.fts + " MATCH ?", condArgs: []interface{}{strings.Join(quoted, " OR ")},
+			// bm25 is lower for better matches
+			score: "-bm25(" + t.fts + ")",
+		}
+	}
+}
+
+// taskScope hides tasks that belong to private repositories the caller cannot see
+func taskScope(scope repositories.AccessScope) (string, []interface{}) {
+	if scope.Admin {
+		return "", nil
+	}
+	hidden, args := repositoryScope("r", scope)
+	return `NOT EXISTS (SELECT 1 FROM repositories r
+		WHERE (r.full_name = x.repository OR r.name = x.repository) AND NOT ` + hidden + `)`, args
+}
+
+// repositoryScope limits repositories aliased as alias to those the caller can see
+func repositoryScope(alias string, scope repositories.AccessScope) (string, []interface{}) {
+	switch {
+	case scope.Admin:
+		return "", nil
+	case scope.UserID == 0:
+		return "(" + alias + ".private = ?)", []interface{}{false}
+	default:
+		return "(" + alias + ".private = ? OR " + alias + ".owner_id = ?)", []interface{}{false, scope.UserID}
+	}
+}
+
+// searchesType reports whether the query includes records of type t
+func searchesType(query repositories.SearchQuery, t string) bool {
+	if len(query.Types) == 0 {
+		return true
+	}
+	for _, typ := range query.Types {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
+
+// qualify prefixes each column in a comma separated list with alias
+func qualify(alias, columns string) string {
+	parts := strings.Split(columns, ",")
+	for i, c := range parts {
+		parts[i] = alias + "." + strings.TrimSpace(c)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// rowsCloser is the part of *sql.Rows used to read search results
+type rowsCloser interface {
+	rowScanner
+	Next() bool
+	Err() error
+	Close() error
+}
+
+// scoredRow appends the score column to the destinations of a row scanner
+type scoredRow struct {
+	rows  rowScanner
+	score *float64
+}
+
+func (r scoredRow) Scan(dest ...interface{}) error {
+	return r.rows.Scan(append(dest, r.score)...)
+}
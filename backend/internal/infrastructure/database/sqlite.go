@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+
+	"ai-git-workbench/internal/infrastructure/config"
+)
+
+// NewSQLiteConnection opens a SQLite database file, creating it if needed
+func NewSQLiteConnection(ctx context.Context, cfg *config.DatabaseConfig) (*DB, error) {
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Set("_time_format", "sqlite")
+
+	db, err := sql.Open("sqlite", "file:"+cfg.Path+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	// SQLite allows a single writer; one connection avoids "database is locked" errors
+	sqliteCfg := *cfg
+	sqliteCfg.MaxOpenConns = 1
+	sqliteCfg.MaxIdleConns = 1
+
+	return connect(ctx, db, sqliteDialect{}, &sqliteCfg)
+}
@@ -0,0 +1,445 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+const taskColumns = `id, title, description, status, repository, epic, branch, assignee, tokens_used,
+	metadata, created_at, updated_at, started_at, completed_at, version`
+
+// taskKeyset lists the fields tasks can be sorted by, newest first by default
+var taskKeyset = keyset[entities.Task]{
+	columns: map[string]sortColumn{
+		"id":          {"id", kindString},
+		"title":       {"title", kindString},
+		"status":      {"status", kindString},
+		"repository":  {"repository", kindString},
+		"epic":        {"epic", kindString},
+		"branch":      {"branch", kindString},
+		"assignee":    {"assignee", kindString},
+		"tokens_used": {"tokens_used", kindInt},
+		"created_at":  {"created_at", kindTime},
+		"updated_at":  {"updated_at", kindTime},
+	},
+	defaults: []repositories.SortField{{Field: "created_at", Desc: true}},
+	value: func(t *entities.Task, field string) interface{} {
+		switch field {
+		case "id":
+			return t.ID
+		case "title":
+			return t.Title
+		case "status":
+			return t.Status
+		case "repository":
+			return t.Repository
+		case "epic":
+			return t.Epic
+		case "branch":
+			return t.Branch
+		case "assignee":
+			return t.Assignee
+		case "tokens_used":
+			return t.TokensUsed
+		case "created_at":
+			return t.CreatedAt
+		case "updated_at":
+			return t.UpdatedAt
+		}
+		return nil
+	},
+}
+
+// TaskStore persists tasks in the tasks table
+type TaskStore struct {
+	db *DB
+}
+
+var _ repositories.TaskRepository = (*TaskStore)(nil)
+
+// NewTaskStore creates a new TaskStore
+func NewTaskStore(db *DB) *TaskStore {
+	return &TaskStore{db: db}
+}
+
+// List returns a page of the tasks matching filter
+func (s *TaskStore) List(ctx context.Context, filter repositories.TaskFilter, opts repositories.ListOptions) (repositories.Page[entities.Task], error) {
+	p, err := taskKeyset.resolve(opts)
+	if err != nil {
+		return repositories.Page[entities.Task]{}, err
+	}
+
+	conds, args := taskConditions(filter)
+	if cond, after := taskKeyset.where(p); cond != "" {
+		conds = append(conds, cond)
+		args = append(args, after...)
+	}
+	query := "SELECT " + taskColumns + " FROM tasks x" + whereClause(conds) + taskKeyset.orderBy(p) + " LIMIT ?"
+	args = append(args, p.limit+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return repositories.Page[entities.Task]{}, fmt.Errorf("error listing tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []entities.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return repositories.Page[entities.Task]{}, fmt.Errorf("error scanning task: %w", err)
+		}
+		tasks = append(tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return repositories.Page[entities.Task]{}, fmt.Errorf("error listing tasks: %w", err)
+	}
+	return taskKeyset.finish(p, tasks)
+}
+
+// taskConditions translates a TaskFilter into WHERE conditions on the tasks
+// table aliased as x
+func taskConditions(filter repositories.TaskFilter) ([]string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if len(filter.Status) > 0 {
+		conds = append(conds, "status IN ("+placeholders(len(filter.Status))+")")
+		for _, status := range filter.Status {
+			args = append(args, status)
+		}
+	}
+	for _, eq := range []struct{ column, value string }{
+		{"repository", filter.Repository},
+		{"epic", filter.Epic},
+		{"branch", filter.Branch},
+		{"assignee", filter.Assignee},
+	} {
+		if eq.value != "" {
+			conds = append(conds, eq.column+" = ?")
+			args = append(args, eq.value)
+		}
+	}
+	for _, bound := range []struct {
+		cond  string
+		value *time.Time
+	}{
+		{"created_at >= ?", filter.CreatedAfter},
+		{"created_at < ?", filter.CreatedBefore},
+		{"updated_at >= ?", filter.UpdatedAfter},
+		{"updated_at < ?", filter.UpdatedBefore},
+	} {
+		if bound.value != nil {
+			conds = append(conds, bound.cond)
+			args = append(args, bound.value.UTC())
+		}
+	}
+	if filter.Scope != nil {
+		if scope, scopeArgs := taskScope(*filter.Scope); scope != "" {
+			conds = append(conds, scope)
+			args = append(args, scopeArgs...)
+		}
+	}
+	return conds, args
+}
+
+// GetByID returns the task with the given id
+func (s *TaskStore) GetByID(ctx context.Context, id string) (*entities.Task, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = ?", id)
+	task, err := scanTask(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositories.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting task: %w", err)
+	}
+	return task, nil
+}
+
+// Create inserts the task, assigning an id, status and timestamps when missing
+func (s *TaskStore) Create(ctx context.Context, task *entities.Task) error {
+	return s.create(ctx, s.db, task)
+}
+
+func (s *TaskStore) create(ctx context.Context, q querier, task *entities.Task) error {
+	if task.ID == "" {
+		id, err := newID()
+		if err != nil {
+			return err
+		}
+		task.ID = id
+	}
+	if task.Status == "" {
+		task.Status = entities.TaskStatusPending
+	}
+	created := now()
+	task.CreatedAt = created
+	task.UpdatedAt = created
+	task.Version = 1
+
+	metadata, err := marshalJSON(task.Metadata)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, `INSERT INTO tasks (`+taskColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		task.ID, task.Title, task.Description, task.Status, task.Repository, task.Epic, task.Branch,
+		task.Assignee, task.TokensUsed, metadata, task.CreatedAt, task.UpdatedAt, task.StartedAt, task.CompletedAt,
+		task.Version,
+	)
+	if s.db.dialect.IsUniqueViolation(err) {
+		return repositories.ErrConflict
+	}
+	if err != nil {
+		return fmt.Errorf("error creating task: %w", err)
+	}
+	return nil
+}
+
+// Update saves every field of the task except its id and creation time. A
+// non-zero version must match the stored one; it is incremented on success.
+func (s *TaskStore) Update(ctx context.Context, task *entities.Task) error {
+	return s.update(ctx, s.db, task)
+}
+
+func (s *TaskStore) update(ctx context.Context, q querier, task *entities.Task) error {
+	metadata, err := marshalJSON(task.Metadata)
+	if err != nil {
+		return err
+	}
+	updated := now()
+
+	query := `UPDATE tasks SET title = ?, description = ?, status = ?,
+		repository = ?, epic = ?, branch = ?, assignee = ?, tokens_used = ?, metadata = ?, updated_at = ?,
+		started_at = ?, completed_at = ?, version = version + 1
+		WHERE id = ?`
+	args := []interface{}{
+		task.Title, task.Description, task.Status, task.Repository, task.Epic, task.Branch,
+		task.Assignee, task.TokensUsed, metadata, updated, task.StartedAt, task.CompletedAt, task.ID,
+	}
+	if task.Version > 0 {
+		query += " AND version = ?"
+		args = append(args, task.Version)
+	}
+
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error updating task: %w", err)
+	}
+	if err := requireVersion(ctx, q, result, "tasks", task.ID, task.Version); err != nil {
+		return err
+	}
+	if task.Version > 0 {
+		task.Version++
+	} else if err := q.QueryRowContext(ctx, "SELECT version FROM tasks WHERE id = ?", task.ID).
+		Scan(&task.Version); err != nil {
+		return fmt.Errorf("error reading task version: %w", err)
+	}
+	task.UpdatedAt = updated
+	return nil
+}
+
+// Delete removes the task with the given id. A non-zero version must match
+// the stored one.
+func (s *TaskStore) Delete(ctx context.Context, id string, version int64) error {
+	return s.delete(ctx, s.db, id, version)
+}
+
+func (s *TaskStore) delete(ctx context.Context, q querier, id string, version int64) error {
+	query := "DELETE FROM tasks WHERE id = ?"
+	args := []interface{}{id}
+	if version > 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error deleting task: %w", err)
+	}
+	return requireVersion(ctx, q, result, "tasks", id, version)
+}
+
+// ApplyBatch carries out writes in one transaction. When one fails, none
+// is applied and a *repositories.BatchError names it.
+func (s *TaskStore) ApplyBatch(ctx context.Context, writes []repositories.TaskWrite) error {
+	return s.db.WithTx(ctx, func(tx *Tx) error {
+		for i, w := range writes {
+			var err error
+			switch w.Op {
+			case repositories.WriteCreate:
+				err = s.create(ctx, tx, w.Task)
+			case repositories.WriteUpdate:
+				err = s.update(ctx, tx, w.Task)
+			case repositories.WriteDelete:
+				err = s.delete(ctx, tx, w.Task.ID, w.Task.Version)
+			default:
+				err = fmt.Errorf("unknown task write %q", w.Op)
+			}
+			if err != nil {
+				return &repositories.BatchError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+}
+
+// RequeueTasks puts interrupted tasks back into the queued state so they are
+// picked up again after a restart
+func (s *TaskStore) RequeueTasks(ctx context.Context, taskIDs []string) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(taskIDs)+3)
+	args = append(args, entities.TaskStatusQueued, now())
+	for _, id := range taskIDs {
+		args = append(args, id)
+	}
+	args = append(args, entities.TaskStatusInProgress)
+
+	query := fmt.Sprintf(
+		"UPDATE tasks SET status = ?, started_at = NULL, updated_at = ?, version = version + 1 WHERE id IN (%s) AND status = ?",
+		placeholders(len(taskIDs)),
+	)
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("error requeuing tasks: %w", err)
+	}
+	return nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask reads a row selected with taskColumns
+func scanTask(row rowScanner) (*entities.Task, error) {
+	var (
+		task        entities.Task
+		description sql.NullString
+		metadata    sql.NullString
+		startedAt   sql.NullTime
+		completedAt sql.NullTime
+	)
+	if err := row.Scan(
+		&task.ID, &task.Title, &description, &task.Status, &task.Repository, &task.Epic, &task.Branch,
+		&task.Assignee, &task.TokensUsed, &metadata, &task.CreatedAt, &task.UpdatedAt, &startedAt, &completedAt,
+		&task.Version,
+	); err != nil {
+		return nil, err
+	}
+
+	task.Description = description.String
+	task.StartedAt = nullTime(startedAt)
+	task.CompletedAt = nullTime(completedAt)
+	if err := unmarshalJSON(metadata, &task.Metadata); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// newID returns a random RFC 4122 version 4 UUID
+func newID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("error generating id: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
+
+// marshalJSON encodes v for a JSON text column, storing NULL for empty values
+func marshalJSON(v interface{}) (sql.NullString, error) {
+	switch v := v.(type) {
+	case map[string]string:
+		if len(v) == 0 {
+			return sql.NullString{}, nil
+		}
+	case []string:
+		if len(v) == 0 {
+			return sql.NullString{}, nil
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("error encoding json column: %w", err)
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+// unmarshalJSON decodes a JSON text column into v, leaving v untouched for NULL
+func unmarshalJSON(s sql.NullString, v interface{}) error {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(s.String), v); err != nil {
+		return fmt.Errorf("error decoding json column: %w", err)
+	}
+	return nil
+}
+
+// nullTime converts a nullable column into an optional time in UTC
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
+
+// requireAffected maps an UPDATE or DELETE that matched no rows to ErrNotFound
+func requireAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repositories.ErrNotFound
+	}
+	return nil
+}
+
+// requireVersion checks that a versioned update changed the row. When it did
+// not, the row is looked up to tell a missing record from a stale version.
+func requireVersion(ctx context.Context, db querier, result sql.Result, table string, id interface{}, version int64) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	if version == 0 {
+		return repositories.ErrNotFound
+	}
+
+	var exists int
+	err = db.QueryRowContext(ctx, "SELECT 1 FROM "+table+" WHERE id = ?", id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repositories.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error checking %s version: %w", table, err)
+	}
+	return repositories.ErrStale
+}
+
+// now returns the current time in UTC at the microsecond precision every dialect stores
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// TokenStore persists hashed API tokens in the api_tokens table
+type TokenStore struct {
+	db *DB
+}
+
+var _ repositories.TokenRepository = (*TokenStore)(nil)
+
+// NewTokenStore creates a new TokenStore
+func NewTokenStore(db *DB) *TokenStore {
+	return &TokenStore{db: db}
+}
+
+// Create inserts the token and sets its generated id and creation time
+func (s *TokenStore) Create(ctx context.Context, token *entities.APIToken) error {
+	token.CreatedAt = now()
+
+	id, err := s.db.dialect.InsertID(ctx, s.db, `INSERT INTO api_tokens
+		(user_id, name, prefix, token_hash, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		token.UserID, token.Name, token.Prefix, token.TokenHash, token.CreatedAt, token.ExpiresAt,
+	)
+	if s.db.dialect.IsUniqueViolation(err) {
+		return repositories.ErrConflict
+	}
+	if err != nil {
+		return fmt.Errorf("error creating token: %w", err)
+	}
+	token.ID = id
+	return nil
+}
+
+// GetByHash returns the token whose secret hashes to hash
+func (s *TokenStore) GetByHash(ctx context.Context, hash string) (*entities.APIToken, error) {
+	var (
+		token      entities.APIToken
+		lastUsedAt sql.NullTime
+		expiresAt  sql.NullTime
+	)
+	err := s.db.QueryRowContext(ctx, `SELECT id, user_id, name, prefix, token_hash, created_at,
+		last_used_at, expires_at FROM api_tokens WHERE token_hash = ?`, hash,
+	).Scan(
+		&token.ID, &token.UserID, &token.Name, &token.Prefix, &token.TokenHash, &token.CreatedAt,
+		&lastUsedAt, &expiresAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositories.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting token: %w", err)
+	}
+	token.LastUsedAt = nullTime(lastUsedAt)
+	token.ExpiresAt = nullTime(expiresAt)
+	return &token, nil
+}
+
+// TouchLastUsed records that the token was just used
+func (s *TokenStore) TouchLastUsed(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, "UPDATE api_tokens SET last_used_at = ? WHERE id = ?", now(), id); err != nil {
+		return fmt.Errorf("error updating token: %w", err)
+	}
+	return nil
+}
+
+// DeleteByHash removes the token whose secret hashes to hash
+func (s *TokenStore) DeleteByHash(ctx context.Context, hash string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM api_tokens WHERE token_hash = ?", hash)
+	if err != nil {
+		return fmt.Errorf("error deleting token: %w", err)
+	}
+	return requireAffected(result)
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "ai-git-workbench/internal/infrastructure/database"
+
+// QueryContext runs a query that returns rows inside a client span.
+// Like all query helpers on DB it accepts ? placeholders for every dialect.
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	query = db.dialect.Rebind(query)
+	ctx, span := db.startSpan(ctx, "db.query", query)
+	defer span.End()
+
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	recordError(span, err)
+	return rows, err
+}
+
+// QueryRowContext runs a query that returns at most one row inside a client span
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	query = db.dialect.Rebind(query)
+	ctx, span := db.startSpan(ctx, "db.query_row", query)
+	defer span.End()
+
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	recordError(span, row.Err())
+	return row
+}
+
+// ExecContext runs a statement without returning rows inside a client span
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	query = db.dialect.Rebind(query)
+	ctx, span := db.startSpan(ctx, "db.exec", query)
+	defer span.End()
+
+	result, err := db.DB.ExecContext(ctx, query, args...)
+	recordError(span, err)
+	return result, err
+}
+
+// startSpan starts a client span describing a SQL statement
+func (db *DB) startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", db.dialect.Name()),
+			attribute.String("db.namespace", db.name),
+			attribute.String("db.query.text", query),
+		),
+	)
+}
+
+// recordError marks the span as failed when err is a real failure
+func recordError(span trace.Span, err error) {
+	if err == nil || err == sql.ErrNoRows {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
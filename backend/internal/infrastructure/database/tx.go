@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// querier is implemented by *DB and *Tx so stores can share query code
+// between single statements and transactions
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Tx is a database transaction whose query helpers rebind placeholders and
+// trace statements like those of DB
+type Tx struct {
+	tx *sql.Tx
+	db *DB
+}
+
+// WithTx runs fn in a transaction, committing if it returns nil and rolling
+// back otherwise
+func (db *DB) WithTx(ctx context.Context, fn func(tx *Tx) error) error {
+	sqlTx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	tx := &Tx{tx: sqlTx, db: db}
+
+	if err := fn(tx); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// QueryContext runs a query that returns rows inside the transaction
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	query = tx.db.dialect.Rebind(query)
+	ctx, span := tx.db.startSpan(ctx, "db.query", query)
+	defer span.End()
+
+	rows, err := tx.tx.QueryContext(ctx, query, args...)
+	recordError(span, err)
+	return rows, err
+}
+
+// QueryRowContext runs a query that returns at most one row inside the transaction
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	query = tx.db.dialect.Rebind(query)
+	ctx, span := tx.db.startSpan(ctx, "db.query_row", query)
+	defer span.End()
+
+	row := tx.tx.QueryRowContext(ctx, query, args...)
+	recordError(span, row.Err())
+	return row
+}
+
+// ExecContext runs a statement without returning rows inside the transaction
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	query = tx.db.dialect.Rebind(query)
+	ctx, span := tx.db.startSpan(ctx, "db.exec", query)
+	defer span.End()
+
+	result, err := tx.tx.ExecContext(ctx, query, args...)
+	recordError(span, err)
+	return result, err
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+const userColumns = "id, login, name, email, role, created_at, updated_at"
+
+// UserStore persists API users in the users table
+type UserStore struct {
+	db *DB
+}
+
+var _ repositories.UserRepository = (*UserStore)(nil)
+
+// NewUserStore creates a new UserStore
+func NewUserStore(db *DB) *UserStore {
+	return &UserStore{db: db}
+}
+
+// GetByID returns the user with the given id
+func (s *UserStore) GetByID(ctx context.Context, id int64) (*entities.User, error) {
+	return s.get(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id)
+}
+
+// GetByLogin returns the user with the given login
+func (s *UserStore) GetByLogin(ctx context.Context, login string) (*entities.User, error) {
+	return s.get(ctx, "SELECT "+userColumns+" FROM users WHERE login = ?", login)
+}
+
+// Create inserts the user and sets its generated id and timestamps
+func (s *UserStore) Create(ctx context.Context, user *entities.User) error {
+	created := now()
+	user.CreatedAt = created
+	user.UpdatedAt = created
+
+	id, err := s.db.dialect.InsertID(ctx, s.db,
+		"INSERT INTO users (login, name, email, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		user.Login, user.Name, user.Email, user.Role, user.CreatedAt, user.UpdatedAt,
+	)
+	if s.db.dialect.IsUniqueViolation(err) {
+		return repositories.ErrConflict
+	}
+	if err != nil {
+		return fmt.Errorf("error creating user: %w", err)
+	}
+	user.ID = id
+	return nil
+}
+
+// get runs a query selecting userColumns and scans the single row
+func (s *UserStore) get(ctx context.Context, query string, args ...interface{}) (*entities.User, error) {
+	var user entities.User
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(
+		&user.ID, &user.Login, &user.Name, &user.Email, &user.Role, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositories.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting user: %w", err)
+	}
+	return &user, nil
+}
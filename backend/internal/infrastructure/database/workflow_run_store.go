@@ -0,0 +1,431 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+const stepRunColumns = `step_id, type, status, attempts, outputs, error, started_at, finished_at, expires_at`
+
+const workflowRunColumns = `id, workflow_id, version, status, inputs, trigger_type, trigger_event, error,
+	created_by, created_at, started_at, finished_at`
+
+// workflowRunKeyset lists the fields runs can be sorted by, newest first by default
+var workflowRunKeyset = keyset[entities.WorkflowRun]{
+	columns: map[string]sortColumn{
+		"id":         {"id", kindString},
+		"status":     {"status", kindString},
+		"created_at": {"created_at", kindTime},
+	},
+	defaults: []repositories.SortField{{Field: "created_at", Desc: true}},
+	value: func(r *entities.WorkflowRun, field string) interface{} {
+		switch field {
+		case "id":
+			return r.ID
+		case "status":
+			return r.Status
+		case "created_at":
+			return r.CreatedAt
+		}
+		return nil
+	},
+}
+
+// WorkflowRunStore persists runs in the workflow_runs and workflow_step_runs tables
+type WorkflowRunStore struct {
+	db *DB
+}
+
+var _ repositories.WorkflowRunRepository = (*WorkflowRunStore)(nil)
+
+// NewWorkflowRunStore creates a new WorkflowRunStore
+func NewWorkflowRunStore(db *DB) *WorkflowRunStore {
+	return &WorkflowRunStore{db: db}
+}
+
+// Create stores the run and its steps, assigning an id and creation time
+func (s *WorkflowRunStore) Create(ctx context.Context, run *entities.WorkflowRun) error {
+	if run.ID == "" {
+		id, err := newID()
+		if err != nil {
+			return err
+		}
+		run.ID = id
+	}
+	run.CreatedAt = now()
+
+	if run.Trigger == "" {
+		run.Trigger = entities.TriggerManual
+	}
+	inputs, err := marshalJSON(run.Inputs)
+	if err != nil {
+		return err
+	}
+	event, err := marshalJSON(run.Event)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.WithTx(ctx, func(tx *Tx) error {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO workflow_runs (`+workflowRunColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			run.ID, run.WorkflowID, run.Version, run.Status, inputs, run.Trigger, event,
+			nullString(run.Error), run.CreatedBy, run.CreatedAt, run.StartedAt, run.FinishedAt,
+		); err != nil {
+			return err
+		}
+		for i := range run.Steps {
+			step := &run.Steps[i]
+			outputs, err := marshalJSON(step.Outputs)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO workflow_step_runs
+				(run_id, step_id, position, type, status, attempts, outputs, error, started_at, finished_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				run.ID, step.StepID, i, step.Type, step.Status, step.Attempts, outputs,
+				nullString(step.Error), step.StartedAt, step.FinishedAt,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if s.db.dialect.IsUniqueViolation(err) {
+		return repositories.ErrConflict
+	}
+	if err != nil {
+		return fmt.Errorf("error creating workflow run: %w", err)
+	}
+	return nil
+}
+
+// List returns a page of runs matching the filter, without their steps
+func (s *WorkflowRunStore) List(ctx context.Context, filter repositories.WorkflowRunFilter, opts repositories.ListOptions) (repositories.Page[entities.WorkflowRun], error) {
+	p, err := workflowRunKeyset.resolve(opts)
+	if err != nil {
+		return repositories.Page[entities.WorkflowRun]{}, err
+	}
+
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if filter.WorkflowID != 0 {
+		conds = append(conds, "workflow_id = ?")
+		args = append(args, filter.WorkflowID)
+	}
+	for _, in := range []struct {
+		column string
+		values []string
+	}{
+		{"status", filter.Status},
+		{"trigger_type", filter.Trigger},
+	} {
+		if len(in.values) == 0 {
+			continue
+		}
+		conds = append(conds, in.column+" IN ("+placeholders(len(in.values))+")")
+		for _, v := range in.values {
+			args = append(args, v)
+		}
+	}
+	if cond, condArgs := workflowRunKeyset.where(p); cond != "" {
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+	}
+
+	query := "SELECT " + workflowRunColumns + " FROM workflow_runs" +
+		whereClause(conds) + workflowRunKeyset.orderBy(p) + " LIMIT ?"
+	args = append(args, p.limit+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return repositories.Page[entities.WorkflowRun]{}, fmt.Errorf("error listing workflow runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []entities.WorkflowRun{}
+	for rows.Next() {
+		run, err := scanWorkflowRun(rows)
+		if err != nil {
+			return repositories.Page[entities.WorkflowRun]{}, fmt.Errorf("error scanning workflow run: %w", err)
+		}
+		runs = append(runs, *run)
+	}
+	if err := rows.Err(); err != nil {
+		return repositories.Page[entities.WorkflowRun]{}, fmt.Errorf("error listing workflow runs: %w", err)
+	}
+	return workflowRunKeyset.finish(p, runs)
+}
+
+// GetByID returns the run with its steps in definition order
+func (s *WorkflowRunStore) GetByID(ctx context.Context, id string) (*entities.WorkflowRun, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+workflowRunColumns+" FROM workflow_runs WHERE id = ?", id)
+	run, err := scanWorkflowRun(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositories.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting workflow run: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+stepRunColumns+
+		" FROM workflow_step_runs WHERE run_id = ? ORDER BY position", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting workflow steps: %w", err)
+	}
+	defer rows.Close()
+
+	run.Steps = []entities.StepRun{}
+	for rows.Next() {
+		step, err := scanStepRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning workflow step: %w", err)
+		}
+		run.Steps = append(run.Steps, *step)
+	}
+	return run, rows.Err()
+}
+
+// UpdateStatus saves the run status, error and timestamps
+func (s *WorkflowRunStore) UpdateStatus(ctx context.Context, run *entities.WorkflowRun) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE workflow_runs SET status = ?, error = ?, started_at = ?,
+		finished_at = ? WHERE id = ?`,
+		run.Status, nullString(run.Error), run.StartedAt, run.FinishedAt, run.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating workflow run: %w", err)
+	}
+	return requireAffected(result)
+}
+
+// UpdateStep saves the state of one step of the run
+func (s *WorkflowRunStore) UpdateStep(ctx context.Context, runID string, step *entities.StepRun) error {
+	outputs, err := marshalJSON(step.Outputs)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx, `UPDATE workflow_step_runs SET status = ?, attempts = ?, outputs = ?,
+		error = ?, started_at = ?, finished_at = ?, expires_at = ? WHERE run_id = ? AND step_id = ?`,
+		step.Status, step.Attempts, outputs, nullString(step.Error), step.StartedAt, step.FinishedAt,
+		step.ExpiresAt, runID, step.StepID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating workflow step: %w", err)
+	}
+	return requireAffected(result)
+}
+
+// TransitionStep saves the state of a step only if its stored status is still
+// from, so concurrent decisions on the same step cannot both apply
+func (s *WorkflowRunStore) TransitionStep(ctx context.Context, runID string, step *entities.StepRun, from string) error {
+	outputs, err := marshalJSON(step.Outputs)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx, `UPDATE workflow_step_runs SET status = ?, attempts = ?, outputs = ?,
+		error = ?, started_at = ?, finished_at = ?, expires_at = ? WHERE run_id = ? AND step_id = ? AND status = ?`,
+		step.Status, step.Attempts, outputs, nullString(step.Error), step.StartedAt, step.FinishedAt,
+		step.ExpiresAt, runID, step.StepID, from,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating workflow step: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repositories.ErrConflict
+	}
+	return nil
+}
+
+// ListExpiredSteps returns the waiting steps whose ExpiresAt is not after now
+func (s *WorkflowRunStore) ListExpiredSteps(ctx context.Context, now time.Time) ([]repositories.StepRef, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT run_id, step_id FROM workflow_step_runs
+		WHERE status = ? AND expires_at IS NOT NULL AND expires_at <= ? ORDER BY expires_at`,
+		entities.StepStatusWaiting, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error listing expired workflow steps: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []repositories.StepRef
+	for rows.Next() {
+		var ref repositories.StepRef
+		if err := rows.Scan(&ref.RunID, &ref.StepID); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// AddEvent appends an entry to the audit trail of a run, assigning an id and
+// creation time
+func (s *WorkflowRunStore) AddEvent(ctx context.Context, event *entities.WorkflowRunEvent) error {
+	event.CreatedAt = now()
+	id, err := s.db.dialect.InsertID(ctx, s.db, `INSERT INTO workflow_run_events
+		(run_id, step_id, type, actor_id, actor, comment, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		event.RunID, event.StepID, event.Type, event.ActorID, event.Actor, nullString(event.Comment), event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error adding workflow run event: %w", err)
+	}
+	event.ID = id
+	return nil
+}
+
+// ListEvents returns the audit trail of a run, oldest first
+func (s *WorkflowRunStore) ListEvents(ctx context.Context, runID string) ([]entities.WorkflowRunEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, run_id, step_id, type, actor_id, actor, comment, created_at
+		FROM workflow_run_events WHERE run_id = ? ORDER BY id`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing workflow run events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []entities.WorkflowRunEvent{}
+	for rows.Next() {
+		var (
+			event   entities.WorkflowRunEvent
+			actorID sql.NullInt64
+			comment sql.NullString
+		)
+		if err := rows.Scan(&event.ID, &event.RunID, &event.StepID, &event.Type, &actorID, &event.Actor,
+			&comment, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning workflow run event: %w", err)
+		}
+		event.ActorID = nullInt64(actorID)
+		event.Comment = comment.String
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// ListUnfinished returns the ids of queued and running runs, oldest first
+func (s *WorkflowRunStore) ListUnfinished(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id FROM workflow_runs WHERE status IN (?, ?) ORDER BY created_at",
+		entities.RunStatusQueued, entities.RunStatusRunning)
+	if err != nil {
+		return nil, fmt.Errorf("error listing unfinished workflow runs: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Requeue marks interrupted runs as queued and their running steps as pending
+// so they are resumed after a restart
+func (s *WorkflowRunStore) Requeue(ctx context.Context, runIDs []string) error {
+	if len(runIDs) == 0 {
+		return nil
+	}
+
+	ids := make([]interface{}, len(runIDs))
+	for i, id := range runIDs {
+		ids[i] = id
+	}
+
+	err := s.db.WithTx(ctx, func(tx *Tx) error {
+		args := append([]interface{}{entities.StepStatusPending}, ids...)
+		args = append(args, entities.StepStatusRunning)
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(
+			"UPDATE workflow_step_runs SET status = ? WHERE run_id IN (%s) AND status = ?",
+			placeholders(len(ids)),
+		), args...); err != nil {
+			return err
+		}
+
+		args = append([]interface{}{entities.RunStatusQueued}, ids...)
+		args = append(args, entities.RunStatusRunning)
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(
+			"UPDATE workflow_runs SET status = ? WHERE id IN (%s) AND status = ?",
+			placeholders(len(ids)),
+		), args...)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error requeuing workflow runs: %w", err)
+	}
+	return nil
+}
+
+// scanWorkflowRun reads a row selected with workflowRunColumns
+func scanWorkflowRun(row rowScanner) (*entities.WorkflowRun, error) {
+	var (
+		run        entities.WorkflowRun
+		inputs     sql.NullString
+		event      sql.NullString
+		runErr     sql.NullString
+		createdBy  sql.NullInt64
+		startedAt  sql.NullTime
+		finishedAt sql.NullTime
+	)
+	if err := row.Scan(
+		&run.ID, &run.WorkflowID, &run.Version, &run.Status, &inputs, &run.Trigger, &event, &runErr,
+		&createdBy, &run.CreatedAt, &startedAt, &finishedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	run.Error = runErr.String
+	run.CreatedBy = nullInt64(createdBy)
+	run.StartedAt = nullTime(startedAt)
+	run.FinishedAt = nullTime(finishedAt)
+	if err := unmarshalJSON(inputs, &run.Inputs); err != nil {
+		return nil, err
+	}
+	if err := unmarshalJSON(event, &run.Event); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// scanStepRun reads a row selected with stepRunColumns
+func scanStepRun(row rowScanner) (*entities.StepRun, error) {
+	var (
+		step       entities.StepRun
+		outputs    sql.NullString
+		stepErr    sql.NullString
+		startedAt  sql.NullTime
+		finishedAt sql.NullTime
+		expiresAt  sql.NullTime
+	)
+	if err := row.Scan(
+		&step.StepID, &step.Type, &step.Status, &step.Attempts, &outputs, &stepErr, &startedAt, &finishedAt,
+		&expiresAt,
+	); err != nil {
+		return nil, err
+	}
+
+	step.Error = stepErr.String
+	step.StartedAt = nullTime(startedAt)
+	step.FinishedAt = nullTime(finishedAt)
+	step.ExpiresAt = nullTime(expiresAt)
+	if err := unmarshalJSON(outputs, &step.Outputs); err != nil {
+		return nil, err
+	}
+	return &step, nil
+}
+
+// nullString stores an empty string as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,292 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+const workflowColumns = "w.id, w.name, w.description, w.version, w.created_by, w.created_at, w.updated_at, v.definition"
+
+// workflowKeyset lists the fields workflows can be sorted by, by name by default
+var workflowKeyset = keyset[entities.Workflow]{
+	columns: map[string]sortColumn{
+		"id":         {"w.id", kindInt},
+		"name":       {"w.name", kindString},
+		"created_at": {"w.created_at", kindTime},
+		"updated_at": {"w.updated_at", kindTime},
+	},
+	defaults: []repositories.SortField{{Field: "name"}},
+	value: func(w *entities.Workflow, field string) interface{} {
+		switch field {
+		case "id":
+			return w.ID
+		case "name":
+			return w.Name
+		case "created_at":
+			return w.CreatedAt
+		case "updated_at":
+			return w.UpdatedAt
+		}
+		return nil
+	},
+}
+
+// WorkflowStore persists workflows in the workflows and workflow_versions tables
+type WorkflowStore struct {
+	db *DB
+}
+
+var _ repositories.WorkflowRepository = (*WorkflowStore)(nil)
+
+// NewWorkflowStore creates a new WorkflowStore
+func NewWorkflowStore(db *DB) *WorkflowStore {
+	return &WorkflowStore{db: db}
+}
+
+// List returns a page of workflows with their latest definitions
+func (s *WorkflowStore) List(ctx context.Context, opts repositories.ListOptions) (repositories.Page[entities.Workflow], error) {
+	p, err := workflowKeyset.resolve(opts)
+	if err != nil {
+		return repositories.Page[entities.Workflow]{}, err
+	}
+
+	var conds []string
+	cond, args := workflowKeyset.where(p)
+	if cond != "" {
+		conds = append(conds, cond)
+	}
+	query := "SELECT " + workflowColumns + ` FROM workflows w
+		JOIN workflow_versions v ON v.workflow_id = w.id AND v.version = w.version` +
+		whereClause(conds) + workflowKeyset.orderBy(p) + " LIMIT ?"
+	args = append(args, p.limit+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return repositories.Page[entities.Workflow]{}, fmt.Errorf("error listing workflows: %w", err)
+	}
+	defer rows.Close()
+
+	workflows := []entities.Workflow{}
+	for rows.Next() {
+		workflow, err := scanWorkflow(rows)
+		if err != nil {
+			return repositories.Page[entities.Workflow]{}, fmt.Errorf("error scanning workflow: %w", err)
+		}
+		workflows = append(workflows, *workflow)
+	}
+	if err := rows.Err(); err != nil {
+		return repositories.Page[entities.Workflow]{}, fmt.Errorf("error listing workflows: %w", err)
+	}
+	return workflowKeyset.finish(p, workflows)
+}
+
+// GetByID returns the workflow with its latest definition
+func (s *WorkflowStore) GetByID(ctx context.Context, id int64) (*entities.Workflow, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+workflowColumns+` FROM workflows w
+		JOIN workflow_versions v ON v.workflow_id = w.id AND v.version = w.version
+		WHERE w.id = ?`, id)
+	workflow, err := scanWorkflow(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositories.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting workflow: %w", err)
+	}
+	return workflow, nil
+}
+
+// Create stores the workflow and version as its first version
+func (s *WorkflowStore) Create(ctx context.Context, workflow *entities.Workflow, version *entities.WorkflowVersion) error {
+	created := now()
+	workflow.Version = 1
+	workflow.CreatedAt = created
+	workflow.UpdatedAt = created
+	workflow.Definition = &version.Definition
+	version.Version = 1
+	version.CreatedBy = workflow.CreatedBy
+	version.CreatedAt = created
+
+	err := s.db.WithTx(ctx, func(tx *Tx) error {
+		id, err := s.db.dialect.InsertID(ctx, tx, `INSERT INTO workflows
+			(name, description, version, created_by, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			workflow.Name, workflow.Description, workflow.Version, workflow.CreatedBy,
+			workflow.CreatedAt, workflow.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		workflow.ID = id
+		version.WorkflowID = id
+		return insertWorkflowVersion(ctx, tx, version)
+	})
+	if s.db.dialect.IsUniqueViolation(err) {
+		return repositories.ErrConflict
+	}
+	if err != nil {
+		return fmt.Errorf("error creating workflow: %w", err)
+	}
+	return nil
+}
+
+// AddVersion stores version as the next version of its workflow
+func (s *WorkflowStore) AddVersion(ctx context.Context, version *entities.WorkflowVersion) error {
+	version.CreatedAt = now()
+
+	err := s.db.WithTx(ctx, func(tx *Tx) error {
+		var current int
+		err := tx.QueryRowContext(ctx, "SELECT version FROM workflows WHERE id = ?", version.WorkflowID).Scan(&current)
+		if errors.Is(err, sql.ErrNoRows) {
+			return repositories.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		version.Version = current + 1
+
+		// Guard on the version read above so concurrent updates cannot both win
+		result, err := tx.ExecContext(ctx, `UPDATE workflows SET name = ?, description = ?, version = ?,
+			updated_at = ? WHERE id = ? AND version = ?`,
+			version.Definition.Name, version.Definition.Description, version.Version,
+			version.CreatedAt, version.WorkflowID, current,
+		)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return repositories.ErrConflict
+		}
+		return insertWorkflowVersion(ctx, tx, version)
+	})
+	if errors.Is(err, repositories.ErrNotFound) || errors.Is(err, repositories.ErrConflict) {
+		return err
+	}
+	if s.db.dialect.IsUniqueViolation(err) {
+		return repositories.ErrConflict
+	}
+	if err != nil {
+		return fmt.Errorf("error adding workflow version: %w", err)
+	}
+	return nil
+}
+
+// GetVersion returns one version of a workflow
+func (s *WorkflowStore) GetVersion(ctx context.Context, workflowID int64, version int) (*entities.WorkflowVersion, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT workflow_id, version, definition, source, created_by, created_at
+		FROM workflow_versions WHERE workflow_id = ? AND version = ?`, workflowID, version)
+	v, err := scanWorkflowVersion(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositories.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting workflow version: %w", err)
+	}
+	return v, nil
+}
+
+// ListVersions returns every version of a workflow, newest first
+func (s *WorkflowStore) ListVersions(ctx context.Context, workflowID int64) ([]entities.WorkflowVersion, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT workflow_id, version, definition, source, created_by, created_at
+		FROM workflow_versions WHERE workflow_id = ? ORDER BY version DESC`, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing workflow versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []entities.WorkflowVersion{}
+	for rows.Next() {
+		v, err := scanWorkflowVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning workflow version: %w", err)
+		}
+		versions = append(versions, *v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, repositories.ErrNotFound
+	}
+	return versions, nil
+}
+
+// Delete removes the workflow with its versions and runs
+func (s *WorkflowStore) Delete(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM workflows WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting workflow: %w", err)
+	}
+	return requireAffected(result)
+}
+
+// insertWorkflowVersion stores the definition as JSON next to its source
+func insertWorkflowVersion(ctx context.Context, q querier, version *entities.WorkflowVersion) error {
+	definition, err := json.Marshal(version.Definition)
+	if err != nil {
+		return fmt.Errorf("error encoding workflow definition: %w", err)
+	}
+	_, err = q.ExecContext(ctx, `INSERT INTO workflow_versions
+		(workflow_id, version, definition, source, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		version.WorkflowID, version.Version, string(definition), version.Source,
+		version.CreatedBy, version.CreatedAt,
+	)
+	return err
+}
+
+// scanWorkflow reads a row selected with workflowColumns
+func scanWorkflow(row rowScanner) (*entities.Workflow, error) {
+	var (
+		workflow    entities.Workflow
+		description sql.NullString
+		createdBy   sql.NullInt64
+		definition  string
+	)
+	if err := row.Scan(
+		&workflow.ID, &workflow.Name, &description, &workflow.Version, &createdBy,
+		&workflow.CreatedAt, &workflow.UpdatedAt, &definition,
+	); err != nil {
+		return nil, err
+	}
+
+	workflow.Description = description.String
+	workflow.CreatedBy = nullInt64(createdBy)
+	workflow.Definition = &entities.WorkflowDefinition{}
+	if err := json.Unmarshal([]byte(definition), workflow.Definition); err != nil {
+		return nil, fmt.Errorf("error decoding workflow definition: %w", err)
+	}
+	return &workflow, nil
+}
+
+// scanWorkflowVersion reads a workflow_versions row
+func scanWorkflowVersion(row rowScanner) (*entities.WorkflowVersion, error) {
+	var (
+		v          entities.WorkflowVersion
+		definition string
+		createdBy  sql.NullInt64
+	)
+	if err := row.Scan(&v.WorkflowID, &v.Version, &definition, &v.Source, &createdBy, &v.CreatedAt); err != nil {
+		return nil, err
+	}
+	v.CreatedBy = nullInt64(createdBy)
+	if err := json.Unmarshal([]byte(definition), &v.Definition); err != nil {
+		return nil, fmt.Errorf("error decoding workflow definition: %w", err)
+	}
+	return &v, nil
+}
+
+// nullInt64 converts a nullable column into an optional id
+func nullInt64(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
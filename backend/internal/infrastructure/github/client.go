@@ -0,0 +1,282 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"ai-git-workbench/internal/infrastructure/config"
+	"ai-git-workbench/internal/infrastructure/tracing"
+)
+
+const (
+	defaultBaseURL = "https://api.github.com"
+	tracerName     = "ai-git-workbench/internal/infrastructure/github"
+)
+
+// Client is a minimal GitHub REST API client
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// Repository represents a repository returned by the GitHub API
+type Repository struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	FullName    string   `json:"full_name"`
+	Description string   `json:"description"`
+	Private     bool     `json:"private"`
+	Language    string   `json:"language"`
+	URL         string   `json:"url"`
+	HTMLURL     string   `json:"html_url"`
+	CloneURL    string   `json:"clone_url"`
+	Stars       int      `json:"stargazers_count"`
+	Forks       int      `json:"forks_count"`
+	Topics      []string `json:"topics"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+// User represents a user returned by the GitHub API
+type User struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// PullRequest represents a pull request returned by the GitHub API
+type PullRequest struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	Draft   bool   `json:"draft"`
+	HTMLURL string `json:"html_url"`
+}
+
+// NewPullRequest holds the fields used to open a pull request
+type NewPullRequest struct {
+	Title string `json:"title"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Body  string `json:"body,omitempty"`
+	Draft bool   `json:"draft,omitempty"`
+}
+
+// Label represents an issue label returned by the GitHub API
+type Label struct {
+	Name string `json:"name"`
+}
+
+// Issue represents an issue returned by the GitHub API
+type Issue struct {
+	Number      int       `json:"number"`
+	Title       string    `json:"title"`
+	Body        string    `json:"body"`
+	State       string    `json:"state"`        // open or closed
+	StateReason string    `json:"state_reason"` // completed, not_planned or reopened
+	Labels      []Label   `json:"labels"`
+	HTMLURL     string    `json:"html_url"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// PullRequest is set when the issue is a pull request
+	PullRequest *struct{} `json:"pull_request,omitempty"`
+}
+
+// IssueRequest holds the fields used to create or update an issue. Labels
+// replace every label of the issue.
+type IssueRequest struct {
+	Title       string   `json:"title"`
+	Body        string   `json:"body"`
+	State       string   `json:"state,omitempty"`
+	StateReason string   `json:"state_reason,omitempty"`
+	Labels      []string `json:"labels"`
+}
+
+// APIError is returned when GitHub responds with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("github api error: status %d: %s", e.StatusCode, e.Message)
+}
+
+// NewClient creates a new GitHub client
+func NewClient(cfg config.GitHubConfig) *Client {
+	baseURL := cfg.APIURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return &Client{
+		httpClient: tracing.NewHTTPClient(30 * time.Second),
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      cfg.Token,
+	}
+}
+
+// Configured reports whether the client has credentials to call the API
+func (c *Client) Configured() bool {
+	return c.token != ""
+}
+
+// WithToken returns a copy of the client that authenticates with token, such
+// as a user's OAuth access token
+func (c *Client) WithToken(token string) *Client {
+	clone := *c
+	clone.token = token
+	return &clone
+}
+
+// AuthenticatedUser returns the user the client's token belongs to
+func (c *Client) AuthenticatedUser(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.do(ctx, "AuthenticatedUser", http.MethodGet, "/user", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GitAuthArgs returns the git -c arguments that authenticate HTTPS clones
+// and fetches from GitHub with token
+func GitAuthArgs(token string) []string {
+	basic := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+	return []string{"-c", "http.extraHeader=Authorization: Basic " + basic}
+}
+
+// ListRepositories returns the repositories visible to the authenticated user
+func (c *Client) ListRepositories(ctx context.Context) ([]Repository, error) {
+	var repos []Repository
+	if err := c.do(ctx, "ListRepositories", http.MethodGet, "/user/repos?per_page=100&sort=updated", nil, &repos); err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
+
+// CreatePullRequest opens a pull request in the repository owner/repo
+func (c *Client) CreatePullRequest(ctx context.Context, owner, repo string, pr NewPullRequest) (*PullRequest, error) {
+	body, err := json.Marshal(pr)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding pull request: %w", err)
+	}
+
+	var created PullRequest
+	path := fmt.Sprintf("/repos/%s/%s/pulls", url.PathEscape(owner), url.PathEscape(repo))
+	if err := c.do(ctx, "CreatePullRequest", http.MethodPost, path, bytes.NewReader(body), &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetIssue returns the issue number of the repository owner/repo
+func (c *Client) GetIssue(ctx context.Context, owner, repo string, number int) (*Issue, error) {
+	var issue Issue
+	path := fmt.Sprintf("/repos/%s/%s/issues/%d", url.PathEscape(owner), url.PathEscape(repo), number)
+	if err := c.do(ctx, "GetIssue", http.MethodGet, path, nil, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// CreateIssue opens an issue in the repository owner/repo. GitHub ignores
+// the state of a new issue.
+func (c *Client) CreateIssue(ctx context.Context, owner, repo string, issue IssueRequest) (*Issue, error) {
+	body, err := json.Marshal(issue)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding issue: %w", err)
+	}
+
+	var created Issue
+	path := fmt.Sprintf("/repos/%s/%s/issues", url.PathEscape(owner), url.PathEscape(repo))
+	if err := c.do(ctx, "CreateIssue", http.MethodPost, path, bytes.NewReader(body), &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateIssue edits the issue number of the repository owner/repo
+func (c *Client) UpdateIssue(ctx context.Context, owner, repo string, number int, issue IssueRequest) (*Issue, error) {
+	body, err := json.Marshal(issue)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding issue: %w", err)
+	}
+
+	var updated Issue
+	path := fmt.Sprintf("/repos/%s/%s/issues/%d", url.PathEscape(owner), url.PathEscape(repo), number)
+	if err := c.do(ctx, "UpdateIssue", http.MethodPatch, path, bytes.NewReader(body), &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// do sends a request to the GitHub API inside a span named after the operation
+func (c *Client) do(ctx context.Context, operation, method, path string, body io.Reader, out interface{}) error {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "github."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("github.operation", operation),
+			attribute.String("http.request.method", method),
+		),
+	)
+	defer span.End()
+
+	err := c.send(ctx, method, path, body, out)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// send performs the HTTP round trip and decodes the JSON response
+func (c *Client) send(ctx context.Context, method, path string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling github: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return &APIError{StatusCode: resp.StatusCode, Message: apiErr.Message}
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding github response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"ai-git-workbench/internal/infrastructure/tracing"
+)
+
+// defaultWebURL is where users authorize OAuth apps
+const defaultWebURL = "https://github.com"
+
+// OAuthScopes are requested when a user signs in
+const OAuthScopes = "read:user user:email"
+
+// OAuth implements the web application flow of a GitHub OAuth app
+type OAuth struct {
+	httpClient   *http.Client
+	webURL       string
+	clientID     string
+	clientSecret string
+}
+
+// NewOAuth creates a new OAuth for the app with the given credentials. An
+// empty webURL selects github.com.
+func NewOAuth(webURL, clientID, clientSecret string) *OAuth {
+	if webURL == "" {
+		webURL = defaultWebURL
+	}
+	return &OAuth{
+		httpClient:   tracing.NewHTTPClient(30 * time.Second),
+		webURL:       strings.TrimSuffix(webURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+	}
+}
+
+// AuthorizeURL returns the page asking the user to authorize the app.
+// GitHub sends the user back to redirectURL with a code and state.
+func (o *OAuth) AuthorizeURL(state, redirectURL string) string {
+	q := url.Values{
+		"client_id":    {o.clientID},
+		"redirect_uri": {redirectURL},
+		"scope":        {OAuthScopes},
+		"state":        {state},
+		"allow_signup": {"false"},
+	}
+	return o.webURL + "/login/oauth/authorize?" + q.Encode()
+}
+
+// Exchange trades the code of an authorization callback for an access token
+func (o *OAuth) Exchange(ctx context.Context, code, redirectURL string) (string, error) {
+	form := url.Values{
+		"client_id":     {o.clientID},
+		"client_secret": {o.clientSecret},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.webURL+"/login/oauth/access_token",
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error calling github: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// GitHub reports a bad code with 200 and an error field
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("error decoding github response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return "", &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(body.Error + " " + body.ErrorDescription)}
+	}
+	if body.AccessToken == "" {
+		return "", errors.New("github returned no access token")
+	}
+	return body.AccessToken, nil
+}
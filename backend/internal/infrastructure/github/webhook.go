@@ -0,0 +1,109 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Webhook request headers
+const (
+	HeaderEvent     = "X-GitHub-Event"
+	HeaderDelivery  = "X-GitHub-Delivery"
+	HeaderSignature = "X-Hub-Signature-256"
+)
+
+// webhookPayload holds the parts of webhook payloads that triggers filter on
+type webhookPayload struct {
+	Action     string `json:"action"`
+	Ref        string `json:"ref"`
+	After      string `json:"after"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+	PullRequest *struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+		Merged  bool   `json:"merged"`
+		Head    struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Review *struct {
+		State string `json:"state"`
+	} `json:"review"`
+	Issue *struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+	} `json:"issue"`
+}
+
+// VerifySignature checks an X-Hub-Signature-256 header against the payload
+func VerifySignature(secret string, payload []byte, signature string) bool {
+	sig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// ParseWebhookEvent flattens a webhook delivery into string fields:
+// event, delivery, action, repository, sender, branch, sha and, for pull
+// requests and issues, number, title, url, head_branch and merged, and for
+// pull request reviews review_state.
+// Branch is the base branch of a pull request or the pushed branch.
+func ParseWebhookEvent(event, delivery string, payload []byte) (map[string]string, error) {
+	var p webhookPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+
+	fields := map[string]string{
+		"event":      event,
+		"delivery":   delivery,
+		"action":     p.Action,
+		"repository": p.Repository.FullName,
+		"sender":     p.Sender.Login,
+	}
+	if branch, ok := strings.CutPrefix(p.Ref, "refs/heads/"); ok {
+		fields["branch"] = branch
+		fields["sha"] = p.After
+	}
+	if pr := p.PullRequest; pr != nil {
+		fields["branch"] = pr.Base.Ref
+		fields["head_branch"] = pr.Head.Ref
+		fields["sha"] = pr.Head.SHA
+		fields["number"] = strconv.Itoa(pr.Number)
+		fields["title"] = pr.Title
+		fields["url"] = pr.HTMLURL
+		fields["merged"] = strconv.FormatBool(pr.Merged)
+	}
+	if review := p.Review; review != nil {
+		fields["review_state"] = review.State
+	}
+	if issue := p.Issue; issue != nil && p.PullRequest == nil {
+		fields["number"] = strconv.Itoa(issue.Number)
+		fields["title"] = issue.Title
+		fields["url"] = issue.HTMLURL
+	}
+	return fields, nil
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/infrastructure/config"
+)
+
+// EmailChannel sends notifications as plain text mail through an SMTP server
+type EmailChannel struct {
+	cfg config.SMTPConfig
+}
+
+// NewEmailChannel creates a new EmailChannel
+func NewEmailChannel(cfg config.SMTPConfig) *EmailChannel {
+	return &EmailChannel{cfg: cfg}
+}
+
+// Validate checks that target is a single bare email address
+func (c *EmailChannel) Validate(target string) error {
+	addr, err := mail.ParseAddress(target)
+	if err != nil || addr.Address != target {
+		return fmt.Errorf("%q is not an email address", target)
+	}
+	return nil
+}
+
+// Send delivers the notification to the subscription target
+func (c *EmailChannel) Send(ctx context.Context, sub *entities.NotificationSubscription, n *entities.Notification) error {
+	if err := c.Validate(sub.Target); err != nil {
+		return err
+	}
+	msg, err := c.message(sub.Target, n)
+	if err != nil {
+		return err
+	}
+
+	client, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if c.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.cfg.Host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := client.Mail(c.cfg.From); err != nil {
+		return fmt.Errorf("smtp MAIL FROM: %w", err)
+	}
+	if err := client.Rcpt(sub.Target); err != nil {
+		return fmt.Errorf("smtp RCPT TO: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	return client.Quit()
+}
+
+// dial connects to the server, upgrading to TLS as configured. The
+// connection deadline follows ctx.
+func (c *EmailChannel) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(c.cfg.Host, c.cfg.Port)
+	tlsConfig := &tls.Config{ServerName: c.cfg.Host, MinVersion: tls.VersionTLS12}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if c.cfg.TLS == "tls" {
+		dialer := &tls.Dialer{Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("smtp connect: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, c.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("smtp connect: %w", err)
+	}
+	if c.cfg.TLS == "starttls" {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp STARTTLS: %w", err)
+		}
+	}
+	return client, nil
+}
+
+// message renders the notification as a quoted-printable text/plain mail
+func (c *EmailChannel) message(to string, n *entities.Notification) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		buf.WriteString(key + ": " + value + "\r\n")
+	}
+	header("From", c.cfg.From)
+	header("To", to)
+	header("Subject", mime.QEncoding.Encode("utf-8", strings.ReplaceAll(n.Title, "\n", " ")))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/plain; charset="utf-8"`)
+	header("Content-Transfer-Encoding", "quoted-printable")
+	header("X-Notification-Event", n.Type)
+	buf.WriteString("\r\n")
+
+	body := n.Message
+	if n.URL != "" {
+		body += "\n\n" + n.URL
+	}
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// validateURL accepts absolute http and https URLs whose host is not a
+// loopback, private or link-local address. Host names are checked again when
+// connecting, by the client NewClient returns.
+func validateURL(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http or https URL", target)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%q points to an internal address", redactURL(target))
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && internalAddr(addr) {
+		return fmt.Errorf("%q points to an internal address", redactURL(target))
+	}
+	return nil
+}
+
+// internalAddr reports whether addr belongs to this host or its private
+// networks rather than to the internet
+func internalAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified()
+}
+
+// NewClient returns the HTTP client of the webhook channels. It refuses to
+// connect to internal addresses, whatever the host name of a subscription
+// or a redirect resolves to, so subscribers cannot reach services behind the
+// server. Proxies are not used since they would connect on its behalf.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if internalAddr(addrPort.Addr()) {
+				return fmt.Errorf("connecting to internal address %s is not allowed", addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// postJSON sends body to target and fails on a non-2xx response
+func postJSON(ctx context.Context, client *http.Client, target string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The response body is not kept: it is the remote server's and may hold
+	// anything
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s responded with status %d", redactURL(target), resp.StatusCode)
+	}
+	return nil
+}
+
+// redactURL drops the path and query of a URL, which for incoming webhooks
+// usually embed the secret
+func redactURL(target string) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "webhook"
+	}
+	return u.Scheme + "://" + u.Host
+}
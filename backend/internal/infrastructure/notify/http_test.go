@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateURL(t *testing.T) {
+	for _, tc := range []struct {
+		target string
+		ok     bool
+	}{
+		{"https://hooks.slack.com/services/x", true},
+		{"http://93.184.215.14/hook", true},
+		{"ftp://example.com/hook", false},
+		{"https://", false},
+		{"http://localhost:8080/hook", false},
+		{"http://api.localhost/hook", false},
+		{"http://127.0.0.1/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://[::ffff:10.0.0.1]/hook", false},
+		{"http://10.1.2.3/hook", false},
+		{"http://192.168.0.10/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://0.0.0.0/hook", false},
+	} {
+		if err := validateURL(tc.target); (err == nil) != tc.ok {
+			t.Errorf("validateURL(%q) = %v, want ok %v", tc.target, err, tc.ok)
+		}
+	}
+}
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	err := postJSON(context.Background(), NewClient(time.Second), srv.URL, []byte("{}"), nil)
+	if err == nil || !strings.Contains(err.Error(), "internal address") {
+		t.Fatalf("postJSON to %s = %v, want an internal address error", srv.URL, err)
+	}
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// SlackChannel posts notifications to Slack-compatible incoming webhooks,
+// which Mattermost, Rocket.Chat and Discord (with /slack) also accept
+type SlackChannel struct {
+	client *http.Client
+}
+
+// NewSlackChannel creates a new SlackChannel
+func NewSlackChannel(client *http.Client) *SlackChannel {
+	return &SlackChannel{client: client}
+}
+
+// Validate checks that target is an incoming webhook URL
+func (c *SlackChannel) Validate(target string) error {
+	return validateURL(target)
+}
+
+// Send posts the notification as a message with mrkdwn formatting
+func (c *SlackChannel) Send(ctx context.Context, sub *entities.NotificationSubscription, n *entities.Notification) error {
+	body, err := json.Marshal(map[string]string{"text": slackText(n)})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, c.client, sub.Target, body, nil)
+}
+
+// slackText renders a notification as "*title*", the message and a link
+func slackText(n *entities.Notification) string {
+	lines := []string{"*" + slackEscape(n.Title) + "*"}
+	if n.Message != "" {
+		lines = append(lines, slackEscape(n.Message))
+	}
+	if n.URL != "" {
+		lines = append(lines, "<"+n.URL+"|Open>")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// slackEscape escapes the characters Slack treats as control sequences
+var slackEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// Generic webhook request headers
+const (
+	HeaderEvent     = "X-Notification-Event"
+	HeaderSignature = "X-Notification-Signature-256"
+)
+
+// WebhookChannel posts notifications as JSON to arbitrary HTTP endpoints.
+// Deliveries of subscriptions with a secret carry an HMAC SHA-256 signature
+// of the body, in the same "sha256=<hex>" form GitHub uses.
+type WebhookChannel struct {
+	client *http.Client
+}
+
+// NewWebhookChannel creates a new WebhookChannel
+func NewWebhookChannel(client *http.Client) *WebhookChannel {
+	return &WebhookChannel{client: client}
+}
+
+// Validate checks that target is an http or https URL
+func (c *WebhookChannel) Validate(target string) error {
+	return validateURL(target)
+}
+
+// Send posts the notification as its JSON representation
+func (c *WebhookChannel) Send(ctx context.Context, sub *entities.NotificationSubscription, n *entities.Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	header.Set(HeaderEvent, n.Type)
+	if sub.Secret != "" {
+		header.Set(HeaderSignature, Sign(sub.Secret, body))
+	}
+	return postJSON(ctx, c.client, sub.Target, body, header)
+}
+
+// Sign returns the signature header value of a webhook body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
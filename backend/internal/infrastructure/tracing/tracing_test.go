@@ -0,0 +1,31 @@
+package tracing
+
+import "testing"
+
+func TestTracesURL(t *testing.T) {
+	for _, tc := range []struct {
+		endpoint string
+		insecure bool
+		want     string
+		wantErr  bool
+	}{
+		{endpoint: "http://localhost:4318", want: "http://localhost:4318/v1/traces"},
+		{endpoint: "https://collector.example.com", insecure: true, want: "https://collector.example.com/v1/traces"},
+		{endpoint: "https://collector.example.com/otlp/", want: "https://collector.example.com/otlp/v1/traces"},
+		{endpoint: "localhost:4318", insecure: true, want: "http://localhost:4318/v1/traces"},
+		{endpoint: "collector:4318", want: "https://collector:4318/v1/traces"},
+		{endpoint: "grpc://collector:4317", wantErr: true},
+		{endpoint: "http://", wantErr: true},
+	} {
+		got, err := tracesURL(tc.endpoint, tc.insecure)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("tracesURL(%q) = %q, want an error", tc.endpoint, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("tracesURL(%q, %v) = %q, %v, want %q", tc.endpoint, tc.insecure, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBody bounds how much of a push service error response is kept
+const maxErrorBody = 512
+
+// ErrGone is returned when the push service reports the subscription as
+// expired or unsubscribed (404 or 410); it should be deleted
+var ErrGone = errors.New("push subscription is no longer valid")
+
+// Subscription is the part of a browser PushSubscription needed to send to it
+type Subscription struct {
+	Endpoint string
+	// P256dh and Auth are the base64url encoded keys from PushSubscription.getKey
+	P256dh string
+	Auth   string
+}
+
+// Validate checks that a subscription has an http(s) endpoint and usable keys
+func (s Subscription) Validate() error {
+	u, err := url.Parse(s.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("endpoint is not an http or https URL")
+	}
+	p256dh, err := decodeBase64(s.P256dh)
+	if err != nil {
+		return errors.New("keys.p256dh is not base64url encoded")
+	}
+	if _, err := ecdh.P256().NewPublicKey(p256dh); err != nil {
+		return errors.New("keys.p256dh is not a P-256 public key")
+	}
+	if auth, err := decodeBase64(s.Auth); err != nil || len(auth) != 16 {
+		return errors.New("keys.auth must be 16 base64url encoded bytes")
+	}
+	return nil
+}
+
+// Options controls how the push service handles a message
+type Options struct {
+	// TTL is how long the push service keeps an undelivered message
+	TTL time.Duration
+	// Urgency is very-low, low, normal or high
+	Urgency string
+	// Topic replaces a pending message with the same topic
+	Topic string
+}
+
+// Client sends encrypted messages to push services
+type Client struct {
+	httpClient *http.Client
+	keys       *Keys
+	subject    string
+}
+
+// NewClient creates a new Client. subject is the mailto: or https: contact
+// URI push services may use to reach the operator.
+func NewClient(httpClient *http.Client, keys *Keys, subject string) *Client {
+	return &Client{httpClient: httpClient, keys: keys, subject: subject}
+}
+
+// PublicKey returns the VAPID public key browsers subscribe with
+func (c *Client) PublicKey() string {
+	return c.keys.PublicKey()
+}
+
+// Send encrypts payload for sub and posts it to the push service. It returns
+// ErrGone when the subscription should be removed.
+func (c *Client) Send(ctx context.Context, sub Subscription, payload []byte, opts Options) error {
+	body, err := encrypt(payload, sub.P256dh, sub.Auth)
+	if err != nil {
+		return err
+	}
+	authorization, err := c.keys.authorization(sub.Endpoint, c.subject, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(opts.TTL.Seconds())))
+	if opts.Urgency != "" {
+		req.Header.Set("Urgency", opts.Urgency)
+	}
+	if opts.Topic != "" {
+		req.Header.Set("Topic", opts.Topic)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrGone
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("push service responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,108 @@
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// recordSize is the aes128gcm record size; the whole message is one record
+	recordSize = 4096
+	// headerSize is salt (16) + record size (4) + key id length (1) + key id (65)
+	headerSize = 16 + 4 + 1 + 65
+	// MaxPayloadSize is the largest plaintext that fits in a single record
+	// after the padding delimiter and the GCM tag
+	MaxPayloadSize = recordSize - headerSize - 1 - 16
+)
+
+// ErrPayloadTooLarge is returned for payloads above MaxPayloadSize
+var ErrPayloadTooLarge = errors.New("push payload is too large")
+
+// encrypt encrypts payload for a subscription with the aes128gcm content
+// encoding of RFC 8188, keyed as specified by RFC 8291
+func encrypt(payload []byte, p256dh, authSecret string) ([]byte, error) {
+	// A fresh application server key pair and salt for every message
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return encryptWith(payload, p256dh, authSecret, asPrivate, salt)
+}
+
+// encryptWith encrypts like encrypt with the given application server key
+// pair and salt
+func encryptWith(payload []byte, p256dh, authSecret string, asPrivate *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	if len(payload) > MaxPayloadSize {
+		return nil, ErrPayloadTooLarge
+	}
+
+	uaPublicBytes, err := decodeBase64(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	auth, err := decodeBase64(authSecret)
+	if err != nil || len(auth) != 16 {
+		return nil, errors.New("invalid auth secret")
+	}
+
+	asPublic := asPrivate.PublicKey().Bytes()
+	sharedSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	// RFC 8291 section 3.4: IKM from the ECDH secret and the auth secret
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublicBytes...), asPublic...)
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, auth, string(keyInfo), 32)
+	if err != nil {
+		return nil, err
+	}
+
+	// RFC 8188 section 2.2 and 2.3: content encryption key and nonce
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	body := make([]byte, headerSize, headerSize+len(payload)+1+gcm.Overhead())
+	copy(body, salt)
+	binary.BigEndian.PutUint32(body[16:20], recordSize)
+	body[20] = byte(len(asPublic))
+	copy(body[21:], asPublic)
+
+	// 0x02 marks the last (and only) record, with no further padding
+	plaintext := append(append(make([]byte, 0, len(payload)+1), payload...), 0x02)
+	return gcm.Seal(body, nonce, plaintext, nil), nil
+}
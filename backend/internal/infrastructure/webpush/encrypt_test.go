@@ -0,0 +1,88 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"errors"
+	"testing"
+)
+
+// The example of RFC 8291 section 5
+const (
+	rfcPlaintext = "When I grow up, I want to be a watermelon"
+	rfcASPrivate = "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"
+	rfcUAPublic  = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+	rfcAuth      = "BTBZMqHH6r4Tts7J_aSIgg"
+	rfcSalt      = "DGv6ra1nlYgDCS1FRnbzlw"
+	rfcMessage   = "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+)
+
+// rfcKeys returns the application server key and salt of the RFC example
+func rfcKeys(t *testing.T) (*ecdh.PrivateKey, []byte) {
+	t.Helper()
+	raw, err := decodeBase64(rfcASPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	asPrivate, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt, err := decodeBase64(rfcSalt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return asPrivate, salt
+}
+
+func TestEncryptWithRFC8291Example(t *testing.T) {
+	asPrivate, salt := rfcKeys(t)
+	want, err := decodeBase64(rfcMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := encryptWith([]byte(rfcPlaintext), rfcUAPublic, rfcAuth, asPrivate, salt)
+	if err != nil {
+		t.Fatalf("encryptWith: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("encryptWith = %s, want %s", b64.EncodeToString(got), rfcMessage)
+	}
+}
+
+func TestEncryptWithRejectsInvalidInput(t *testing.T) {
+	asPrivate, salt := rfcKeys(t)
+	for _, tc := range []struct {
+		name         string
+		payloadSize  int
+		p256dh, auth string
+		wantTooLarge bool
+		wantErr      bool
+	}{
+		{name: "largest payload", payloadSize: MaxPayloadSize, p256dh: rfcUAPublic, auth: rfcAuth},
+		{name: "payload too large", payloadSize: MaxPayloadSize + 1, p256dh: rfcUAPublic, auth: rfcAuth, wantTooLarge: true},
+		{name: "p256dh not base64", p256dh: "not base64!", auth: rfcAuth, wantErr: true},
+		{name: "p256dh not a P-256 point", p256dh: rfcAuth, auth: rfcAuth, wantErr: true},
+		{name: "auth secret too short", p256dh: rfcUAPublic, auth: "BTBZMqHH6r4T", wantErr: true},
+		{name: "auth secret missing", p256dh: rfcUAPublic, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := encryptWith(make([]byte, tc.payloadSize), tc.p256dh, tc.auth, asPrivate, salt)
+			switch {
+			case tc.wantTooLarge:
+				if !errors.Is(err, ErrPayloadTooLarge) {
+					t.Errorf("err = %v, want ErrPayloadTooLarge", err)
+				}
+			case tc.wantErr:
+				if err == nil {
+					t.Error("err = nil, want an error")
+				}
+			case err != nil:
+				t.Errorf("err = %v", err)
+			case len(body) > recordSize:
+				t.Errorf("body is %d bytes, more than the %d byte record", len(body), recordSize)
+			}
+		})
+	}
+}
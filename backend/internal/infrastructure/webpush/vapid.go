@@ -0,0 +1,113 @@
+package webpush
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+)
+
+// jwtLifetime is how long a VAPID token is valid; RFC 8292 allows up to 24h
+const jwtLifetime = 12 * time.Hour
+
+// b64 is the unpadded base64url encoding used throughout Web Push
+var b64 = base64.RawURLEncoding
+
+// Keys is a VAPID (RFC 8292) application server key pair on P-256
+type Keys struct {
+	private *ecdsa.PrivateKey
+	// public is the uncompressed point browsers receive as applicationServerKey
+	public []byte
+}
+
+// GenerateKeys creates a new VAPID key pair
+func GenerateKeys() (*Keys, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeys(b64.EncodeToString(key.Bytes()))
+}
+
+// ParseKeys loads a key pair from its base64url encoded 32 byte private
+// scalar, the format used by common web-push tooling
+func ParseKeys(private string) (*Keys, error) {
+	raw, err := decodeBase64(private)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	key, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	public := key.PublicKey().Bytes()
+	return &Keys{
+		private: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(public[1:33]),
+				Y:     new(big.Int).SetBytes(public[33:65]),
+			},
+			D: new(big.Int).SetBytes(raw),
+		},
+		public: public,
+	}, nil
+}
+
+// PublicKey returns the base64url encoded public key
+func (k *Keys) PublicKey() string {
+	return b64.EncodeToString(k.public)
+}
+
+// PrivateKey returns the base64url encoded private scalar
+func (k *Keys) PrivateKey() string {
+	return b64.EncodeToString(k.private.D.FillBytes(make([]byte, 32)))
+}
+
+// authorization returns the VAPID Authorization header for a push endpoint
+func (k *Keys) authorization(endpoint, subject string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	header := b64.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(jwtLifetime).Unix(),
+		"sub": subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := header + "." + b64.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, k.private, digest[:])
+	if err != nil {
+		return "", err
+	}
+	// JWS encodes ES256 signatures as the fixed size concatenation r || s
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return fmt.Sprintf("vapid t=%s.%s, k=%s", signingInput, b64.EncodeToString(sig), k.PublicKey()), nil
+}
+
+// decodeBase64 accepts base64url with or without padding, which is how
+// browsers and tools variously export keys
+func decodeBase64(s string) ([]byte, error) {
+	if b, err := b64.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
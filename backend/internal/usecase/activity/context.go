@@ -0,0 +1,23 @@
+package activity
+
+import "context"
+
+type clientKey struct{}
+
+// client identifies where a request came from
+type client struct {
+	ip        string
+	userAgent string
+}
+
+// WithClient returns a copy of ctx carrying the IP address and user agent of
+// the caller, recorded with every activity log entry
+func WithClient(ctx context.Context, ip, userAgent string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client{ip: ip, userAgent: userAgent})
+}
+
+// clientFromContext returns the caller stored by WithClient
+func clientFromContext(ctx context.Context) client {
+	c, _ := ctx.Value(clientKey{}).(client)
+	return c
+}
@@ -0,0 +1,152 @@
+package activity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/infrastructure/logger"
+	"ai-git-workbench/internal/usecase/auth"
+)
+
+// maxUserAgentLength bounds the stored user agent
+const maxUserAgentLength = 512
+
+// Service records and queries the audit log
+type Service struct {
+	logs repositories.ActivityRepository
+}
+
+// NewService creates a new Service
+func NewService(logs repositories.ActivityRepository) *Service {
+	return &Service{logs: logs}
+}
+
+// Record appends an entry for an action on a resource. before is nil for
+// created resources and after is nil for deleted ones. The actor, request ID
+// and client are taken from ctx. Failures are logged rather than returned so
+// an audit problem never undoes a change that already happened.
+func (s *Service) Record(ctx context.Context, action, resourceType, resourceID string, before, after interface{}) {
+	// Record even when the caller disconnected after the change was made
+	ctx = context.WithoutCancel(ctx)
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to diff activity", "action", action, "resource_id", resourceID, "error", err)
+	}
+
+	client := clientFromContext(ctx)
+	entry := &entities.ActivityLog{
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Changes:      changes,
+		IP:           client.ip,
+		RequestID:    logger.RequestIDFromContext(ctx),
+		UserAgent:    strings.ToValidUTF8(truncate(client.userAgent, maxUserAgentLength), ""),
+	}
+	if user := auth.UserFromContext(ctx); user != nil {
+		entry.ActorID = &user.ID
+		entry.Actor = user.Login
+	}
+
+	if err := s.logs.Append(ctx, entry); err != nil {
+		slog.ErrorContext(ctx, "failed to record activity",
+			"action", action, "resource_type", resourceType, "resource_id", resourceID, "error", err)
+	}
+}
+
+// List returns a page of entries matching filter
+func (s *Service) List(ctx context.Context, filter repositories.ActivityFilter, opts repositories.ListOptions) (repositories.Page[entities.ActivityLog], error) {
+	return s.logs.List(ctx, filter, opts)
+}
+
+// Export calls fn with every entry matching filter in the order of sort,
+// reading the log one page at a time
+func (s *Service) Export(ctx context.Context, filter repositories.ActivityFilter, sort []repositories.SortField, fn func(*entities.ActivityLog) error) error {
+	opts := repositories.ListOptions{Limit: repositories.MaxPageSize, Sort: sort}
+	for {
+		page, err := s.logs.List(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		for i := range page.Items {
+			if err := fn(&page.Items[i]); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+// Diff compares the JSON encodings of before and after field by field and
+// returns the top-level fields that differ. A nil side contributes no fields,
+// so a creation lists every field of after and a deletion every field of
+// before. Null fields that only exist on one side are left out.
+func Diff(before, after interface{}) (map[string]entities.FieldChange, error) {
+	old, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]entities.FieldChange{}
+	for name, value := range old {
+		next, ok := cur[name]
+		switch {
+		case !ok && isNull(value):
+		case !ok:
+			changes[name] = entities.FieldChange{Before: value}
+		case !bytes.Equal(value, next):
+			changes[name] = entities.FieldChange{Before: value, After: next}
+		}
+	}
+	for name, value := range cur {
+		if _, ok := old[name]; !ok && !isNull(value) {
+			changes[name] = entities.FieldChange{After: value}
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return changes, nil
+}
+
+// fields decodes the JSON object encoding of v into its fields
+func fields(v interface{}) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding activity resource: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("activity resource is not a JSON object: %w", err)
+	}
+	return fields, nil
+}
+
+func isNull(value json.RawMessage) bool {
+	return bytes.Equal(value, []byte("null"))
+}
+
+// truncate cuts s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,103 @@
+package assistant
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/infrastructure/ai"
+)
+
+// ErrTokenLimit is returned when the user spent the daily token limit
+var ErrTokenLimit = errors.New("daily AI token limit reached")
+
+// Status is the AI usage of a user. Remaining is nil without a daily limit.
+type Status struct {
+	Provider   string                 `json:"provider"`
+	DailyLimit int                    `json:"daily_limit"`
+	Remaining  *int                   `json:"remaining_today,omitempty"`
+	Today      entities.AIUsageTotals `json:"today"`
+	Month      entities.AIUsageTotals `json:"month"`
+	ResetsAt   time.Time              `json:"resets_at"`
+}
+
+// Service sends prompts to the AI provider on behalf of users, recording the
+// tokens spent and enforcing the daily limit
+type Service struct {
+	provider   ai.Provider
+	usage      repositories.AIUsageRepository
+	dailyLimit int
+}
+
+// NewService creates a new Service. A dailyLimit of 0 is unlimited.
+func NewService(provider ai.Provider, usage repositories.AIUsageRepository, dailyLimit int) *Service {
+	return &Service{provider: provider, usage: usage, dailyLimit: dailyLimit}
+}
+
+// Complete sends req to the provider for user. source and taskID tell what
+// the tokens were spent on.
+func (s *Service) Complete(ctx context.Context, user *entities.User, source, taskID string, req ai.CompletionRequest) (*ai.CompletionResponse, error) {
+	if s.dailyLimit > 0 {
+		today, err := s.usage.Totals(ctx, user.ID, startOfDay(time.Now()))
+		if err != nil {
+			return nil, err
+		}
+		if today.TotalTokens >= s.dailyLimit {
+			return nil, ErrTokenLimit
+		}
+	}
+
+	resp, err := s.provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &entities.AIUsage{
+		UserID:       &user.ID,
+		Source:       source,
+		TaskID:       taskID,
+		Model:        resp.Model,
+		InputTokens:  resp.InputTokens,
+		OutputTokens: resp.OutputTokens,
+	}
+	// The tokens are spent even if the caller went away
+	if err := s.usage.Append(context.WithoutCancel(ctx), usage); err != nil {
+		slog.ErrorContext(ctx, "failed to record ai usage", "user_id", user.ID, "source", source, "error", err)
+	}
+	return resp, nil
+}
+
+// Status returns the usage of user today and this month (UTC)
+func (s *Service) Status(ctx context.Context, user *entities.User) (*Status, error) {
+	now := time.Now()
+	day := startOfDay(now)
+	today, err := s.usage.Totals(ctx, user.ID, day)
+	if err != nil {
+		return nil, err
+	}
+	month, err := s.usage.Totals(ctx, user.ID, time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{
+		Provider:   s.provider.Name(),
+		DailyLimit: s.dailyLimit,
+		Today:      today,
+		Month:      month,
+		ResetsAt:   day.AddDate(0, 0, 1),
+	}
+	if s.dailyLimit > 0 {
+		remaining := max(s.dailyLimit-today.TotalTokens, 0)
+		status.Remaining = &remaining
+	}
+	return status, nil
+}
+
+// startOfDay returns midnight UTC of the day of t
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
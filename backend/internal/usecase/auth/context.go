@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"context"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+type userKey struct{}
+
+// WithUser returns a copy of ctx carrying the authenticated user
+func WithUser(ctx context.Context, user *entities.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the authenticated user, or nil for anonymous callers
+func UserFromContext(ctx context.Context) *entities.User {
+	user, _ := ctx.Value(userKey{}).(*entities.User)
+	return user
+}
+
+// Scope returns the access scope of the caller in ctx
+func Scope(ctx context.Context) repositories.AccessScope {
+	user := UserFromContext(ctx)
+	if user == nil {
+		return repositories.AccessScope{}
+	}
+	return repositories.AccessScope{UserID: user.ID, Admin: user.IsAdmin()}
+}
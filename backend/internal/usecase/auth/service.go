@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// tokenPrefix marks workbench API tokens so they are easy to spot in secret scanners
+const tokenPrefix = "wfk_"
+
+var (
+	// ErrInvalidToken is returned for unknown, malformed or expired tokens
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrUnknownUser is returned when signing in as a user that does not
+	// exist while sign-up is disabled
+	ErrUnknownUser = errors.New("user is not registered")
+)
+
+// Service issues and verifies API tokens
+type Service struct {
+	users  repositories.UserRepository
+	tokens repositories.TokenRepository
+}
+
+// NewService creates a new Service
+func NewService(users repositories.UserRepository, tokens repositories.TokenRepository) *Service {
+	return &Service{users: users, tokens: tokens}
+}
+
+// Authenticate returns the owner of a raw bearer token
+func (s *Service) Authenticate(ctx context.Context, raw string) (*entities.User, error) {
+	if !strings.HasPrefix(raw, tokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+
+	token, err := s.tokens.GetByHash(ctx, hashToken(raw))
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if token.Expired(time.Now()) {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.users.GetByID(ctx, token.UserID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tokens.TouchLastUsed(ctx, token.ID); err != nil {
+		slog.WarnContext(ctx, "failed to record token use", "token_prefix", token.Prefix, "error", err)
+	}
+	return user, nil
+}
+
+// IssueToken creates a token for the user and returns its secret, which is
+// not stored and cannot be shown again. A zero ttl never expires.
+func (s *Service) IssueToken(ctx context.Context, user *entities.User, name string, ttl time.Duration) (string, *entities.APIToken, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("error generating token: %w", err)
+	}
+	raw := tokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	token := &entities.APIToken{
+		UserID:    user.ID,
+		Name:      name,
+		Prefix:    raw[:len(tokenPrefix)+6],
+		TokenHash: hashToken(raw),
+	}
+	if ttl > 0 {
+		expires := time.Now().UTC().Add(ttl)
+		token.ExpiresAt = &expires
+	}
+	if err := s.tokens.Create(ctx, token); err != nil {
+		return "", nil, err
+	}
+	return raw, token, nil
+}
+
+// Revoke deletes a raw bearer token so it can no longer be used
+func (s *Service) Revoke(ctx context.Context, raw string) error {
+	if !strings.HasPrefix(raw, tokenPrefix) {
+		return ErrInvalidToken
+	}
+	err := s.tokens.DeleteByHash(ctx, hashToken(raw))
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrInvalidToken
+	}
+	return err
+}
+
+// SignIn returns the user with the given login after it authenticated with
+// an identity provider. Unknown users are created with signupRole, or
+// rejected with ErrUnknownUser when signupRole is empty.
+func (s *Service) SignIn(ctx context.Context, profile entities.User, signupRole string) (*entities.User, error) {
+	user, err := s.users.GetByLogin(ctx, profile.Login)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+	if signupRole == "" {
+		return nil, ErrUnknownUser
+	}
+	if !entities.ValidRole(signupRole) {
+		return nil, fmt.Errorf("unknown role %q", signupRole)
+	}
+
+	user = &entities.User{Login: profile.Login, Name: profile.Name, Email: profile.Email, Role: signupRole}
+	err = s.users.Create(ctx, user)
+	if errors.Is(err, repositories.ErrConflict) {
+		// Signed up by a concurrent sign-in
+		return s.users.GetByLogin(ctx, profile.Login)
+	}
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "user signed up", "user_id", user.ID, "login", user.Login, "role", user.Role)
+	return user, nil
+}
+
+// EnsureUser returns the user with the given login, creating it with role if missing
+func (s *Service) EnsureUser(ctx context.Context, login, role string) (*entities.User, error) {
+	user, err := s.users.GetByLogin(ctx, login)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+	if !entities.ValidRole(role) {
+		return nil, fmt.Errorf("unknown role %q", role)
+	}
+
+	user = &entities.User{Login: login, Role: role}
+	if err := s.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// hashToken returns the hex SHA-256 of a raw token. Tokens carry 256 bits of
+// randomness, so a fast unsalted hash is sufficient.
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,161 @@
+package execution
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/infrastructure/ai"
+	"ai-git-workbench/internal/usecase/assistant"
+)
+
+// maxOutputLength bounds the AI output kept in a task's metadata
+const maxOutputLength = 16 << 10
+
+// Metadata keys written by task executions
+const (
+	MetadataOutput = "ai_output"
+	MetadataModel  = "ai_model"
+	MetadataError  = "execution_error"
+)
+
+// systemPrompt frames every task execution
+const systemPrompt = "You are a software engineer working on a task tracked in AI Git Workbench. " +
+	"Reply with a concise plan and the concrete code changes needed to complete the task."
+
+// StatusListener is notified after an execution changes the status of a task
+type StatusListener interface {
+	TaskStatusChanged(ctx context.Context, task *entities.Task, previous string)
+}
+
+// Executor carries out tasks with the AI provider in the background, moving
+// them through in_progress to completed or failed
+type Executor struct {
+	tasks     repositories.TaskRepository
+	runner    *Runner
+	assistant *assistant.Service
+	listeners []StatusListener
+}
+
+// NewExecutor creates a new Executor running tasks on runner
+func NewExecutor(tasks repositories.TaskRepository, runner *Runner, assistant *assistant.Service, listeners ...StatusListener) *Executor {
+	return &Executor{tasks: tasks, runner: runner, assistant: assistant, listeners: listeners}
+}
+
+// Execute marks a task in progress and starts carrying it out on behalf of
+// user. It returns the task as of the start of the execution.
+func (e *Executor) Execute(ctx context.Context, taskID string, user *entities.User) (*entities.Task, error) {
+	task, err := e.tasks.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	// Another instance may be running it
+	if task.Status == entities.TaskStatusInProgress {
+		return nil, ErrAlreadyRunning
+	}
+
+	previous, started := task.Status, time.Now().UTC()
+	task.Status = entities.TaskStatusInProgress
+	task.StartedAt = &started
+	task.CompletedAt = nil
+	if err := e.tasks.Update(ctx, task); err != nil {
+		return nil, err
+	}
+
+	err = e.runner.Start(taskID, func(runCtx context.Context) error {
+		return e.run(runCtx, taskID, user)
+	})
+	if err != nil {
+		task.Status, task.StartedAt = previous, nil
+		if restoreErr := e.tasks.Update(ctx, task); restoreErr != nil {
+			slog.ErrorContext(ctx, "failed to restore task status", "task_id", taskID, "error", restoreErr)
+		}
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "task execution started", "task_id", taskID, "user_id", user.ID)
+	e.notify(ctx, task, previous)
+	return task, nil
+}
+
+// run asks the AI provider to carry out the task and stores the outcome
+func (e *Executor) run(ctx context.Context, taskID string, user *entities.User) error {
+	task, err := e.tasks.GetByID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	resp, runErr := e.assistant.Complete(ctx, user, entities.AIUsageTask, taskID, ai.CompletionRequest{
+		System:   systemPrompt,
+		Messages: []ai.Message{{Role: "user", Content: taskPrompt(task)}},
+	})
+	if ctx.Err() != nil {
+		// Shutting down; the runner puts the task back in the queue
+		return ctx.Err()
+	}
+
+	// The task may have been edited while the model was working
+	task, err = e.tasks.GetByID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	previous, finished := task.Status, time.Now().UTC()
+	task.CompletedAt = &finished
+	if task.Metadata == nil {
+		task.Metadata = map[string]string{}
+	}
+	if runErr != nil {
+		task.Status = entities.TaskStatusFailed
+		task.Metadata[MetadataError] = runErr.Error()
+	} else {
+		task.Status = entities.TaskStatusCompleted
+		task.TokensUsed += resp.InputTokens + resp.OutputTokens
+		task.Metadata[MetadataOutput] = truncate(resp.Content, maxOutputLength)
+		task.Metadata[MetadataModel] = resp.Model
+		delete(task.Metadata, MetadataError)
+	}
+	if err := e.tasks.Update(ctx, task); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "task execution finished", "task_id", taskID, "status", task.Status)
+	e.notify(ctx, task, previous)
+	return runErr
+}
+
+// notify tells the listeners about a status change
+func (e *Executor) notify(ctx context.Context, task *entities.Task, previous string) {
+	if task.Status == previous {
+		return
+	}
+	for _, listener := range e.listeners {
+		listener.TaskStatusChanged(ctx, task, previous)
+	}
+}
+
+// taskPrompt describes a task to the model
+func taskPrompt(task *entities.Task) string {
+	var b strings.Builder
+	b.WriteString("Task: " + task.Title + "\n")
+	if task.Description != "" {
+		b.WriteString("\n" + task.Description + "\n")
+	}
+	if task.Repository != "" {
+		b.WriteString("\nRepository: " + task.Repository + "\n")
+	}
+	if task.Branch != "" {
+		b.WriteString("Branch: " + task.Branch + "\n")
+	}
+	return b.String()
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
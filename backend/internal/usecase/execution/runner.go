@@ -0,0 +1,140 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrDraining is returned when a run is started while the runner shuts down
+	ErrDraining = errors.New("task runner is shutting down")
+	// ErrAlreadyRunning is returned when the task already has an active run
+	ErrAlreadyRunning = errors.New("task is already running")
+)
+
+// checkpointTimeout bounds how long requeuing unfinished tasks may take
+const checkpointTimeout = 5 * time.Second
+
+// RunFunc executes a task. It must return promptly once ctx is cancelled.
+type RunFunc func(ctx context.Context) error
+
+// Checkpointer stores unfinished tasks so they are picked up again after a restart
+type Checkpointer interface {
+	RequeueTasks(ctx context.Context, taskIDs []string) error
+}
+
+// Runner tracks running task executions so they can be drained on shutdown
+type Runner struct {
+	checkpointer Checkpointer
+
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	draining bool
+	running  map[string]context.CancelFunc
+}
+
+// NewRunner creates a new Runner
+func NewRunner(checkpointer Checkpointer) *Runner {
+	return &Runner{
+		checkpointer: checkpointer,
+		running:      make(map[string]context.CancelFunc),
+	}
+}
+
+// Start runs fn for the task in the background
+func (r *Runner) Start(taskID string, fn RunFunc) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.draining {
+		return ErrDraining
+	}
+	if _, ok := r.running[taskID]; ok {
+		return ErrAlreadyRunning
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.running[taskID] = cancel
+	r.wg.Add(1)
+
+	go func() {
+		defer r.wg.Done()
+		defer r.finish(taskID)
+
+		if err := fn(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			slog.Error("task execution failed", "task_id", taskID, "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// Running returns the IDs of tasks with an active run
+func (r *Runner) Running() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]string, 0, len(r.running))
+	for id := range r.running {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Shutdown stops accepting runs and waits for running ones until ctx is done.
+// Runs still active at the deadline are cancelled and checkpointed back to the queue.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	r.draining = true
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	r.mu.Lock()
+	unfinished := make([]string, 0, len(r.running))
+	for id, cancel := range r.running {
+		cancel()
+		unfinished = append(unfinished, id)
+	}
+	r.mu.Unlock()
+
+	if len(unfinished) == 0 {
+		return nil
+	}
+	sort.Strings(unfinished)
+	slog.Warn("requeuing unfinished tasks", "count", len(unfinished), "task_ids", unfinished)
+
+	if r.checkpointer == nil {
+		return nil
+	}
+
+	checkpointCtx, cancel := context.WithTimeout(context.Background(), checkpointTimeout)
+	defer cancel()
+	return r.checkpointer.RequeueTasks(checkpointCtx, unfinished)
+}
+
+// finish removes a completed run
+func (r *Runner) finish(taskID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cancel, ok := r.running[taskID]; ok {
+		cancel()
+		delete(r.running, taskID)
+	}
+}
@@ -0,0 +1,101 @@
+// Package idempotency makes retried requests safe: the response to the first
+// request sent with a key is stored and replayed to retries of it
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// pruneInterval is how often expired keys are deleted
+const pruneInterval = 10 * time.Minute
+
+var (
+	// ErrKeyReused is returned when a key is sent again with another request
+	ErrKeyReused = errors.New("idempotency key was used for a different request")
+	// ErrInProgress is returned when the first request with a key has not
+	// finished yet
+	ErrInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// Service reserves idempotency keys and stores the responses to them
+type Service struct {
+	keys repositories.IdempotencyKeyRepository
+	ttl  time.Duration
+}
+
+// NewService creates a new Service keeping responses for ttl
+func NewService(keys repositories.IdempotencyKeyRepository, ttl time.Duration) *Service {
+	return &Service{keys: keys, ttl: ttl}
+}
+
+// Begin reserves key in scope for a request with the given hash. When the
+// key was already used for the same request and its response is stored,
+// that response is returned with replay set. Otherwise the returned key is
+// the reservation to pass to Finish or Abandon.
+func (s *Service) Begin(ctx context.Context, scope, key, requestHash string) (*entities.IdempotencyKey, bool, error) {
+	reservation := &entities.IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   time.Now().Add(s.ttl),
+	}
+	existing, err := s.keys.Reserve(ctx, reservation)
+	switch {
+	case errors.Is(err, repositories.ErrConflict):
+		if existing.RequestHash != requestHash {
+			return nil, false, ErrKeyReused
+		}
+		if !existing.Completed() {
+			return nil, false, ErrInProgress
+		}
+		return existing, true, nil
+	case err != nil:
+		return nil, false, err
+	}
+	return reservation, false, nil
+}
+
+// Finish stores the response to a reserved key
+func (s *Service) Finish(ctx context.Context, key *entities.IdempotencyKey) {
+	// The response is sent even if the caller went away, so it is stored
+	ctx = context.WithoutCancel(ctx)
+	if err := s.keys.Complete(ctx, key); err != nil {
+		slog.ErrorContext(ctx, "failed to store idempotent response", "idempotency_key", key.Key, "error", err)
+	}
+}
+
+// Abandon releases a reserved key without a response, so retries of the
+// request are carried out again
+func (s *Service) Abandon(ctx context.Context, key *entities.IdempotencyKey) {
+	ctx = context.WithoutCancel(ctx)
+	if err := s.keys.Delete(ctx, key.Scope, key.Key); err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		slog.ErrorContext(ctx, "failed to release idempotency key", "idempotency_key", key.Key, "error", err)
+	}
+}
+
+// Run deletes expired keys until ctx is cancelled
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.keys.DeleteExpired(ctx, time.Now())
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to prune idempotency keys", "error", err)
+				continue
+			}
+			if deleted > 0 {
+				slog.DebugContext(ctx, "pruned idempotency keys", "count", deleted)
+			}
+		}
+	}
+}
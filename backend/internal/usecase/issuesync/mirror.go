@@ -0,0 +1,59 @@
+package issuesync
+
+import (
+	"context"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// taskMirror queues the issue sync of tasks after every successful write
+type taskMirror struct {
+	repositories.TaskRepository
+	s *Service
+}
+
+// Mirror wraps tasks so changes of the tasks of repositories with issue sync
+// enabled are pushed to their issues
+func (s *Service) Mirror(tasks repositories.TaskRepository) repositories.TaskRepository {
+	return &taskMirror{TaskRepository: tasks, s: s}
+}
+
+func (m *taskMirror) Create(ctx context.Context, task *entities.Task) error {
+	if err := m.TaskRepository.Create(ctx, task); err != nil {
+		return err
+	}
+	m.s.taskChanged(ctx, task)
+	return nil
+}
+
+func (m *taskMirror) Update(ctx context.Context, task *entities.Task) error {
+	if err := m.TaskRepository.Update(ctx, task); err != nil {
+		return err
+	}
+	m.s.taskChanged(ctx, task)
+	return nil
+}
+
+func (m *taskMirror) Delete(ctx context.Context, id string, version int64) error {
+	if err := m.TaskRepository.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	m.s.taskDeleted(ctx, id)
+	return nil
+}
+
+// ApplyBatch queues the written tasks once the whole batch is stored
+func (m *taskMirror) ApplyBatch(ctx context.Context, writes []repositories.TaskWrite) error {
+	if err := m.TaskRepository.ApplyBatch(ctx, writes); err != nil {
+		return err
+	}
+	for _, w := range writes {
+		if w.Op == repositories.WriteDelete {
+			m.s.taskDeleted(ctx, w.Task.ID)
+		} else {
+			m.s.taskChanged(ctx, w.Task)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,620 @@
+// Package issuesync mirrors the tasks of repositories with issue sync enabled
+// to GitHub issues and applies changes of those issues back to the tasks.
+// When a task and its issue both changed since they were last synced, the
+// side updated last wins and the conflict is logged.
+package issuesync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/infrastructure/github"
+)
+
+const (
+	// syncInterval is how often pending links are retried without a wake up
+	syncInterval = time.Minute
+	// syncBatch is how many pending links are pushed at a time
+	syncBatch = 50
+)
+
+// Label prefixes mapping issue labels to the epic and status of a task
+const (
+	epicLabelPrefix   = "epic:"
+	statusLabelPrefix = "status:"
+)
+
+// markerPattern matches the comment ending the body of a mirrored issue,
+// naming its task
+var markerPattern = regexp.MustCompile(`\s*<!-- workbench-task: (\S+) -->\s*$`)
+
+// StatusListener is notified after a change pulled from an issue moves a
+// task to another status
+type StatusListener interface {
+	TaskStatusChanged(ctx context.Context, task *entities.Task, previous string)
+}
+
+// ActivityRecorder records the task changes pulled from issues
+type ActivityRecorder interface {
+	Record(ctx context.Context, action, resourceType, resourceID string, before, after interface{})
+}
+
+// Service pushes task changes to GitHub issues and pulls issue changes from
+// webhooks
+type Service struct {
+	tasks     repositories.TaskRepository
+	repos     repositories.RepositoryRepository
+	links     repositories.IssueSyncRepository
+	client    *github.Client
+	activity  ActivityRecorder
+	listeners []StatusListener
+	wake      chan struct{}
+	// mu serializes pushes and webhooks so they see each other's links
+	mu sync.Mutex
+}
+
+// NewService creates a new Service. tasks must not be wrapped by Mirror, so
+// changes pulled from issues are not pushed back. Those changes are recorded
+// in activity and status changes are passed to listeners, like the ones made
+// through the API.
+func NewService(tasks repositories.TaskRepository, repos repositories.RepositoryRepository,
+	links repositories.IssueSyncRepository, client *github.Client, activity ActivityRecorder, listeners ...StatusListener) *Service {
+	return &Service{tasks: tasks, repos: repos, links: links, client: client, activity: activity, listeners: listeners,
+		wake: make(chan struct{}, 1)}
+}
+
+// Conflicts returns a page of the conflict log
+func (s *Service) Conflicts(ctx context.Context, filter repositories.SyncConflictFilter, opts repositories.ListOptions) (repositories.Page[entities.SyncConflict], error) {
+	return s.links.ListConflicts(ctx, filter, opts)
+}
+
+// Run pushes pending task changes to GitHub until ctx is cancelled
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+	for {
+		s.pushPending(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// notify wakes Run up without waiting for it
+func (s *Service) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pushPending pushes pending links a batch at a time, stopping at the first
+// batch with a failure so failing links are retried on the next tick
+func (s *Service) pushPending(ctx context.Context) {
+	if !s.client.Configured() {
+		return
+	}
+	for ctx.Err() == nil {
+		links, err := s.links.ListPending(ctx, syncBatch)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to list pending issue links", "error", err)
+			return
+		}
+		failed := false
+		for i := range links {
+			if err := s.push(ctx, &links[i]); err != nil {
+				failed = true
+				slog.WarnContext(ctx, "failed to sync task to issue",
+					"task_id", links[i].TaskID, "repository", links[i].Repository, "error", err)
+			}
+		}
+		if failed || len(links) < syncBatch {
+			return
+		}
+	}
+}
+
+// push brings the issue of a pending link up to date with its task, or
+// closes it when the task was deleted. Errors are stored on the link, which
+// stays pending.
+func (s *Service) push(ctx context.Context, link *entities.IssueLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.pushLink(ctx, link)
+	if err != nil {
+		link.Error = err.Error()
+		if saveErr := s.links.SaveLink(ctx, link); saveErr != nil {
+			slog.ErrorContext(ctx, "failed to save issue link", "task_id", link.TaskID, "error", saveErr)
+		}
+	}
+	return err
+}
+
+func (s *Service) pushLink(ctx context.Context, link *entities.IssueLink) error {
+	owner, name, _ := strings.Cut(link.Repository, "/")
+
+	task, err := s.tasks.GetByID(ctx, link.TaskID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return s.closeIssue(ctx, link, owner, name)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Tasks moved out of the repository, or of one that stopped syncing, are
+	// left alone
+	if repo, ok := s.enabled(ctx, task.Repository); !ok || repo.FullName != link.Repository {
+		link.Pending = false
+		return s.links.SaveLink(ctx, link)
+	}
+
+	local := taskFields(task)
+	localHash := hashFields(local)
+
+	var issue *github.Issue
+	if link.IssueNumber != 0 {
+		issue, err = s.client.GetIssue(ctx, owner, name, link.IssueNumber)
+		if issueGone(err) {
+			// Deleted or transferred on GitHub: a new issue takes its place
+			link.IssueNumber, link.IssueURL, link.SyncedHash = 0, "", ""
+			issue = nil
+		} else if err != nil {
+			return err
+		}
+	}
+	if issue == nil {
+		req := issueRequest(local, task.ID, nil)
+		issue, err = s.client.CreateIssue(ctx, owner, name, req)
+		if err != nil {
+			return err
+		}
+		link.IssueNumber = issue.Number
+		if req.State != issue.State {
+			// New issues are always open
+			if issue, err = s.client.UpdateIssue(ctx, owner, name, issue.Number, req); err != nil {
+				return err
+			}
+		}
+		slog.InfoContext(ctx, "issue created for task",
+			"task_id", task.ID, "repository", link.Repository, "issue", issue.Number)
+		return s.saveSynced(ctx, link, issue, localHash)
+	}
+
+	remote := issueFields(issue)
+	remoteHash := hashFields(remote)
+	switch {
+	case remoteHash == localHash:
+		return s.saveSynced(ctx, link, issue, localHash)
+	case link.SyncedHash != "" && remoteHash != link.SyncedHash:
+		// The issue changed too; keep it when it changed last or the task
+		// did not really change
+		if localHash == link.SyncedHash || s.resolve(ctx, link, task, local, issue, remote) == entities.SyncSideIssue {
+			return s.pull(ctx, link, task, issue, remote)
+		}
+	}
+
+	issue, err = s.client.UpdateIssue(ctx, owner, name, link.IssueNumber, issueRequest(local, task.ID, issue.Labels))
+	if err != nil {
+		return err
+	}
+	slog.DebugContext(ctx, "issue updated from task",
+		"task_id", task.ID, "repository", link.Repository, "issue", issue.Number)
+	return s.saveSynced(ctx, link, issue, localHash)
+}
+
+// closeIssue closes the issue of a deleted task as not planned and removes
+// the link
+func (s *Service) closeIssue(ctx context.Context, link *entities.IssueLink, owner, name string) error {
+	if link.IssueNumber != 0 {
+		issue, err := s.client.GetIssue(ctx, owner, name, link.IssueNumber)
+		switch {
+		case issueGone(err):
+		case err != nil:
+			return err
+		case issue.State != "closed":
+			labels := make([]string, len(issue.Labels))
+			for i, label := range issue.Labels {
+				labels[i] = label.Name
+			}
+			if _, err := s.client.UpdateIssue(ctx, owner, name, link.IssueNumber, github.IssueRequest{
+				Title:       issue.Title,
+				Body:        issue.Body,
+				State:       "closed",
+				StateReason: "not_planned",
+				Labels:      labels,
+			}); err != nil {
+				return err
+			}
+			slog.InfoContext(ctx, "issue of deleted task closed",
+				"task_id", link.TaskID, "repository", link.Repository, "issue", link.IssueNumber)
+		}
+	}
+	if err := s.links.DeleteLink(ctx, link.TaskID); err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// GitHubEvent applies the changes of issues webhooks to the tasks mirrored
+// by them, and creates tasks for issues opened on GitHub. event is a webhook
+// flattened by github.ParseWebhookEvent.
+func (s *Service) GitHubEvent(ctx context.Context, event map[string]string) {
+	if event["event"] != "issues" || !s.client.Configured() {
+		return
+	}
+	number, err := strconv.Atoi(event["number"])
+	if err != nil {
+		return
+	}
+	repo, ok := s.enabled(ctx, event["repository"])
+	if !ok || repo.FullName != event["repository"] {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := s.issueChanged(ctx, repo, number, event["action"]); err != nil {
+			slog.WarnContext(ctx, "failed to sync issue to task",
+				"repository", repo.FullName, "issue", number, "action", event["action"], "error", err)
+		}
+	}()
+}
+
+func (s *Service) issueChanged(ctx context.Context, repo *entities.Repository, number int, action string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, err := s.links.GetLinkByIssue(ctx, repo.FullName, number)
+	if errors.Is(err, repositories.ErrNotFound) {
+		link = nil
+	} else if err != nil {
+		return err
+	}
+	if action == "deleted" || action == "transferred" {
+		if link == nil {
+			return nil
+		}
+		// The task stays; its next change opens a new issue
+		link.IssueNumber, link.IssueURL, link.SyncedHash = 0, "", ""
+		return s.links.SaveLink(ctx, link)
+	}
+
+	owner, name, _ := strings.Cut(repo.FullName, "/")
+	issue, err := s.client.GetIssue(ctx, owner, name, number)
+	if err != nil {
+		return err
+	}
+	if issue.PullRequest != nil {
+		return nil
+	}
+	remote := issueFields(issue)
+	remoteHash := hashFields(remote)
+
+	if link == nil {
+		// Issues opened for a task are linked once their creation returns
+		if action != "opened" || markerPattern.MatchString(issue.Body) {
+			return nil
+		}
+		task := &entities.Task{
+			Title:       remote.Title,
+			Description: remote.Description,
+			Status:      remote.Status,
+			Repository:  repo.FullName,
+			Epic:        remote.Epic,
+		}
+		if task.Status == entities.TaskStatusInProgress {
+			// Only the executor starts tasks
+			task.Status = entities.TaskStatusPending
+		}
+		if err := s.tasks.Create(ctx, task); err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "task created from issue", "task_id", task.ID, "repository", repo.FullName, "issue", number)
+		s.activity.Record(ctx, entities.ActivityTaskCreated, entities.ResourceTask, task.ID, nil, task)
+		return s.saveSynced(ctx, &entities.IssueLink{TaskID: task.ID, Repository: repo.FullName, IssueNumber: number},
+			issue, remoteHash)
+	}
+
+	if remoteHash == link.SyncedHash {
+		// Echo of a push, or a change of fields that are not mirrored
+		return nil
+	}
+	task, err := s.tasks.GetByID(ctx, link.TaskID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		// The push closing the issue is pending
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	local := taskFields(task)
+	if localHash := hashFields(local); localHash == remoteHash {
+		return s.saveSynced(ctx, link, issue, remoteHash)
+	} else if localHash != link.SyncedHash {
+		// Both changed: the push resolves the conflict
+		if err := s.links.MarkPending(ctx, task.ID, link.Repository); err != nil {
+			return err
+		}
+		s.notify()
+		return nil
+	}
+	return s.pull(ctx, link, task, issue, remote)
+}
+
+// pull overwrites the mirrored fields of task with those of its issue. The
+// status follows the task's transition rules: a move they refuse, such as
+// into or out of in_progress, keeps the task's status, and the next push
+// puts it back on the issue.
+func (s *Service) pull(ctx context.Context, link *entities.IssueLink, task *entities.Task, issue *github.Issue,
+	remote entities.IssueFields) error {
+	before, previous := *task, task.Status
+	task.Title = remote.Title
+	task.Description = remote.Description
+	task.Epic = remote.Epic
+	if remote.Status != task.Status {
+		if task.CanTransition(remote.Status) {
+			task.Transition(remote.Status, time.Now().UTC())
+		} else {
+			slog.InfoContext(ctx, "issue status not applied to task", "task_id", task.ID,
+				"issue", issue.Number, "status", task.Status, "issue_status", remote.Status)
+		}
+	}
+
+	if taskFields(task) != taskFields(&before) {
+		if err := s.tasks.Update(ctx, task); err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "task updated from issue",
+			"task_id", task.ID, "repository", link.Repository, "issue", issue.Number)
+		s.activity.Record(ctx, entities.ActivityTaskUpdated, entities.ResourceTask, task.ID, before, task)
+		if task.Status != previous {
+			for _, listener := range s.listeners {
+				listener.TaskStatusChanged(ctx, task, previous)
+			}
+		}
+	}
+	return s.saveSynced(ctx, link, issue, hashFields(remote))
+}
+
+// resolve logs a conflict between a task and its issue and returns the side
+// that wins it: the one updated last, or the task on a tie
+func (s *Service) resolve(ctx context.Context, link *entities.IssueLink, task *entities.Task,
+	local entities.IssueFields, issue *github.Issue, remote entities.IssueFields) string {
+	winner := entities.SyncSideTask
+	if issue.UpdatedAt.After(task.UpdatedAt) {
+		winner = entities.SyncSideIssue
+	}
+	conflict := &entities.SyncConflict{
+		TaskID:         task.ID,
+		Repository:     link.Repository,
+		IssueNumber:    issue.Number,
+		Winner:         winner,
+		Task:           local,
+		Issue:          remote,
+		TaskUpdatedAt:  task.UpdatedAt,
+		IssueUpdatedAt: issue.UpdatedAt,
+	}
+	if err := s.links.AddConflict(ctx, conflict); err != nil {
+		slog.ErrorContext(ctx, "failed to log sync conflict", "task_id", task.ID, "error", err)
+	}
+	slog.WarnContext(ctx, "task and issue changed concurrently",
+		"task_id", task.ID, "repository", link.Repository, "issue", issue.Number, "winner", winner)
+	return winner
+}
+
+// saveSynced stores link as synced with issue at hash. A change of the task
+// made meanwhile flags it pending again.
+func (s *Service) saveSynced(ctx context.Context, link *entities.IssueLink, issue *github.Issue, hash string) error {
+	synced := time.Now().UTC()
+	updated := issue.UpdatedAt
+	link.IssueURL = issue.HTMLURL
+	link.SyncedHash = hash
+	link.IssueUpdatedAt = &updated
+	link.Pending = false
+	link.Error = ""
+	link.SyncedAt = &synced
+	if err := s.links.SaveLink(ctx, link); err != nil {
+		return err
+	}
+
+	task, err := s.tasks.GetByID(ctx, link.TaskID)
+	if err == nil && hashFields(taskFields(task)) == hash {
+		return nil
+	}
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return err
+	}
+	if err := s.links.MarkPending(ctx, link.TaskID, link.Repository); err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+// enabled returns the repository tasks name as name when its tasks are
+// mirrored
+func (s *Service) enabled(ctx context.Context, name string) (*entities.Repository, bool) {
+	if name == "" || !s.client.Configured() {
+		return nil, false
+	}
+	repo, err := s.repos.GetByName(ctx, name)
+	if err != nil {
+		if !errors.Is(err, repositories.ErrNotFound) {
+			slog.ErrorContext(ctx, "failed to load repository for issue sync", "repository", name, "error", err)
+		}
+		return nil, false
+	}
+	owner, repoName, ok := strings.Cut(repo.FullName, "/")
+	return repo, repo.IssueSync && ok && owner != "" && repoName != ""
+}
+
+// taskChanged flags the link of a task of a mirrored repository pending when
+// its mirrored fields differ from the last synced ones
+func (s *Service) taskChanged(ctx context.Context, task *entities.Task) {
+	repo, ok := s.enabled(ctx, task.Repository)
+	if !ok {
+		return
+	}
+	repository := repo.FullName
+	link, err := s.links.GetLink(ctx, task.ID)
+	switch {
+	case err == nil && link.SyncedHash == hashFields(taskFields(task)):
+		return
+	case err == nil:
+		// Already linked in the repository it was created in
+		repository = link.Repository
+	case !errors.Is(err, repositories.ErrNotFound):
+		slog.ErrorContext(ctx, "failed to load issue link", "task_id", task.ID, "error", err)
+		return
+	}
+	if err := s.links.MarkPending(ctx, task.ID, repository); err != nil {
+		slog.ErrorContext(ctx, "failed to queue task for issue sync", "task_id", task.ID, "error", err)
+		return
+	}
+	s.notify()
+}
+
+// taskDeleted flags the link of a deleted task pending, so its issue is closed
+func (s *Service) taskDeleted(ctx context.Context, id string) {
+	if !s.client.Configured() {
+		return
+	}
+	link, err := s.links.GetLink(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return
+	}
+	if err == nil {
+		err = s.links.MarkPending(ctx, id, link.Repository)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to queue deleted task for issue sync", "task_id", id, "error", err)
+		return
+	}
+	s.notify()
+}
+
+// taskFields returns the fields of task mirrored to its issue
+func taskFields(task *entities.Task) entities.IssueFields {
+	return entities.IssueFields{
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Epic:        task.Epic,
+	}
+}
+
+// issueFields maps an issue to task fields. Its epic: label names the epic.
+// A closed issue is completed, or failed when closed as not planned or
+// labeled status:failed; an open one is pending unless labeled
+// status:queued or status:in_progress. The in_progress label only matches
+// the pushes of running tasks: pull and new issues never apply it.
+func issueFields(issue *github.Issue) entities.IssueFields {
+	fields := entities.IssueFields{
+		Title:       issue.Title,
+		Description: stripMarker(issue.Body),
+		Status:      entities.TaskStatusPending,
+	}
+	labeled := ""
+	for _, label := range issue.Labels {
+		if epic, ok := strings.CutPrefix(label.Name, epicLabelPrefix); ok && fields.Epic == "" {
+			fields.Epic = strings.TrimSpace(epic)
+		}
+		if status, ok := strings.CutPrefix(label.Name, statusLabelPrefix); ok && labeled == "" {
+			if status = strings.TrimSpace(status); entities.ValidTaskStatus(status) {
+				labeled = status
+			}
+		}
+	}
+
+	switch {
+	case issue.State == "closed":
+		fields.Status = entities.TaskStatusCompleted
+		if labeled == entities.TaskStatusFailed || (labeled == "" && issue.StateReason == "not_planned") {
+			fields.Status = entities.TaskStatusFailed
+		}
+	case labeled == entities.TaskStatusQueued || labeled == entities.TaskStatusInProgress:
+		fields.Status = labeled
+	}
+	return fields
+}
+
+// issueRequest maps task fields to an issue, keeping the labels of current
+// that do not name an epic or status
+func issueRequest(fields entities.IssueFields, taskID string, current []github.Label) github.IssueRequest {
+	labels := []string{}
+	for _, label := range current {
+		if !strings.HasPrefix(label.Name, epicLabelPrefix) && !strings.HasPrefix(label.Name, statusLabelPrefix) {
+			labels = append(labels, label.Name)
+		}
+	}
+	if fields.Epic != "" {
+		labels = append(labels, epicLabelPrefix+fields.Epic)
+	}
+
+	req := github.IssueRequest{
+		Title: fields.Title,
+		Body:  issueBody(fields.Description, taskID),
+		State: "open",
+	}
+	switch fields.Status {
+	case entities.TaskStatusQueued, entities.TaskStatusInProgress:
+		labels = append(labels, statusLabelPrefix+fields.Status)
+	case entities.TaskStatusCompleted:
+		req.State, req.StateReason = "closed", "completed"
+	case entities.TaskStatusFailed:
+		req.State, req.StateReason = "closed", "not_planned"
+		labels = append(labels, statusLabelPrefix+fields.Status)
+	}
+	req.Labels = labels
+	return req
+}
+
+// issueBody is the description of a task followed by the marker naming it
+func issueBody(description, taskID string) string {
+	marker := fmt.Sprintf("<!-- workbench-task: %s -->", taskID)
+	if strings.TrimSpace(description) == "" {
+		return marker
+	}
+	return description + "\n\n" + marker
+}
+
+// stripMarker returns the description in an issue body
+func stripMarker(body string) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	return markerPattern.ReplaceAllString(body, "")
+}
+
+// hashFields identifies mirrored fields, ignoring the surrounding space and
+// line endings GitHub may change
+func hashFields(fields entities.IssueFields) string {
+	fields.Title = strings.TrimSpace(fields.Title)
+	fields.Description = strings.TrimSpace(strings.ReplaceAll(fields.Description, "\r\n", "\n"))
+	fields.Epic = strings.TrimSpace(fields.Epic)
+	b, _ := json.Marshal(fields)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// issueGone reports whether err tells that an issue was deleted or moved
+func issueGone(err error) bool {
+	var apiErr *github.APIError
+	return errors.As(err, &apiErr) &&
+		(apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusGone)
+}
@@ -0,0 +1,94 @@
+package issuesync
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/infrastructure/config"
+	"ai-git-workbench/internal/infrastructure/database"
+	"ai-git-workbench/internal/infrastructure/github"
+)
+
+// recorder collects recorded activity and status changes
+type recorder struct {
+	actions  []string
+	statuses []string
+}
+
+func (r *recorder) Record(_ context.Context, action, _, _ string, _, _ interface{}) {
+	r.actions = append(r.actions, action)
+}
+
+func (r *recorder) TaskStatusChanged(_ context.Context, task *entities.Task, previous string) {
+	r.statuses = append(r.statuses, previous+"->"+task.Status)
+}
+
+func TestPull(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		status     string
+		labels     []string
+		state      string
+		wantStatus string
+		completed  bool
+		changes    []string
+	}{
+		{"closed completes", entities.TaskStatusPending, nil, "closed", entities.TaskStatusCompleted, true,
+			[]string{"pending->completed"}},
+		{"label queues", entities.TaskStatusPending, []string{"status:queued"}, "open", entities.TaskStatusQueued, false,
+			[]string{"pending->queued"}},
+		{"in_progress label is ignored", entities.TaskStatusPending, []string{"status:in_progress"}, "open",
+			entities.TaskStatusPending, false, nil},
+		{"running task keeps its status", entities.TaskStatusInProgress, nil, "closed",
+			entities.TaskStatusInProgress, false, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			db, err := database.Open(ctx, &config.DatabaseConfig{
+				Driver: "sqlite", Path: filepath.Join(t.TempDir(), "test.db"), MaxOpenConns: 1, MaxIdleConns: 1,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			tasks := database.NewTaskStore(db)
+			task := &entities.Task{Title: "Old", Status: tc.status, Repository: "acme/web"}
+			if err := tasks.Create(ctx, task); err != nil {
+				t.Fatal(err)
+			}
+			rec := &recorder{}
+			s := NewService(tasks, database.NewRepositoryStore(db), database.NewIssueSyncStore(db),
+				github.NewClient(config.GitHubConfig{}), rec, rec)
+
+			issue := &github.Issue{Number: 7, Title: "New", State: tc.state, UpdatedAt: time.Now()}
+			for _, label := range tc.labels {
+				issue.Labels = append(issue.Labels, github.Label{Name: label})
+			}
+			link := &entities.IssueLink{TaskID: task.ID, Repository: "acme/web", IssueNumber: 7}
+			if err := s.pull(ctx, link, task, issue, issueFields(issue)); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := tasks.GetByID(ctx, task.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Title != "New" || got.Status != tc.wantStatus {
+				t.Errorf("task = %q %s, want %q %s", got.Title, got.Status, "New", tc.wantStatus)
+			}
+			if (got.CompletedAt != nil) != tc.completed {
+				t.Errorf("completed_at = %v, want set %v", got.CompletedAt, tc.completed)
+			}
+			if len(rec.actions) != 1 || rec.actions[0] != entities.ActivityTaskUpdated {
+				t.Errorf("activity = %v, want one %s", rec.actions, entities.ActivityTaskUpdated)
+			}
+			if len(rec.statuses) != len(tc.changes) || (len(tc.changes) > 0 && rec.statuses[0] != tc.changes[0]) {
+				t.Errorf("status changes = %v, want %v", rec.statuses, tc.changes)
+			}
+		})
+	}
+}
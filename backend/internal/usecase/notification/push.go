@@ -0,0 +1,230 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/infrastructure/webpush"
+)
+
+// pushMessageLimit bounds the message of a push payload that would otherwise
+// not fit in a single encrypted record
+const pushMessageLimit = 1024
+
+// LoadVAPIDKeys returns the key pair for the configured private key or, when
+// none is configured, the pair stored in keys. The first instance to start
+// generates and stores the pair so every replica signs with the same key.
+func LoadVAPIDKeys(ctx context.Context, keys repositories.VAPIDKeyRepository, private string) (*webpush.Keys, error) {
+	if private != "" {
+		return webpush.ParseKeys(private)
+	}
+
+	stored, err := keys.Get(ctx)
+	if err == nil {
+		return webpush.ParseKeys(stored.PrivateKey)
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+
+	generated, err := webpush.GenerateKeys()
+	if err != nil {
+		return nil, fmt.Errorf("error generating vapid keys: %w", err)
+	}
+	err = keys.Create(ctx, &entities.VAPIDKeyPair{
+		PublicKey:  generated.PublicKey(),
+		PrivateKey: generated.PrivateKey(),
+	})
+	if errors.Is(err, repositories.ErrConflict) {
+		// Another instance stored its pair first
+		return LoadVAPIDKeys(ctx, keys, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "generated vapid keys", "public_key", generated.PublicKey())
+	return generated, nil
+}
+
+// PushDelivery is the outcome of sending a push message to one browser.
+// Pruned is set when the push service reported the browser as gone and its
+// subscription was deleted.
+type PushDelivery struct {
+	SubscriptionID int64  `json:"subscription_id"`
+	Pruned         bool   `json:"pruned,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// pushPayload is the JSON the service worker receives in its push event
+type pushPayload struct {
+	Type    string            `json:"type"`
+	Title   string            `json:"title"`
+	Body    string            `json:"body,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Data    map[string]string `json:"data,omitempty"`
+	Created time.Time         `json:"created_at"`
+}
+
+// PushChannel delivers notifications to every browser the subscription's
+// owner registered for Web Push, and manages those registrations
+type PushChannel struct {
+	client *webpush.Client
+	subs   repositories.PushSubscriptionRepository
+	ttl    time.Duration
+}
+
+var _ Channel = (*PushChannel)(nil)
+
+// NewPushChannel creates a new PushChannel. ttl is how long push services
+// keep messages for offline browsers.
+func NewPushChannel(client *webpush.Client, subs repositories.PushSubscriptionRepository, ttl time.Duration) *PushChannel {
+	return &PushChannel{client: client, subs: subs, ttl: ttl}
+}
+
+// PublicKey returns the VAPID public key browsers subscribe with
+func (p *PushChannel) PublicKey() string {
+	return p.client.PublicKey()
+}
+
+// Subscriptions returns the browsers user registered
+func (p *PushChannel) Subscriptions(ctx context.Context, user *entities.User) ([]entities.PushSubscription, error) {
+	return p.subs.ListByUser(ctx, user.ID)
+}
+
+// Register stores a browser subscription of user
+func (p *PushChannel) Register(ctx context.Context, user *entities.User, sub *entities.PushSubscription) error {
+	sub.Endpoint = strings.TrimSpace(sub.Endpoint)
+	err := webpush.Subscription{Endpoint: sub.Endpoint, P256dh: sub.P256dh, Auth: sub.Auth}.Validate()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSubscription, err)
+	}
+	sub.ID = 0
+	sub.UserID = user.ID
+	sub.LastUsedAt = nil
+	return p.subs.Save(ctx, sub)
+}
+
+// Unregister deletes a browser subscription of user
+func (p *PushChannel) Unregister(ctx context.Context, user *entities.User, endpoint string) error {
+	return p.subs.DeleteByEndpoint(ctx, strings.TrimSpace(endpoint), user.ID)
+}
+
+// Test sends a test message to every browser of user
+func (p *PushChannel) Test(ctx context.Context, user *entities.User) ([]PushDelivery, error) {
+	return p.deliver(ctx, user.ID, &entities.Notification{
+		Type:      "test",
+		Title:     "Test notification",
+		Message:   "Push notifications are working.",
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// Validate accepts only an empty target: push subscriptions go to the
+// browsers registered with Register
+func (p *PushChannel) Validate(target string) error {
+	if target != "" {
+		return errors.New("push subscriptions have no target, register browsers with POST /api/v1/push/subscriptions")
+	}
+	return nil
+}
+
+// Send delivers n to the browsers of the subscription's owner. Browsers the
+// push service reports as gone are pruned and do not fail the delivery.
+func (p *PushChannel) Send(ctx context.Context, sub *entities.NotificationSubscription, n *entities.Notification) error {
+	deliveries, err := p.deliver(ctx, sub.UserID, n)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	active := 0
+	for _, d := range deliveries {
+		switch {
+		case d.Error != "":
+			errs = append(errs, fmt.Errorf("browser %d: %s", d.SubscriptionID, d.Error))
+		case !d.Pruned:
+			active++
+		}
+	}
+	if len(errs) == 0 && active == 0 {
+		return errors.New("no browser is registered for push")
+	}
+	return errors.Join(errs...)
+}
+
+// deliver sends n to every browser of a user
+func (p *PushChannel) deliver(ctx context.Context, userID int64, n *entities.Notification) ([]PushDelivery, error) {
+	subs, err := p.subs.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := encodePushPayload(n)
+	if err != nil {
+		return nil, err
+	}
+	opts := webpush.Options{TTL: p.ttl, Urgency: "normal"}
+	if n.Type == entities.EventApprovalRequested {
+		opts.Urgency = "high"
+	}
+
+	deliveries := make([]PushDelivery, 0, len(subs))
+	for _, sub := range subs {
+		d := PushDelivery{SubscriptionID: sub.ID}
+		err := p.client.Send(ctx, webpush.Subscription{Endpoint: sub.Endpoint, P256dh: sub.P256dh, Auth: sub.Auth}, payload, opts)
+		switch {
+		case errors.Is(err, webpush.ErrGone):
+			d.Pruned = true
+			if err := p.subs.DeleteByEndpoint(ctx, sub.Endpoint, 0); err != nil && !errors.Is(err, repositories.ErrNotFound) {
+				slog.WarnContext(ctx, "failed to prune push subscription", "push_subscription_id", sub.ID, "error", err)
+			} else {
+				slog.InfoContext(ctx, "pruned expired push subscription", "push_subscription_id", sub.ID, "user_id", userID)
+			}
+		case err != nil:
+			slog.WarnContext(ctx, "push delivery failed", "push_subscription_id", sub.ID, "error", err)
+			d.Error = err.Error()
+		default:
+			if err := p.subs.TouchLastUsed(ctx, sub.ID); err != nil {
+				slog.WarnContext(ctx, "failed to record push delivery", "push_subscription_id", sub.ID, "error", err)
+			}
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
+}
+
+// encodePushPayload renders n for the service worker, dropping data and
+// shortening the message when it does not fit in one push message
+func encodePushPayload(n *entities.Notification) ([]byte, error) {
+	created := n.CreatedAt
+	if created.IsZero() {
+		created = time.Now().UTC()
+	}
+	payload := pushPayload{
+		Type:    n.Type,
+		Title:   n.Title,
+		Body:    n.Message,
+		URL:     n.URL,
+		Data:    n.Data,
+		Created: created,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil || len(body) <= webpush.MaxPayloadSize {
+		return body, err
+	}
+
+	payload.Data = nil
+	if len(payload.Body) > pushMessageLimit {
+		payload.Body = strings.ToValidUTF8(payload.Body[:pushMessageLimit], "") + "…"
+	}
+	if len(payload.Title) > pushMessageLimit {
+		payload.Title = strings.ToValidUTF8(payload.Title[:pushMessageLimit], "") + "…"
+	}
+	return json.Marshal(payload)
+}
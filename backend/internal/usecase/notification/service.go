@@ -0,0 +1,274 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/usecase/workflow"
+)
+
+// ErrInvalidSubscription is returned for subscriptions that cannot be stored
+var ErrInvalidSubscription = errors.New("invalid subscription")
+
+// Channel delivers notifications to a subscription target, such as an email
+// address or a webhook URL
+type Channel interface {
+	// Validate checks a target before a subscription is stored
+	Validate(target string) error
+	Send(ctx context.Context, sub *entities.NotificationSubscription, n *entities.Notification) error
+}
+
+// Delivery is the outcome of sending a notification to one subscription
+type Delivery struct {
+	SubscriptionID int64  `json:"subscription_id"`
+	Channel        string `json:"channel"`
+	Error          string `json:"error,omitempty"`
+}
+
+// Service manages subscriptions and delivers events to them
+type Service struct {
+	subs     repositories.NotificationSubscriptionRepository
+	channels map[string]Channel
+	timeout  time.Duration
+}
+
+var _ workflow.ApprovalNotifier = (*Service)(nil)
+
+// NewService creates a new Service. Only the given channels can be
+// subscribed to; timeout bounds each delivery.
+func NewService(subs repositories.NotificationSubscriptionRepository, channels map[string]Channel, timeout time.Duration) *Service {
+	return &Service{subs: subs, channels: channels, timeout: timeout}
+}
+
+// Channels returns the names of the available channels
+func (s *Service) Channels() []string {
+	names := make([]string, 0, len(s.channels))
+	for name := range s.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Subscriptions returns the subscriptions of user
+func (s *Service) Subscriptions(ctx context.Context, user *entities.User) ([]entities.NotificationSubscription, error) {
+	return s.subs.ListByUser(ctx, user.ID)
+}
+
+// Subscribe stores a subscription of user. Email subscriptions without a
+// target go to the user's address.
+func (s *Service) Subscribe(ctx context.Context, user *entities.User, sub *entities.NotificationSubscription) error {
+	if len(sub.EventTypes) == 0 {
+		return fmt.Errorf("%w: event_types is required", ErrInvalidSubscription)
+	}
+	seen := make(map[string]bool, len(sub.EventTypes))
+	types := make([]string, 0, len(sub.EventTypes))
+	for _, t := range sub.EventTypes {
+		if !entities.ValidEventType(t) {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidSubscription, t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	sub.EventTypes = types
+
+	channel, ok := s.channels[sub.Channel]
+	if !ok {
+		return fmt.Errorf("%w: channel %q is not available, use one of %s",
+			ErrInvalidSubscription, sub.Channel, strings.Join(s.Channels(), ", "))
+	}
+	sub.Target = strings.TrimSpace(sub.Target)
+	if sub.Target == "" && sub.Channel == entities.ChannelEmail {
+		sub.Target = user.Email
+	}
+	if err := channel.Validate(sub.Target); err != nil {
+		return fmt.Errorf("%w: target: %v", ErrInvalidSubscription, err)
+	}
+
+	sub.ID = 0
+	sub.UserID = user.ID
+	sub.Repository = strings.TrimSpace(sub.Repository)
+	return s.subs.Create(ctx, sub)
+}
+
+// Unsubscribe deletes a subscription of user. Admins may delete any
+// subscription; others get ErrNotFound for subscriptions they do not own.
+func (s *Service) Unsubscribe(ctx context.Context, user *entities.User, id int64) error {
+	if _, err := s.owned(ctx, user, id); err != nil {
+		return err
+	}
+	return s.subs.Delete(ctx, id)
+}
+
+// Test sends a test notification through a subscription of user and returns
+// the delivery error, if any
+func (s *Service) Test(ctx context.Context, user *entities.User, id int64) (*Delivery, error) {
+	sub, err := s.owned(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+	n := &entities.Notification{
+		Type:      "test",
+		Title:     "Test notification",
+		Message:   fmt.Sprintf("Subscription %d for %s is working.", sub.ID, strings.Join(sub.EventTypes, ", ")),
+		CreatedAt: time.Now().UTC(),
+	}
+	return s.send(ctx, sub, n), nil
+}
+
+// Deliver sends n to every matching subscription concurrently and waits for
+// the outcomes
+func (s *Service) Deliver(ctx context.Context, n *entities.Notification) ([]Delivery, error) {
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now().UTC()
+	}
+	subs, err := s.subs.ListSubscribers(ctx, repositories.SubscriberFilter{
+		EventType:  n.Type,
+		Repository: n.Repository,
+		Logins:     n.Recipients,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]Delivery, len(subs))
+	var wg sync.WaitGroup
+	for i := range subs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			deliveries[i] = *s.send(ctx, &subs[i], n)
+		}()
+	}
+	wg.Wait()
+	return deliveries, nil
+}
+
+// Publish delivers n in the background so callers are not slowed down by
+// slow channels. Failures are logged.
+func (s *Service) Publish(ctx context.Context, n *entities.Notification) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		deliveries, err := s.Deliver(ctx, n)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to load notification subscribers", "type", n.Type, "error", err)
+			return
+		}
+		failed := 0
+		for _, d := range deliveries {
+			if d.Error != "" {
+				failed++
+			}
+		}
+		slog.DebugContext(ctx, "notification delivered",
+			"type", n.Type, "repository", n.Repository, "subscriptions", len(deliveries), "failed", failed)
+	}()
+}
+
+// TaskStatusChanged notifies the subscribers of the task's repository
+func (s *Service) TaskStatusChanged(ctx context.Context, task *entities.Task, previous string) {
+	if task.Status == previous {
+		return
+	}
+	s.Publish(ctx, &entities.Notification{
+		Type:       entities.EventTaskStatusChanged,
+		Repository: task.Repository,
+		Title:      fmt.Sprintf("Task %q is now %s", task.Title, task.Status),
+		Message:    fmt.Sprintf("Status changed from %s to %s.", previous, task.Status),
+		Data: map[string]string{
+			"task_id":         task.ID,
+			"status":          task.Status,
+			"previous_status": previous,
+			"branch":          task.Branch,
+			"assignee":        task.Assignee,
+		},
+	})
+}
+
+// GitHubEvent notifies the subscribers of a repository when a pull request
+// review is submitted. event is a webhook flattened by github.ParseWebhookEvent.
+func (s *Service) GitHubEvent(ctx context.Context, event map[string]string) {
+	if event["event"] != "pull_request_review" || event["action"] != "submitted" {
+		return
+	}
+	state := strings.ToLower(strings.ReplaceAll(event["review_state"], "_", " "))
+	s.Publish(ctx, &entities.Notification{
+		Type:       entities.EventPRReviewDone,
+		Repository: event["repository"],
+		Title:      fmt.Sprintf("%s#%s reviewed by %s: %s", event["repository"], event["number"], event["sender"], state),
+		Message:    event["title"],
+		URL:        event["url"],
+		Data: map[string]string{
+			"number":       event["number"],
+			"reviewer":     event["sender"],
+			"review_state": event["review_state"],
+		},
+	})
+}
+
+// ApprovalRequested notifies the approvers of a workflow step, or every
+// subscriber when the step has no approvers. It implements
+// workflow.ApprovalNotifier.
+func (s *Service) ApprovalRequested(ctx context.Context, req workflow.ApprovalRequest) error {
+	message := req.Message
+	if req.ExpiresAt != nil {
+		message = strings.TrimSpace(message + "\nDecide before " + req.ExpiresAt.Format(time.RFC3339) + ".")
+	}
+	data := map[string]string{
+		"run_id":      req.RunID,
+		"workflow_id": fmt.Sprint(req.WorkflowID),
+		"step_id":     req.StepID,
+	}
+	if req.ExpiresAt != nil {
+		data["expires_at"] = req.ExpiresAt.Format(time.RFC3339)
+	}
+	s.Publish(ctx, &entities.Notification{
+		Type:       entities.EventApprovalRequested,
+		Title:      fmt.Sprintf("Workflow %q is waiting for approval of step %q", req.WorkflowName, req.StepID),
+		Message:    message,
+		Data:       data,
+		Recipients: req.Approvers,
+	})
+	return nil
+}
+
+// owned returns a subscription if user owns it or is an admin
+func (s *Service) owned(ctx context.Context, user *entities.User, id int64) (*entities.NotificationSubscription, error) {
+	sub, err := s.subs.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.UserID != user.ID && !user.IsAdmin() {
+		return nil, repositories.ErrNotFound
+	}
+	return sub, nil
+}
+
+// send delivers n through the channel of sub, bounded by the delivery timeout
+func (s *Service) send(ctx context.Context, sub *entities.NotificationSubscription, n *entities.Notification) *Delivery {
+	d := &Delivery{SubscriptionID: sub.ID, Channel: sub.Channel}
+	channel, ok := s.channels[sub.Channel]
+	if !ok {
+		d.Error = fmt.Sprintf("channel %q is not available", sub.Channel)
+		return d
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	if err := channel.Send(ctx, sub, n); err != nil {
+		slog.WarnContext(ctx, "notification delivery failed",
+			"subscription_id", sub.ID, "channel", sub.Channel, "type", n.Type, "error", err)
+		d.Error = err.Error()
+	}
+	return d
+}
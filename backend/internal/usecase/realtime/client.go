@@ -0,0 +1,185 @@
+package realtime
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// Topics clients subscribe to. The workspace topic receives every change the
+// client may see; the others are followed by a repository full name or a
+// task ID, such as "repository:acme/web".
+const (
+	TopicWorkspace        = "workspace"
+	TopicRepositoryPrefix = "repository:"
+	TopicTaskPrefix       = "task:"
+)
+
+// maxTopics bounds the subscriptions of one client
+const maxTopics = 100
+
+// Reasons a client is dropped
+const (
+	DropSlow     = "slow_consumer"
+	DropShutdown = "shutdown"
+)
+
+// ErrInvalidTopic is returned when subscribing to an unknown topic
+var ErrInvalidTopic = errors.New("invalid topic")
+
+// Client is one connection receiving the changes its user may see on the
+// topics it subscribed to. Messages are queued up to a fixed buffer; a client
+// that falls behind is dropped rather than slowing down the others.
+type Client struct {
+	user *entities.User
+	send chan []byte
+
+	dropOnce sync.Once
+	dropped  chan struct{}
+	reason   string
+
+	mu     sync.Mutex
+	topics map[string]bool
+}
+
+func newClient(user *entities.User, buffer int) *Client {
+	return &Client{
+		user:    user,
+		send:    make(chan []byte, buffer),
+		dropped: make(chan struct{}),
+		topics:  make(map[string]bool),
+	}
+}
+
+// User returns the user the client authenticated as
+func (c *Client) User() *entities.User {
+	return c.user
+}
+
+// Messages returns the queue of messages to write to the connection
+func (c *Client) Messages() <-chan []byte {
+	return c.send
+}
+
+// Dropped is closed when the client must be disconnected; Reason tells why
+func (c *Client) Dropped() <-chan struct{} {
+	return c.dropped
+}
+
+// Reason returns why the client was dropped, once Dropped is closed
+func (c *Client) Reason() string {
+	<-c.dropped
+	return c.reason
+}
+
+// Enqueue queues msg without blocking. A full queue drops the client and
+// returns false.
+func (c *Client) Enqueue(msg []byte) bool {
+	select {
+	case <-c.dropped:
+		return false
+	default:
+	}
+	select {
+	case c.send <- msg:
+		return true
+	default:
+		c.drop(DropSlow)
+		return false
+	}
+}
+
+// Subscribe adds topics and returns all subscribed topics
+func (c *Client) Subscribe(topics ...string) ([]string, error) {
+	normalized := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		t, err := normalizeTopic(topic)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, t)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range normalized {
+		if !c.topics[t] && len(c.topics) >= maxTopics {
+			return nil, fmt.Errorf("%w: at most %d topics per connection", ErrInvalidTopic, maxTopics)
+		}
+		c.topics[t] = true
+	}
+	return c.topicList(), nil
+}
+
+// Unsubscribe removes topics and returns the remaining ones
+func (c *Client) Unsubscribe(topics ...string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, topic := range topics {
+		if t, err := normalizeTopic(topic); err == nil {
+			delete(c.topics, t)
+		}
+	}
+	return c.topicList()
+}
+
+// matches reports whether the client may see event and follows one of its
+// topics. Dropped clients match nothing.
+func (c *Client) matches(event *entities.ChangeEvent) bool {
+	select {
+	case <-c.dropped:
+		return false
+	default:
+	}
+	if event.Private && !c.user.IsAdmin() && (event.OwnerID == nil || *event.OwnerID != c.user.ID) {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.topics[TopicWorkspace] {
+		return true
+	}
+	if event.Repository != "" && c.topics[TopicRepositoryPrefix+strings.ToLower(event.Repository)] {
+		return true
+	}
+	return strings.HasPrefix(event.Type, "task.") && c.topics[TopicTaskPrefix+event.EntityID]
+}
+
+// drop marks the client for disconnection
+func (c *Client) drop(reason string) {
+	c.dropOnce.Do(func() {
+		c.reason = reason
+		close(c.dropped)
+	})
+}
+
+// topicList returns the subscribed topics sorted; c.mu must be held
+func (c *Client) topicList() []string {
+	topics := make([]string, 0, len(c.topics))
+	for t := range c.topics {
+		topics = append(topics, t)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// normalizeTopic validates a topic and lower cases repository names, which
+// GitHub compares without case
+func normalizeTopic(topic string) (string, error) {
+	topic = strings.TrimSpace(topic)
+	switch {
+	case topic == TopicWorkspace:
+		return topic, nil
+	case strings.HasPrefix(topic, TopicRepositoryPrefix) && len(topic) > len(TopicRepositoryPrefix):
+		return strings.ToLower(topic), nil
+	case strings.HasPrefix(topic, TopicTaskPrefix) && len(topic) > len(TopicTaskPrefix):
+		return topic, nil
+	}
+	return "", fmt.Errorf("%w %q, use %s, %s<owner/name> or %s<id>",
+		ErrInvalidTopic, topic, TopicWorkspace, TopicRepositoryPrefix, TopicTaskPrefix)
+}
@@ -0,0 +1,227 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+const (
+	// pollBatch is how many events one read of the log returns
+	pollBatch = 500
+	// gapTimeout is how long a missing event ID is waited for. IDs are taken
+	// before commit, so a concurrent insert may become visible after a higher
+	// ID; a gap older than this is treated as a rolled back insert.
+	gapTimeout = 2 * time.Second
+	// pruneInterval is how often events past the retention are deleted
+	pruneInterval = time.Minute
+)
+
+// Hub streams change events to the clients connected to this instance.
+// Events are appended to a log in the database and every instance polls
+// that log, so a change made on any replica reaches clients on all of them.
+type Hub struct {
+	events       repositories.ChangeEventRepository
+	pollInterval time.Duration
+	retention    time.Duration
+	wake         chan struct{}
+
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+}
+
+// NewHub creates a new Hub. It delivers nothing until Run is called.
+func NewHub(events repositories.ChangeEventRepository, pollInterval, retention time.Duration) *Hub {
+	return &Hub{
+		events:       events,
+		pollInterval: pollInterval,
+		retention:    retention,
+		wake:         make(chan struct{}, 1),
+		clients:      make(map[*Client]struct{}),
+	}
+}
+
+// Publish appends event to the log. Failures are logged: the change itself
+// is already stored and clients catch up by reloading.
+func (h *Hub) Publish(ctx context.Context, event *entities.ChangeEvent) {
+	// The change is stored even if the caller went away, so is its event
+	ctx = context.WithoutCancel(ctx)
+	if err := h.events.Append(ctx, event); err != nil {
+		slog.ErrorContext(ctx, "failed to publish change event", "type", event.Type, "entity_id", event.EntityID, "error", err)
+		return
+	}
+	// Changes made here are delivered without waiting for the next poll
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Connect registers a client of user with room for buffer queued messages
+func (h *Hub) Connect(user *entities.User, buffer int) *Client {
+	c := newClient(user, buffer)
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+	return c
+}
+
+// Disconnect unregisters a client
+func (h *Hub) Disconnect(c *Client) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+}
+
+// Clients returns the number of connected clients
+func (h *Hub) Clients() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// Run follows the event log until ctx is done, then drops every client. Only
+// events appended after Run starts are delivered.
+func (h *Hub) Run(ctx context.Context) {
+	log := newLogCursor()
+	started := false
+
+	poll := time.NewTicker(h.pollInterval)
+	defer poll.Stop()
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+
+	for {
+		if !started {
+			latest, err := h.events.LatestID(ctx)
+			if err == nil {
+				log.position, started = latest, true
+			} else if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "failed to read change event log", "error", err)
+			}
+		} else {
+			h.poll(ctx, log)
+		}
+
+		select {
+		case <-ctx.Done():
+			h.mu.Lock()
+			for c := range h.clients {
+				c.drop(DropShutdown)
+			}
+			h.mu.Unlock()
+			return
+		case <-prune.C:
+			h.prune(ctx)
+		case <-poll.C:
+		case <-h.wake:
+		}
+	}
+}
+
+// poll delivers the events appended since the last poll
+func (h *Hub) poll(ctx context.Context, log *logCursor) {
+	for {
+		events, err := h.events.ListAfter(ctx, log.position, pollBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "failed to read change event log", "error", err)
+			}
+			return
+		}
+		before := log.position
+		for i := range events {
+			if log.deliver(events[i].ID) {
+				h.dispatch(ctx, &events[i])
+			}
+		}
+		log.advance(time.Now())
+		if len(events) < pollBatch || log.position == before {
+			return
+		}
+	}
+}
+
+// dispatch queues event for every client that follows it
+func (h *Hub) dispatch(ctx context.Context, event *entities.ChangeEvent) {
+	msg, err := json.Marshal(event)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode change event", "id", event.ID, "error", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		if c.matches(event) && !c.Enqueue(msg) {
+			slog.WarnContext(ctx, "dropping slow realtime client", "user_id", c.user.ID, "event_id", event.ID)
+		}
+	}
+}
+
+// prune deletes events older than the retention
+func (h *Hub) prune(ctx context.Context) {
+	deleted, err := h.events.DeleteBefore(ctx, time.Now().Add(-h.retention))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to prune change events", "error", err)
+		return
+	}
+	if deleted > 0 {
+		slog.DebugContext(ctx, "pruned change events", "count", deleted)
+	}
+}
+
+// logCursor tracks which events were delivered so each is delivered once,
+// even when concurrent inserts become visible out of ID order
+type logCursor struct {
+	// position is the ID up to which every event was delivered or given up on
+	position int64
+	// seen holds delivered IDs above position
+	seen map[int64]bool
+	// gaps holds missing IDs above position and when they were noticed
+	gaps map[int64]time.Time
+}
+
+func newLogCursor() *logCursor {
+	return &logCursor{seen: make(map[int64]bool), gaps: make(map[int64]time.Time)}
+}
+
+// deliver records id and reports whether it was not delivered before
+func (l *logCursor) deliver(id int64) bool {
+	if id <= l.position || l.seen[id] {
+		return false
+	}
+	l.seen[id] = true
+	delete(l.gaps, id)
+	return true
+}
+
+// advance moves position over delivered IDs and expired gaps
+func (l *logCursor) advance(now time.Time) {
+	highest := l.position
+	for id := range l.seen {
+		highest = max(highest, id)
+	}
+	for id := l.position + 1; id < highest; id++ {
+		if _, ok := l.gaps[id]; !ok && !l.seen[id] {
+			l.gaps[id] = now
+		}
+	}
+
+	for {
+		next := l.position + 1
+		if l.seen[next] {
+			delete(l.seen, next)
+		} else if noticed, ok := l.gaps[next]; ok && now.Sub(noticed) >= gapTimeout {
+			delete(l.gaps, next)
+		} else {
+			return
+		}
+		l.position = next
+	}
+}
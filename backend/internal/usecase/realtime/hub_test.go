@@ -0,0 +1,90 @@
+package realtime
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// cursorStep delivers IDs, then advances the cursor at an offset from the
+// start of the test
+type cursorStep struct {
+	deliver   []int64
+	delivered []bool
+	at        time.Duration
+	position  int64
+	gaps      []int64
+}
+
+func TestLogCursorAdvance(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		steps []cursorStep
+	}{
+		{name: "in order", steps: []cursorStep{
+			{deliver: []int64{1, 2, 3}, delivered: []bool{true, true, true}, position: 3},
+			{deliver: []int64{4}, delivered: []bool{true}, position: 4},
+		}},
+		{name: "duplicates delivered once", steps: []cursorStep{
+			{deliver: []int64{1, 2, 2}, delivered: []bool{true, true, false}, position: 2},
+			{deliver: []int64{2, 3}, delivered: []bool{false, true}, position: 3},
+		}},
+		{name: "gap filled late", steps: []cursorStep{
+			{deliver: []int64{1, 3, 4}, delivered: []bool{true, true, true}, position: 1, gaps: []int64{2}},
+			{deliver: []int64{3, 4}, delivered: []bool{false, false}, at: time.Second, position: 1, gaps: []int64{2}},
+			{deliver: []int64{2}, delivered: []bool{true}, at: time.Second, position: 4},
+		}},
+		{name: "gap given up", steps: []cursorStep{
+			{deliver: []int64{1, 3}, delivered: []bool{true, true}, position: 1, gaps: []int64{2}},
+			{at: gapTimeout - time.Millisecond, position: 1, gaps: []int64{2}},
+			{at: gapTimeout, position: 3},
+			// A rolled back insert never shows up, and one that does is too late
+			{deliver: []int64{2, 4}, delivered: []bool{false, true}, at: gapTimeout, position: 4},
+		}},
+		{name: "gaps expire from when they are noticed", steps: []cursorStep{
+			{deliver: []int64{1, 3}, delivered: []bool{true, true}, position: 1, gaps: []int64{2}},
+			{deliver: []int64{6}, delivered: []bool{true}, at: time.Second, position: 1, gaps: []int64{2, 4, 5}},
+			{at: gapTimeout, position: 3, gaps: []int64{4, 5}},
+			{deliver: []int64{5}, delivered: []bool{true}, at: gapTimeout + time.Second, position: 6},
+		}},
+		{name: "out of order batch", steps: []cursorStep{
+			{deliver: []int64{5, 2, 4, 1}, delivered: []bool{true, true, true, true}, position: 2, gaps: []int64{3}},
+			{deliver: []int64{3}, delivered: []bool{true}, position: 5},
+		}},
+		{name: "nothing new", steps: []cursorStep{
+			{position: 0},
+			{deliver: []int64{1}, delivered: []bool{true}, at: time.Hour, position: 1},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			cursor := newLogCursor()
+			for i, step := range tc.steps {
+				for j, id := range step.deliver {
+					if got := cursor.deliver(id); got != step.delivered[j] {
+						t.Errorf("step %d: deliver(%d) = %v, want %v", i, id, got, step.delivered[j])
+					}
+				}
+				cursor.advance(start.Add(step.at))
+
+				if cursor.position != step.position {
+					t.Errorf("step %d: position = %d, want %d", i, cursor.position, step.position)
+				}
+				gaps := make([]int64, 0, len(cursor.gaps))
+				for id := range cursor.gaps {
+					gaps = append(gaps, id)
+				}
+				sort.Slice(gaps, func(a, b int) bool { return gaps[a] < gaps[b] })
+				if want := append([]int64{}, step.gaps...); !reflect.DeepEqual(gaps, want) {
+					t.Errorf("step %d: gaps = %v, want %v", i, gaps, want)
+				}
+				for id := range cursor.seen {
+					if id <= cursor.position {
+						t.Errorf("step %d: delivered ID %d kept at or below position %d", i, id, cursor.position)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// taskPublisher publishes a change event after every successful task write
+type taskPublisher struct {
+	repositories.TaskRepository
+	hub *Hub
+}
+
+// PublishTasks wraps tasks so created, updated and deleted tasks are streamed
+// to clients
+func PublishTasks(tasks repositories.TaskRepository, hub *Hub) repositories.TaskRepository {
+	return &taskPublisher{TaskRepository: tasks, hub: hub}
+}
+
+func (p *taskPublisher) Create(ctx context.Context, task *entities.Task) error {
+	if err := p.TaskRepository.Create(ctx, task); err != nil {
+		return err
+	}
+	p.publish(ctx, entities.EventTaskCreated, task)
+	return nil
+}
+
+func (p *taskPublisher) Update(ctx context.Context, task *entities.Task) error {
+	if err := p.TaskRepository.Update(ctx, task); err != nil {
+		return err
+	}
+	p.publish(ctx, entities.EventTaskUpdated, task)
+	return nil
+}
+
+// Delete loads the task first so the event can name its repository
+func (p *taskPublisher) Delete(ctx context.Context, id string, version int64) error {
+	task, err := p.TaskRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := p.TaskRepository.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	p.publish(ctx, entities.EventTaskDeleted, task)
+	return nil
+}
+
+// ApplyBatch publishes an event per write once the whole batch is stored
+func (p *taskPublisher) ApplyBatch(ctx context.Context, writes []repositories.TaskWrite) error {
+	// Deleted tasks are loaded first so their events can name the repository
+	deleted := make(map[int]*entities.Task)
+	for i, w := range writes {
+		if w.Op != repositories.WriteDelete {
+			continue
+		}
+		task, err := p.TaskRepository.GetByID(ctx, w.Task.ID)
+		if err != nil {
+			return &repositories.BatchError{Index: i, Err: err}
+		}
+		deleted[i] = task
+	}
+	if err := p.TaskRepository.ApplyBatch(ctx, writes); err != nil {
+		return err
+	}
+
+	for i, w := range writes {
+		switch w.Op {
+		case repositories.WriteCreate:
+			p.publish(ctx, entities.EventTaskCreated, w.Task)
+		case repositories.WriteUpdate:
+			p.publish(ctx, entities.EventTaskUpdated, w.Task)
+		case repositories.WriteDelete:
+			p.publish(ctx, entities.EventTaskDeleted, deleted[i])
+		}
+	}
+	return nil
+}
+
+func (p *taskPublisher) publish(ctx context.Context, eventType string, task *entities.Task) {
+	data, err := json.Marshal(task)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode change event", "type", eventType, "error", err)
+		return
+	}
+	p.hub.Publish(ctx, &entities.ChangeEvent{
+		Type:       eventType,
+		EntityID:   task.ID,
+		Repository: task.Repository,
+		Data:       data,
+	})
+}
+
+// repositoryPublisher publishes a change event after every successful
+// repository write
+type repositoryPublisher struct {
+	repositories.RepositoryRepository
+	hub *Hub
+}
+
+// PublishRepositories wraps repos so created, updated and deleted
+// repositories are streamed to clients
+func PublishRepositories(repos repositories.RepositoryRepository, hub *Hub) repositories.RepositoryRepository {
+	return &repositoryPublisher{RepositoryRepository: repos, hub: hub}
+}
+
+func (p *repositoryPublisher) Create(ctx context.Context, repo *entities.Repository) error {
+	if err := p.RepositoryRepository.Create(ctx, repo); err != nil {
+		return err
+	}
+	p.publish(ctx, entities.EventRepositoryCreated, repo)
+	return nil
+}
+
+func (p *repositoryPublisher) Update(ctx context.Context, repo *entities.Repository) error {
+	if err := p.RepositoryRepository.Update(ctx, repo); err != nil {
+		return err
+	}
+	p.publish(ctx, entities.EventRepositoryUpdated, repo)
+	return nil
+}
+
+// Delete loads the repository first so the event keeps its visibility
+func (p *repositoryPublisher) Delete(ctx context.Context, id int64, version int64) error {
+	repo, err := p.RepositoryRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := p.RepositoryRepository.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	p.publish(ctx, entities.EventRepositoryDeleted, repo)
+	return nil
+}
+
+func (p *repositoryPublisher) publish(ctx context.Context, eventType string, repo *entities.Repository) {
+	data, err := json.Marshal(repo)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode change event", "type", eventType, "error", err)
+		return
+	}
+	p.hub.Publish(ctx, &entities.ChangeEvent{
+		Type:       eventType,
+		EntityID:   strconv.FormatInt(repo.ID, 10),
+		Repository: repo.FullName,
+		Data:       data,
+		Private:    repo.Private,
+		OwnerID:    repo.OwnerID,
+	})
+}
@@ -0,0 +1,118 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	// snippetLength is the maximum number of runes in a highlight snippet
+	snippetLength = 160
+	// snippetLead is how much context is kept before the first match
+	snippetLead = 40
+	ellipsis    = "…"
+)
+
+// Terms splits a query into lower-case words, dropping punctuation and
+// duplicates. Only letters and digits reach the database, so the terms are
+// safe to embed in any full-text query syntax.
+func Terms(query string, max int) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+		if len(terms) == max {
+			break
+		}
+	}
+	return terms
+}
+
+// Highlight returns an HTML-escaped snippet of text around the first match
+// with every whole-word match of terms wrapped in <mark>. It returns an empty
+// string when no term occurs in text.
+func Highlight(text string, terms []string) string {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		// Lower-casing changed the length; fall back to matching the original
+		lower = runes
+	}
+
+	var spans [][2]int
+	for i := 0; i < len(lower); {
+		end := matchAt(lower, i, terms)
+		if end < 0 {
+			i++
+			continue
+		}
+		spans = append(spans, [2]int{i, end})
+		i = end
+	}
+	if len(spans) == 0 {
+		return ""
+	}
+
+	start := max(0, spans[0][0]-snippetLead)
+	for start > 0 && !unicode.IsSpace(runes[start-1]) && spans[0][0]-start < snippetLead+10 {
+		start--
+	}
+	end := min(len(runes), start+snippetLength)
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString(ellipsis)
+	}
+	pos := start
+	for _, span := range spans {
+		if span[0] >= end {
+			break
+		}
+		spanEnd := min(span[1], end)
+		b.WriteString(html.EscapeString(string(runes[pos:span[0]])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[span[0]:spanEnd])))
+		b.WriteString("</mark>")
+		pos = spanEnd
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		b.WriteString(ellipsis)
+	}
+	return b.String()
+}
+
+// matchAt returns the end of the longest term that occurs as a whole word at
+// position i, or -1 if none does
+func matchAt(text []rune, i int, terms []string) int {
+	if i > 0 && isWordRune(text[i-1]) {
+		return -1
+	}
+	best := -1
+	for _, term := range terms {
+		t := []rune(term)
+		end := i + len(t)
+		if end > len(text) || string(text[i:end]) != term {
+			continue
+		}
+		if end < len(text) && isWordRune(text[end]) {
+			continue
+		}
+		if end > best {
+			best = end
+		}
+	}
+	return best
+}
+
+// isWordRune reports whether r is part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
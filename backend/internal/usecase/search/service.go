@@ -0,0 +1,105 @@
+package search
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/usecase/auth"
+)
+
+// Query limits
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+	MaxTerms     = 10
+)
+
+// Hit is a search result ready to be rendered by the API
+type Hit struct {
+	Type       string               `json:"type"`
+	ID         string               `json:"id"`
+	Title      string               `json:"title"`
+	Score      float64              `json:"score"`
+	Highlights map[string]string    `json:"highlights,omitempty"`
+	Task       *entities.Task       `json:"task,omitempty"`
+	Repository *entities.Repository `json:"repository,omitempty"`
+}
+
+// Result is a page of hits with the number of matches per type
+type Result struct {
+	Query  string         `json:"query"`
+	Hits   []Hit          `json:"results"`
+	Facets map[string]int `json:"facets"`
+	Total  int            `json:"total"`
+}
+
+// Service searches tasks and repositories visible to the caller
+type Service struct {
+	search repositories.SearchRepository
+}
+
+// NewService creates a new Service
+func NewService(search repositories.SearchRepository) *Service {
+	return &Service{search: search}
+}
+
+// Search runs query for the caller in ctx. Types restricts the record types;
+// limit is clamped to MaxLimit.
+func (s *Service) Search(ctx context.Context, query string, types []string, limit, offset int) (*Result, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	terms := Terms(query, MaxTerms)
+	found, err := s.search.Search(ctx, repositories.SearchQuery{
+		Terms:  terms,
+		Types:  types,
+		Limit:  limit,
+		Offset: offset,
+		Scope:  auth.Scope(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Query: query, Hits: []Hit{}, Facets: found.Facets}
+	for _, n := range found.Facets {
+		result.Total += n
+	}
+	for _, h := range found.Hits {
+		result.Hits = append(result.Hits, newHit(h, terms))
+	}
+	return result, nil
+}
+
+// newHit converts a stored hit and highlights the searched fields
+func newHit(h repositories.SearchHit, terms []string) Hit {
+	hit := Hit{Type: h.Type, Score: h.Score, Highlights: make(map[string]string)}
+	fields := map[string]string{}
+
+	switch {
+	case h.Task != nil:
+		hit.ID = h.Task.ID
+		hit.Title = h.Task.Title
+		hit.Task = h.Task
+		fields["title"] = h.Task.Title
+		fields["description"] = h.Task.Description
+	case h.Repository != nil:
+		hit.ID = strconv.FormatInt(h.Repository.ID, 10)
+		hit.Title = h.Repository.FullName
+		hit.Repository = h.Repository
+		fields["description"] = h.Repository.Description
+		fields["topics"] = strings.Join(h.Repository.Topics, ", ")
+	}
+
+	for name, text := range fields {
+		if snippet := Highlight(text, terms); snippet != "" {
+			hit.Highlights[name] = snippet
+		}
+	}
+	return hit
+}
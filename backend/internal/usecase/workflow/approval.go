@@ -0,0 +1,278 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/usecase/execution"
+)
+
+// Approval decisions, also the values of the timeout_outcome parameter
+const (
+	DecisionApprove = "approve"
+	DecisionReject  = "reject"
+)
+
+// approvalWatchInterval is how often expired approvals are decided
+const approvalWatchInterval = 15 * time.Second
+
+var (
+	// ErrNotApprover is returned when the user may not decide an approval step
+	ErrNotApprover = errors.New("user is not an approver of this step")
+	// ErrStepNotWaiting is returned when deciding a step that is not waiting for approval
+	ErrStepNotWaiting = errors.New("step is not waiting for approval")
+)
+
+// ApprovalRequest describes an approval step that started waiting
+type ApprovalRequest struct {
+	RunID        string
+	WorkflowID   int64
+	WorkflowName string
+	StepID       string
+	Message      string
+	// Approvers are the logins allowed to decide, empty for any member but
+	// the user who started the run
+	Approvers []string
+	// ExpiresAt is when the step times out, nil when it waits forever
+	ExpiresAt *time.Time
+}
+
+// ApprovalNotifier tells the approvers that a run is waiting for them
+type ApprovalNotifier interface {
+	ApprovalRequested(ctx context.Context, req ApprovalRequest) error
+}
+
+// LogNotifier is an ApprovalNotifier that only logs the request
+type LogNotifier struct{}
+
+// ApprovalRequested implements ApprovalNotifier
+func (LogNotifier) ApprovalRequested(ctx context.Context, req ApprovalRequest) error {
+	slog.InfoContext(ctx, "workflow run waiting for approval",
+		"run_id", req.RunID, "workflow_id", req.WorkflowID, "step_id", req.StepID, "approvers", req.Approvers)
+	return nil
+}
+
+// Approve approves a waiting approval step on behalf of user and resumes the run
+func (e *Engine) Approve(ctx context.Context, runID, stepID string, user *entities.User, comment string) (*entities.WorkflowRun, error) {
+	return e.decideByUser(ctx, runID, stepID, user, DecisionApprove, comment)
+}
+
+// Reject rejects a waiting approval step on behalf of user, which fails the
+// step and skips the steps needing it
+func (e *Engine) Reject(ctx context.Context, runID, stepID string, user *entities.User, comment string) (*entities.WorkflowRun, error) {
+	return e.decideByUser(ctx, runID, stepID, user, DecisionReject, comment)
+}
+
+// ExpireApprovals applies the timeout_outcome of the approval steps whose
+// deadline is not after now and returns how many were decided
+func (e *Engine) ExpireApprovals(ctx context.Context, now time.Time) (int, error) {
+	refs, err := e.runs.ListExpiredSteps(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	decided := 0
+	for _, ref := range refs {
+		run, err := e.runs.GetByID(ctx, ref.RunID)
+		if err != nil {
+			return decided, err
+		}
+		state := run.Step(ref.StepID)
+		if state == nil {
+			continue
+		}
+		outcome := state.Outputs["timeout_outcome"]
+		if outcome != DecisionApprove {
+			outcome = DecisionReject
+		}
+
+		err = e.decide(ctx, run, state, outcome, &entities.WorkflowRunEvent{
+			Type:    entities.RunEventApprovalTimedOut,
+			Comment: "no decision before the deadline, outcome: " + outcome,
+		})
+		switch {
+		case errors.Is(err, ErrStepNotWaiting):
+			// Decided concurrently by a user or another replica
+		case err != nil:
+			return decided, err
+		default:
+			decided++
+		}
+	}
+	return decided, nil
+}
+
+// Watch decides expired approval steps periodically until ctx is done
+func (e *Engine) Watch(ctx context.Context) {
+	ticker := time.NewTicker(approvalWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if n, err := e.ExpireApprovals(ctx, now); err != nil {
+				slog.ErrorContext(ctx, "failed to expire workflow approvals", "error", err)
+			} else if n > 0 {
+				slog.InfoContext(ctx, "expired workflow approvals", "count", n)
+			}
+		}
+	}
+}
+
+// requestApproval records that a step started waiting and notifies its
+// approvers. Notification failures are logged: the step can still be decided.
+func (e *Engine) requestApproval(ctx context.Context, run *entities.WorkflowRun, def *entities.WorkflowDefinition,
+	state *entities.StepRun) error {
+	if err := e.runs.AddEvent(ctx, &entities.WorkflowRunEvent{
+		RunID:  run.ID,
+		StepID: state.StepID,
+		Type:   entities.RunEventApprovalRequested,
+	}); err != nil {
+		return err
+	}
+
+	err := e.notifier.ApprovalRequested(ctx, ApprovalRequest{
+		RunID:        run.ID,
+		WorkflowID:   run.WorkflowID,
+		WorkflowName: def.Name,
+		StepID:       state.StepID,
+		Message:      state.Outputs["message"],
+		Approvers:    splitApprovers(state.Outputs["approvers"]),
+		ExpiresAt:    state.ExpiresAt,
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to notify approvers", "run_id", run.ID, "step_id", state.StepID, "error", err)
+	}
+	return nil
+}
+
+// decideByUser checks that user may decide the step before deciding it
+func (e *Engine) decideByUser(ctx context.Context, runID, stepID string, user *entities.User, decision, comment string) (*entities.WorkflowRun, error) {
+	run, err := e.runs.GetByID(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	state := run.Step(stepID)
+	if state == nil {
+		return nil, repositories.ErrNotFound
+	}
+	if state.Type != entities.StepTypeApproval || state.Status != entities.StepStatusWaiting {
+		return nil, ErrStepNotWaiting
+	}
+	if !mayApprove(user, run, splitApprovers(state.Outputs["approvers"])) {
+		return nil, ErrNotApprover
+	}
+
+	eventType := entities.RunEventApproved
+	if decision == DecisionReject {
+		eventType = entities.RunEventRejected
+	}
+	if err := e.decide(ctx, run, state, decision, &entities.WorkflowRunEvent{
+		Type:    eventType,
+		ActorID: &user.ID,
+		Actor:   user.Login,
+		Comment: comment,
+	}); err != nil {
+		return nil, err
+	}
+	return e.Run(ctx, runID)
+}
+
+// decide moves a waiting approval step to succeeded or failed, appends event
+// to the audit trail and resumes the run. Of concurrent decisions on the same
+// step only the first applies; the others get ErrStepNotWaiting.
+func (e *Engine) decide(ctx context.Context, run *entities.WorkflowRun, state *entities.StepRun, decision string,
+	event *entities.WorkflowRunEvent) error {
+	decidedBy := event.Actor
+	if decidedBy == "" {
+		decidedBy = "system"
+	}
+
+	outcome := entities.RunEventApproved
+	if decision == DecisionReject {
+		outcome = entities.RunEventRejected
+	}
+
+	finished := time.Now().UTC()
+	next := *state
+	next.Outputs = map[string]string{
+		"decision":   outcome,
+		"decided_by": decidedBy,
+		"comment":    event.Comment,
+	}
+	next.FinishedAt = &finished
+	next.ExpiresAt = nil
+	if decision == DecisionApprove {
+		next.Status = entities.StepStatusSucceeded
+	} else {
+		next.Status = entities.StepStatusFailed
+		next.Error = "rejected by " + decidedBy
+		if event.Comment != "" {
+			next.Error += ": " + event.Comment
+		}
+	}
+
+	err := e.runs.TransitionStep(ctx, run.ID, &next, entities.StepStatusWaiting)
+	if errors.Is(err, repositories.ErrConflict) {
+		return ErrStepNotWaiting
+	}
+	if err != nil {
+		return err
+	}
+
+	event.RunID = run.ID
+	event.StepID = state.StepID
+	if err := e.runs.AddEvent(ctx, event); err != nil {
+		return err
+	}
+
+	// Queued runs are resumed by Recover should the process stop before
+	// the run is picked up again
+	run.Status = entities.RunStatusQueued
+	if err := e.runs.UpdateStatus(ctx, run); err != nil {
+		return err
+	}
+	if err := e.launch(run.ID); err != nil && !errors.Is(err, execution.ErrDraining) {
+		return fmt.Errorf("error resuming workflow run: %w", err)
+	}
+	return nil
+}
+
+// mayApprove reports whether user may decide a step of run restricted to
+// approvers. Viewers and the user who started the run never may; with no
+// approvers listed any other user may.
+func mayApprove(user *entities.User, run *entities.WorkflowRun, approvers []string) bool {
+	if user == nil || user.Role == entities.RoleViewer {
+		return false
+	}
+	if run.CreatedBy != nil && *run.CreatedBy == user.ID {
+		return false
+	}
+	if len(approvers) == 0 {
+		return true
+	}
+	for _, login := range approvers {
+		if strings.EqualFold(login, user.Login) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitApprovers parses the comma separated approvers parameter
+func splitApprovers(value string) []string {
+	var logins []string
+	for _, login := range strings.Split(value, ",") {
+		if login = strings.TrimSpace(login); login != "" {
+			logins = append(logins, login)
+		}
+	}
+	return logins
+}
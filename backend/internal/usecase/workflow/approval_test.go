@@ -0,0 +1,38 @@
+package workflow
+
+import (
+	"testing"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+func TestMayApprove(t *testing.T) {
+	alice := &entities.User{ID: 1, Login: "alice", Role: entities.RoleMember}
+	bob := &entities.User{ID: 2, Login: "Bob", Role: entities.RoleMember}
+	vic := &entities.User{ID: 3, Login: "vic", Role: entities.RoleViewer}
+	startedBy := func(id int64) *entities.WorkflowRun { return &entities.WorkflowRun{CreatedBy: &id} }
+	triggered := &entities.WorkflowRun{}
+
+	for _, tc := range []struct {
+		name      string
+		user      *entities.User
+		run       *entities.WorkflowRun
+		approvers []string
+		want      bool
+	}{
+		{name: "anyone when no approvers", user: bob, run: startedBy(alice.ID), want: true},
+		{name: "listed approver, any case", user: bob, run: startedBy(alice.ID), approvers: []string{"alice", "bob"}, want: true},
+		{name: "not listed", user: bob, run: triggered, approvers: []string{"alice"}},
+		{name: "starter without approvers", user: alice, run: startedBy(alice.ID)},
+		{name: "starter listed as approver", user: alice, run: startedBy(alice.ID), approvers: []string{"alice"}},
+		{name: "triggered run", user: alice, run: triggered, approvers: []string{"alice"}, want: true},
+		{name: "viewer", user: vic, run: triggered, approvers: []string{"vic"}},
+		{name: "anonymous", run: triggered},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := mayApprove(tc.user, tc.run, tc.approvers); got != tc.want {
+				t.Errorf("mayApprove = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
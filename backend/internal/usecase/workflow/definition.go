@@ -0,0 +1,426 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// Definition document formats
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// maxRetries bounds the retries of a single step
+const maxRetries = 10
+
+// identifierPattern restricts step and input names so they can be referenced
+// from ${{ }} expressions
+var identifierPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// stepParams lists the required and optional With keys of each step type
+var stepParams = map[string]struct{ required, optional []string }{
+	entities.StepTypeGitCheckout: {required: []string{"repository"}, optional: []string{"ref", "path"}},
+	entities.StepTypeAIPrompt:    {required: []string{"prompt"}, optional: []string{"system", "max_tokens"}},
+	entities.StepTypeShell:       {required: []string{"run"}, optional: []string{"working_directory"}},
+	entities.StepTypeOpenPR:      {required: []string{"repository", "head", "title"}, optional: []string{"base", "body", "draft"}},
+	entities.StepTypeApproval:    {optional: []string{"message", "approvers", "timeout_outcome"}},
+}
+
+// ValidationError lists every problem found in a workflow definition
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return "invalid workflow definition: " + strings.Join(e.Problems, "; ")
+}
+
+// Parse decodes a YAML or JSON definition and validates it. Unknown fields
+// are rejected so typos do not silently change a workflow.
+func Parse(source []byte, format string) (*entities.WorkflowDefinition, error) {
+	var def entities.WorkflowDefinition
+	switch format {
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(source))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&def); err != nil {
+			return nil, &ValidationError{Problems: []string{"invalid JSON: " + err.Error()}}
+		}
+	case FormatYAML:
+		dec := yaml.NewDecoder(bytes.NewReader(source))
+		dec.KnownFields(true)
+		if err := dec.Decode(&def); err != nil {
+			return nil, &ValidationError{Problems: []string{"invalid YAML: " + err.Error()}}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported definition format %q", format)
+	}
+
+	if err := Validate(&def); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// Validate checks a definition and reports every problem at once: missing
+// fields, unknown step types and parameters, dangling needs, references to
+// outputs of steps that do not run before, dependency cycles, and shell
+// commands using inputs that triggers fill from events.
+func Validate(def *entities.WorkflowDefinition) error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if strings.TrimSpace(def.Name) == "" {
+		add("name: is required")
+	} else if len(def.Name) > 255 {
+		add("name: must be at most 255 characters")
+	}
+
+	inputs := make(map[string]bool)
+	for i, input := range def.Inputs {
+		switch {
+		case !identifierPattern.MatchString(input.Name):
+			add("inputs[%d].name: %q must start with a letter and contain only letters, digits, - and _", i, input.Name)
+		case inputs[input.Name]:
+			add("inputs[%d].name: %q is declared more than once", i, input.Name)
+		}
+		inputs[input.Name] = true
+	}
+
+	for i, trigger := range def.Triggers {
+		for _, problem := range validateTrigger(def, trigger) {
+			add("triggers[%d]%s", i, problem)
+		}
+	}
+
+	if len(def.Steps) == 0 {
+		add("steps: at least one step is required")
+	}
+	steps := make(map[string]*entities.WorkflowStep)
+	for i := range def.Steps {
+		step := &def.Steps[i]
+		switch {
+		case !identifierPattern.MatchString(step.ID):
+			add("steps[%d].id: %q must start with a letter and contain only letters, digits, - and _", i, step.ID)
+		case steps[step.ID] != nil:
+			add("steps[%d].id: %q is used by more than one step", i, step.ID)
+		}
+		steps[step.ID] = step
+	}
+
+	for _, step := range def.Steps {
+		where := "steps." + step.ID
+		params, ok := stepParams[step.Type]
+		if !ok {
+			add("%s.type: %q must be one of %s", where, step.Type, strings.Join(StepTypes(), ", "))
+		} else {
+			for _, key := range params.required {
+				if strings.TrimSpace(step.With[key]) == "" {
+					add("%s.with.%s: is required for %s steps", where, key, step.Type)
+				}
+			}
+			for key := range step.With {
+				if !contains(params.required, key) && !contains(params.optional, key) {
+					add("%s.with.%s: is not a parameter of %s steps", where, key, step.Type)
+				}
+			}
+		}
+
+		for _, need := range step.Needs {
+			switch {
+			case need == step.ID:
+				add("%s.needs: a step cannot need itself", where)
+			case steps[need] == nil:
+				add("%s.needs: unknown step %q", where, need)
+			}
+		}
+		if step.Retries < 0 || step.Retries > maxRetries {
+			add("%s.retries: must be between 0 and %d", where, maxRetries)
+		}
+		if step.RetryDelay < 0 || step.Timeout < 0 {
+			add("%s: retry_delay and timeout must not be negative", where)
+		}
+		if step.Type == entities.StepTypeApproval {
+			// Who decides is fixed by the definition, not by whoever starts the run
+			for _, key := range []string{"approvers", "timeout_outcome"} {
+				if expressionPattern.MatchString(step.With[key]) {
+					add("%s.with.%s: cannot use expressions", where, key)
+				}
+			}
+			if outcome, ok := step.With["timeout_outcome"]; ok && !expressionPattern.MatchString(outcome) &&
+				outcome != DecisionApprove && outcome != DecisionReject {
+				add("%s.with.timeout_outcome: must be %s or %s", where, DecisionApprove, DecisionReject)
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		if _, err := Order(def); err != nil {
+			add("%v", err)
+		}
+	}
+
+	// Expressions are checked last because they need an acyclic graph
+	if len(problems) == 0 {
+		fromEvents := eventInputs(def)
+		for _, step := range def.Steps {
+			ancestors := Ancestors(def, step.ID)
+			keys := make([]string, 0, len(step.With))
+			for key := range step.With {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				for _, ref := range references(step.With[key]) {
+					if err := checkReference(ref, inputs, ancestors); err != nil {
+						add("steps.%s.with.%s: %v", step.ID, key, err)
+					} else if trigger, ok := fromEvents[strings.TrimPrefix(ref, "inputs.")]; ok &&
+						step.Type == entities.StepTypeShell && key == "run" {
+						// Event fields such as issue titles are chosen by whoever
+						// sends the event, so they must not become shell code
+						add("steps.%s.with.run: input %q is set from event fields by triggers[%d] and cannot be used in shell commands",
+							step.ID, strings.TrimPrefix(ref, "inputs."), trigger)
+					}
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// eventInputs returns the inputs that a trigger sets from event fields,
+// with the index of the first such trigger
+func eventInputs(def *entities.WorkflowDefinition) map[string]int {
+	inputs := make(map[string]int)
+	for i, trigger := range def.Triggers {
+		for name, value := range trigger.Inputs {
+			if _, ok := inputs[name]; !ok && expressionPattern.MatchString(value) {
+				inputs[name] = i
+			}
+		}
+	}
+	return inputs
+}
+
+// triggerFields lists the filter fields each trigger type accepts
+var triggerFields = map[string][]string{
+	entities.TriggerCron:       {"schedule"},
+	entities.TriggerGitHub:     {"event", "actions", "branches", "repositories"},
+	entities.TriggerTaskStatus: {"statuses", "repositories"},
+}
+
+// validateTrigger returns the problems of one trigger, each starting with
+// the field it concerns
+func validateTrigger(def *entities.WorkflowDefinition, trigger entities.WorkflowTrigger) []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	allowed, ok := triggerFields[trigger.Type]
+	if !ok {
+		add(".type: %q must be one of %s, %s, %s", trigger.Type,
+			entities.TriggerCron, entities.TriggerGitHub, entities.TriggerTaskStatus)
+		return problems
+	}
+	set := map[string]bool{
+		"schedule":     trigger.Schedule != "",
+		"event":        trigger.Event != "",
+		"actions":      len(trigger.Actions) > 0,
+		"branches":     len(trigger.Branches) > 0,
+		"repositories": len(trigger.Repositories) > 0,
+		"statuses":     len(trigger.Statuses) > 0,
+	}
+	for _, field := range []string{"schedule", "event", "actions", "branches", "repositories", "statuses"} {
+		if set[field] && !contains(allowed, field) {
+			add(".%s: does not apply to %s triggers", field, trigger.Type)
+		}
+	}
+
+	switch trigger.Type {
+	case entities.TriggerCron:
+		if trigger.Schedule == "" {
+			add(".schedule: is required for cron triggers")
+		} else if _, err := ParseSchedule(trigger.Schedule); err != nil {
+			add(".schedule: %v", err)
+		}
+	case entities.TriggerGitHub:
+		if trigger.Event == "" {
+			add(".event: is required for github triggers, e.g. pull_request or push")
+		}
+		for _, pattern := range append(append([]string{}, trigger.Branches...), trigger.Repositories...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				add(": invalid pattern %q", pattern)
+			}
+		}
+	case entities.TriggerTaskStatus:
+		for _, status := range trigger.Statuses {
+			if !entities.ValidTaskStatus(status) {
+				add(".statuses: unknown task status %q", status)
+			}
+		}
+	}
+
+	declared := make(map[string]bool, len(def.Inputs))
+	for _, input := range def.Inputs {
+		declared[input.Name] = true
+		if input.Required && input.Default == "" {
+			if _, ok := trigger.Inputs[input.Name]; !ok {
+				add(".inputs.%s: required input must be set", input.Name)
+			}
+		}
+	}
+	names := make([]string, 0, len(trigger.Inputs))
+	for name := range trigger.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !declared[name] {
+			add(".inputs.%s: unknown input", name)
+			continue
+		}
+		for _, ref := range references(trigger.Inputs[name]) {
+			if err := checkEventReference(ref); err != nil {
+				add(".inputs.%s: %v", name, err)
+			}
+		}
+	}
+	return problems
+}
+
+// Order returns the step ids in an order where every step follows the steps
+// it needs. It fails with the steps forming a cycle if there is one, or with
+// the first need naming no step.
+func Order(def *entities.WorkflowDefinition) ([]string, error) {
+	indegree := make(map[string]int, len(def.Steps))
+	for _, step := range def.Steps {
+		indegree[step.ID] = 0
+	}
+	dependents := make(map[string][]string)
+	for _, step := range def.Steps {
+		for _, need := range step.Needs {
+			if _, ok := indegree[need]; !ok {
+				return nil, fmt.Errorf("steps.%s.needs: unknown step %q", step.ID, need)
+			}
+			indegree[step.ID]++
+			dependents[need] = append(dependents[need], step.ID)
+		}
+	}
+
+	// Kahn's algorithm, keeping the definition order among ready steps
+	var order []string
+	done := make(map[string]bool)
+	for len(order) < len(def.Steps) {
+		progressed := false
+		for _, step := range def.Steps {
+			if done[step.ID] || indegree[step.ID] > 0 {
+				continue
+			}
+			done[step.ID] = true
+			order = append(order, step.ID)
+			for _, dep := range dependents[step.ID] {
+				indegree[dep]--
+			}
+			progressed = true
+		}
+		if !progressed {
+			return nil, fmt.Errorf("steps: dependency cycle %s", strings.Join(findCycle(def, done), " -> "))
+		}
+	}
+	return order, nil
+}
+
+// Ancestors returns the ids of every step that must finish before id starts
+func Ancestors(def *entities.WorkflowDefinition, id string) map[string]bool {
+	needs := make(map[string][]string, len(def.Steps))
+	for _, step := range def.Steps {
+		needs[step.ID] = step.Needs
+	}
+
+	ancestors := make(map[string]bool)
+	stack := append([]string{}, needs[id]...)
+	for len(stack) > 0 {
+		next := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if ancestors[next] {
+			continue
+		}
+		ancestors[next] = true
+		stack = append(stack, needs[next]...)
+	}
+	return ancestors
+}
+
+// StepTypes returns the supported step types in alphabetical order
+func StepTypes() []string {
+	types := make([]string, 0, len(stepParams))
+	for t := range stepParams {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// findCycle walks the needs of the steps left over by Order until a step
+// repeats and returns the path of the cycle
+func findCycle(def *entities.WorkflowDefinition, done map[string]bool) []string {
+	needs := make(map[string][]string)
+	var start string
+	for _, step := range def.Steps {
+		if done[step.ID] {
+			continue
+		}
+		if start == "" {
+			start = step.ID
+		}
+		for _, need := range step.Needs {
+			if !done[need] {
+				needs[step.ID] = append(needs[step.ID], need)
+			}
+		}
+	}
+
+	// Every remaining step has a remaining need, so the walk must repeat
+	seen := make(map[string]int)
+	var path []string
+	for id := start; ; id = needs[id][0] {
+		if i, ok := seen[id]; ok {
+			cycle := append(path[i:], id)
+			// Report in execution order: a -> b means b needs a
+			for l, r := 0, len(cycle)-1; l < r; l, r = l+1, r-1 {
+				cycle[l], cycle[r] = cycle[r], cycle[l]
+			}
+			return cycle
+		}
+		seen[id] = len(path)
+		path = append(path, id)
+	}
+}
+
+// contains reports whether list contains s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package workflow
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// shell returns a shell step needing the given steps
+func shell(id string, needs ...string) entities.WorkflowStep {
+	return entities.WorkflowStep{ID: id, Type: entities.StepTypeShell, Needs: needs, With: map[string]string{"run": "true"}}
+}
+
+// definition returns a workflow made of steps
+func definition(steps ...entities.WorkflowStep) *entities.WorkflowDefinition {
+	return &entities.WorkflowDefinition{Name: "test", Steps: steps}
+}
+
+func TestValidateSteps(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		def  *entities.WorkflowDefinition
+		want []string
+	}{
+		{name: "single step", def: definition(shell("a"))},
+		{name: "diamond", def: definition(shell("a"), shell("b", "a"), shell("c", "a"), shell("d", "b", "c"))},
+		{name: "no steps", def: definition(), want: []string{"steps: at least one step is required"}},
+		{name: "self need", def: definition(shell("a", "a")),
+			want: []string{"steps.a.needs: a step cannot need itself"}},
+		{name: "dangling need", def: definition(shell("a"), shell("b", "missing")),
+			want: []string{`steps.b.needs: unknown step "missing"`}},
+		{name: "every problem at once", def: definition(shell("a", "a"), shell("b", "x"), shell("c", "y")),
+			want: []string{
+				"steps.a.needs: a step cannot need itself",
+				`steps.b.needs: unknown step "x"`,
+				`steps.c.needs: unknown step "y"`,
+			}},
+		{name: "two step cycle", def: definition(shell("a", "b"), shell("b", "a")),
+			want: []string{"steps: dependency cycle a -> b -> a"}},
+		{name: "three step cycle", def: definition(shell("a", "c"), shell("b", "a"), shell("c", "b")),
+			want: []string{"steps: dependency cycle a -> b -> c -> a"}},
+		{name: "duplicate id", def: definition(shell("a"), shell("a")),
+			want: []string{`steps[1].id: "a" is used by more than one step`}},
+		{name: "output of a later step", def: definition(
+			entities.WorkflowStep{ID: "a", Type: entities.StepTypeShell, With: map[string]string{"run": "${{ steps.b.outputs.stdout }}"}},
+			shell("b")),
+			want: []string{`steps.a.with.run: step "b" is not in needs (directly or indirectly)`}},
+		{name: "approvers from inputs", def: definition(entities.WorkflowStep{ID: "gate", Type: entities.StepTypeApproval,
+			With: map[string]string{"approvers": "alice, ${{ inputs.approver }}", "timeout_outcome": "${{ inputs.outcome }}"}}),
+			want: []string{
+				"steps.gate.with.approvers: cannot use expressions",
+				"steps.gate.with.timeout_outcome: cannot use expressions",
+			}},
+		{name: "event fields in a shell command", def: &entities.WorkflowDefinition{
+			Name:   "test",
+			Inputs: []entities.WorkflowInput{{Name: "repo"}, {Name: "title"}},
+			Triggers: []entities.WorkflowTrigger{
+				{Type: entities.TriggerCron, Schedule: "@daily", Inputs: map[string]string{"repo": "acme/web", "title": "nightly"}},
+				{Type: entities.TriggerGitHub, Event: "issues", Inputs: map[string]string{"repo": "acme/web", "title": "${{ event.title }}"}},
+			},
+			Steps: []entities.WorkflowStep{
+				{ID: "a", Type: entities.StepTypeShell, With: map[string]string{"run": "echo ${{ inputs.repo }} ${{ inputs.title }}"}},
+				{ID: "b", Type: entities.StepTypeAIPrompt, With: map[string]string{"prompt": "Triage ${{ inputs.title }}"}},
+			},
+		},
+			want: []string{`steps.a.with.run: input "title" is set from event fields by triggers[1] and cannot be used in shell commands`}},
+		{name: "unknown timeout outcome", def: definition(entities.WorkflowStep{ID: "gate", Type: entities.StepTypeApproval,
+			With: map[string]string{"approvers": "alice", "timeout_outcome": "skip"}}),
+			want: []string{"steps.gate.with.timeout_outcome: must be approve or reject"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.def)
+			if tc.want == nil {
+				if err != nil {
+					t.Errorf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("Validate = %v, want a *ValidationError", err)
+			}
+			if !reflect.DeepEqual(invalid.Problems, tc.want) {
+				t.Errorf("problems = %q, want %q", invalid.Problems, tc.want)
+			}
+		})
+	}
+}
+
+func TestOrder(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		def     *entities.WorkflowDefinition
+		want    []string
+		wantErr string
+	}{
+		{name: "independent steps keep their order", def: definition(shell("b"), shell("a"), shell("c")),
+			want: []string{"b", "a", "c"}},
+		{name: "needs first", def: definition(shell("deploy", "build", "test"), shell("test", "build"), shell("build")),
+			want: []string{"build", "test", "deploy"}},
+		{name: "diamond", def: definition(shell("d", "b", "c"), shell("c", "a"), shell("b", "a"), shell("a")),
+			want: []string{"a", "c", "b", "d"}},
+		{name: "self need", def: definition(shell("a", "a")),
+			wantErr: "steps: dependency cycle a -> a"},
+		{name: "cycle after finished steps", def: definition(shell("x"), shell("a", "x", "b"), shell("b", "a")),
+			wantErr: "steps: dependency cycle a -> b -> a"},
+		{name: "cycle reached from outside", def: definition(shell("s", "a"), shell("a", "b"), shell("b", "a")),
+			wantErr: "steps: dependency cycle a -> b -> a"},
+		{name: "dangling need", def: definition(shell("a"), shell("b", "a", "missing")),
+			wantErr: `steps.b.needs: unknown step "missing"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Order(tc.def)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Errorf("Order error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Order: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Order = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		def  *entities.WorkflowDefinition
+		done map[string]bool
+		want []string
+	}{
+		{name: "self", def: definition(shell("a", "a")), want: []string{"a", "a"}},
+		{name: "pair", def: definition(shell("a", "b"), shell("b", "a")), want: []string{"a", "b", "a"}},
+		{name: "done needs ignored", def: definition(shell("x"), shell("a", "x", "b"), shell("b", "x", "a")),
+			done: map[string]bool{"x": true}, want: []string{"a", "b", "a"}},
+		{name: "walk into the cycle", def: definition(shell("s", "a"), shell("a", "b"), shell("b", "c"), shell("c", "a")),
+			want: []string{"a", "c", "b", "a"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			done := tc.done
+			if done == nil {
+				done = map[string]bool{}
+			}
+			if got := findCycle(tc.def, done); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("findCycle = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,550 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/usecase/execution"
+)
+
+// maxRetryDelay caps the exponential backoff between step attempts
+const maxRetryDelay = 10 * time.Minute
+
+// relaunchWait bounds how long launch waits for a finishing execution of the
+// same run to release it
+const relaunchWait = 5 * time.Second
+
+// Engine executes workflow runs. Steps whose needs have succeeded run
+// concurrently; a step whose needs failed or were skipped is skipped. Every
+// state change is persisted so interrupted runs resume after a restart.
+type Engine struct {
+	workflows repositories.WorkflowRepository
+	runs      repositories.WorkflowRunRepository
+	executors map[string]StepExecutor
+	notifier  ApprovalNotifier
+	runner    *execution.Runner
+	workDir   string
+
+	// active holds the runs being executed; relaunch marks those that must
+	// be executed again because a step was decided meanwhile
+	mu       sync.Mutex
+	active   map[string]bool
+	relaunch map[string]bool
+}
+
+// NewEngine creates a new Engine. Each run gets a private directory below
+// workDir. A nil notifier logs approval requests.
+func NewEngine(workflows repositories.WorkflowRepository, runs repositories.WorkflowRunRepository,
+	executors map[string]StepExecutor, notifier ApprovalNotifier, workDir string) *Engine {
+	if notifier == nil {
+		notifier = LogNotifier{}
+	}
+	return &Engine{
+		workflows: workflows,
+		runs:      runs,
+		executors: executors,
+		notifier:  notifier,
+		runner:    execution.NewRunner(runCheckpointer{runs: runs}),
+		workDir:   workDir,
+		active:    make(map[string]bool),
+		relaunch:  make(map[string]bool),
+	}
+}
+
+// RunRequest describes a run to start
+type RunRequest struct {
+	// Version selects the workflow version, 0 for the latest
+	Version   int
+	Inputs    map[string]string
+	Trigger   string
+	Event     map[string]string
+	CreatedBy *int64
+	// RunID, when set, makes starting idempotent: a run with the same ID
+	// already existing fails with repositories.ErrConflict
+	RunID string
+}
+
+// Start creates a run of the workflow and executes it in the background.
+// Missing inputs take their defaults.
+func (e *Engine) Start(ctx context.Context, workflowID int64, req RunRequest) (*entities.WorkflowRun, error) {
+	version := req.Version
+	var def *entities.WorkflowDefinition
+	if version == 0 {
+		wf, err := e.workflows.GetByID(ctx, workflowID)
+		if err != nil {
+			return nil, err
+		}
+		version, def = wf.Version, wf.Definition
+	} else {
+		v, err := e.workflows.GetVersion(ctx, workflowID, version)
+		if err != nil {
+			return nil, err
+		}
+		def = &v.Definition
+	}
+
+	resolved, err := resolveInputs(def, req.Inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	trigger := req.Trigger
+	if trigger == "" {
+		trigger = entities.TriggerManual
+	}
+	run := &entities.WorkflowRun{
+		ID:         req.RunID,
+		WorkflowID: workflowID,
+		Version:    version,
+		Status:     entities.RunStatusQueued,
+		Inputs:     resolved,
+		Trigger:    trigger,
+		Event:      req.Event,
+		Steps:      make([]entities.StepRun, 0, len(def.Steps)),
+		CreatedBy:  req.CreatedBy,
+	}
+	for _, step := range def.Steps {
+		run.Steps = append(run.Steps, entities.StepRun{
+			StepID: step.ID,
+			Type:   step.Type,
+			Status: entities.StepStatusPending,
+		})
+	}
+	if err := e.runs.Create(ctx, run); err != nil {
+		return nil, err
+	}
+
+	if err := e.launch(run.ID); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// Runs returns a page of run summaries
+func (e *Engine) Runs(ctx context.Context, filter repositories.WorkflowRunFilter, opts repositories.ListOptions) (repositories.Page[entities.WorkflowRun], error) {
+	return e.runs.List(ctx, filter, opts)
+}
+
+// Run returns a run with the state of its steps and its audit trail
+func (e *Engine) Run(ctx context.Context, runID string) (*entities.WorkflowRun, error) {
+	run, err := e.runs.GetByID(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run.Audit, err = e.runs.ListEvents(ctx, runID); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// Recover resumes the runs left queued or running by a previous process
+func (e *Engine) Recover(ctx context.Context) error {
+	ids, err := e.runs.ListUnfinished(ctx)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	// Steps interrupted mid-attempt are pending again and will be retried
+	if err := e.runs.Requeue(ctx, ids); err != nil {
+		return err
+	}
+
+	slog.Info("resuming workflow runs", "count", len(ids))
+	for _, id := range ids {
+		if err := e.launch(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Running returns the IDs of runs being executed
+func (e *Engine) Running() []string {
+	return e.runner.Running()
+}
+
+// Shutdown stops accepting runs and waits for running ones until ctx is done.
+// Runs still active at the deadline are requeued and resumed by Recover.
+func (e *Engine) Shutdown(ctx context.Context) error {
+	return e.runner.Shutdown(ctx)
+}
+
+// launch executes the run in the background. A run already being executed
+// is executed once more afterwards, so steps decided meanwhile are picked up.
+func (e *Engine) launch(runID string) error {
+	e.mu.Lock()
+	if e.active[runID] {
+		e.relaunch[runID] = true
+		e.mu.Unlock()
+		return nil
+	}
+	e.active[runID] = true
+	e.mu.Unlock()
+
+	fn := func(ctx context.Context) error {
+		for {
+			err := e.execute(ctx, runID)
+
+			e.mu.Lock()
+			again := e.relaunch[runID] && err == nil && ctx.Err() == nil
+			delete(e.relaunch, runID)
+			if !again {
+				delete(e.active, runID)
+			}
+			e.mu.Unlock()
+			if !again {
+				return err
+			}
+		}
+	}
+
+	// The runner releases a run shortly after its execution returns
+	deadline := time.Now().Add(relaunchWait)
+	for {
+		err := e.runner.Start(runID, fn)
+		if errors.Is(err, execution.ErrAlreadyRunning) && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		if err != nil {
+			e.mu.Lock()
+			delete(e.active, runID)
+			e.mu.Unlock()
+		}
+		return err
+	}
+}
+
+// execute starts each pending step as soon as its needs have succeeded and,
+// once nothing is left to run, records the outcome of the run
+func (e *Engine) execute(ctx context.Context, runID string) error {
+	run, err := e.runs.GetByID(ctx, runID)
+	if err != nil {
+		return err
+	}
+	if run.IsFinished() {
+		return nil
+	}
+	version, err := e.workflows.GetVersion(ctx, run.WorkflowID, run.Version)
+	if err != nil {
+		return err
+	}
+	def := &version.Definition
+
+	workDir := filepath.Join(e.workDir, run.ID)
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		return err
+	}
+
+	run.Status = entities.RunStatusRunning
+	if run.StartedAt == nil {
+		started := time.Now().UTC()
+		run.StartedAt = &started
+	}
+	if err := e.runs.UpdateStatus(ctx, run); err != nil {
+		return err
+	}
+
+	running := make(map[string]bool)
+	results := make(chan stepResult)
+	var firstErr error
+	for {
+		if firstErr == nil {
+			ready, err := e.readySteps(ctx, run, def, running)
+			if err != nil {
+				firstErr = err
+			}
+			outputs := stepOutputs(run)
+			for _, step := range ready {
+				running[step.ID] = true
+				state := *run.Step(step.ID)
+				go func() {
+					err := e.runStep(ctx, run, step, &state, outputs, workDir)
+					if err == nil && state.Status == entities.StepStatusWaiting {
+						err = e.requestApproval(ctx, run, def, &state)
+					}
+					results <- stepResult{state: state, err: err}
+				}()
+			}
+		}
+		if len(running) == 0 {
+			break
+		}
+
+		// Steps report back one at a time so only this goroutine touches run
+		result := <-results
+		delete(running, result.state.StepID)
+		*run.Step(result.state.StepID) = result.state
+		if result.err != nil && firstErr == nil {
+			firstErr = result.err
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return e.finish(ctx, run, workDir)
+}
+
+// stepResult is the final state of a step reported to the run loop
+type stepResult struct {
+	state entities.StepRun
+	err   error
+}
+
+// readySteps skips the pending steps that can no longer run and returns the
+// ones not yet running whose needs have all succeeded
+func (e *Engine) readySteps(ctx context.Context, run *entities.WorkflowRun, def *entities.WorkflowDefinition,
+	running map[string]bool) ([]entities.WorkflowStep, error) {
+	for {
+		var ready []entities.WorkflowStep
+		skipped := false
+
+		for _, step := range def.Steps {
+			state := run.Step(step.ID)
+			if state == nil || state.Status != entities.StepStatusPending || running[step.ID] {
+				continue
+			}
+
+			blocked, waiting := "", false
+			for _, need := range step.Needs {
+				switch run.Step(need).Status {
+				case entities.StepStatusFailed, entities.StepStatusSkipped:
+					blocked = need
+				case entities.StepStatusSucceeded:
+				default:
+					waiting = true
+				}
+			}
+
+			switch {
+			case blocked != "":
+				state.Status = entities.StepStatusSkipped
+				state.Error = fmt.Sprintf("needed step %q did not succeed", blocked)
+				if err := e.runs.UpdateStep(ctx, run.ID, state); err != nil {
+					return nil, err
+				}
+				skipped = true
+			case !waiting:
+				ready = append(ready, step)
+			}
+		}
+
+		// A skipped step may block others, so look again before running anything
+		if !skipped {
+			return ready, nil
+		}
+	}
+}
+
+// runStep executes one step, retrying failed attempts with exponential
+// backoff, and leaves its final state in state. It returns an error only when
+// the run must stop without an outcome.
+func (e *Engine) runStep(ctx context.Context, run *entities.WorkflowRun, step entities.WorkflowStep,
+	state *entities.StepRun, outputs map[string]map[string]string, workDir string) error {
+	fail := func(err error) error {
+		finished := time.Now().UTC()
+		state.Status = entities.StepStatusFailed
+		state.Error = err.Error()
+		state.FinishedAt = &finished
+		return e.runs.UpdateStep(ctx, run.ID, state)
+	}
+
+	executor, ok := e.executors[step.Type]
+	if !ok {
+		return fail(fmt.Errorf("no executor for step type %q", step.Type))
+	}
+
+	with := make(map[string]string, len(step.With))
+	for key, value := range step.With {
+		expanded, err := expand(value, run.Inputs, outputs)
+		if err != nil {
+			return fail(fmt.Errorf("%s: %w", key, err))
+		}
+		with[key] = expanded
+	}
+
+	for {
+		state.Status = entities.StepStatusRunning
+		state.Attempts++
+		if state.StartedAt == nil {
+			started := time.Now().UTC()
+			state.StartedAt = &started
+		}
+		if err := e.runs.UpdateStep(ctx, run.ID, state); err != nil {
+			return err
+		}
+
+		result, err := e.attempt(ctx, executor, StepContext{
+			RunID:   run.ID,
+			Step:    step,
+			With:    with,
+			WorkDir: workDir,
+		})
+		if ctx.Err() != nil {
+			// Interrupted by shutdown: the step is requeued, not failed
+			return ctx.Err()
+		}
+
+		switch {
+		case err == nil:
+			finished := time.Now().UTC()
+			state.Status = entities.StepStatusSucceeded
+			state.Outputs = result
+			state.Error = ""
+			state.FinishedAt = &finished
+			return e.runs.UpdateStep(ctx, run.ID, state)
+		case errors.Is(err, ErrWaitingForApproval):
+			// The step timeout is the deadline of the approval
+			state.Status = entities.StepStatusWaiting
+			state.Outputs = result
+			state.Error = ""
+			if timeout := time.Duration(step.Timeout); timeout > 0 {
+				expires := time.Now().UTC().Add(timeout)
+				state.ExpiresAt = &expires
+			}
+			return e.runs.UpdateStep(ctx, run.ID, state)
+		case state.Attempts > step.Retries:
+			return fail(err)
+		}
+
+		delay := retryDelay(time.Duration(step.RetryDelay), state.Attempts)
+		slog.Warn("workflow step failed, retrying",
+			"run_id", run.ID, "step_id", step.ID, "attempt", state.Attempts, "delay", delay, "error", err)
+		state.Error = err.Error()
+		if err := e.runs.UpdateStep(ctx, run.ID, state); err != nil {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// attempt runs the executor once, bounded by the step timeout
+func (e *Engine) attempt(ctx context.Context, executor StepExecutor, sc StepContext) (map[string]string, error) {
+	timeout := time.Duration(sc.Step.Timeout)
+	if timeout <= 0 || sc.Step.Type == entities.StepTypeApproval {
+		return executor.Execute(ctx, sc)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := executor.Execute(attemptCtx, sc)
+	if err != nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil, fmt.Errorf("step timed out after %s", timeout)
+	}
+	return result, err
+}
+
+// finish records the outcome of a run once no step is ready. A run with a
+// waiting step stays open; otherwise its directory is removed.
+func (e *Engine) finish(ctx context.Context, run *entities.WorkflowRun, workDir string) error {
+	var failed []string
+	waiting := false
+	for _, step := range run.Steps {
+		switch step.Status {
+		case entities.StepStatusFailed:
+			failed = append(failed, step.StepID)
+		case entities.StepStatusWaiting:
+			waiting = true
+		}
+	}
+
+	switch {
+	case waiting:
+		run.Status = entities.RunStatusWaiting
+		return e.runs.UpdateStatus(ctx, run)
+	case len(failed) > 0:
+		sort.Strings(failed)
+		run.Status = entities.RunStatusFailed
+		run.Error = fmt.Sprintf("failed steps: %v", failed)
+	default:
+		run.Status = entities.RunStatusSucceeded
+	}
+
+	finished := time.Now().UTC()
+	run.FinishedAt = &finished
+	if err := os.RemoveAll(workDir); err != nil {
+		slog.Warn("failed to remove workflow run directory", "run_id", run.ID, "error", err)
+	}
+	return e.runs.UpdateStatus(ctx, run)
+}
+
+// resolveInputs applies defaults and rejects missing or undeclared inputs
+func resolveInputs(def *entities.WorkflowDefinition, inputs map[string]string) (map[string]string, error) {
+	var problems []string
+	resolved := make(map[string]string, len(def.Inputs))
+	declared := make(map[string]bool, len(def.Inputs))
+
+	for _, input := range def.Inputs {
+		declared[input.Name] = true
+		value, ok := inputs[input.Name]
+		switch {
+		case ok:
+			resolved[input.Name] = value
+		case input.Required:
+			problems = append(problems, fmt.Sprintf("input %q is required", input.Name))
+		default:
+			resolved[input.Name] = input.Default
+		}
+	}
+	for name := range inputs {
+		if !declared[name] {
+			problems = append(problems, fmt.Sprintf("unknown input %q", name))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, &ValidationError{Problems: problems}
+	}
+	return resolved, nil
+}
+
+// stepOutputs collects the outputs of the succeeded steps of a run
+func stepOutputs(run *entities.WorkflowRun) map[string]map[string]string {
+	outputs := make(map[string]map[string]string)
+	for _, step := range run.Steps {
+		if step.Status == entities.StepStatusSucceeded {
+			outputs[step.StepID] = step.Outputs
+		}
+	}
+	return outputs
+}
+
+// retryDelay doubles the base delay after every failed attempt
+func retryDelay(base time.Duration, attempts int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	delay := base
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// runCheckpointer requeues the runs interrupted by a shutdown
+type runCheckpointer struct {
+	runs repositories.WorkflowRunRepository
+}
+
+// RequeueTasks implements execution.Checkpointer
+func (c runCheckpointer) RequeueTasks(ctx context.Context, runIDs []string) error {
+	return c.runs.Requeue(ctx, runIDs)
+}
@@ -0,0 +1,36 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+
+	"ai-git-workbench/internal/domain/entities"
+)
+
+// ErrWaitingForApproval is returned by a step executor to pause the run
+// until the step is resumed
+var ErrWaitingForApproval = errors.New("step is waiting for approval")
+
+// StepContext is everything a step executor needs to run one attempt
+type StepContext struct {
+	RunID string
+	Step  entities.WorkflowStep
+	// With holds the step parameters with ${{ }} expressions expanded
+	With map[string]string
+	// WorkDir is a directory private to the run, shared by its steps
+	WorkDir string
+}
+
+// StepExecutor runs one attempt of a step and returns its outputs, which
+// later steps can reference as ${{ steps.<id>.outputs.<key> }}
+type StepExecutor interface {
+	Execute(ctx context.Context, sc StepContext) (map[string]string, error)
+}
+
+// StepExecutorFunc adapts a function to the StepExecutor interface
+type StepExecutorFunc func(ctx context.Context, sc StepContext) (map[string]string, error)
+
+// Execute implements StepExecutor
+func (f StepExecutorFunc) Execute(ctx context.Context, sc StepContext) (map[string]string, error) {
+	return f(ctx, sc)
+}
@@ -0,0 +1,86 @@
+package workflow
+
+import (
+	"context"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// Service manages workflow definitions and their versions
+type Service struct {
+	workflows repositories.WorkflowRepository
+}
+
+// NewService creates a new Service
+func NewService(workflows repositories.WorkflowRepository) *Service {
+	return &Service{workflows: workflows}
+}
+
+// List returns a page of workflows
+func (s *Service) List(ctx context.Context, opts repositories.ListOptions) (repositories.Page[entities.Workflow], error) {
+	return s.workflows.List(ctx, opts)
+}
+
+// Get returns a workflow with its latest definition
+func (s *Service) Get(ctx context.Context, id int64) (*entities.Workflow, error) {
+	return s.workflows.GetByID(ctx, id)
+}
+
+// Create parses and validates source and stores it as version 1 of a new
+// workflow. A workflow with the same name returns repositories.ErrConflict.
+func (s *Service) Create(ctx context.Context, source []byte, format string, createdBy *int64) (*entities.Workflow, error) {
+	def, err := Parse(source, format)
+	if err != nil {
+		return nil, err
+	}
+
+	wf := &entities.Workflow{
+		Name:        def.Name,
+		Description: def.Description,
+		CreatedBy:   createdBy,
+	}
+	version := &entities.WorkflowVersion{Definition: *def, Source: string(source)}
+	if err := s.workflows.Create(ctx, wf, version); err != nil {
+		return nil, err
+	}
+	return wf, nil
+}
+
+// Update parses and validates source and stores it as the next version of
+// the workflow. Runs already started keep using the version they started with.
+func (s *Service) Update(ctx context.Context, id int64, source []byte, format string, createdBy *int64) (*entities.Workflow, error) {
+	def, err := Parse(source, format)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &entities.WorkflowVersion{
+		WorkflowID: id,
+		Definition: *def,
+		Source:     string(source),
+		CreatedBy:  createdBy,
+	}
+	if err := s.workflows.AddVersion(ctx, version); err != nil {
+		return nil, err
+	}
+	return s.workflows.GetByID(ctx, id)
+}
+
+// Delete removes a workflow with its versions and runs
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	return s.workflows.Delete(ctx, id)
+}
+
+// Versions returns every version of a workflow, newest first
+func (s *Service) Versions(ctx context.Context, id int64) ([]entities.WorkflowVersion, error) {
+	if _, err := s.workflows.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.workflows.ListVersions(ctx, id)
+}
+
+// Version returns one version of a workflow
+func (s *Service) Version(ctx context.Context, id int64, version int) (*entities.WorkflowVersion, error) {
+	return s.workflows.GetVersion(ctx, id, version)
+}
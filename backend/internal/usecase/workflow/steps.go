@@ -0,0 +1,198 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/infrastructure/ai"
+	"ai-git-workbench/internal/infrastructure/command"
+	"ai-git-workbench/internal/infrastructure/github"
+)
+
+// repositoryShorthand matches owner/name repository references; GitHub owners
+// are letters, digits and hyphens
+var repositoryShorthand = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*/[A-Za-z0-9_.-]+$`)
+
+// ExecutorDeps are the services used by the built-in step types
+type ExecutorDeps struct {
+	AI     ai.Provider
+	GitHub *github.Client
+	// GitHubToken authenticates clones of owner/name repositories
+	GitHubToken string
+	// AllowShell enables shell steps, which run arbitrary commands on the server
+	AllowShell bool
+}
+
+// NewExecutors returns the executors of the built-in step types
+func NewExecutors(deps ExecutorDeps) map[string]StepExecutor {
+	return map[string]StepExecutor{
+		entities.StepTypeGitCheckout: StepExecutorFunc(deps.gitCheckout),
+		entities.StepTypeAIPrompt:    StepExecutorFunc(deps.aiPrompt),
+		entities.StepTypeShell:       StepExecutorFunc(deps.shell),
+		entities.StepTypeOpenPR:      StepExecutorFunc(deps.openPR),
+		entities.StepTypeApproval:    StepExecutorFunc(waitForApproval),
+	}
+}
+
+// gitCheckout clones a repository into the run directory.
+// Outputs: path, commit.
+func (d ExecutorDeps) gitCheckout(ctx context.Context, sc StepContext) (map[string]string, error) {
+	dir, err := runPath(sc.WorkDir, sc.With["path"], "repo")
+	if err != nil {
+		return nil, err
+	}
+	// A retried attempt starts from a clean directory
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+
+	repo, shorthand, err := checkoutURL(sc.With["repository"])
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"clone", "--depth", "1"}
+	if shorthand && d.GitHubToken != "" {
+		args = append(github.GitAuthArgs(d.GitHubToken), args...)
+	}
+	if ref := sc.With["ref"]; ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, "--", repo, dir)
+
+	if _, err := command.Run(ctx, sc.WorkDir, "git", args...); err != nil {
+		return nil, err
+	}
+	commit, err := command.Run(ctx, dir, "git", "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"path": dir, "commit": strings.TrimSpace(commit)}, nil
+}
+
+// checkoutURL returns the URL to clone repository from, which is an owner/name
+// shorthand for a GitHub repository or an http(s) URL. Other schemes and
+// local paths are refused so a run cannot read the server's files.
+func checkoutURL(repository string) (string, bool, error) {
+	if repositoryShorthand.MatchString(repository) && !strings.HasSuffix(repository, "/.") &&
+		!strings.HasSuffix(repository, "/..") {
+		return "https://github.com/" + repository + ".git", true, nil
+	}
+	u, err := url.Parse(repository)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", false, fmt.Errorf("repository must be owner/name or an http(s) URL, got %q", repository)
+	}
+	return repository, false, nil
+}
+
+// aiPrompt sends a prompt to the configured AI provider.
+// Outputs: text, model, input_tokens, output_tokens.
+func (d ExecutorDeps) aiPrompt(ctx context.Context, sc StepContext) (map[string]string, error) {
+	if d.AI == nil {
+		return nil, errors.New("no AI provider is configured")
+	}
+
+	req := ai.CompletionRequest{
+		System:   sc.With["system"],
+		Messages: []ai.Message{{Role: "user", Content: sc.With["prompt"]}},
+	}
+	if raw := sc.With["max_tokens"]; raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("max_tokens must be a positive integer, got %q", raw)
+		}
+		req.MaxTokens = n
+	}
+
+	resp, err := d.AI.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"text":          resp.Content,
+		"model":         resp.Model,
+		"input_tokens":  strconv.Itoa(resp.InputTokens),
+		"output_tokens": strconv.Itoa(resp.OutputTokens),
+	}, nil
+}
+
+// shell runs a command with sh -c in the run directory.
+// Outputs: stdout.
+func (d ExecutorDeps) shell(ctx context.Context, sc StepContext) (map[string]string, error) {
+	if !d.AllowShell {
+		return nil, errors.New("shell steps are disabled, set workflow.allow_shell to enable them")
+	}
+	dir, err := runPath(sc.WorkDir, sc.With["working_directory"], ".")
+	if err != nil {
+		return nil, err
+	}
+	out, err := command.Run(ctx, dir, "sh", "-c", sc.With["run"])
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"stdout": strings.TrimRight(out, "\n")}, nil
+}
+
+// openPR opens a pull request on GitHub.
+// Outputs: number, url.
+func (d ExecutorDeps) openPR(ctx context.Context, sc StepContext) (map[string]string, error) {
+	if d.GitHub == nil || !d.GitHub.Configured() {
+		return nil, errors.New("GitHub is not configured")
+	}
+	owner, repo, ok := strings.Cut(sc.With["repository"], "/")
+	if !ok || owner == "" || repo == "" {
+		return nil, fmt.Errorf("repository must be owner/name, got %q", sc.With["repository"])
+	}
+
+	base := sc.With["base"]
+	if base == "" {
+		base = "main"
+	}
+	draft, _ := strconv.ParseBool(sc.With["draft"])
+
+	pr, err := d.GitHub.CreatePullRequest(ctx, owner, repo, github.NewPullRequest{
+		Title: sc.With["title"],
+		Head:  sc.With["head"],
+		Base:  base,
+		Body:  sc.With["body"],
+		Draft: draft,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"number": strconv.Itoa(pr.Number), "url": pr.HTMLURL}, nil
+}
+
+// waitForApproval pauses the run; the engine resumes it when the step is
+// approved, rejected or timed out. Outputs while waiting: message, approvers,
+// timeout_outcome.
+func waitForApproval(ctx context.Context, sc StepContext) (map[string]string, error) {
+	outcome := sc.With["timeout_outcome"]
+	if outcome == "" {
+		outcome = DecisionReject
+	}
+	return map[string]string{
+		"message":         sc.With["message"],
+		"approvers":       strings.Join(splitApprovers(sc.With["approvers"]), ","),
+		"timeout_outcome": outcome,
+	}, ErrWaitingForApproval
+}
+
+// runPath resolves a path relative to the run directory, refusing paths that escape it
+func runPath(workDir, rel, def string) (string, error) {
+	if rel == "" {
+		rel = def
+	}
+	path := filepath.Join(workDir, rel)
+	if path != workDir && !strings.HasPrefix(path, workDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside the run directory", rel)
+	}
+	return path, nil
+}
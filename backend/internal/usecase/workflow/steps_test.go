@@ -0,0 +1,37 @@
+package workflow
+
+import "testing"
+
+func TestCheckoutURL(t *testing.T) {
+	for _, tc := range []struct {
+		repository string
+		want       string
+		shorthand  bool
+		wantErr    bool
+	}{
+		{repository: "octo/hello", want: "https://github.com/octo/hello.git", shorthand: true},
+		{repository: "octo/hello.go", want: "https://github.com/octo/hello.go.git", shorthand: true},
+		{repository: "https://gitlab.com/octo/hello.git", want: "https://gitlab.com/octo/hello.git"},
+		{repository: "http://git.internal/octo/hello", want: "http://git.internal/octo/hello"},
+		{repository: "file:///etc", wantErr: true},
+		{repository: "/var/lib/repos/secret", wantErr: true},
+		{repository: "../other-run", wantErr: true},
+		{repository: "octo/..", wantErr: true},
+		{repository: "ssh://git@github.com/octo/hello.git", wantErr: true},
+		{repository: "git@github.com:octo/hello.git", wantErr: true},
+		{repository: "ext::sh -c touch% /tmp/pwned", wantErr: true},
+		{repository: "https:///no-host", wantErr: true},
+		{repository: "", wantErr: true},
+	} {
+		got, shorthand, err := checkoutURL(tc.repository)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("checkoutURL(%q) = %q, want an error", tc.repository, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want || shorthand != tc.shorthand {
+			t.Errorf("checkoutURL(%q) = %q, %v, %v, want %q, %v", tc.repository, got, shorthand, err, tc.want, tc.shorthand)
+		}
+	}
+}
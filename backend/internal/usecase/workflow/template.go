@@ -0,0 +1,80 @@
+package workflow
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// expressionPattern matches ${{ expression }} placeholders in step parameters
+var expressionPattern = regexp.MustCompile(`\$\{\{\s*([^}]*?)\s*\}\}`)
+
+// references returns the expressions used in value
+func references(value string) []string {
+	var refs []string
+	for _, m := range expressionPattern.FindAllStringSubmatch(value, -1) {
+		refs = append(refs, m[1])
+	}
+	return refs
+}
+
+// checkReference validates an expression against the declared inputs and the
+// steps guaranteed to have finished
+func checkReference(ref string, inputs, ancestors map[string]bool) error {
+	parts := strings.Split(ref, ".")
+	switch {
+	case len(parts) == 2 && parts[0] == "inputs":
+		if !inputs[parts[1]] {
+			return fmt.Errorf("unknown input %q", parts[1])
+		}
+	case len(parts) == 4 && parts[0] == "steps" && parts[2] == "outputs":
+		if !ancestors[parts[1]] {
+			return fmt.Errorf("step %q is not in needs (directly or indirectly)", parts[1])
+		}
+	default:
+		return fmt.Errorf("unsupported expression %q, use inputs.<name> or steps.<id>.outputs.<key>", ref)
+	}
+	return nil
+}
+
+// checkEventReference validates an expression used in trigger inputs
+func checkEventReference(ref string) error {
+	key, ok := strings.CutPrefix(ref, "event.")
+	if !ok || key == "" {
+		return fmt.Errorf("unsupported expression %q, trigger inputs may only use event.<key>", ref)
+	}
+	return nil
+}
+
+// expandEvent substitutes the event fields referenced by value. Fields the
+// event does not carry expand to an empty string.
+func expandEvent(value string, event map[string]string) string {
+	return expressionPattern.ReplaceAllStringFunc(value, func(match string) string {
+		ref := expressionPattern.FindStringSubmatch(match)[1]
+		return event[strings.TrimPrefix(ref, "event.")]
+	})
+}
+
+// expand substitutes the inputs and step outputs referenced by value
+func expand(value string, inputs map[string]string, outputs map[string]map[string]string) (string, error) {
+	var expandErr error
+	result := expressionPattern.ReplaceAllStringFunc(value, func(match string) string {
+		ref := expressionPattern.FindStringSubmatch(match)[1]
+		parts := strings.Split(ref, ".")
+		switch {
+		case len(parts) == 2 && parts[0] == "inputs":
+			if v, ok := inputs[parts[1]]; ok {
+				return v
+			}
+		case len(parts) == 4 && parts[0] == "steps" && parts[2] == "outputs":
+			if v, ok := outputs[parts[1]][parts[3]]; ok {
+				return v
+			}
+		}
+		if expandErr == nil {
+			expandErr = fmt.Errorf("%s has no value", ref)
+		}
+		return ""
+	})
+	return result, expandErr
+}
@@ -0,0 +1,205 @@
+package workflow
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+)
+
+// Dispatcher starts workflow runs from the triggers of the latest version of
+// every workflow: cron schedules, GitHub webhook events and task status changes
+type Dispatcher struct {
+	workflows repositories.WorkflowRepository
+	engine    *Engine
+}
+
+// NewDispatcher creates a new Dispatcher
+func NewDispatcher(workflows repositories.WorkflowRepository, engine *Engine) *Dispatcher {
+	return &Dispatcher{workflows: workflows, engine: engine}
+}
+
+// Run fires cron triggers at the start of every minute until ctx is done.
+// Minutes missed while the server was down are not caught up.
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		next := time.Now().UTC().Truncate(time.Minute).Add(time.Minute)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		d.Tick(ctx, next)
+	}
+}
+
+// Tick starts the runs of the cron triggers matching minute. Run IDs are
+// derived from the trigger and minute, so replicas ticking the same minute
+// start each run once.
+func (d *Dispatcher) Tick(ctx context.Context, minute time.Time) []*entities.WorkflowRun {
+	minute = minute.UTC().Truncate(time.Minute)
+	return d.dispatch(ctx, entities.TriggerCron, func(wf *entities.Workflow, i int, trigger *entities.WorkflowTrigger) (map[string]string, string, bool) {
+		schedule, err := ParseSchedule(trigger.Schedule)
+		if err != nil || !schedule.Matches(minute) {
+			return nil, "", false
+		}
+		event := map[string]string{"time": minute.Format(time.RFC3339)}
+		return event, stableRunID("cron", wf.ID, i, minute.Unix()), true
+	})
+}
+
+// GitHubEvent starts the runs of the github triggers matching a webhook
+// event flattened by github.ParseWebhookEvent. Redelivered events with the
+// same delivery ID do not start runs twice.
+func (d *Dispatcher) GitHubEvent(ctx context.Context, event map[string]string) []*entities.WorkflowRun {
+	return d.dispatch(ctx, entities.TriggerGitHub, func(wf *entities.Workflow, i int, trigger *entities.WorkflowTrigger) (map[string]string, string, bool) {
+		if trigger.Event != event["event"] ||
+			(len(trigger.Actions) > 0 && !contains(trigger.Actions, event["action"])) ||
+			!matchAny(trigger.Branches, event["branch"]) ||
+			!matchAny(trigger.Repositories, event["repository"]) {
+			return nil, "", false
+		}
+		runID := ""
+		if delivery := event["delivery"]; delivery != "" {
+			runID = stableRunID("github", wf.ID, i, delivery)
+		}
+		return event, runID, true
+	})
+}
+
+// TaskStatusChanged starts the runs of the task_status triggers matching a
+// task whose status changed from previous
+func (d *Dispatcher) TaskStatusChanged(ctx context.Context, task *entities.Task, previous string) {
+	if task.Status == previous {
+		return
+	}
+	event := map[string]string{
+		"task_id":         task.ID,
+		"title":           task.Title,
+		"status":          task.Status,
+		"previous_status": previous,
+		"repository":      task.Repository,
+		"branch":          task.Branch,
+		"assignee":        task.Assignee,
+	}
+	d.dispatch(ctx, entities.TriggerTaskStatus, func(wf *entities.Workflow, i int, trigger *entities.WorkflowTrigger) (map[string]string, string, bool) {
+		if (len(trigger.Statuses) > 0 && !contains(trigger.Statuses, task.Status)) ||
+			!matchAny(trigger.Repositories, task.Repository) {
+			return nil, "", false
+		}
+		return event, "", true
+	})
+}
+
+// triggerMatch decides whether a trigger fires, returning the event its
+// inputs are expanded from and an optional run ID for deduplication
+type triggerMatch func(wf *entities.Workflow, index int, trigger *entities.WorkflowTrigger) (event map[string]string, runID string, ok bool)
+
+// dispatch starts a run for every trigger of the given type that matches.
+// Failures are logged so one broken workflow does not stop the others.
+func (d *Dispatcher) dispatch(ctx context.Context, triggerType string, match triggerMatch) []*entities.WorkflowRun {
+	var started []*entities.WorkflowRun
+	err := d.eachWorkflow(ctx, func(wf *entities.Workflow) {
+		for i := range wf.Definition.Triggers {
+			trigger := &wf.Definition.Triggers[i]
+			if trigger.Type != triggerType {
+				continue
+			}
+			event, runID, ok := match(wf, i, trigger)
+			if !ok {
+				continue
+			}
+			// Definitions saved before a check was added, such as shell
+			// commands using event fields, do not run until they are fixed
+			if err := Validate(wf.Definition); err != nil {
+				slog.WarnContext(ctx, "invalid workflow not triggered",
+					"workflow_id", wf.ID, "trigger", triggerType, "error", err)
+				continue
+			}
+
+			inputs := make(map[string]string, len(trigger.Inputs))
+			for name, value := range trigger.Inputs {
+				inputs[name] = expandEvent(value, event)
+			}
+
+			run, err := d.engine.Start(ctx, wf.ID, RunRequest{
+				Version: wf.Version,
+				Inputs:  inputs,
+				Trigger: triggerType,
+				Event:   event,
+				RunID:   runID,
+			})
+			switch {
+			case errors.Is(err, repositories.ErrConflict):
+				slog.DebugContext(ctx, "workflow run already started", "workflow_id", wf.ID, "run_id", runID)
+			case err != nil:
+				slog.ErrorContext(ctx, "failed to start triggered workflow run",
+					"workflow_id", wf.ID, "trigger", triggerType, "error", err)
+			default:
+				slog.InfoContext(ctx, "workflow run triggered",
+					"workflow_id", wf.ID, "run_id", run.ID, "trigger", triggerType)
+				started = append(started, run)
+			}
+		}
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load workflow triggers", "trigger", triggerType, "error", err)
+	}
+	return started
+}
+
+// eachWorkflow calls fn with the latest version of every workflow
+func (d *Dispatcher) eachWorkflow(ctx context.Context, fn func(wf *entities.Workflow)) error {
+	opts := repositories.ListOptions{Limit: repositories.MaxPageSize}
+	for {
+		page, err := d.workflows.List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for i := range page.Items {
+			if page.Items[i].Definition != nil {
+				fn(&page.Items[i])
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+// matchAny reports whether value matches one of the glob patterns, ignoring
+// case. An empty pattern list matches everything.
+func matchAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(value)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// stableRunID derives a UUID-formatted run ID from parts, so the same
+// trigger firing twice produces the same ID
+func stableRunID(parts ...interface{}) string {
+	key := make([]string, len(parts))
+	for i, part := range parts {
+		key[i] = fmt.Sprint(part)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(key, "|")))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
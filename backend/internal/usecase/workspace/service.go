@@ -0,0 +1,195 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-git-workbench/internal/domain/entities"
+	"ai-git-workbench/internal/domain/repositories"
+	"ai-git-workbench/internal/infrastructure/command"
+	"ai-git-workbench/internal/infrastructure/github"
+)
+
+// fullNamePattern matches the owner/name a clone directory is derived from
+var fullNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]*/[A-Za-z0-9_-][A-Za-z0-9_.-]*$`)
+
+var (
+	// ErrInvalidRepository is returned for repositories that cannot be cloned
+	ErrInvalidRepository = errors.New("repository cannot be cloned")
+	// ErrSyncFailed is returned when git fails to clone or fetch a repository
+	ErrSyncFailed = errors.New("repository sync failed")
+)
+
+// Status is the state of the local clone of a repository. Ahead and Behind
+// count commits relative to the upstream branch as of the last fetch.
+type Status struct {
+	Cloned  bool   `json:"cloned"`
+	Path    string `json:"path,omitempty"`
+	Branch  string `json:"branch,omitempty"`
+	Commit  string `json:"commit,omitempty"`
+	Dirty   bool   `json:"dirty"`
+	Changes int    `json:"changes"`
+	Ahead   int    `json:"ahead"`
+	Behind  int    `json:"behind"`
+}
+
+// Service keeps local clones of connected repositories under one directory
+type Service struct {
+	repos   repositories.RepositoryRepository
+	root    string
+	token   string
+	timeout time.Duration
+
+	locks sync.Map // clone path -> *sync.Mutex
+}
+
+// NewService creates a new Service cloning into root. token authenticates
+// clones from github.com; timeout bounds each clone or fetch.
+func NewService(repos repositories.RepositoryRepository, root, token string, timeout time.Duration) (*Service, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving workspace directory: %w", err)
+	}
+	return &Service{repos: repos, root: abs, token: token, timeout: timeout}, nil
+}
+
+// Clone clones a repository into the workspace, or fetches it when it was
+// cloned before, and marks it connected. fetched reports which happened.
+func (s *Service) Clone(ctx context.Context, repoID int64) (repo *entities.Repository, status *Status, fetched bool, err error) {
+	repo, err = s.repos.GetByID(ctx, repoID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	dir, err := s.path(repo)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	remote, err := cloneURL(repo)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	lock, _ := s.locks.LoadOrStore(dir, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	gitCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	var auth []string
+	if s.token != "" && strings.HasPrefix(remote, "https://github.com/") {
+		auth = github.GitAuthArgs(s.token)
+	}
+
+	if _, statErr := os.Stat(filepath.Join(dir, ".git")); statErr == nil {
+		fetched = true
+		args := append(auth, "fetch", "--prune", "origin")
+		if _, err := command.Run(gitCtx, dir, "git", args...); err != nil {
+			return nil, nil, false, fmt.Errorf("%w: %w", ErrSyncFailed, err)
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+			return nil, nil, false, fmt.Errorf("error creating workspace directory: %w", err)
+		}
+		args := append(auth, "clone", "--", remote, dir)
+		if _, err := command.Run(gitCtx, s.root, "git", args...); err != nil {
+			return nil, nil, false, fmt.Errorf("%w: %w", ErrSyncFailed, err)
+		}
+	}
+
+	// The repository may have been edited while git was running
+	if repo, err = s.repos.GetByID(ctx, repoID); err != nil {
+		return nil, nil, false, err
+	}
+	synced := time.Now().UTC()
+	repo.IsConnected = true
+	repo.LastSync = &synced
+	if err := s.repos.Update(ctx, repo); err != nil {
+		return nil, nil, false, err
+	}
+	slog.InfoContext(ctx, "repository synced to workspace", "repository_id", repo.ID, "path", dir, "fetched", fetched)
+
+	status, err = s.status(ctx, dir)
+	return repo, status, fetched, err
+}
+
+// Status returns the state of the local clone of a repository
+func (s *Service) Status(ctx context.Context, repoID int64) (*entities.Repository, *Status, error) {
+	repo, err := s.repos.GetByID(ctx, repoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	dir, err := s.path(repo)
+	if err != nil {
+		// Repositories that cannot be cloned are simply never cloned
+		return repo, &Status{}, nil
+	}
+	status, err := s.status(ctx, dir)
+	return repo, status, err
+}
+
+// path returns the clone directory of a repository
+func (s *Service) path(repo *entities.Repository) (string, error) {
+	if !fullNamePattern.MatchString(repo.FullName) {
+		return "", fmt.Errorf("%w: full name %q is not owner/name", ErrInvalidRepository, repo.FullName)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(repo.FullName)), nil
+}
+
+// status reads the state of the clone in dir
+func (s *Service) status(ctx context.Context, dir string) (*Status, error) {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return &Status{}, nil
+	}
+
+	status := &Status{Cloned: true, Path: dir}
+	if out, err := command.Run(ctx, dir, "git", "rev-parse", "--abbrev-ref", "HEAD"); err == nil {
+		status.Branch = strings.TrimSpace(out)
+	}
+	// An empty repository has no commit yet
+	if out, err := command.Run(ctx, dir, "git", "rev-parse", "HEAD"); err == nil {
+		status.Commit = strings.TrimSpace(out)
+	}
+
+	out, err := command.Run(ctx, dir, "git", "status", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if line != "" {
+			status.Changes++
+		}
+	}
+	status.Dirty = status.Changes > 0
+
+	// Without an upstream branch there is nothing to compare with
+	if out, err := command.Run(ctx, dir, "git", "rev-list", "--left-right", "--count", "HEAD...@{upstream}"); err == nil {
+		if counts := strings.Fields(out); len(counts) == 2 {
+			status.Ahead, _ = strconv.Atoi(counts[0])
+			status.Behind, _ = strconv.Atoi(counts[1])
+		}
+	}
+	return status, nil
+}
+
+// cloneURL returns the HTTPS URL to clone a repository from. Other schemes
+// are refused because git supports transports that run commands.
+func cloneURL(repo *entities.Repository) (string, error) {
+	if repo.CloneURL == "" {
+		return "https://github.com/" + repo.FullName + ".git", nil
+	}
+	u, err := url.Parse(repo.CloneURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", fmt.Errorf("%w: clone URL must be http(s)", ErrInvalidRepository)
+	}
+	return repo.CloneURL, nil
+}